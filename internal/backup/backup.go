@@ -1,10 +1,14 @@
 package backup
 
 import (
+	"archive/tar"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
@@ -14,11 +18,77 @@ import (
 type BackupService interface {
 	// CreateBackup creates a backup of the GPG keyring and trust database.
 	CreateBackup(ctx context.Context, keyID string, backupDir string) (string, error)
+
+	// CreateEncryptedBackup creates a backup and symmetrically encrypts it into a single .tar.gpg file.
+	CreateEncryptedBackup(ctx context.Context, keyID string, backupDir string, passphrase string) (string, error)
+
+	// ListBackups returns the backups found in backupDir, newest first.
+	ListBackups(ctx context.Context, backupDir string) ([]BackupResult, error)
+
+	// PruneBackups removes all but the keep most recent complete backups.
+	PruneBackups(ctx context.Context, backupDir string, keep int) ([]string, error)
+
+	// RestoreBackup imports the public key and ownertrust from a backup directory.
+	RestoreBackup(ctx context.Context, backupPath string) error
+
+	// VerifyBackup checks that a backup directory holds the key with the
+	// given fingerprint and a readable key list, without changing the keyring.
+	VerifyBackup(ctx context.Context, backupPath, fingerprint string) error
+}
+
+const (
+	// backupPrefix is the name prefix of every backup created by CreateBackup.
+	backupPrefix = "gpg-backup-"
+	// backupTimestampFormat is the timestamp layout used in backup names.
+	backupTimestampFormat = "20060102-150405"
+	// encryptedBackupSuffix is the file extension of encrypted backups.
+	encryptedBackupSuffix = ".tar.gpg"
+	// maxBackupsPerSecond bounds the -N suffixes tried for backups created
+	// within the same second.
+	maxBackupsPerSecond = 100
+)
+
+// backupFiles are the files every complete plaintext backup contains.
+var backupFiles = []string{"public-key.asc", "trustdb.txt", "key-list.txt"}
+
+// secretSubkeysFile holds the secret subkeys when they are backed up.
+const secretSubkeysFile = "secret-subkeys.gpg"
+
+// secretSubkeysNote returns the key-list.txt comment added when the secret
+// subkeys are backed up. gpg exports only a stub for a subkey whose secret
+// part is on a card or offline, so those subkeys are named as stubs rather
+// than counted as included.
+func secretSubkeysNote(keys []gpg.Key) string {
+	var included, stubs []string
+	for _, key := range keys {
+		if key.Type != "ssb" {
+			continue
+		}
+		if key.Secret == gpg.SecretLocal {
+			included = append(included, key.KeyID)
+		} else {
+			stubs = append(stubs, key.KeyID)
+		}
+	}
+
+	if len(included) == 0 {
+		return "# " + secretSubkeysFile + " holds stubs only: no secret subkey is in the keyring (on a card or offline)\n"
+	}
+	note := "# secret subkeys included in " + secretSubkeysFile + ": " + strings.Join(included, ", ") + "\n"
+	if len(stubs) > 0 {
+		note += "# stubs only, the secret part is on a card or offline: " + strings.Join(stubs, ", ") + "\n"
+	}
+	return note
 }
 
 // Service implements BackupService.
 type Service struct {
 	gpgService gpg.GPGService
+
+	// IncludeSecretSubkeys makes CreateBackup also save the secret subkeys
+	// (never the master key) to secret-subkeys.gpg, readable only by the
+	// owner. Such backups should be encrypted.
+	IncludeSecretSubkeys bool
 }
 
 // NewService creates a new backup service.
@@ -30,18 +100,24 @@ func NewService(gpgService gpg.GPGService) *Service {
 type BackupResult struct {
 	Path      string
 	Timestamp time.Time
+	// Complete is true when all expected backup files are present.
+	// Encrypted backups can't be inspected and are assumed complete.
+	Complete bool
+	// Encrypted is true for .tar.gpg backups.
+	Encrypted bool
+	// Missing lists the expected files absent from an incomplete backup.
+	Missing []string
+
+	// sequence orders backups created within the same second.
+	sequence int
 }
 
 // CreateBackup creates a backup of the GPG keyring and trust database.
 // Returns the path to the created backup directory.
 func (s *Service) CreateBackup(ctx context.Context, keyID string, backupDir string) (string, error) {
-	// Create backup directory with timestamp
-	timestamp := time.Now().Format("20060102-150405")
-	backupName := fmt.Sprintf("gpg-backup-%s", timestamp)
-	backupPath := filepath.Join(backupDir, backupName)
-
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	backupPath, err := createBackupDir(backupDir, time.Now())
+	if err != nil {
+		return "", err
 	}
 
 	// Backup public key
@@ -74,13 +150,312 @@ func (s *Service) CreateBackup(ctx context.Context, keyID string, backupDir stri
 
 	keyListPath := filepath.Join(backupPath, "key-list.txt")
 	keyListContent := formatKeyList(keys)
+	if s.IncludeSecretSubkeys {
+		keyListContent += secretSubkeysNote(keys)
+	}
 	if err := os.WriteFile(keyListPath, []byte(keyListContent), 0644); err != nil {
 		return "", fmt.Errorf("failed to write key list backup: %w", err)
 	}
 
+	if s.IncludeSecretSubkeys {
+		subkeyData, err := s.gpgService.ExportSecretSubkeys(ctx, keyID)
+		if err != nil {
+			return "", fmt.Errorf("failed to export secret subkeys: %w", err)
+		}
+		if len(subkeyData) == 0 {
+			return "", fmt.Errorf("failed to export secret subkeys: gpg exported nothing")
+		}
+		subkeysPath := filepath.Join(backupPath, secretSubkeysFile)
+		if err := os.WriteFile(subkeysPath, subkeyData, 0600); err != nil {
+			return "", fmt.Errorf("failed to write secret subkeys backup: %w", err)
+		}
+	}
+
 	return backupPath, nil
 }
 
+// CreateEncryptedBackup creates a backup, archives the backup directory into a
+// tarball, and encrypts it with gpg --symmetric (AES256) using the passphrase.
+// The plaintext directory and tarball are removed afterwards.
+// Returns the path to the created .tar.gpg file.
+func (s *Service) CreateEncryptedBackup(ctx context.Context, keyID string, backupDir string, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", fmt.Errorf("a passphrase is required to encrypt the backup")
+	}
+
+	backupPath, err := s.CreateBackup(ctx, keyID, backupDir)
+	if err != nil {
+		return "", err
+	}
+	// Never leave the plaintext backup behind, even on failure
+	defer os.RemoveAll(backupPath)
+
+	tarPath := backupPath + ".tar"
+	if err := tarDirectory(backupPath, tarPath); err != nil {
+		return "", fmt.Errorf("failed to archive backup: %w", err)
+	}
+	defer os.Remove(tarPath)
+
+	encryptedPath := tarPath + ".gpg"
+	if err := s.gpgService.EncryptSymmetric(ctx, tarPath, encryptedPath, passphrase); err != nil {
+		return "", fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+
+	return encryptedPath, nil
+}
+
+// createBackupDir creates a new, empty gpg-backup-YYYYMMDD-HHMMSS directory
+// in backupDir and returns its path. If a backup from the same second
+// exists, plaintext or encrypted, a -2, -3, ... suffix is added instead of
+// reusing it.
+func createBackupDir(backupDir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := backupPrefix + now.Format(backupTimestampFormat)
+	for sequence := 1; sequence <= maxBackupsPerSecond; sequence++ {
+		backupPath := filepath.Join(backupDir, name)
+		if sequence > 1 {
+			backupPath = fmt.Sprintf("%s-%d", backupPath, sequence)
+		}
+		if _, err := os.Lstat(backupPath + encryptedBackupSuffix); err == nil {
+			continue
+		}
+		// Mkdir fails if the directory exists, so a backup is never merged
+		// into another one
+		err := os.Mkdir(backupPath, 0755)
+		if err == nil {
+			return backupPath, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create backup directory: %w", err)
+		}
+	}
+	return "", fmt.Errorf("failed to create backup directory: %d backups already exist for %s", maxBackupsPerSecond, now.Format(backupTimestampFormat))
+}
+
+// ListBackups scans backupDir for gpg-backup-YYYYMMDD-HHMMSS directories and
+// encrypted .tar.gpg backups, checks each directory for the expected files,
+// and returns them sorted newest-first. A missing backupDir yields no backups.
+func (s *Service) ListBackups(ctx context.Context, backupDir string) ([]BackupResult, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var results []BackupResult
+	for _, entry := range entries {
+		name := entry.Name()
+		encrypted := !entry.IsDir() && strings.HasSuffix(name, encryptedBackupSuffix)
+		if !entry.IsDir() && !encrypted {
+			continue
+		}
+
+		timestamp, sequence, ok := parseBackupName(strings.TrimSuffix(name, encryptedBackupSuffix))
+		if !ok {
+			continue
+		}
+
+		result := BackupResult{
+			Path:      filepath.Join(backupDir, name),
+			Timestamp: timestamp,
+			Encrypted: encrypted,
+			sequence:  sequence,
+		}
+		if !encrypted {
+			for _, file := range backupFiles {
+				if _, err := os.Stat(filepath.Join(result.Path, file)); err != nil {
+					result.Missing = append(result.Missing, file)
+				}
+			}
+		}
+		result.Complete = len(result.Missing) == 0
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].Timestamp.Equal(results[j].Timestamp) {
+			return results[i].Timestamp.After(results[j].Timestamp)
+		}
+		return results[i].sequence > results[j].sequence
+	})
+
+	return results, nil
+}
+
+// PruneBackups deletes every backup in backupDir except the keep most recent
+// complete ones, and returns the paths it removed. Only entries matching the
+// gpg-backup-* naming pattern are ever considered for removal.
+func (s *Service) PruneBackups(ctx context.Context, backupDir string, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("keep must be at least 1, got %d", keep)
+	}
+
+	backups, err := s.ListBackups(ctx, backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	kept := 0
+	for _, b := range backups {
+		if b.Complete && kept < keep {
+			kept++
+			continue
+		}
+
+		// Defense in depth: never remove anything outside the naming pattern
+		if !strings.HasPrefix(filepath.Base(b.Path), backupPrefix) {
+			continue
+		}
+
+		if err := os.RemoveAll(b.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", b.Path, err)
+		}
+		removed = append(removed, b.Path)
+	}
+
+	return removed, nil
+}
+
+// RestoreBackup imports the public key and then the ownertrust saved in a
+// backup directory created by CreateBackup, so trust levels apply to keys
+// that exist. Encrypted backups must be decrypted and unpacked first.
+func (s *Service) RestoreBackup(ctx context.Context, backupPath string) error {
+	if strings.HasSuffix(backupPath, encryptedBackupSuffix) {
+		return fmt.Errorf("%s is encrypted; decrypt and unpack it first with 'gpg --decrypt %s | tar -x'", backupPath, backupPath)
+	}
+
+	publicKeyData, err := os.ReadFile(filepath.Join(backupPath, "public-key.asc"))
+	if err != nil {
+		return fmt.Errorf("failed to read public key backup: %w", err)
+	}
+	trustData, err := os.ReadFile(filepath.Join(backupPath, "trustdb.txt"))
+	if err != nil {
+		return fmt.Errorf("failed to read trustdb backup: %w", err)
+	}
+
+	if err := s.gpgService.ImportKey(ctx, publicKeyData); err != nil {
+		return fmt.Errorf("failed to restore public key: %w", err)
+	}
+	if err := s.gpgService.ImportOwnerTrust(ctx, trustData); err != nil {
+		return fmt.Errorf("failed to restore ownertrust: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyBackup checks a backup directory created by CreateBackup: that
+// public-key.asc imports cleanly into a throwaway GnuPG home and holds the
+// primary key with the given fingerprint, and that key-list.txt can be
+// parsed and lists that key. The user's keyring is never touched.
+func (s *Service) VerifyBackup(ctx context.Context, backupPath, fingerprint string) error {
+	if strings.HasSuffix(backupPath, encryptedBackupSuffix) {
+		return fmt.Errorf("%s is encrypted; decrypt and unpack it first with 'gpg --decrypt %s | tar -x'", backupPath, backupPath)
+	}
+
+	keys, err := s.gpgService.ImportKeyIsolated(ctx, filepath.Join(backupPath, "public-key.asc"))
+	if err != nil {
+		return fmt.Errorf("public-key.asc does not import cleanly: %w", err)
+	}
+	matched := false
+	var found []string
+	for _, key := range keys {
+		matched = matched || gpg.KeyIDMatches(key.Fingerprint, fingerprint)
+		found = append(found, key.Fingerprint)
+	}
+	if !matched {
+		return fmt.Errorf("public-key.asc does not contain primary key %s (found: %s)", fingerprint, strings.Join(found, ", "))
+	}
+
+	keyList, err := os.ReadFile(filepath.Join(backupPath, "key-list.txt"))
+	if err != nil {
+		return fmt.Errorf("failed to read key list: %w", err)
+	}
+	listed, err := parseKeyList(string(keyList))
+	if err != nil {
+		return fmt.Errorf("key-list.txt is not valid: %w", err)
+	}
+	for _, key := range listed {
+		if key.Type == "sec" && gpg.KeyIDMatches(fingerprint, key.KeyID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("key-list.txt does not list primary key %s", fingerprint)
+}
+
+// parseBackupName extracts the timestamp and sequence number from a
+// gpg-backup-YYYYMMDD-HHMMSS name, optionally followed by a -N suffix for
+// later backups within the same second. The first backup has sequence 1.
+func parseBackupName(name string) (time.Time, int, bool) {
+	if !strings.HasPrefix(name, backupPrefix) {
+		return time.Time{}, 0, false
+	}
+	stamp := strings.TrimPrefix(name, backupPrefix)
+	sequence := 1
+	if len(stamp) > len(backupTimestampFormat) {
+		suffix, ok := strings.CutPrefix(stamp[len(backupTimestampFormat):], "-")
+		n, err := strconv.Atoi(suffix)
+		if !ok || err != nil || n < 2 || suffix != strconv.Itoa(n) {
+			return time.Time{}, 0, false
+		}
+		stamp, sequence = stamp[:len(backupTimestampFormat)], n
+	}
+	timestamp, err := time.ParseInLocation(backupTimestampFormat, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return timestamp, sequence, true
+}
+
+// tarDirectory writes the regular files in dir into a tar archive at tarPath.
+// Entries are stored under the directory's base name.
+func tarDirectory(dir, tarPath string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	tarFile, err := os.OpenFile(tarPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer tarFile.Close()
+
+	tw := tar.NewWriter(tarFile)
+	baseName := filepath.Base(dir)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		header := &tar.Header{
+			Name:    filepath.ToSlash(filepath.Join(baseName, entry.Name())),
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
 // formatKeyList formats a list of keys into a readable string.
 func formatKeyList(keys []gpg.Key) string {
 	var result string
@@ -97,6 +472,45 @@ func formatKeyList(keys []gpg.Key) string {
 	return result
 }
 
+// keyListTypes are the key types formatKeyList writes.
+var keyListTypes = map[string]bool{"sec": true, "ssb": true, "pub": true, "sub": true}
+
+// parseKeyList parses the key-list.txt written by formatKeyList, returning
+// the type and key ID of each key. Comment lines starting with # are
+// skipped. It is an error if a line doesn't have that form or the list is
+// empty.
+func parseKeyList(content string) ([]gpg.Key, error) {
+	var keys []gpg.Key
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !keyListTypes[fields[0]] || !isHex(fields[1]) || !strings.HasPrefix(fields[2], "[") {
+			return nil, fmt.Errorf("line %d: unexpected entry %q", i+1, line)
+		}
+		keys = append(keys, gpg.Key{Type: fields[0], KeyID: fields[1]})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys listed")
+	}
+	return keys, nil
+}
+
+// isHex reports whether s is a non-empty string of hexadecimal digits.
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
 // formatCapabilities formats capability flags as a string.
 func formatCapabilities(caps []string) string {
 	return fmt.Sprintf("%v", caps)
@@ -1,11 +1,17 @@
 package backup
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/bobbydams/yubikey-manager/internal/executor"
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,9 +19,15 @@ import (
 
 // MockGPGService is a simple mock implementation of GPGService for testing.
 type MockGPGService struct {
-	ExportPublicKeyFunc  func(ctx context.Context, keyID string) ([]byte, error)
-	ExportOwnerTrustFunc func(ctx context.Context) ([]byte, error)
-	ListSecretKeysFunc   func(ctx context.Context, keyID string) ([]gpg.Key, error)
+	ExportPublicKeyFunc   func(ctx context.Context, keyID string) ([]byte, error)
+	ExportOwnerTrustFunc  func(ctx context.Context) ([]byte, error)
+	ImportKeyFunc         func(ctx context.Context, keyData []byte) error
+	ImportOwnerTrustFunc  func(ctx context.Context, data []byte) error
+	ListSecretKeysFunc    func(ctx context.Context, keyID string) ([]gpg.Key, error)
+	EncryptSymmetricFunc  func(ctx context.Context, inputPath, outputPath, passphrase string) error
+	ImportKeyIsolatedFunc func(ctx context.Context, path string) ([]gpg.Key, error)
+
+	ExportSecretSubkeysFunc func(ctx context.Context, keyID string) ([]byte, error)
 }
 
 func (m *MockGPGService) ListSecretKeys(ctx context.Context, keyID string) ([]gpg.Key, error) {
@@ -25,6 +37,14 @@ func (m *MockGPGService) ListSecretKeys(ctx context.Context, keyID string) ([]gp
 	return nil, nil
 }
 
+func (m *MockGPGService) ListAllSecretKeys(ctx context.Context) ([]gpg.Key, error) {
+	return nil, nil
+}
+
+func (m *MockGPGService) ListPublicKeys(ctx context.Context, keyID string) ([]gpg.Key, error) {
+	return nil, nil
+}
+
 func (m *MockGPGService) CardStatus(ctx context.Context) (*gpg.CardInfo, error) {
 	return nil, nil
 }
@@ -36,7 +56,14 @@ func (m *MockGPGService) ExportPublicKey(ctx context.Context, keyID string) ([]b
 	return nil, nil
 }
 
+func (m *MockGPGService) ExportSSHKey(ctx context.Context, keyID string) ([]byte, error) {
+	return nil, nil
+}
+
 func (m *MockGPGService) ExportSecretSubkeys(ctx context.Context, keyID string) ([]byte, error) {
+	if m.ExportSecretSubkeysFunc != nil {
+		return m.ExportSecretSubkeysFunc(ctx, keyID)
+	}
 	return nil, nil
 }
 
@@ -45,9 +72,27 @@ func (m *MockGPGService) DeleteSecretKey(ctx context.Context, fingerprint string
 }
 
 func (m *MockGPGService) ImportKey(ctx context.Context, keyData []byte) error {
+	if m.ImportKeyFunc != nil {
+		return m.ImportKeyFunc(ctx, keyData)
+	}
 	return nil
 }
 
+func (m *MockGPGService) ImportKeyFile(ctx context.Context, path, fingerprint string) (*gpg.ImportResult, error) {
+	return &gpg.ImportResult{}, nil
+}
+
+func (m *MockGPGService) InspectKeyFile(ctx context.Context, path string) (*gpg.Key, error) {
+	return &gpg.Key{}, nil
+}
+
+func (m *MockGPGService) ImportKeyIsolated(ctx context.Context, path string) ([]gpg.Key, error) {
+	if m.ImportKeyIsolatedFunc != nil {
+		return m.ImportKeyIsolatedFunc(ctx, path)
+	}
+	return nil, nil
+}
+
 func (m *MockGPGService) ExportOwnerTrust(ctx context.Context) ([]byte, error) {
 	if m.ExportOwnerTrustFunc != nil {
 		return m.ExportOwnerTrustFunc(ctx)
@@ -55,6 +100,13 @@ func (m *MockGPGService) ExportOwnerTrust(ctx context.Context) ([]byte, error) {
 	return nil, nil
 }
 
+func (m *MockGPGService) ImportOwnerTrust(ctx context.Context, data []byte) error {
+	if m.ImportOwnerTrustFunc != nil {
+		return m.ImportOwnerTrustFunc(ctx, data)
+	}
+	return nil
+}
+
 func (m *MockGPGService) CheckTrustDB(ctx context.Context) error {
 	return nil
 }
@@ -63,6 +115,61 @@ func (m *MockGPGService) EditKey(ctx context.Context, keyID string) error {
 	return nil
 }
 
+func (m *MockGPGService) EncryptSymmetric(ctx context.Context, inputPath, outputPath, passphrase string) error {
+	if m.EncryptSymmetricFunc != nil {
+		return m.EncryptSymmetricFunc(ctx, inputPath, outputPath, passphrase)
+	}
+	return nil
+}
+
+func (m *MockGPGService) GPGVersion(ctx context.Context) (int, int, int, error) {
+	return 2, 4, 0, nil
+}
+
+func (m *MockGPGService) PingAgent(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockGPGService) LaunchAgent(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockGPGService) AddSubkey(ctx context.Context, fingerprint, algorithm, usage, expiry, passphrase string) error {
+	return nil
+}
+
+func (m *MockGPGService) MoveSubkeyToCard(ctx context.Context, keyID, subkeyID string, slot int, passphrase, adminPIN string) error {
+	return nil
+}
+
+func (m *MockGPGService) GenerateRevocationCertificate(ctx context.Context, keyID string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockGPGService) ExtendExpiration(ctx context.Context, keyID string, subkeyIDs []string, expiry string, passphrase string) error {
+	return nil
+}
+
+func (m *MockGPGService) PublishKey(ctx context.Context, keyID string, servers []string) []error {
+	return make([]error, len(servers))
+}
+
+func (m *MockGPGService) RefreshKey(ctx context.Context, keyID, server string) (bool, error) {
+	return false, nil
+}
+
+func (m *MockGPGService) ExportPublicKeyMinimal(ctx context.Context, keyID string) ([]byte, error) {
+	return m.ExportPublicKey(ctx, keyID)
+}
+
+func (m *MockGPGService) ExportPublicKeyBinary(ctx context.Context, keyID string, minimal bool) ([]byte, error) {
+	return m.ExportPublicKey(ctx, keyID)
+}
+
+func (m *MockGPGService) ExportForWKD(ctx context.Context, email string) (string, []byte, error) {
+	return "", nil, nil
+}
+
 func TestService_CreateBackup(t *testing.T) {
 	keyID := "ABC123DEF4567890"
 	publicKeyData := []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----")
@@ -103,6 +210,7 @@ func TestService_CreateBackup(t *testing.T) {
 	assert.FileExists(t, publicKeyPath)
 	assert.FileExists(t, trustPath)
 	assert.FileExists(t, keyListPath)
+	assert.NoFileExists(t, filepath.Join(backupPath, "secret-subkeys.gpg"), "secret subkeys are opt-in")
 
 	// Verify file contents
 	publicKeyContent, err := os.ReadFile(publicKeyPath)
@@ -113,3 +221,415 @@ func TestService_CreateBackup(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, trustData, trustContent)
 }
+
+func TestService_CreateEncryptedBackup(t *testing.T) {
+	keyID := "ABC123DEF4567890"
+	var encryptedInput []byte
+	var usedPassphrase string
+
+	mockGPG := &MockGPGService{
+		ExportPublicKeyFunc: func(ctx context.Context, kID string) ([]byte, error) {
+			return []byte("public key"), nil
+		},
+		ExportOwnerTrustFunc: func(ctx context.Context) ([]byte, error) {
+			return []byte("trust data"), nil
+		},
+		EncryptSymmetricFunc: func(ctx context.Context, inputPath, outputPath, passphrase string) error {
+			var err error
+			encryptedInput, err = os.ReadFile(inputPath)
+			if err != nil {
+				return err
+			}
+			usedPassphrase = passphrase
+			return os.WriteFile(outputPath, []byte("encrypted"), 0600)
+		},
+	}
+	svc := NewService(mockGPG)
+
+	tmpDir, err := os.MkdirTemp("", "backup-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	encryptedPath, err := svc.CreateEncryptedBackup(context.Background(), keyID, tmpDir, "s3cret")
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(encryptedPath, ".tar.gpg"))
+	assert.FileExists(t, encryptedPath)
+	assert.Equal(t, "s3cret", usedPassphrase)
+
+	// The tarball handed to gpg should contain the backup files
+	tr := tar.NewReader(bytes.NewReader(encryptedInput))
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, filepath.Base(header.Name))
+	}
+	assert.ElementsMatch(t, []string{"public-key.asc", "trustdb.txt", "key-list.txt"}, names)
+
+	// Only the encrypted file should remain
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestSecretSubkeysNote(t *testing.T) {
+	primary := gpg.Key{Type: "sec", KeyID: "ABC123DEF4567890", Secret: gpg.SecretStub}
+	local := gpg.Key{Type: "ssb", KeyID: "0257F6B8152D7F35", Secret: gpg.SecretLocal}
+	onCard := gpg.Key{Type: "ssb", KeyID: "DC47D1B090A51498", Secret: gpg.SecretOnCard}
+	stub := gpg.Key{Type: "ssb", KeyID: "116DB85718F8B287", Secret: gpg.SecretStub}
+
+	assert.Equal(t, "# secret subkeys included in secret-subkeys.gpg: 0257F6B8152D7F35\n",
+		secretSubkeysNote([]gpg.Key{primary, local}))
+	assert.Equal(t, "# secret subkeys included in secret-subkeys.gpg: 0257F6B8152D7F35\n"+
+		"# stubs only, the secret part is on a card or offline: DC47D1B090A51498, 116DB85718F8B287\n",
+		secretSubkeysNote([]gpg.Key{primary, local, onCard, stub}))
+
+	note := secretSubkeysNote([]gpg.Key{primary, onCard, stub})
+	assert.Contains(t, note, "stubs only")
+	assert.NotContains(t, note, "included", "subkeys moved to cards aren't in the backup")
+}
+
+func TestService_CreateBackup_IncludeSecretSubkeys(t *testing.T) {
+	mock := &MockGPGService{
+		ExportPublicKeyFunc: func(ctx context.Context, keyID string) ([]byte, error) {
+			return []byte("public key"), nil
+		},
+		ListSecretKeysFunc: func(ctx context.Context, keyID string) ([]gpg.Key, error) {
+			return []gpg.Key{
+				{Type: "sec", KeyID: "ABC123DEF4567890", Capabilities: []string{"C"}},
+				{Type: "ssb", KeyID: "0257F6B8152D7F35", Capabilities: []string{"E"}, Secret: gpg.SecretLocal},
+			}, nil
+		},
+		ExportSecretSubkeysFunc: func(ctx context.Context, keyID string) ([]byte, error) {
+			return []byte("secret subkeys"), nil
+		},
+	}
+	svc := NewService(mock)
+	svc.IncludeSecretSubkeys = true
+
+	backupPath, err := svc.CreateBackup(context.Background(), "ABC123DEF4567890", t.TempDir())
+	require.NoError(t, err)
+
+	subkeysPath := filepath.Join(backupPath, "secret-subkeys.gpg")
+	data, err := os.ReadFile(subkeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, "secret subkeys", string(data))
+	info, err := os.Stat(subkeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	keyList, err := os.ReadFile(filepath.Join(backupPath, "key-list.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(keyList), "secret subkeys included in secret-subkeys.gpg: 0257F6B8152D7F35")
+	keys, err := parseKeyList(string(keyList))
+	require.NoError(t, err, "the note doesn't break parsing")
+	assert.Len(t, keys, 2)
+
+	t.Run("export fails", func(t *testing.T) {
+		mock.ExportSecretSubkeysFunc = func(ctx context.Context, keyID string) ([]byte, error) {
+			return nil, fmt.Errorf("no secret subkeys")
+		}
+		_, err := svc.CreateBackup(context.Background(), "ABC123DEF4567890", t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to export secret subkeys")
+	})
+}
+
+func TestService_CreateBackup_SameSecond(t *testing.T) {
+	mock := &MockGPGService{
+		ExportPublicKeyFunc: func(ctx context.Context, keyID string) ([]byte, error) {
+			return []byte("public key"), nil
+		},
+	}
+	svc := NewService(mock)
+	tmpDir := t.TempDir()
+
+	first, err := svc.CreateBackup(context.Background(), "ABC123DEF4567890", tmpDir)
+	require.NoError(t, err)
+	second, err := svc.CreateBackup(context.Background(), "ABC123DEF4567890", tmpDir)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	backups, err := svc.ListBackups(context.Background(), tmpDir)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, second, backups[0].Path, "the later backup is listed first")
+	assert.Equal(t, first, backups[1].Path)
+}
+
+func TestCreateBackupDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+
+	first, err := createBackupDir(tmpDir, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "gpg-backup-20240102-030405"), first)
+
+	second, err := createBackupDir(tmpDir, now)
+	require.NoError(t, err)
+	assert.Equal(t, first+"-2", second)
+
+	// An encrypted backup from the same second is never overwritten either
+	require.NoError(t, os.WriteFile(first+"-3.tar.gpg", []byte("encrypted"), 0600))
+	third, err := createBackupDir(tmpDir, now)
+	require.NoError(t, err)
+	assert.Equal(t, first+"-4", third)
+
+	// A file in the way of the backup directory is an error, not reused
+	blocked := filepath.Join(tmpDir, "blocked")
+	require.NoError(t, os.WriteFile(blocked, []byte("file"), 0644))
+	_, err = createBackupDir(blocked, now)
+	assert.Error(t, err)
+}
+
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name         string
+		wantSequence int
+		wantOK       bool
+	}{
+		{name: "gpg-backup-20240102-030405", wantSequence: 1, wantOK: true},
+		{name: "gpg-backup-20240102-030405-2", wantSequence: 2, wantOK: true},
+		{name: "gpg-backup-20240102-030405-12", wantSequence: 12, wantOK: true},
+		{name: "gpg-backup-20240102-030405-1"},
+		{name: "gpg-backup-20240102-030405-02"},
+		{name: "gpg-backup-20240102-030405-x"},
+		{name: "gpg-backup-20240102-030405x"},
+		{name: "gpg-backup-notadate"},
+		{name: "backup-20240102-030405"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamp, sequence, ok := parseBackupName(tt.name)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantSequence, sequence)
+			if ok {
+				assert.Equal(t, "2024-01-02 03:04:05", timestamp.Format("2006-01-02 15:04:05"))
+			}
+		})
+	}
+}
+
+func TestService_CreateEncryptedBackup_RequiresPassphrase(t *testing.T) {
+	svc := NewService(&MockGPGService{})
+
+	_, err := svc.CreateEncryptedBackup(context.Background(), "ABC123DEF4567890", t.TempDir(), "")
+	assert.Error(t, err)
+}
+
+func TestService_ListBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Complete backup
+	complete := filepath.Join(tmpDir, "gpg-backup-20240102-030405")
+	require.NoError(t, os.MkdirAll(complete, 0755))
+	for _, file := range []string{"public-key.asc", "trustdb.txt", "key-list.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(complete, file), []byte("data"), 0644))
+	}
+
+	// Incomplete backup, newer
+	incomplete := filepath.Join(tmpDir, "gpg-backup-20240201-000000")
+	require.NoError(t, os.MkdirAll(incomplete, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(incomplete, "public-key.asc"), []byte("data"), 0644))
+
+	// Encrypted backup, oldest
+	encrypted := filepath.Join(tmpDir, "gpg-backup-20231231-235959.tar.gpg")
+	require.NoError(t, os.WriteFile(encrypted, []byte("encrypted"), 0600))
+
+	// Entries that must be ignored
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "gpg-backup-notadate"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "other-dir"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "gpg-backup-20240101-000000"), []byte("file"), 0644))
+
+	svc := NewService(&MockGPGService{})
+	backups, err := svc.ListBackups(context.Background(), tmpDir)
+
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+
+	assert.Equal(t, incomplete, backups[0].Path)
+	assert.False(t, backups[0].Complete)
+	assert.Equal(t, []string{"trustdb.txt", "key-list.txt"}, backups[0].Missing)
+
+	assert.Equal(t, complete, backups[1].Path)
+	assert.True(t, backups[1].Complete)
+	assert.Equal(t, "2024-01-02 03:04:05", backups[1].Timestamp.Format("2006-01-02 15:04:05"))
+
+	assert.Equal(t, encrypted, backups[2].Path)
+	assert.True(t, backups[2].Encrypted)
+	assert.True(t, backups[2].Complete)
+}
+
+func TestService_ListBackups_MissingDir(t *testing.T) {
+	svc := NewService(&MockGPGService{})
+
+	backups, err := svc.ListBackups(context.Background(), filepath.Join(t.TempDir(), "does-not-exist"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestService_PruneBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	makeBackup := func(name string, complete bool) string {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.MkdirAll(path, 0755))
+		files := []string{"public-key.asc"}
+		if complete {
+			files = append(files, "trustdb.txt", "key-list.txt")
+		}
+		for _, file := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(path, file), []byte("data"), 0644))
+		}
+		return path
+	}
+
+	newest := makeBackup("gpg-backup-20240301-000000", true)
+	incomplete := makeBackup("gpg-backup-20240201-000000", false)
+	second := makeBackup("gpg-backup-20240101-000000", true)
+	oldest := makeBackup("gpg-backup-20231201-000000", true)
+	unrelated := filepath.Join(tmpDir, "keep-me")
+	require.NoError(t, os.MkdirAll(unrelated, 0755))
+
+	svc := NewService(&MockGPGService{})
+	removed, err := svc.PruneBackups(context.Background(), tmpDir, 2)
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{incomplete, oldest}, removed)
+	assert.DirExists(t, newest)
+	assert.DirExists(t, second)
+	assert.DirExists(t, unrelated)
+	assert.NoDirExists(t, incomplete)
+	assert.NoDirExists(t, oldest)
+}
+
+func TestService_PruneBackups_InvalidKeep(t *testing.T) {
+	svc := NewService(&MockGPGService{})
+
+	_, err := svc.PruneBackups(context.Background(), t.TempDir(), 0)
+	assert.Error(t, err)
+}
+
+func TestService_RestoreBackup_OwnerTrustRoundTrip(t *testing.T) {
+	keyID := "07AAA1E535650AF5"
+	publicKeyData := []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----\n")
+	trustData := []byte("# List of assigned trustvalues\nFA57C85131F11B28EE236A4F07AAA1E535650AF5:6:\n")
+
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --export --armor "+keyID, publicKeyData)
+	mockExec.SetOutput("gpg --export-ownertrust", trustData)
+	svc := NewService(gpg.NewService(mockExec))
+
+	backupPath, err := svc.CreateBackup(context.Background(), keyID, t.TempDir())
+	require.NoError(t, err)
+
+	mockExec.Reset()
+	require.NoError(t, svc.RestoreBackup(context.Background(), backupPath))
+
+	// The public key is imported before the trust that refers to it
+	require.Len(t, mockExec.Calls, 2)
+	assert.Equal(t, "--import", mockExec.Calls[0].Args[0])
+	assert.Equal(t, []string{"--import-ownertrust"}, mockExec.Calls[1].Args)
+	assert.Equal(t, trustData, mockExec.Calls[1].Input)
+}
+
+func TestService_RestoreBackup_Errors(t *testing.T) {
+	svc := NewService(&MockGPGService{})
+
+	err := svc.RestoreBackup(context.Background(), filepath.Join(t.TempDir(), "gpg-backup-20240101-000000.tar.gpg"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decrypt and unpack it first")
+
+	backupPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(backupPath, "public-key.asc"), []byte("key"), 0644))
+	err = svc.RestoreBackup(context.Background(), backupPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read trustdb backup")
+}
+
+func TestService_VerifyBackup(t *testing.T) {
+	fingerprint := "FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+	importKey := func(fingerprints ...string) func(ctx context.Context, path string) ([]gpg.Key, error) {
+		return func(ctx context.Context, path string) ([]gpg.Key, error) {
+			var keys []gpg.Key
+			for _, fpr := range fingerprints {
+				keys = append(keys, gpg.Key{Type: "pub", Fingerprint: fpr})
+			}
+			return keys, nil
+		}
+	}
+	mock := &MockGPGService{
+		ExportPublicKeyFunc: func(ctx context.Context, keyID string) ([]byte, error) {
+			return []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n"), nil
+		},
+		ListSecretKeysFunc: func(ctx context.Context, keyID string) ([]gpg.Key, error) {
+			return []gpg.Key{
+				{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}},
+				{Type: "ssb", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}, CardNo: "0006 12345678"},
+			}, nil
+		},
+		ImportKeyIsolatedFunc: importKey(fingerprint),
+	}
+	svc := NewService(mock)
+	backupPath, err := svc.CreateBackup(context.Background(), "07AAA1E535650AF5", t.TempDir())
+	require.NoError(t, err)
+
+	t.Run("valid backup", func(t *testing.T) {
+		assert.NoError(t, svc.VerifyBackup(context.Background(), backupPath, fingerprint))
+	})
+
+	t.Run("different key", func(t *testing.T) {
+		mock.ImportKeyIsolatedFunc = importKey("0000000000000000000000001111222233334444")
+		defer func() { mock.ImportKeyIsolatedFunc = importKey(fingerprint) }()
+
+		err := svc.VerifyBackup(context.Background(), backupPath, fingerprint)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not contain primary key")
+	})
+
+	t.Run("import fails", func(t *testing.T) {
+		mock.ImportKeyIsolatedFunc = func(ctx context.Context, path string) ([]gpg.Key, error) {
+			return nil, fmt.Errorf("no valid OpenPGP data found")
+		}
+		defer func() { mock.ImportKeyIsolatedFunc = importKey(fingerprint) }()
+
+		err := svc.VerifyBackup(context.Background(), backupPath, fingerprint)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not import cleanly")
+	})
+
+	t.Run("corrupt key list", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(backupPath, "key-list.txt"), []byte("garbage\n"), 0644))
+
+		err := svc.VerifyBackup(context.Background(), backupPath, fingerprint)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key-list.txt is not valid")
+	})
+
+	t.Run("encrypted backup", func(t *testing.T) {
+		err := svc.VerifyBackup(context.Background(), backupPath+".tar.gpg", fingerprint)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "decrypt and unpack it first")
+	})
+}
+
+func TestParseKeyList(t *testing.T) {
+	keys, err := parseKeyList(formatKeyList([]gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}, Expires: "2030-01-01"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}, CardNo: "0006 12345678"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []gpg.Key{{Type: "sec", KeyID: "07AAA1E535650AF5"}, {Type: "ssb", KeyID: "DC47D1B090A51498"}}, keys)
+
+	_, err = parseKeyList("")
+	assert.Error(t, err)
+
+	_, err = parseKeyList("sec 07AAA1E535650AF5 [[S C]]\nssb not-a-key [[S]]\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
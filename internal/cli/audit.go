@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/git"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// auditSchemaVersion is the version of the JSON document emitted by
+// `audit --json`. Bump it on any incompatible change to the fields below.
+const auditSchemaVersion = 1
+
+// auditReport is the JSON document emitted by `audit --json`.
+type auditReport struct {
+	Schema int           `json:"schema"`
+	Score  int           `json:"score"`
+	Grade  string        `json:"grade"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// auditInput is the state the audit checks are computed from.
+type auditInput struct {
+	keys     []gpg.Key
+	primary  string // primary key fingerprint, or key ID if unknown
+	now      time.Time
+	warnDays int
+
+	// touchPolicies is keyed by slot ("sig", "enc", "aut"); touchErr is set
+	// if they couldn't be read.
+	touchPolicies map[string]string
+	touchErr      error
+
+	gitSigningKey string
+	gitCommitSign string
+}
+
+func newAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Score the security of your key and YubiKey setup",
+		Long: `Run the security checks from status, verify and doctor in one go and
+grade the result:
+
+  - the master key is offline
+  - every active signing subkey is on a YubiKey
+  - no key has expired or expires soon (expiry_warn_days)
+  - the YubiKey requires a touch to sign (needs ykman)
+  - the signing subkeys use ed25519
+  - git signs commits with your key
+
+Each check passes (2 points), warns (1) or fails (0); the score is the
+percentage of the maximum. The command exits with an error if any check fails.`,
+		SilenceUsage: true,
+		RunE:         runAudit,
+	}
+
+	cmd.Flags().Bool("json", false, "Output the audit as a versioned JSON document")
+
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	gpgSvc, yubikeySvc, _ := getServices()
+	gitSvc := git.NewService(newExecutor())
+	ctx := cmd.Context()
+
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	primary := cfg.PrimaryKeyFingerprint
+	if primary == "" {
+		primary = cfg.PrimaryKeyID
+	}
+	in := auditInput{
+		keys:          keys,
+		primary:       primary,
+		now:           time.Now(),
+		warnDays:      cfg.ExpiryWarnDays,
+		gitSigningKey: getGitConfig(ctx, gitSvc, "user.signingkey"),
+		gitCommitSign: getGitConfig(ctx, gitSvc, "commit.gpgsign"),
+	}
+
+	// Bound the card checks so a gpg waiting on the agent can't hang the audit
+	cardCtx, cancel := context.WithTimeout(ctx, watchCardTimeout)
+	defer cancel()
+	if present, err := yubikeySvc.IsPresent(cardCtx); err != nil || !present {
+		in.touchErr = fmt.Errorf("no YubiKey detected")
+	} else {
+		in.touchPolicies, in.touchErr = yubikeySvc.GetTouchPolicy(cardCtx)
+	}
+
+	checks := auditChecks(in)
+	score, grade := auditScore(checks)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(auditReport{Schema: auditSchemaVersion, Score: score, Grade: grade, Checks: checks}); err != nil {
+			return err
+		}
+	} else {
+		ui.PrintHeader("Security Audit")
+		for _, check := range checks {
+			printCheckLine(check)
+		}
+		fmt.Println()
+		ui.PrintKeyValue("Score", fmt.Sprintf("%d/100", score))
+		ui.PrintKeyValue("Grade", grade)
+		fmt.Println()
+	}
+
+	if failures := countChecks(checks, CheckFail); failures > 0 {
+		return fmt.Errorf("audit found %d failing check(s)", failures)
+	}
+	return nil
+}
+
+// getGitConfig returns the global git config value of key with surrounding
+// whitespace, including a CRLF line ending, removed. It is "" if the key is
+// unset or git config can't be read.
+func getGitConfig(ctx context.Context, gitSvc git.GitService, key string) string {
+	value, err := gitSvc.GetConfig(ctx, git.ScopeGlobal, key)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// auditChecks runs the audit checks against in.
+func auditChecks(in auditInput) []CheckResult {
+	var checks []CheckResult
+	add := func(name, status, message string) {
+		checks = append(checks, CheckResult{Name: name, Status: status, Message: message})
+	}
+
+	switch found, onMachine := gpg.MasterKeyOnMachine(in.keys, in.primary); {
+	case !found:
+		add("master key is offline", CheckWarn, "primary key not in the secret key listing")
+	case onMachine:
+		add("master key is offline", CheckFail, "the master key's secret is on this machine; remove it after backing it up")
+	default:
+		add("master key is offline", CheckPass, "")
+	}
+
+	live := liveSigningSubkeys(in.keys, in.now)
+	var local []string
+	for _, key := range live {
+		if key.Secret == gpg.SecretLocal {
+			local = append(local, key.KeyID)
+		}
+	}
+	switch {
+	case len(live) == 0:
+		add("signing subkeys are on YubiKeys", CheckFail, "no active signing subkey")
+	case len(local) > 0:
+		add("signing subkeys are on YubiKeys", CheckFail, fmt.Sprintf("on this machine: %s; run 'ykgpg move-subkey'", strings.Join(local, ", ")))
+	default:
+		add("signing subkeys are on YubiKeys", CheckPass, fmt.Sprintf("%d active", len(live)))
+	}
+
+	var primaryNotice string
+	for _, key := range in.keys {
+		if isPrimaryKey(key, in.primary) {
+			primaryNotice = expiryNotice(key.Expires, in.now, in.warnDays)
+		}
+	}
+	onCard, localExpiring := expiringSubkeys(in.keys, in.now, in.warnDays)
+	var expiring []string
+	for _, key := range append(onCard, localExpiring...) {
+		expiring = append(expiring, fmt.Sprintf("%s %s", key.KeyID, expiryNotice(key.Expires, in.now, in.warnDays)))
+	}
+	switch {
+	case strings.HasPrefix(primaryNotice, "expired"):
+		add("keys are not expiring", CheckFail, "primary key "+primaryNotice)
+	case primaryNotice != "":
+		add("keys are not expiring", CheckWarn, "primary key "+primaryNotice+"; run 'ykgpg extend'")
+	case len(expiring) > 0:
+		add("keys are not expiring", CheckWarn, strings.Join(expiring, "; "))
+	default:
+		add("keys are not expiring", CheckPass, "")
+	}
+
+	switch policy := in.touchPolicies["sig"]; {
+	case in.touchErr != nil:
+		add("signing requires touch", CheckWarn, fmt.Sprintf("not checked: %v", in.touchErr))
+	case policy == "" || policy == "off":
+		add("signing requires touch", CheckWarn, "touch policy is off; run 'ykgpg touch set sig on'")
+	default:
+		add("signing requires touch", CheckPass, "touch policy: "+policy)
+	}
+
+	var nonECC []string
+	for _, key := range live {
+		if !isCurve25519(key.Algorithm) {
+			nonECC = append(nonECC, fmt.Sprintf("%s (%s)", key.KeyID, key.Algorithm))
+		}
+	}
+	switch {
+	case len(live) == 0:
+		add("signing subkeys use ed25519", CheckWarn, "no active signing subkey")
+	case len(nonECC) > 0:
+		add("signing subkeys use ed25519", CheckWarn, strings.Join(nonECC, ", "))
+	default:
+		add("signing subkeys use ed25519", CheckPass, "")
+	}
+
+	keyMatches := in.gitSigningKey != "" && in.primary != "" &&
+		(containsString(in.gitSigningKey, in.primary) || gpg.KeyIDMatches(in.primary, strings.TrimSuffix(in.gitSigningKey, "!")))
+	switch {
+	case !keyMatches:
+		add("git signs commits", CheckFail, fmt.Sprintf("user.signingkey is %q; run 'ykgpg git-config'", in.gitSigningKey))
+	case in.gitCommitSign != "true":
+		add("git signs commits", CheckFail, "commit.gpgsign is not enabled; run 'ykgpg git-config'")
+	default:
+		add("git signs commits", CheckPass, "")
+	}
+
+	return checks
+}
+
+// auditScore scores checks at 2 points per pass and 1 per warning, as a
+// percentage of the maximum, and grades the score from A to F.
+func auditScore(checks []CheckResult) (int, string) {
+	if len(checks) == 0 {
+		return 0, "F"
+	}
+	points := 2*countChecks(checks, CheckPass) + countChecks(checks, CheckWarn)
+	score := points * 100 / (2 * len(checks))
+
+	switch {
+	case score >= 90:
+		return score, "A"
+	case score >= 75:
+		return score, "B"
+	case score >= 60:
+		return score, "C"
+	case score >= 40:
+		return score, "D"
+	default:
+		return score, "F"
+	}
+}
+
+// countChecks returns the number of checks with the given status.
+func countChecks(checks []CheckResult, status string) int {
+	n := 0
+	for _, check := range checks {
+		if check.Status == status {
+			n++
+		}
+	}
+	return n
+}
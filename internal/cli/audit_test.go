@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/git"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuditCmd(t *testing.T) {
+	cmd := newAuditCmd()
+	assert.Equal(t, "audit", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("json"))
+}
+
+func TestGetGitConfig(t *testing.T) {
+	ctx := context.Background()
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("git config --global --get user.name", []byte("Test User\n"))
+	mockExec.SetOutput("git config --global --get user.email", []byte("test@example.com"))
+	mockExec.SetOutput("git config --global --get commit.gpgsign", []byte("true\r\n"))
+	mockExec.SetOutput("git config --global --get user.signingkey", []byte(""))
+	mockExec.SetError("git config --global --get gpg.program", fmt.Errorf("exit status 1"))
+	gitSvc := git.NewService(mockExec)
+
+	assert.Equal(t, "Test User", getGitConfig(ctx, gitSvc, "user.name"))
+	assert.Equal(t, "test@example.com", getGitConfig(ctx, gitSvc, "user.email"), "no trailing newline")
+	assert.Equal(t, "true", getGitConfig(ctx, gitSvc, "commit.gpgsign"), "CRLF line ending")
+	assert.Empty(t, getGitConfig(ctx, gitSvc, "user.signingkey"), "empty output")
+	assert.Empty(t, getGitConfig(ctx, gitSvc, "gpg.program"), "git config fails")
+	assert.Empty(t, getGitConfig(ctx, gitSvc, "tag.gpgsign"), "unset key")
+}
+
+// goodAuditInput returns an audit input for which every check passes.
+func goodAuditInput() auditInput {
+	return auditInput{
+		keys: []gpg.Key{
+			{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: "FA57C85131F11B28EE236A4F07AAA1E535650AF5",
+				Capabilities: []string{"S", "C"}, Expires: "2030-09-04", Secret: gpg.SecretStub},
+			{Type: "ssb", KeyID: "DC47D1B090A51498", Algorithm: "ed25519", Capabilities: []string{"S"},
+				Expires: "2030-09-04", Secret: gpg.SecretOnCard, CardSerial: "12345678"},
+		},
+		primary:       "FA57C85131F11B28EE236A4F07AAA1E535650AF5",
+		now:           time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		warnDays:      30,
+		touchPolicies: map[string]string{"sig": "on", "enc": "off", "aut": "off"},
+		gitSigningKey: "07AAA1E535650AF5",
+		gitCommitSign: "true",
+	}
+}
+
+// checkStatus returns the status of the named check.
+func checkStatus(t *testing.T, checks []CheckResult, name string) string {
+	t.Helper()
+	for _, check := range checks {
+		if check.Name == name {
+			return check.Status
+		}
+	}
+	require.Failf(t, "missing check", "no check named %q", name)
+	return ""
+}
+
+func TestAuditChecks_AllPass(t *testing.T) {
+	checks := auditChecks(goodAuditInput())
+
+	require.Len(t, checks, 6)
+	for _, check := range checks {
+		assert.Equal(t, CheckPass, check.Status, "%s: %s", check.Name, check.Message)
+	}
+	score, grade := auditScore(checks)
+	assert.Equal(t, 100, score)
+	assert.Equal(t, "A", grade)
+}
+
+func TestAuditChecks_Problems(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(in *auditInput)
+		check  string
+		want   string
+	}{
+		{"master key on machine", func(in *auditInput) { in.keys[0].Secret = gpg.SecretLocal }, "master key is offline", CheckFail},
+		{"signing subkey local", func(in *auditInput) { in.keys[1].Secret = gpg.SecretLocal }, "signing subkeys are on YubiKeys", CheckFail},
+		{"subkey expiring", func(in *auditInput) { in.keys[1].Expires = "2026-10-30" }, "keys are not expiring", CheckWarn},
+		{"primary expired", func(in *auditInput) { in.keys[0].Expires = "2026-10-01" }, "keys are not expiring", CheckFail},
+		{"no touch", func(in *auditInput) { in.touchPolicies["sig"] = "off" }, "signing requires touch", CheckWarn},
+		{"touch unknown", func(in *auditInput) { in.touchErr = fmt.Errorf("ykman is not installed") }, "signing requires touch", CheckWarn},
+		{"rsa subkey", func(in *auditInput) { in.keys[1].Algorithm = "rsa4096" }, "signing subkeys use ed25519", CheckWarn},
+		{"git key mismatch", func(in *auditInput) { in.gitSigningKey = "1111222233334444" }, "git signs commits", CheckFail},
+		{"git signing off", func(in *auditInput) { in.gitCommitSign = "" }, "git signs commits", CheckFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := goodAuditInput()
+			tt.modify(&in)
+			assert.Equal(t, tt.want, checkStatus(t, auditChecks(in), tt.check))
+		})
+	}
+}
+
+func TestAuditScore(t *testing.T) {
+	checks := []CheckResult{{Status: CheckPass}, {Status: CheckPass}, {Status: CheckWarn}, {Status: CheckFail}}
+	score, grade := auditScore(checks)
+	assert.Equal(t, 62, score)
+	assert.Equal(t, "C", grade)
+
+	_, grade = auditScore(nil)
+	assert.Equal(t, "F", grade)
+}
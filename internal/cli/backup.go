@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Manage key backups",
+		Long:  "Commands for inspecting and restoring the backups stored in the backup directory",
+	}
+
+	cmd.AddCommand(newBackupListCmd())
+	cmd.AddCommand(newBackupPruneCmd())
+	cmd.AddCommand(newBackupRestoreCmd())
+	cmd.AddCommand(newBackupVerifyCmd())
+
+	return cmd
+}
+
+func newBackupListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List existing backups, newest first",
+		Long: `List the gpg-backup-YYYYMMDD-HHMMSS backups in the backup directory,
+showing when each was taken and whether it contains all expected files.`,
+		RunE: runBackupList,
+	}
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	_, _, backupSvc := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Backups")
+
+	backups, err := backupSvc.ListBackups(ctx, cfg.BackupDir)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		ui.LogInfo("No backups found in %s", cfg.BackupDir)
+		return nil
+	}
+
+	for _, b := range backups {
+		date := b.Timestamp.Format("2006-01-02 15:04:05")
+		switch {
+		case b.Encrypted:
+			fmt.Printf("  %s  %s  %s\n", date, ui.SuccessColor.Sprint("encrypted "), b.Path)
+		case b.Complete:
+			fmt.Printf("  %s  %s  %s\n", date, ui.SuccessColor.Sprint("complete  "), b.Path)
+		default:
+			fmt.Printf("  %s  %s  %s (missing: %s)\n", date, ui.ErrorColor.Sprint("incomplete"), b.Path, strings.Join(b.Missing, ", "))
+		}
+	}
+
+	fmt.Println()
+	ui.LogInfo("%d backup(s) in %s", len(backups), cfg.BackupDir)
+
+	return nil
+}
+
+func newBackupPruneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old backups, keeping the most recent ones",
+		Long: `Delete all but the --keep most recent complete backups in the backup directory.
+Incomplete backups are removed as well. Only gpg-backup-* entries are ever deleted.`,
+		RunE: runBackupPrune,
+	}
+
+	cmd.Flags().Int("keep", 5, "Number of most recent complete backups to keep")
+	cmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+
+	return cmd
+}
+
+func runBackupPrune(cmd *cobra.Command, args []string) error {
+	_, _, backupSvc := getServices()
+	ctx := cmd.Context()
+
+	keep, _ := cmd.Flags().GetInt("keep")
+	skipConfirm, _ := cmd.Flags().GetBool("yes")
+	if keep < 1 {
+		return fmt.Errorf("--keep must be at least 1")
+	}
+
+	ui.PrintHeader("Prune Backups")
+
+	if !skipConfirm && !ui.Confirm(fmt.Sprintf("Delete all but the %d most recent backups in %s?", keep, cfg.BackupDir)) {
+		ui.LogInfo("Prune cancelled")
+		return nil
+	}
+
+	removed, err := backupSvc.PruneBackups(ctx, cfg.BackupDir, keep)
+	for _, path := range removed {
+		ui.LogInfo("Removed %s", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		ui.LogSuccess("Nothing to prune")
+		return nil
+	}
+	ui.LogSuccess("Removed %d backup(s)", len(removed))
+
+	return nil
+}
+
+func newBackupRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <backup-path>",
+		Short: "Import the public key and ownertrust from a backup",
+		Long: `Import public-key.asc and then trustdb.txt from a gpg-backup-YYYYMMDD-HHMMSS
+directory, restoring the public key and the trust levels assigned to keys.
+Secret keys are not part of these backups. Encrypted .tar.gpg backups must be
+decrypted and unpacked first.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runBackupRestore,
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+
+	return cmd
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	_, _, backupSvc := getServices()
+	ctx := cmd.Context()
+	backupPath := args[0]
+
+	skipConfirm, _ := cmd.Flags().GetBool("yes")
+
+	ui.PrintHeader("Restore Backup")
+
+	if !skipConfirm && !ui.Confirm(fmt.Sprintf("Import the public key and ownertrust from %s?", backupPath)) {
+		ui.LogInfo("Restore cancelled")
+		return nil
+	}
+
+	if err := backupSvc.RestoreBackup(ctx, backupPath); err != nil {
+		return err
+	}
+	ui.LogSuccess("Restored public key and ownertrust from %s", backupPath)
+
+	return nil
+}
+
+func newBackupVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify <backup-path>",
+		Short: "Check that a backup holds your key and can be restored",
+		Long: `Check a gpg-backup-YYYYMMDD-HHMMSS directory without restoring it: import
+public-key.asc into a throwaway GnuPG home, check it holds the primary key
+with the configured fingerprint, and check that key-list.txt can be parsed
+and lists that key. Your keyring is not modified. Encrypted .tar.gpg backups
+must be decrypted and unpacked first.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runBackupVerify,
+	}
+}
+
+func runBackupVerify(cmd *cobra.Command, args []string) error {
+	_, _, backupSvc := getServices()
+	backupPath := args[0]
+
+	if err := backupSvc.VerifyBackup(cmd.Context(), backupPath, cfg.PrimaryKeyFingerprint); err != nil {
+		return fmt.Errorf("backup %s failed verification: %w", backupPath, err)
+	}
+	ui.LogSuccess("Backup %s holds key %s and can be restored", backupPath, cfg.PrimaryKeyFingerprint)
+
+	return nil
+}
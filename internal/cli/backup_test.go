@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBackupCmd(t *testing.T) {
+	cmd := newBackupCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "backup", cmd.Use)
+
+	listCmd, _, err := cmd.Find([]string{"list"})
+	assert.NoError(t, err)
+	assert.Equal(t, "list", listCmd.Use)
+}
+
+func TestNewBackupPruneCmd(t *testing.T) {
+	cmd := newBackupPruneCmd()
+	assert.Equal(t, "prune", cmd.Use)
+
+	keep, err := cmd.Flags().GetInt("keep")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, keep)
+	assert.NotNil(t, cmd.Flags().Lookup("yes"))
+}
+
+func TestNewBackupRestoreCmd(t *testing.T) {
+	cmd := newBackupRestoreCmd()
+	assert.Equal(t, "restore <backup-path>", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("yes"))
+	assert.Error(t, cmd.Args(cmd, []string{}))
+	assert.NoError(t, cmd.Args(cmd, []string{"gpg-backup-20240101-000000"}))
+}
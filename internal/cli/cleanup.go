@@ -1,19 +1,27 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"time"
 
-	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
 func newCleanupCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "cleanup",
 		Short: "Remove old/expired keys from keyring",
 		RunE:  runCleanup,
 	}
+
+	cmd.Flags().Int("older-than", 5*365, "Flag keys created more than this many days ago as removal candidates")
+	cmd.Flags().Bool("expired-only", false, "Only handle expired keys, deleting their secret keys after confirmation")
+	cmd.Flags().BoolP("yes", "y", false, "With --expired-only, delete without asking for confirmation")
+
+	return cmd
 }
 
 func runCleanup(cmd *cobra.Command, args []string) error {
@@ -22,12 +30,44 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 
 	ui.PrintHeader("Cleanup Old Keys")
 
+	expiredOnly, _ := cmd.Flags().GetBool("expired-only")
+	skipConfirm, _ := cmd.Flags().GetBool("yes")
+
+	allKeys, err := gpgSvc.ListAllSecretKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+	expired := expiredKeys(allKeys, time.Now(), cfg.PrimaryKeyID)
+
+	if expiredOnly {
+		if len(expired) == 0 {
+			ui.LogSuccess("No expired keys found")
+			return nil
+		}
+
+		fmt.Println("Expired keys:")
+		printExpiredKeys(expired)
+		fmt.Println()
+
+		if !skipConfirm {
+			// Deleting secret keys is never the default answer
+			if ui.IsNonInteractive() {
+				return fmt.Errorf("refusing to delete secret keys with --non-interactive; pass --yes to confirm")
+			}
+			if !ui.Confirm(fmt.Sprintf("Delete the secret keys of %d expired key(s)?", len(expired))) {
+				return nil
+			}
+		}
+		deleteExpiredKeys(ctx, gpgSvc, expired)
+		return nil
+	}
+
 	fmt.Println("Current keys in keyring:")
 	fmt.Println()
 
 	// List all keys (we'll need to list without a specific key ID)
-	exec := executor.NewRealExecutor()
-	output, err := exec.Run(ctx, "gpg", "--list-secret-keys", "--keyid-format=long")
+	exec := newExecutor()
+	output, err := exec.Run(ctx, gpgBinary(), "--list-secret-keys", "--keyid-format=long")
 	if err != nil {
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
@@ -39,19 +79,36 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Check for expired keys
+	if len(expired) == 0 {
+		fmt.Println("  No expired keys")
+	} else {
+		printExpiredKeys(expired)
+	}
+
+	// Check for old keys
+	olderThan, _ := cmd.Flags().GetInt("older-than")
 	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
 	if err == nil {
-		for _, key := range keys {
-			_ = key // In a real implementation, we'd parse and check the expiration date
-			// For now, just iterate through keys
+		cutoff := time.Now().AddDate(0, 0, -olderThan)
+		oldKeys := keysCreatedBefore(keys, cutoff)
+		if len(oldKeys) == 0 {
+			fmt.Printf("  No keys created more than %d days ago\n", olderThan)
+		}
+		for _, key := range oldKeys {
+			fmt.Printf("  %s %s created %s (older than %d days)\n", key.Type, key.KeyID, key.Created, olderThan)
 		}
+		fmt.Println()
 	}
 
 	// Keys not matching primary
 	fmt.Println("Keys other than your primary (" + cfg.PrimaryKeyID + "):")
-	// This would require parsing all keys, which is more complex
-	// For now, we'll just show instructions
-	fmt.Println("  (use gpg --list-secret-keys to see all keys)")
+	others := otherPrimaryKeys(allKeys, cfg.PrimaryKeyID)
+	if len(others) == 0 {
+		fmt.Println("  None")
+	}
+	for _, key := range others {
+		fmt.Printf("  %s %s created %s\n", key.Type, key.KeyID, key.Created)
+	}
 	fmt.Println()
 
 	fmt.Println("To delete a key:")
@@ -59,7 +116,8 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	fmt.Println("  gpg --delete-keys <KEY_ID>")
 	fmt.Println()
 
-	if ui.Confirm("Would you like to interactively delete keys?") {
+	// Deleting by hand needs someone to type the key IDs
+	if !ui.IsNonInteractive() && ui.Confirm("Would you like to interactively delete keys?") {
 		for {
 			keyToDelete, err := ui.Prompt("Enter KEY ID to delete (or 'q' to quit): ")
 			if err != nil {
@@ -76,13 +134,13 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 
 			if ui.Confirm(fmt.Sprintf("Delete %s?", keyToDelete)) {
 				// Delete secret key
-				_, err := exec.Run(ctx, "gpg", "--batch", "--yes", "--delete-secret-keys", keyToDelete)
+				_, err := exec.Run(ctx, gpgBinary(), "--batch", "--yes", "--delete-secret-keys", keyToDelete)
 				if err != nil {
 					ui.LogWarning("Failed to delete secret key: %v", err)
 				}
 
 				// Delete public key
-				_, err = exec.Run(ctx, "gpg", "--batch", "--yes", "--delete-keys", keyToDelete)
+				_, err = exec.Run(ctx, gpgBinary(), "--batch", "--yes", "--delete-keys", keyToDelete)
 				if err != nil {
 					ui.LogWarning("Failed to delete public key: %v", err)
 				} else {
@@ -103,3 +161,96 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// keysCreatedBefore returns the keys created before cutoff.
+// Keys whose creation date can't be parsed are skipped.
+func keysCreatedBefore(keys []gpg.Key, cutoff time.Time) []gpg.Key {
+	var result []gpg.Key
+	for _, key := range keys {
+		created, err := gpg.ParseKeyDate(key.Created)
+		if err != nil {
+			continue
+		}
+		if created.Before(cutoff) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// expiredKeys returns the keys whose expiry date is before now, as removal
+// candidates. Neither the configured primary key nor any of its subkeys is a
+// candidate, since an expired subkey may still be needed to decrypt old
+// data. Subkeys of an expired primary key are left out since they are
+// removed along with it.
+func expiredKeys(keys []gpg.Key, now time.Time, primaryKeyID string) []gpg.Key {
+	var result []gpg.Key
+	skipSubkeys := false
+	for _, key := range keys {
+		expires, err := gpg.ParseKeyDate(key.Expires)
+		isExpired := err == nil && expires.Before(now)
+
+		if key.Type == "sec" {
+			isPrimary := isPrimaryKey(key, primaryKeyID)
+			skipSubkeys = isPrimary || isExpired
+			if isExpired && !isPrimary {
+				result = append(result, key)
+			}
+			continue
+		}
+
+		if isExpired && !skipSubkeys {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// otherPrimaryKeys returns the primary keys other than the configured one.
+func otherPrimaryKeys(keys []gpg.Key, primaryKeyID string) []gpg.Key {
+	var result []gpg.Key
+	for _, key := range keys {
+		if key.Type == "sec" && !isPrimaryKey(key, primaryKeyID) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// isPrimaryKey reports whether key is the configured primary key.
+func isPrimaryKey(key gpg.Key, primaryKeyID string) bool {
+	return key.Type == "sec" &&
+		(gpg.KeyIDMatches(key.KeyID, primaryKeyID) || gpg.KeyIDMatches(key.Fingerprint, primaryKeyID))
+}
+
+// printExpiredKeys lists expired keys as removal candidates.
+func printExpiredKeys(keys []gpg.Key) {
+	for _, key := range keys {
+		fmt.Printf("  %s %s expired %s\n", key.Type, key.KeyID, key.Expires)
+	}
+}
+
+// deleteExpiredKeys deletes the secret keys of expired keys.
+// gpg only deletes secret keys in batch mode when given a fingerprint; a
+// trailing "!" restricts the deletion to that subkey alone.
+func deleteExpiredKeys(ctx context.Context, gpgSvc gpg.GPGService, keys []gpg.Key) {
+	for _, key := range keys {
+		if isPrimaryKey(key, cfg.PrimaryKeyID) {
+			continue
+		}
+		if key.Fingerprint == "" {
+			ui.LogWarning("Skipping %s: fingerprint unknown", key.KeyID)
+			continue
+		}
+
+		target := key.Fingerprint
+		if key.Type == "ssb" {
+			target += "!"
+		}
+		if err := gpgSvc.DeleteSecretKey(ctx, target); err != nil {
+			ui.LogWarning("Failed to delete %s: %v", key.KeyID, err)
+			continue
+		}
+		ui.LogSuccess("Deleted secret key %s", key.KeyID)
+	}
+}
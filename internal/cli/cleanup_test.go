@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCleanupCmd(t *testing.T) {
+	cmd := newCleanupCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "cleanup", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("older-than"))
+	assert.NotNil(t, cmd.Flags().Lookup("expired-only"))
+	assert.NotNil(t, cmd.Flags().Lookup("yes"))
+}
+
+func TestKeysCreatedBefore(t *testing.T) {
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "OLDPRIMARY", Created: "2015-01-01"},
+		{Type: "ssb", KeyID: "NEWSUBKEY", Created: "2025-01-01"},
+		{Type: "ssb", KeyID: "NODATE"},
+	}
+	cutoff := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	result := keysCreatedBefore(keys, cutoff)
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "OLDPRIMARY", result[0].KeyID)
+}
+
+func TestExpiredKeys(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	keys := []gpg.Key{
+		// Configured primary key: it and its subkeys, expired or not, must
+		// never be candidates
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Expires: "2025-01-01"},
+		{Type: "ssb", KeyID: "EXPIREDSUB000001", Expires: "2024-01-01"},
+		{Type: "ssb", KeyID: "ACTIVESUB0000001", Expires: "2030-01-01"},
+		// Another key that hasn't expired: only its expired subkey is one
+		{Type: "sec", KeyID: "OTHERPRIMARY0001", Expires: "2030-01-01"},
+		{Type: "ssb", KeyID: "OTHEREXPIRED0001", Expires: "2024-01-01"},
+		// Another expired key: its subkeys go with it
+		{Type: "sec", KeyID: "OLDPRIMARY000001", Expires: "2020-01-01"},
+		{Type: "ssb", KeyID: "OLDSUB0000000001", Expires: "2020-01-01"},
+		// A key that never expires
+		{Type: "sec", KeyID: "NOEXPIRY00000001"},
+	}
+
+	result := expiredKeys(keys, now, "07AAA1E535650AF5")
+
+	var ids []string
+	for _, key := range result {
+		ids = append(ids, key.KeyID)
+	}
+	assert.Equal(t, []string{"OTHEREXPIRED0001", "OLDPRIMARY000001"}, ids)
+}
+
+func TestOtherPrimaryKeys(t *testing.T) {
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35"},
+		{Type: "sec", KeyID: "1111222233334444"},
+	}
+
+	others := otherPrimaryKeys(keys, "07AAA1E535650AF5")
+	require.Len(t, others, 1)
+	assert.Equal(t, "1111222233334444", others[0].KeyID)
+}
+
+func TestDeleteExpiredKeys(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+	cfg = &config.Config{PrimaryKeyID: "07AAA1E535650AF5"}
+
+	mockExecutor := executor.NewMockExecutor()
+	gpgSvc := gpg.NewService(mockExecutor)
+
+	deleteExpiredKeys(context.Background(), gpgSvc, []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: "FA57C85131F11B28EE236A4F07AAA1E535650AF5"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Fingerprint: "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35"},
+		{Type: "sec", KeyID: "1111222233334444", Fingerprint: "AAAABBBBCCCCDDDDEEEEFFFF1111222233334444"},
+		{Type: "ssb", KeyID: "NOFINGERPRINT001"},
+	})
+
+	require.Len(t, mockExecutor.Calls, 2)
+	assert.True(t, mockExecutor.VerifyCall("gpg", "--batch", "--yes", "--delete-secret-keys", "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35!"))
+	assert.True(t, mockExecutor.VerifyCall("gpg", "--batch", "--yes", "--delete-secret-keys", "AAAABBBBCCCCDDDDEEEEFFFF1111222233334444"))
+}
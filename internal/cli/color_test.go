@@ -40,7 +40,7 @@ func TestNoColorFlag(t *testing.T) {
 
 	configFile := filepath.Join(configDir, "config.yaml")
 	configContent := `primary_key_id: "ABC123DEF4567890"
-primary_key_fingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12"
+primary_key_fingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890"
 user_name: "Test User"
 user_email: "test@example.com"
 `
@@ -93,7 +93,7 @@ func TestNoColorConfigOption(t *testing.T) {
 
 	configFile := filepath.Join(configDir, "config.yaml")
 	configContent := `primary_key_id: "ABC123DEF4567890"
-primary_key_fingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12"
+primary_key_fingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890"
 user_name: "Test User"
 user_email: "test@example.com"
 no_color: true
@@ -147,7 +147,7 @@ func TestNoColorEnvironmentVariable(t *testing.T) {
 
 	configFile := filepath.Join(configDir, "config.yaml")
 	configContent := `primary_key_id: "ABC123DEF4567890"
-primary_key_fingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12"
+primary_key_fingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890"
 user_name: "Test User"
 user_email: "test@example.com"
 `
@@ -183,7 +183,7 @@ func TestColorEnabledByDefault(t *testing.T) {
 func TestConfigNoColorField(t *testing.T) {
 	cfg := &config.Config{
 		PrimaryKeyID:          "ABC123DEF4567890",
-		PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+		PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 		UserName:              "Test User",
 		UserEmail:             "test@example.com",
 		NoColor:               false,
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/spf13/cobra"
+)
+
+func newCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion <bash|zsh|fish|powershell>",
+		Short: "Generate a shell completion script",
+		Long: `Print a completion script for the given shell. Besides commands and flags,
+it completes the serials of connected YubiKeys and the key IDs in your
+keyring.
+
+  bash:       source <(ykgpg completion bash)
+  zsh:        ykgpg completion zsh > "${fpath[1]}/_ykgpg"
+  fish:       ykgpg completion fish > ~/.config/fish/completions/ykgpg.fish
+  powershell: ykgpg completion powershell | Out-String | Invoke-Expression`,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE:                  runCompletion,
+	}
+	// Skip PersistentPreRunE validation for completion command
+	// Completion scripts don't depend on the configuration
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+	return cmd
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	root := cmd.Root()
+	switch args[0] {
+	case "bash":
+		return root.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return root.GenZshCompletion(os.Stdout)
+	case "fish":
+		return root.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(os.Stdout)
+	}
+	return fmt.Errorf("unsupported shell %q", args[0])
+}
+
+// isCompletionRequest reports whether cmd is the hidden command shells call
+// to get dynamic completions.
+func isCompletionRequest(cmd *cobra.Command) bool {
+	return cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+// completeCardSerials completes the serials of the connected YubiKeys.
+func completeCardSerials(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	_, yubikeySvc, _ := getServices()
+	serials, err := yubikeySvc.ListCards(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return serialCompletions(serials, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLabelArgs completes the serial, the first argument of label.
+func completeLabelArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeCardSerials(cmd, args, toComplete)
+}
+
+// completeSecretKeyIDs completes the IDs of the primary keys in the secret keyring.
+func completeSecretKeyIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	gpgSvc, _, _ := getServices()
+	keys, err := gpgSvc.ListAllSecretKeys(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return keyIDCompletions(keys, "sec", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSubkeyIDs completes the IDs of the configured primary key's
+// subkeys, or of every subkey if no primary key is configured.
+func completeSubkeyIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	gpgSvc, _, _ := getServices()
+	var keys []gpg.Key
+	var err error
+	if primary := toolConfig().PrimaryKeyID; primary != "" {
+		keys, err = gpgSvc.ListSecretKeys(cmd.Context(), primary)
+	} else {
+		keys, err = gpgSvc.ListAllSecretKeys(cmd.Context())
+	}
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return keyIDCompletions(keys, "ssb", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// serialCompletions returns the serials starting with toComplete, each with
+// its recorded label as the description.
+func serialCompletions(serials []string, toComplete string) []string {
+	var completions []string
+	for _, serial := range serials {
+		if !strings.HasPrefix(serial, toComplete) {
+			continue
+		}
+		if label := yubikeyLabel(serial); label != "" {
+			serial += "\t" + label
+		}
+		completions = append(completions, serial)
+	}
+	return completions
+}
+
+// keyIDCompletions returns the IDs of the keys of the given type ("sec" or
+// "ssb") that start with toComplete, described by their user ID or
+// algorithm and capabilities.
+func keyIDCompletions(keys []gpg.Key, keyType, toComplete string) []string {
+	var completions []string
+	for _, key := range keys {
+		if key.Type != keyType || !strings.HasPrefix(strings.ToUpper(key.KeyID), strings.ToUpper(toComplete)) {
+			continue
+		}
+		description := key.UserID
+		if description == "" {
+			description = fmt.Sprintf("%s [%s]", key.Algorithm, strings.Join(key.Capabilities, ""))
+		}
+		completions = append(completions, key.KeyID+"\t"+description)
+	}
+	return completions
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCompletionCmd(t *testing.T) {
+	cmd := newCompletionCmd()
+	assert.Equal(t, "completion <bash|zsh|fish|powershell>", cmd.Use)
+	assert.Equal(t, []string{"bash", "zsh", "fish", "powershell"}, cmd.ValidArgs)
+	assert.NoError(t, cmd.Args(cmd, []string{"zsh"}))
+	assert.Error(t, cmd.Args(cmd, []string{"tcsh"}))
+	assert.Error(t, cmd.Args(cmd, nil))
+}
+
+func TestCompletionCmdRegistered(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"completion"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "completion", cmd.Name())
+	}
+	assert.True(t, rootCmd.CompletionOptions.DisableDefaultCmd, "only one completion command should be registered")
+}
+
+func TestKeyIDCompletions(t *testing.T) {
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", UserID: "Test User <test@example.com>"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Algorithm: "ed25519", Capabilities: []string{"S"}},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Algorithm: "cv25519", Capabilities: []string{"E"}},
+		{Type: "sec", KeyID: "1111222233334444"},
+	}
+
+	assert.Equal(t, []string{"07AAA1E535650AF5\tTest User <test@example.com>"}, keyIDCompletions(keys, "sec", "07a"))
+	assert.Equal(t, []string{"DC47D1B090A51498\ted25519 [S]", "0257F6B8152D7F35\tcv25519 [E]"}, keyIDCompletions(keys, "ssb", ""))
+	assert.Empty(t, keyIDCompletions(keys, "ssb", "FF"))
+}
+
+func TestSerialCompletions(t *testing.T) {
+	assert.Equal(t, []string{"12345678", "12999999"}, serialCompletions([]string{"12345678", "12999999", "23456789"}, "12"))
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/bobbydams/yubikey-manager/internal/config"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
@@ -34,8 +38,10 @@ func newConfigInitCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "init",
 		Short: "Interactively generate configuration file",
-		Long: `Interactively generate a configuration file at ~/.config/ykgpg/config.yaml.
-This command will prompt you for all required configuration values.`,
+		Long: `Interactively generate a configuration file at ~/.config/ykgpg/config.yaml
+($XDG_CONFIG_HOME/ykgpg/config.yaml if XDG_CONFIG_HOME is set), or at the
+path given with --config. This command will prompt you for all
+required configuration values.`,
 		RunE: runConfigInit,
 	}
 }
@@ -56,7 +62,11 @@ func newConfigShowCmd() *cobra.Command {
 func runConfigInit(cmd *cobra.Command, args []string) error {
 	ui.PrintHeader("Generate Configuration File")
 
-	fmt.Println("This will create a configuration file at ~/.config/ykgpg/config.yaml")
+	configFile := config.ExplicitPath()
+	if configFile == "" {
+		configFile = config.DefaultPath()
+	}
+	fmt.Printf("This will create a configuration file at %s\n", configFile)
 	fmt.Println("You can override these values later with environment variables or CLI flags.")
 	fmt.Println()
 
@@ -86,7 +96,7 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Optional values with defaults
-	keyserver, err := ui.Prompt("Keyserver URL [hkps://keys.openpgp.org]: ")
+	keyserver, err := ui.Prompt("Keyserver URL, comma-separated for several [hkps://keys.openpgp.org]: ")
 	if err != nil {
 		return err
 	}
@@ -96,12 +106,12 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 		cfg.Keyserver = keyserver
 	}
 
-	backupDir, err := ui.Prompt(fmt.Sprintf("Backup directory [%s/.gnupg/backups]: ", os.Getenv("HOME")))
+	backupDir, err := ui.Prompt(fmt.Sprintf("Backup directory [%s]: ", config.DefaultBackupDir()))
 	if err != nil {
 		return err
 	}
 	if backupDir == "" {
-		cfg.BackupDir = filepath.Join(os.Getenv("HOME"), ".gnupg", "backups")
+		cfg.BackupDir = config.DefaultBackupDir()
 	} else {
 		cfg.BackupDir = backupDir
 	}
@@ -124,13 +134,11 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create config directory
-	configDir := filepath.Join(os.Getenv("HOME"), ".config", "ykgpg")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
 	// Write config file
-	configFile := filepath.Join(configDir, "config.yaml")
 	configData := map[string]interface{}{
 		"primary_key_id":          cfg.PrimaryKeyID,
 		"primary_key_fingerprint": cfg.PrimaryKeyFingerprint,
@@ -165,6 +173,7 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 
 func runConfigShow(cmd *cobra.Command, args []string) error {
 	// Load config (this will use the normal priority: flags > env > file > defaults)
+	bindFlags(cmd)
 	cfg, err := config.Load()
 	if err != nil {
 		// If config loading fails, show what we can
@@ -175,17 +184,30 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 
 		// Show environment variables
 		fmt.Println("Environment Variables:")
+		fmt.Println("  YKGPG_PROFILE:", os.Getenv("YKGPG_PROFILE"))
 		fmt.Println("  YKGPG_PRIMARY_KEY_ID:", os.Getenv("YKGPG_PRIMARY_KEY_ID"))
 		fmt.Println("  YKGPG_PRIMARY_KEY_FINGERPRINT:", os.Getenv("YKGPG_PRIMARY_KEY_FINGERPRINT"))
 		fmt.Println("  YKGPG_USER_NAME:", os.Getenv("YKGPG_USER_NAME"))
 		fmt.Println("  YKGPG_USER_EMAIL:", os.Getenv("YKGPG_USER_EMAIL"))
 		fmt.Println("  YKGPG_KEYSERVER:", os.Getenv("YKGPG_KEYSERVER"))
+		fmt.Println("  YKGPG_KEYSERVERS:", os.Getenv("YKGPG_KEYSERVERS"))
 		fmt.Println("  YKGPG_MASTER_KEY_PATH:", os.Getenv("YKGPG_MASTER_KEY_PATH"))
 		fmt.Println("  YKGPG_BACKUP_DIR:", os.Getenv("YKGPG_BACKUP_DIR"))
+		fmt.Println("  YKGPG_GPG_TIMEOUT:", os.Getenv("YKGPG_GPG_TIMEOUT"))
+		fmt.Println("  YKGPG_GPG_BINARY:", os.Getenv("YKGPG_GPG_BINARY"))
+		fmt.Println("  YKGPG_YKMAN_BINARY:", os.Getenv("YKGPG_YKMAN_BINARY"))
+		fmt.Println("  YKGPG_GNUPG_HOME:", os.Getenv("YKGPG_GNUPG_HOME"))
+		fmt.Println("  YKGPG_CARD_RETRIES:", os.Getenv("YKGPG_CARD_RETRIES"))
+		fmt.Println("  YKGPG_EXPIRY_WARN_DAYS:", os.Getenv("YKGPG_EXPIRY_WARN_DAYS"))
 		fmt.Println()
 
 		// Show config file location
-		configFile := filepath.Join(os.Getenv("HOME"), ".config", "ykgpg", "config.yaml")
+		fmt.Println("  YKGPG_CONFIG:", os.Getenv("YKGPG_CONFIG"))
+		fmt.Println()
+		configFile := config.ExplicitPath()
+		if configFile == "" {
+			configFile = config.DefaultPath()
+		}
 		if _, err := os.Stat(configFile); err == nil {
 			fmt.Printf("Config file exists: %s\n", configFile)
 		} else {
@@ -199,37 +221,72 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("Configuration values (showing effective values from all sources):")
 	fmt.Println()
+	if cfg.Profile != "" {
+		ui.PrintKeyValue("Active Profile", cfg.Profile)
+	} else {
+		ui.PrintKeyValue("Active Profile", "(none)")
+	}
+	if len(cfg.Profiles) > 0 {
+		ui.PrintKeyValue("Available Profiles", strings.Join(profileNames(cfg.Profiles), ", "))
+	}
 	ui.PrintKeyValueKey("Primary Key ID", cfg.PrimaryKeyID)
 	ui.PrintKeyValueKey("Primary Key Fingerprint", cfg.PrimaryKeyFingerprint)
 	ui.PrintKeyValue("User Name", cfg.UserName)
 	ui.PrintKeyValue("User Email", cfg.UserEmail)
-	ui.PrintKeyValue("Keyserver", cfg.Keyserver)
+	ui.PrintKeyValue("Keyservers", strings.Join(cfg.KeyserverList(), ", "))
+	if cfg.KeyserverProxy != "" {
+		ui.PrintKeyValue("Keyserver Proxy", cfg.KeyserverProxy)
+	}
 	if cfg.MasterKeyPath != "" {
 		ui.PrintKeyValue("Master Key Path", cfg.MasterKeyPath)
 	} else {
 		ui.PrintKeyValue("Master Key Path", "(not set)")
 	}
 	ui.PrintKeyValue("Backup Directory", cfg.BackupDir)
+	if cfg.GPGTimeout > 0 {
+		ui.PrintKeyValue("GPG Timeout", cfg.GPGTimeout.String())
+	} else {
+		ui.PrintKeyValue("GPG Timeout", "(disabled)")
+	}
+	ui.PrintKeyValue("GPG Binary", cfg.GPGBinary)
+	ui.PrintKeyValue("ykman Binary", cfg.YkmanBinary)
+	ui.PrintKeyValue("Card Retries", strconv.Itoa(cfg.CardRetries))
+	ui.PrintKeyValue("Expiry Warning", fmt.Sprintf("%d days", cfg.ExpiryWarnDays))
+	ui.PrintKeyValue("Default Subkey Expiry", cfg.DefaultSubkeyExpiry)
+	if cfg.ExpectedSigningSubkeys > 0 {
+		ui.PrintKeyValue("Expected Signing Subkeys", strconv.Itoa(cfg.ExpectedSigningSubkeys))
+	}
+	if cfg.GnuPGHome != "" {
+		ui.PrintKeyValue("GnuPG Home", cfg.GnuPGHome)
+	}
 	fmt.Println()
 
 	// Show where values come from
 	fmt.Println("Configuration Sources:")
-	configFile := filepath.Join(os.Getenv("HOME"), ".config", "ykgpg", "config.yaml")
-	if _, err := os.Stat(configFile); err == nil {
+	if configFile := viper.ConfigFileUsed(); configFile != "" {
 		fmt.Printf("  ✓ Config file: %s\n", configFile)
 	} else {
-		fmt.Printf("  ✗ Config file: %s (not found)\n", configFile)
+		fmt.Printf("  ✗ Config file: %s (not found)\n", config.DefaultPath())
 	}
 
 	// Check for environment variables
 	envVars := []string{
+		"YKGPG_CONFIG",
+		"YKGPG_PROFILE",
 		"YKGPG_PRIMARY_KEY_ID",
 		"YKGPG_PRIMARY_KEY_FINGERPRINT",
 		"YKGPG_USER_NAME",
 		"YKGPG_USER_EMAIL",
 		"YKGPG_KEYSERVER",
+		"YKGPG_KEYSERVERS",
 		"YKGPG_MASTER_KEY_PATH",
 		"YKGPG_BACKUP_DIR",
+		"YKGPG_GPG_TIMEOUT",
+		"YKGPG_GPG_BINARY",
+		"YKGPG_YKMAN_BINARY",
+		"YKGPG_GNUPG_HOME",
+		"YKGPG_CARD_RETRIES",
+		"YKGPG_EXPIRY_WARN_DAYS",
 	}
 	hasEnvVars := false
 	for _, envVar := range envVars {
@@ -251,3 +308,13 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// profileNames returns the configured profile names in sorted order.
+func profileNames(profiles map[string]config.Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -61,7 +61,7 @@ func TestConfigInit_FileWriting(t *testing.T) {
 	// Create config data
 	configData := map[string]interface{}{
 		"primary_key_id":          "ABC123DEF4567890",
-		"primary_key_fingerprint": "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+		"primary_key_fingerprint": "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 		"user_name":               "Test User",
 		"user_email":              "test@example.com",
 		"keyserver":               "hkps://keys.openpgp.org",
@@ -152,7 +152,7 @@ func TestConfigValidation(t *testing.T) {
 			name: "valid config",
 			config: &config.Config{
 				PrimaryKeyID:          "ABC123DEF4567890",
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserName:              "Test User",
 				UserEmail:             "test@example.com",
 			},
@@ -161,7 +161,7 @@ func TestConfigValidation(t *testing.T) {
 		{
 			name: "missing primary key ID",
 			config: &config.Config{
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserName:              "Test User",
 				UserEmail:             "test@example.com",
 			},
@@ -180,7 +180,7 @@ func TestConfigValidation(t *testing.T) {
 			name: "missing user name",
 			config: &config.Config{
 				PrimaryKeyID:          "ABC123DEF4567890",
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserEmail:             "test@example.com",
 			},
 			wantErr: true,
@@ -189,7 +189,7 @@ func TestConfigValidation(t *testing.T) {
 			name: "missing user email",
 			config: &config.Config{
 				PrimaryKeyID:          "ABC123DEF4567890",
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserName:              "Test User",
 			},
 			wantErr: true,
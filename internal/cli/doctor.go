@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// Doctor check statuses.
+const (
+	checkOK   = "OK"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+// doctorCheck is a single environment check run by the doctor command.
+type doctorCheck struct {
+	name string
+	// required checks make doctor exit non-zero when they fail
+	required bool
+	run      func(ctx context.Context, exec executor.Executor) (status, detail string)
+}
+
+// agentCheckName is the name of the gpg-agent check, which doctor offers
+// to fix by starting the agent.
+const agentCheckName = "gpg-agent reachable"
+
+// doctorChecks returns the checks for the current platform, in display order.
+func doctorChecks() []doctorCheck {
+	checks := []doctorCheck{
+		{name: "gpg installed", required: true, run: checkGPGInstalled},
+		{name: agentCheckName, required: true, run: checkGPGAgent},
+		{name: "scdaemon installed", required: true, run: checkScdaemon},
+	}
+	// macOS and Windows talk to smartcards without pcscd
+	if runtime.GOOS == "linux" {
+		checks = append(checks, doctorCheck{name: "pcscd running", run: checkPcscd})
+	}
+	return append(checks,
+		doctorCheck{name: "ykman installed", run: checkYkman},
+		doctorCheck{name: "GPG_TTY set", run: checkGPGTTY},
+		doctorCheck{name: "pinentry configured", run: checkPinentry},
+	)
+}
+
+func newDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that gpg, scdaemon, ykman and pinentry are set up",
+		Long: `Check the environment ykgpg depends on: gpg and its version, gpg-agent,
+scdaemon, pcscd (Linux), ykman, GPG_TTY, and the pinentry program.
+
+Each check prints OK, WARN or FAIL. The command exits non-zero if a
+required check (gpg, gpg-agent or scdaemon) fails.`,
+		SilenceUsage: true,
+		RunE:         runDoctor,
+	}
+	// Skip PersistentPreRunE validation for doctor command
+	// The environment can be checked before ykgpg is configured
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Probes only read state, so they run even with --dry-run
+	exec := logCommands(newRealExecutor(executor.DefaultTimeout))
+
+	ui.PrintHeader("ykgpg doctor")
+
+	failures := 0
+	for _, check := range doctorChecks() {
+		fmt.Printf("Checking %s... ", check.name)
+		status, detail := check.run(ctx, exec)
+		// Offer to start gpg-agent rather than only report it
+		if check.name == agentCheckName && status == checkFail && !ui.IsNonInteractive() {
+			fmt.Println(status)
+			if !launchAgent(ctx, gpg.NewService(exec)) {
+				failures++
+				continue
+			}
+			fmt.Printf("Checking %s... ", check.name)
+			status, detail = check.run(ctx, exec)
+		}
+		if detail != "" {
+			fmt.Printf("%s (%s)\n", status, detail)
+		} else {
+			fmt.Printf("%s\n", status)
+		}
+		if status == checkFail && check.required {
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		fmt.Println()
+		return fmt.Errorf("%d required check(s) failed", failures)
+	}
+
+	fmt.Println()
+	ui.LogSuccess("All required checks passed")
+	return nil
+}
+
+// checkGPGInstalled reports the installed gpg version.
+func checkGPGInstalled(ctx context.Context, exec executor.Executor) (string, string) {
+	version := probeVersion(ctx, exec, gpgBinary(), "--version")
+	if version == "not available" {
+		return checkFail, "gpg not found; install GnuPG 2.2 or later"
+	}
+	return checkOK, version
+}
+
+// checkGPGAgent checks that gpg-agent is running, without starting it.
+func checkGPGAgent(ctx context.Context, exec executor.Executor) (string, string) {
+	if err := gpg.NewService(exec).PingAgent(ctx); err != nil {
+		return checkFail, "gpg-agent is not running; start it with 'gpgconf --launch gpg-agent'"
+	}
+	return checkOK, ""
+}
+
+// checkScdaemon checks that scdaemon, which gpg uses to talk to the card, is installed.
+func checkScdaemon(ctx context.Context, exec executor.Executor) (string, string) {
+	version := probeScdaemonVersion(ctx, exec)
+	if version == "not available" {
+		return checkFail, "scdaemon not found; install your distribution's scdaemon package"
+	}
+	return checkOK, version
+}
+
+// checkPcscd checks that the PC/SC daemon is running.
+func checkPcscd(ctx context.Context, exec executor.Executor) (string, string) {
+	if _, err := exec.Run(ctx, "pgrep", "-x", "pcscd"); err != nil {
+		return checkWarn, "pcscd is not running; start it with 'sudo systemctl start pcscd' if the card isn't found"
+	}
+	return checkOK, ""
+}
+
+// checkYkman checks that ykman, needed for PIN, touch and reset commands, is installed.
+func checkYkman(ctx context.Context, exec executor.Executor) (string, string) {
+	version := probeVersion(ctx, exec, ykmanBinary(), "--version")
+	if version == "not available" {
+		return checkWarn, "ykman not found; pin, touch and reset commands need it"
+	}
+	return checkOK, version
+}
+
+// checkGPGTTY checks that GPG_TTY is set, which terminal pinentry programs need.
+func checkGPGTTY(ctx context.Context, exec executor.Executor) (string, string) {
+	if os.Getenv("GPG_TTY") == "" {
+		return checkWarn, "add 'export GPG_TTY=$(tty)' to your shell profile"
+	}
+	return checkOK, ""
+}
+
+// checkPinentry reports the pinentry program gpg-agent uses.
+func checkPinentry(ctx context.Context, exec executor.Executor) (string, string) {
+	output, err := exec.Run(ctx, "gpgconf", "--list-options", "gpg-agent")
+	if err == nil {
+		if program := parsePinentryProgram(output); program != "" {
+			if _, err := os.Stat(program); err != nil {
+				return checkWarn, fmt.Sprintf("configured pinentry-program %s does not exist", program)
+			}
+			return checkOK, program
+		}
+	}
+
+	if path, err := lookPath("pinentry"); err == nil {
+		return checkOK, "default: " + path
+	}
+	return checkWarn, "no pinentry-program configured and pinentry not found on PATH"
+}
+
+// lookPath finds a program on $PATH. It is a variable so tests can replace it.
+var lookPath = exec.LookPath
+
+// parsePinentryProgram returns the pinentry-program value from
+// gpgconf --list-options gpg-agent output, or "" if it isn't set.
+// Format: pinentry-program:0:1:description:32:1:filename:::"/usr/bin/pinentry-mac
+func parsePinentryProgram(output []byte) string {
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 10 || fields[0] != "pinentry-program" {
+			continue
+		}
+		// String values are prefixed with a double quote
+		return strings.TrimPrefix(fields[9], `"`)
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDoctorCmd(t *testing.T) {
+	cmd := newDoctorCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "doctor", cmd.Use)
+	assert.NoError(t, cmd.PersistentPreRunE(cmd, nil), "doctor must run without a valid config")
+}
+
+func TestDoctorChecks_Required(t *testing.T) {
+	required := map[string]bool{}
+	for _, check := range doctorChecks() {
+		required[check.name] = check.required
+	}
+	assert.True(t, required["gpg installed"])
+	assert.True(t, required["gpg-agent reachable"])
+	assert.True(t, required["scdaemon installed"])
+	assert.False(t, required["ykman installed"])
+	assert.False(t, required["GPG_TTY set"])
+}
+
+func TestCheckGPGInstalled(t *testing.T) {
+	ctx := context.Background()
+
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --version", []byte("gpg (GnuPG) 2.4.3\nlibgcrypt 1.10.2\n"))
+	status, detail := checkGPGInstalled(ctx, mockExec)
+	assert.Equal(t, checkOK, status)
+	assert.Equal(t, "gpg (GnuPG) 2.4.3", detail)
+
+	mockExec = executor.NewMockExecutor()
+	mockExec.SetError("gpg --version", fmt.Errorf("executable file not found"))
+	status, _ = checkGPGInstalled(ctx, mockExec)
+	assert.Equal(t, checkFail, status)
+}
+
+func TestCheckGPGAgent(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	status, _ := checkGPGAgent(context.Background(), mockExec)
+	assert.Equal(t, checkOK, status)
+	assert.True(t, mockExec.VerifyCall("gpg-connect-agent", "--no-autostart", "/bye"), "the check never starts the agent")
+
+	mockExec.SetError("gpg-connect-agent --no-autostart /bye", fmt.Errorf("no agent"))
+	status, detail := checkGPGAgent(context.Background(), mockExec)
+	assert.Equal(t, checkFail, status)
+	assert.Contains(t, detail, "gpgconf --launch gpg-agent")
+}
+
+func TestCheckGPGTTY(t *testing.T) {
+	t.Setenv("GPG_TTY", "")
+	status, _ := checkGPGTTY(context.Background(), nil)
+	assert.Equal(t, checkWarn, status)
+
+	t.Setenv("GPG_TTY", "/dev/ttys001")
+	status, _ = checkGPGTTY(context.Background(), nil)
+	assert.Equal(t, checkOK, status)
+}
+
+func TestCheckPinentry(t *testing.T) {
+	ctx := context.Background()
+	oldLookPath := lookPath
+	defer func() { lookPath = oldLookPath }()
+	lookPath = func(string) (string, error) { return "", fmt.Errorf("not found") }
+
+	t.Run("configured program exists", func(t *testing.T) {
+		program := filepath.Join(t.TempDir(), "pinentry-mac")
+		require.NoError(t, os.WriteFile(program, nil, 0755))
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("gpgconf --list-options gpg-agent",
+			[]byte("verbose:16:0:verbose:0:0::::\npinentry-program:0:1:path to PINentry program:32:1:filename:::\""+program+":\n"))
+
+		status, detail := checkPinentry(ctx, mockExec)
+		assert.Equal(t, checkOK, status)
+		assert.Equal(t, program, detail)
+	})
+
+	t.Run("configured program missing", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("gpgconf --list-options gpg-agent",
+			[]byte("pinentry-program:0:1:path to PINentry program:32:1:filename:::\"/nonexistent/pinentry:\n"))
+
+		status, _ := checkPinentry(ctx, mockExec)
+		assert.Equal(t, checkWarn, status)
+	})
+
+	t.Run("not configured and not on PATH", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("gpgconf --list-options gpg-agent", []byte("pinentry-program:0:1:path to PINentry program:32:1:filename:::\n"))
+
+		status, _ := checkPinentry(ctx, mockExec)
+		assert.Equal(t, checkWarn, status)
+	})
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Process exit codes. Commands return an *ExitCodeError to select one;
+// any other error exits with ExitFailure.
+const (
+	ExitOK               = 0
+	ExitFailure          = 1
+	ExitNoCard           = 2
+	ExitSigningFailed    = 3
+	ExitGitMisconfigured = 4
+	// ExitInterrupted follows the shell convention of 128+SIGINT.
+	ExitInterrupted = 130
+)
+
+// ExitCodeError wraps an error with the process exit code it should map to.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// newExitCodeError creates an ExitCodeError with a formatted message.
+func newExitCodeError(code int, format string, args ...interface{}) *ExitCodeError {
+	return &ExitCodeError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ykgpg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain error", errors.New("boom"), ExitFailure},
+		{"exit code error", newExitCodeError(ExitNoCard, "no card"), ExitNoCard},
+		{"wrapped exit code error", fmt.Errorf("verify: %w", newExitCodeError(ExitGitMisconfigured, "git")), ExitGitMisconfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestVerifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ykgpg.VerifyResult
+		want   int
+	}{
+		{"all ok", ykgpg.VerifyResult{}, ExitOK},
+		{"other failure", ykgpg.VerifyResult{Checks: failedChecks(1)}, ExitFailure},
+		{"git", ykgpg.VerifyResult{Checks: failedChecks(1), GitMisconfigured: true}, ExitGitMisconfigured},
+		{"encryption", ykgpg.VerifyResult{Checks: failedChecks(1), EncryptionFailed: true}, ExitFailure},
+		{"git beats encryption", ykgpg.VerifyResult{Checks: failedChecks(2), GitMisconfigured: true, EncryptionFailed: true}, ExitGitMisconfigured},
+		{"signing beats git", ykgpg.VerifyResult{Checks: failedChecks(2), SigningFailed: true, GitMisconfigured: true}, ExitSigningFailed},
+		{"no card beats all", ykgpg.VerifyResult{Checks: failedChecks(3), NoCard: true, SigningFailed: true, GitMisconfigured: true}, ExitNoCard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(verifyError(&tt.result)))
+		})
+	}
+}
+
+// failedChecks returns n failed verify checks.
+func failedChecks(n int) []CheckResult {
+	checks := make([]CheckResult, n)
+	for i := range checks {
+		checks[i] = CheckResult{Name: fmt.Sprintf("check %d", i), Status: CheckFail}
+	}
+	return checks
+}
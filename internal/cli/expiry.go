@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+)
+
+// daysUntilExpiry returns the number of whole days from now until a key with
+// the given expiry date expires: 0 on the expiry date itself, negative once
+// it has passed. ok is false for a key that doesn't expire.
+func daysUntilExpiry(expires string, now time.Time) (days int, ok bool) {
+	date, err := gpg.ParseKeyDate(expires)
+	if err != nil {
+		return 0, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(date.Sub(today).Hours() / 24), true
+}
+
+// expiryNotice describes when a key expires if it has expired or expires
+// within warnDays, e.g. "expires in 12 days (2030-09-04)". It returns "" for
+// keys outside the warning window and keys that don't expire.
+func expiryNotice(expires string, now time.Time, warnDays int) string {
+	days, ok := daysUntilExpiry(expires, now)
+	switch {
+	case !ok:
+		return ""
+	case days < 0:
+		return fmt.Sprintf("expired on %s", expires)
+	case days == 0:
+		return fmt.Sprintf("expires today (%s)", expires)
+	case days == 1:
+		return fmt.Sprintf("expires tomorrow (%s)", expires)
+	case days <= warnDays:
+		return fmt.Sprintf("expires in %d days (%s)", days, expires)
+	}
+	return ""
+}
+
+// warnPrimaryExpiry prints a red warning if the configured primary key has
+// expired or expires within warnDays. Returns true if a warning was printed.
+func warnPrimaryExpiry(keys []gpg.Key, primaryKeyID string, now time.Time, warnDays int) bool {
+	for _, key := range keys {
+		if !isPrimaryKey(key, primaryKeyID) {
+			continue
+		}
+		notice := expiryNotice(key.Expires, now, warnDays)
+		if notice == "" {
+			return false
+		}
+		ui.ErrorColor.Fprintf(os.Stderr, "[WARNING] Primary key %s %s\n", key.KeyID, notice)
+		ui.ErrorColor.Fprintf(os.Stderr, "[WARNING] Every operation with this key will fail once it expires. Run 'ykgpg extend' to extend it.\n")
+		return true
+	}
+	return false
+}
+
+// expiringSubkeys returns the subkeys that have expired or expire within
+// warnDays, split into those stored on a YubiKey and the rest.
+func expiringSubkeys(keys []gpg.Key, now time.Time, warnDays int) (onCard, local []gpg.Key) {
+	for _, key := range keys {
+		if key.Type != "ssb" || key.Revoked || expiryNotice(key.Expires, now, warnDays) == "" {
+			continue
+		}
+		if key.CardSerial != "" || key.Secret == gpg.SecretOnCard {
+			onCard = append(onCard, key)
+		} else {
+			local = append(local, key)
+		}
+	}
+	return onCard, local
+}
+
+// printExpiringSubkeys lists the subkeys that have expired or expire within
+// warnDays, naming the YubiKey each on-card subkey is stored on. Prints
+// nothing if there are none.
+func printExpiringSubkeys(keys []gpg.Key, now time.Time, warnDays int) {
+	onCard, local := expiringSubkeys(keys, now, warnDays)
+	if len(onCard) == 0 && len(local) == 0 {
+		return
+	}
+
+	ui.PrintSection("EXPIRING SUBKEYS")
+	if len(onCard) > 0 {
+		ui.PrintLabel("On a YubiKey:\n")
+		for _, key := range onCard {
+			yubikey := "unknown YubiKey"
+			if key.CardSerial != "" {
+				yubikey = "YubiKey " + serialWithLabel(key.CardSerial)
+			}
+			ui.LogWarning("  %s %s - %s", subkeySummary(key), expiryNotice(key.Expires, now, warnDays), yubikey)
+		}
+	}
+	if len(local) > 0 {
+		ui.PrintLabel("Not on a YubiKey:\n")
+		for _, key := range local {
+			ui.LogWarning("  %s %s", subkeySummary(key), expiryNotice(key.Expires, now, warnDays))
+		}
+	}
+	fmt.Println()
+	fmt.Println("Run 'ykgpg extend' to extend them, or 'ykgpg cleanup' to remove subkeys no longer in use.")
+	fmt.Println()
+}
+
+// liveSigningSubkeys returns the signing subkeys that haven't expired or
+// been revoked.
+func liveSigningSubkeys(keys []gpg.Key, now time.Time) []gpg.Key {
+	var live []gpg.Key
+	for _, key := range keys {
+		if key.Type != "ssb" || !contains(key.Capabilities, "S") || key.Revoked {
+			continue
+		}
+		if days, ok := daysUntilExpiry(key.Expires, now); ok && days < 0 {
+			continue
+		}
+		live = append(live, key)
+	}
+	return live
+}
+
+// warnExtraSigningSubkeys prints a warning listing the live signing subkeys
+// if there are more than expected, as happens when YubiKeys are rotated
+// without retiring the old subkeys. An expected count of zero disables the
+// check. Returns true if a warning was printed.
+func warnExtraSigningSubkeys(keys []gpg.Key, now time.Time, expected int) bool {
+	live := liveSigningSubkeys(keys, now)
+	if expected <= 0 || len(live) <= expected {
+		return false
+	}
+
+	ui.LogWarning("%d active signing subkeys, but expected_signing_subkeys is %d:", len(live), expected)
+	for _, key := range live {
+		location := "not on a YubiKey"
+		if key.CardSerial != "" {
+			location = "YubiKey " + serialWithLabel(key.CardSerial)
+		}
+		ui.LogWarning("  %s created %s - %s", subkeySummary(key), valueOrDefault(key.Created, "unknown"), location)
+	}
+	fmt.Println("Revoke the subkeys of YubiKeys no longer in use with 'ykgpg revoke', or let them expire.")
+	fmt.Println()
+	return true
+}
+
+// subkeySummary describes a subkey as its key ID and capabilities, e.g.
+// "DC47D1B090A51498 [S]".
+func subkeySummary(key gpg.Key) string {
+	return fmt.Sprintf("%s [%s]", key.KeyID, strings.Join(key.Capabilities, ""))
+}
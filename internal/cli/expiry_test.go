@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaysUntilExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC)
+
+	days, ok := daysUntilExpiry("2026-11-15", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30, days)
+
+	days, ok = daysUntilExpiry("2026-10-16", now)
+	assert.True(t, ok)
+	assert.Equal(t, 0, days)
+
+	days, ok = daysUntilExpiry("2026-10-01", now)
+	assert.True(t, ok)
+	assert.Equal(t, -15, days)
+
+	_, ok = daysUntilExpiry("", now)
+	assert.False(t, ok, "keys without an expiry date never expire")
+}
+
+func TestExpiryNotice(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expires  string
+		expected string
+	}{
+		{"2026-10-01", "expired on 2026-10-01"},
+		{"2026-10-16", "expires today (2026-10-16)"},
+		{"2026-10-17", "expires tomorrow (2026-10-17)"},
+		{"2026-11-15", "expires in 30 days (2026-11-15)"},
+		{"2026-11-16", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, expiryNotice(tt.expires, now, 30), tt.expires)
+	}
+}
+
+func TestWarnPrimaryExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: "FA57C85131F11B28EE236A4F07AAA1E535650AF5", Expires: "2026-11-01"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Expires: "2026-10-01"},
+	}
+
+	assert.True(t, warnPrimaryExpiry(keys, "07AAA1E535650AF5", now, 30))
+	assert.False(t, warnPrimaryExpiry(keys, "07AAA1E535650AF5", now, 7), "outside the warning window")
+	assert.False(t, warnPrimaryExpiry(keys, "1111222233334444", now, 30), "another key's expiry doesn't count")
+}
+
+func TestExpiringSubkeys(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Expires: "2026-10-20"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Expires: "2026-10-28", CardSerial: "12345678", Secret: gpg.SecretOnCard},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Expires: "2026-10-10", Secret: gpg.SecretLocal},
+		{Type: "ssb", KeyID: "1111222233334444", Expires: "2028-01-01", CardSerial: "23456789", Secret: gpg.SecretOnCard},
+		{Type: "ssb", KeyID: "5555666677778888", Secret: gpg.SecretStub},
+	}
+
+	onCard, local := expiringSubkeys(keys, now, 30)
+	if assert.Len(t, onCard, 1) {
+		assert.Equal(t, "DC47D1B090A51498", onCard[0].KeyID)
+	}
+	if assert.Len(t, local, 1) {
+		assert.Equal(t, "0257F6B8152D7F35", local[0].KeyID, "expired subkeys are listed too")
+	}
+}
+
+func TestLiveSigningSubkeys(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}, Expires: "2026-10-01"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Capabilities: []string{"S"}, Expires: "2027-10-01"},
+		{Type: "ssb", KeyID: "116DB85718F8B287", Capabilities: []string{"E"}},
+		{Type: "ssb", KeyID: "9A8B7C6D5E4F3A2B", Capabilities: []string{"S"}},
+		{Type: "ssb", KeyID: "5F4E3D2C1B0A9988", Capabilities: []string{"S"}, Revoked: true},
+	}
+
+	live := liveSigningSubkeys(keys, now)
+
+	require.Len(t, live, 2)
+	assert.Equal(t, "0257F6B8152D7F35", live[0].KeyID)
+	assert.Equal(t, "9A8B7C6D5E4F3A2B", live[1].KeyID, "subkeys without an expiry are live")
+
+	assert.True(t, warnExtraSigningSubkeys(keys, now, 1))
+	assert.False(t, warnExtraSigningSubkeys(keys, now, 2))
+	assert.False(t, warnExtraSigningSubkeys(keys, now, 0), "zero disables the check")
+}
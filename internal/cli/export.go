@@ -1,12 +1,18 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/skip2/go-qrcode"
 	"github.com/spf13/cobra"
 )
 
@@ -15,10 +21,43 @@ func newExportCmd() *cobra.Command {
 		Use:     "export",
 		Aliases: []string{"export-public"},
 		Short:   "Export public key to file",
-		RunE:    runExport,
+		Long: `Export the public key to a file, armored by default. With --format binary
+the key is written as binary OpenPGP packets to a .gpg file instead, for
+upload targets that don't accept ASCII armor.
+
+With --qr the key is printed to the terminal as QR codes instead, for
+moving it to a phone or an air-gapped machine. Keys too large for one
+code are split into numbered segments; scan them in order and join the
+text. No file is written unless --output is also given.
+
+With --minimal, third-party signatures and superseded self-signatures
+are stripped (export-minimal, export-clean). This keeps keys that have
+collected many cross-signatures small enough for GitHub or a QR code.
+
+With --wkd the key is exported for a Web Key Directory on your own
+domain: a minimal binary key for your configured email is written to
+<out-dir>/.well-known/openpgpkey/hu/<hash>, with an empty policy file
+next to it. Upload the .well-known directory to the web root of your
+email domain.
+
+With --bundle <dir> every format is written at once, for setting up a new
+machine: public-key.asc (armored), public-key-minimal.asc (for GitHub),
+public-key.gpg (binary) and, if the key has an authentication subkey,
+ssh-key.pub (OpenSSH). Existing files with these names are replaced.`,
+		RunE: runExport,
 	}
 
-	cmd.Flags().StringP("output", "o", "", "Output file path (default: ~/public-key-YYYYMMDD.asc)")
+	cmd.Flags().StringP("output", "o", "", "Output file path (default: ~/public-key-YYYYMMDD.asc, or .gpg with --format binary)")
+	cmd.Flags().String("format", "armor", "Output format: armor or binary")
+	cmd.Flags().Bool("minimal", false, "Strip third-party signatures for a compact key (e.g. for GitHub)")
+	cmd.Flags().Bool("qr", false, "Print the public key as QR codes instead of writing a file")
+	cmd.Flags().Bool("wkd", false, "Export the key in Web Key Directory layout")
+	cmd.Flags().String("out-dir", ".", "Directory to create the .well-known tree in (with --wkd)")
+	cmd.Flags().String("bundle", "", "Write the armored, minimal, binary and SSH keys into this directory")
+	cmd.MarkFlagsMutuallyExclusive("qr", "wkd", "bundle")
+	cmd.MarkFlagsMutuallyExclusive("output", "bundle")
+	cmd.MarkFlagsMutuallyExclusive("format", "bundle")
+	cmd.MarkFlagsMutuallyExclusive("minimal", "bundle")
 
 	return cmd
 }
@@ -30,17 +69,74 @@ func runExport(cmd *cobra.Command, args []string) error {
 	ui.PrintHeader("Export Public Key")
 
 	outputFile, _ := cmd.Flags().GetString("output")
+	showQR, _ := cmd.Flags().GetBool("qr")
+	minimal, _ := cmd.Flags().GetBool("minimal")
+	wkd, _ := cmd.Flags().GetBool("wkd")
+	format, _ := cmd.Flags().GetString("format")
+
+	binary, err := parseExportFormat(format)
+	if err != nil {
+		return err
+	}
+	if binary && showQR {
+		return fmt.Errorf("--qr needs armored output; drop --format binary")
+	}
+
+	if wkd {
+		outDir, _ := cmd.Flags().GetString("out-dir")
+		return exportWKD(cmd, gpgSvc, outDir)
+	}
+
+	if bundleDir, _ := cmd.Flags().GetString("bundle"); bundleDir != "" {
+		paths, err := writeExportBundle(ctx, gpgSvc, cfg.PrimaryKeyID, bundleDir)
+		if err != nil {
+			return err
+		}
+		ui.LogSuccess("Public key bundle exported to: %s", bundleDir)
+		for _, path := range paths {
+			fmt.Printf("  %s\n", path)
+		}
+		return nil
+	}
+
+	exportKey := gpgSvc.ExportPublicKey
+	if minimal {
+		exportKey = gpgSvc.ExportPublicKeyMinimal
+	}
+	if binary {
+		exportKey = func(ctx context.Context, keyID string) ([]byte, error) {
+			return gpgSvc.ExportPublicKeyBinary(ctx, keyID, minimal)
+		}
+	}
+
+	if showQR {
+		publicKeyData, err := exportKey(ctx, cfg.PrimaryKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to export public key: %w", err)
+		}
+		if err := printQRCodes(os.Stdout, publicKeyData); err != nil {
+			return err
+		}
+		if outputFile == "" {
+			return nil
+		}
+		if err := os.WriteFile(outputFile, publicKeyData, 0644); err != nil {
+			return fmt.Errorf("failed to write public key: %w", err)
+		}
+		ui.LogSuccess("Public key exported to: %s", outputFile)
+		return nil
+	}
+
 	if outputFile == "" {
-		timestamp := time.Now().Format("20060102")
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return fmt.Errorf("failed to get home directory: %w", err)
 		}
-		outputFile = filepath.Join(homeDir, fmt.Sprintf("public-key-%s.asc", timestamp))
+		outputFile = defaultExportPath(homeDir, time.Now(), binary)
 	}
 
 	// Export public key
-	publicKeyData, err := gpgSvc.ExportPublicKey(ctx, cfg.PrimaryKeyID)
+	publicKeyData, err := exportKey(ctx, cfg.PrimaryKeyID)
 	if err != nil {
 		return fmt.Errorf("failed to export public key: %w", err)
 	}
@@ -59,3 +155,212 @@ func runExport(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// parseExportFormat reports whether the --format value asks for binary output.
+func parseExportFormat(format string) (binary bool, err error) {
+	switch format {
+	case "armor":
+		return false, nil
+	case "binary":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid --format %q: must be armor or binary", format)
+}
+
+// defaultExportPath returns ~/public-key-YYYYMMDD.asc, or .gpg for binary output.
+func defaultExportPath(homeDir string, now time.Time, binary bool) string {
+	ext := ".asc"
+	if binary {
+		ext = ".gpg"
+	}
+	return filepath.Join(homeDir, fmt.Sprintf("public-key-%s%s", now.Format("20060102"), ext))
+}
+
+// exportWKD exports the key for the configured email into a Web Key
+// Directory tree under outDir.
+func exportWKD(cmd *cobra.Command, gpgSvc *gpg.Service, outDir string) error {
+	if cfg.UserEmail == "" {
+		return fmt.Errorf("no email configured; set user_email or pass --email")
+	}
+
+	hash, data, err := gpgSvc.ExportForWKD(cmd.Context(), cfg.UserEmail)
+	if err != nil {
+		return err
+	}
+
+	keyPath, err := writeWKD(outDir, hash, data)
+	if err != nil {
+		return err
+	}
+
+	_, domain, _ := strings.Cut(cfg.UserEmail, "@")
+	ui.LogSuccess("WKD key for %s written to: %s", cfg.UserEmail, keyPath)
+	fmt.Println()
+	fmt.Printf("Upload %s to the web root of %s, so the key is served at:\n",
+		filepath.Join(outDir, ".well-known"), domain)
+	fmt.Printf("  https://%s/.well-known/openpgpkey/hu/%s\n", domain, hash)
+	fmt.Println()
+	fmt.Println("Check it with: gpg --locate-keys --auto-key-locate clear,nodefault,wkd", cfg.UserEmail)
+
+	return nil
+}
+
+// writeWKD writes a key into the WKD direct method layout under outDir:
+// .well-known/openpgpkey/hu/<hash>, plus an empty policy file if there
+// isn't one. Returns the path of the key file.
+func writeWKD(outDir, hash string, data []byte) (string, error) {
+	wkdDir := filepath.Join(outDir, ".well-known", "openpgpkey")
+	huDir := filepath.Join(wkdDir, "hu")
+	if err := os.MkdirAll(huDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create WKD directory: %w", err)
+	}
+
+	policyPath := filepath.Join(wkdDir, "policy")
+	if _, err := os.Stat(policyPath); os.IsNotExist(err) {
+		if err := os.WriteFile(policyPath, nil, 0644); err != nil {
+			return "", fmt.Errorf("failed to write WKD policy file: %w", err)
+		}
+	}
+
+	keyPath := filepath.Join(huDir, hash)
+	if err := os.WriteFile(keyPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write WKD key: %w", err)
+	}
+	return keyPath, nil
+}
+
+// Files written by writeExportBundle.
+const (
+	bundleArmoredFile = "public-key.asc"
+	bundleMinimalFile = "public-key-minimal.asc"
+	bundleBinaryFile  = "public-key.gpg"
+	bundleSSHFile     = "ssh-key.pub"
+)
+
+// bundleExport is a file of the export bundle and the export that fills it.
+type bundleExport struct {
+	file   string
+	export func(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// writeExportBundle writes the key in every export format into dir, creating
+// it if needed, and returns the paths written. The SSH key is only written
+// if the key has an authentication subkey that isn't revoked.
+func writeExportBundle(ctx context.Context, gpgSvc gpg.GPGService, keyID, dir string) ([]string, error) {
+	keys, err := gpgSvc.ListSecretKeys(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	exports := []bundleExport{
+		{bundleArmoredFile, gpgSvc.ExportPublicKey},
+		{bundleMinimalFile, gpgSvc.ExportPublicKeyMinimal},
+		{bundleBinaryFile, func(ctx context.Context, keyID string) ([]byte, error) {
+			return gpgSvc.ExportPublicKeyBinary(ctx, keyID, false)
+		}},
+	}
+	if hasAuthSubkey(keys) {
+		exports = append(exports, bundleExport{bundleSSHFile, gpgSvc.ExportSSHKey})
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+
+	var paths []string
+	for _, e := range exports {
+		data, err := e.export(ctx, keyID)
+		if err != nil {
+			return paths, fmt.Errorf("failed to export %s: %w", e.file, err)
+		}
+		path := filepath.Join(dir, e.file)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %w", e.file, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// hasAuthSubkey reports whether keys include a subkey with the
+// authentication capability that isn't revoked.
+func hasAuthSubkey(keys []gpg.Key) bool {
+	for _, key := range keys {
+		if key.Type == "ssb" && !key.Revoked && slices.Contains(key.Capabilities, "A") {
+			return true
+		}
+	}
+	return false
+}
+
+// qrSegmentSize is the number of bytes of key data per QR code. 350 bytes
+// fits in a version 14 code, which is 77 columns wide with its quiet zone,
+// so each code fits an 80 column terminal.
+const qrSegmentSize = 350
+
+// splitQRSegments splits data into consecutive segments of at most size bytes.
+func splitQRSegments(data []byte, size int) [][]byte {
+	var segments [][]byte
+	for len(data) > size {
+		segments = append(segments, data[:size])
+		data = data[size:]
+	}
+	if len(data) > 0 {
+		segments = append(segments, data)
+	}
+	return segments
+}
+
+// printQRCodes writes data to w as one QR code per segment, each preceded by
+// a "Part N of M" heading when there is more than one.
+func printQRCodes(w io.Writer, data []byte) error {
+	segments := splitQRSegments(data, qrSegmentSize)
+	for i, segment := range segments {
+		code, err := qrcode.New(string(segment), qrcode.Medium)
+		if err != nil {
+			return fmt.Errorf("failed to encode QR code: %w", err)
+		}
+		code.DisableBorder = true
+		if len(segments) > 1 {
+			fmt.Fprintf(w, "Part %d of %d\n", i+1, len(segments))
+		}
+		fmt.Fprint(w, renderQRCode(code.Bitmap()))
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// qrQuietZone is the light border drawn around each QR code, in modules.
+// The standard asks for 4; 2 is enough for phone scanners and keeps a
+// version 14 code within 80 columns.
+const qrQuietZone = 2
+
+// renderQRCode draws a QR code bitmap ([y][x], true = dark) with Unicode
+// half blocks, two module rows per text line. Light modules are drawn as
+// blocks so the code scans on the usual light-on-dark terminal, like
+// `qrencode -t UTF8`.
+func renderQRCode(bitmap [][]bool) string {
+	size := len(bitmap)
+	light := func(x, y int) bool {
+		return x < 0 || y < 0 || x >= size || y >= size || !bitmap[y][x]
+	}
+
+	var b strings.Builder
+	for y := -qrQuietZone; y < size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < size+qrQuietZone; x++ {
+			top, bottom := light(x, y), light(x, y+1) && y+1 < size+qrQuietZone
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
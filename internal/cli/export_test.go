@@ -1,13 +1,168 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewExportCmd(t *testing.T) {
 	cmd := newExportCmd()
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "export", cmd.Use)
+	for _, flag := range []string{"output", "format", "minimal", "qr", "wkd", "out-dir", "bundle"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	binary, err := parseExportFormat("armor")
+	require.NoError(t, err)
+	assert.False(t, binary)
+
+	binary, err = parseExportFormat("binary")
+	require.NoError(t, err)
+	assert.True(t, binary)
+
+	_, err = parseExportFormat("pem")
+	assert.Error(t, err)
+}
+
+func TestDefaultExportPath(t *testing.T) {
+	now := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, filepath.Join("/home/u", "public-key-20250309.asc"), defaultExportPath("/home/u", now, false))
+	assert.Equal(t, filepath.Join("/home/u", "public-key-20250309.gpg"), defaultExportPath("/home/u", now, true))
+}
+
+func TestSplitQRSegments(t *testing.T) {
+	data := []byte(strings.Repeat("a", 25))
+
+	segments := splitQRSegments(data, 10)
+	require.Len(t, segments, 3)
+	assert.Len(t, segments[0], 10)
+	assert.Len(t, segments[2], 5)
+	assert.Equal(t, data, bytes.Join(segments, nil))
+
+	assert.Len(t, splitQRSegments(data, 25), 1)
+	assert.Empty(t, splitQRSegments(nil, 10))
+}
+
+func TestPrintQRCodes(t *testing.T) {
+	var single bytes.Buffer
+	require.NoError(t, printQRCodes(&single, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n")))
+	assert.NotContains(t, single.String(), "Part")
+	assert.Contains(t, single.String(), "█")
+
+	var multi bytes.Buffer
+	require.NoError(t, printQRCodes(&multi, []byte(strings.Repeat("x", qrSegmentSize*2+1))))
+	assert.Contains(t, multi.String(), "Part 1 of 3")
+	assert.Contains(t, multi.String(), "Part 3 of 3")
+
+	// Each code fits an 80 column terminal
+	for _, line := range strings.Split(multi.String(), "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), 80)
+	}
+}
+
+func TestRenderQRCode(t *testing.T) {
+	// One dark module inside the quiet zone
+	assert.Equal(t, "█████\n██▄██\n▀▀▀▀▀\n", renderQRCode([][]bool{{true}}))
+}
+
+func TestWriteWKD(t *testing.T) {
+	outDir := t.TempDir()
+	hash := "iy9q119eutrkn8s1mk4r39qejnbu3n5q"
+
+	keyPath, err := writeWKD(outDir, hash, []byte{0x98, 0x33})
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(outDir, ".well-known", "openpgpkey", "hu", hash), keyPath)
+	data, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x98, 0x33}, data)
+
+	policy, err := os.ReadFile(filepath.Join(outDir, ".well-known", "openpgpkey", "policy"))
+	require.NoError(t, err)
+	assert.Empty(t, policy)
+
+	// An existing policy file is kept
+	policyPath := filepath.Join(outDir, ".well-known", "openpgpkey", "policy")
+	require.NoError(t, os.WriteFile(policyPath, []byte("protocol-version: 5\n"), 0644))
+	_, err = writeWKD(outDir, hash, []byte{0x98, 0x33})
+	require.NoError(t, err)
+	policy, err = os.ReadFile(policyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "protocol-version: 5\n", string(policy))
+}
+
+func TestWriteExportBundle(t *testing.T) {
+	keyID := "ABC123DEF4567890"
+	listKeys := "gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint " + keyID
+	signOnly := `sec:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+`
+	withAuth := signOnly + `ssb:u:255:22:1234567890ABCDEF:1757030400:1914710400:::::a:::D2760001240103040006123456780000::ed25519::
+`
+
+	newMock := func(listing string) *executor.MockExecutor {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(listKeys, []byte(listing))
+		mockExec.SetOutput("gpg --export --armor "+keyID, []byte("armored"))
+		mockExec.SetOutput("gpg --export --armor --export-options export-minimal,export-clean "+keyID, []byte("minimal"))
+		mockExec.SetOutput("gpg --export "+keyID, []byte{0x98, 0x33})
+		mockExec.SetOutput("gpg --export-ssh-key "+keyID, []byte("ssh-ed25519 AAAA openpgp:0x12345678\n"))
+		return mockExec
+	}
+
+	t.Run("with an authentication subkey", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "bundle")
+		paths, err := writeExportBundle(context.Background(), gpg.NewService(newMock(withAuth)), keyID, dir)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			filepath.Join(dir, "public-key.asc"),
+			filepath.Join(dir, "public-key-minimal.asc"),
+			filepath.Join(dir, "public-key.gpg"),
+			filepath.Join(dir, "ssh-key.pub"),
+		}, paths)
+		for path, want := range map[string]string{
+			"public-key.asc":         "armored",
+			"public-key-minimal.asc": "minimal",
+			"public-key.gpg":         "\x98\x33",
+			"ssh-key.pub":            "ssh-ed25519 AAAA openpgp:0x12345678\n",
+		} {
+			data, err := os.ReadFile(filepath.Join(dir, path))
+			require.NoError(t, err)
+			assert.Equal(t, want, string(data), path)
+		}
+	})
+
+	t.Run("without an authentication subkey", func(t *testing.T) {
+		dir := t.TempDir()
+		mockExec := newMock(signOnly)
+		paths, err := writeExportBundle(context.Background(), gpg.NewService(mockExec), keyID, dir)
+		require.NoError(t, err)
+
+		assert.Len(t, paths, 3)
+		assert.NoFileExists(t, filepath.Join(dir, "ssh-key.pub"))
+		assert.False(t, mockExec.VerifyCall("gpg", "--export-ssh-key", keyID))
+	})
+
+	t.Run("export fails", func(t *testing.T) {
+		mockExec := newMock(signOnly)
+		mockExec.SetError("gpg --export "+keyID, fmt.Errorf("exit status 2"))
+		_, err := writeExportBundle(context.Background(), gpg.NewService(mockExec), keyID, t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "public-key.gpg")
+	})
 }
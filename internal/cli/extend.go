@@ -2,24 +2,39 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"strings"
+	"time"
 
-	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
 func newExtendCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "extend",
 		Short: "Extend expiration dates on keys",
-		RunE:  runExtend,
+		Long: `Extend the expiration of the primary key and every subkey to the same date.
+Revoked subkeys and subkeys that have already expired are left alone.
+
+The new expiration can be relative ("5y", "18m", "6w", "90d") or an absolute
+date ("2035-01-01"). The master key is imported, the expiration is changed in
+a single scripted gpg --edit-key session, and the master key is removed again.`,
+		RunE: runExtend,
 	}
+
+	cmd.Flags().String("expires", "", "New expiration, e.g. 5y or 2035-01-01 (prompted if not set)")
+	cmd.Flags().String("passphrase-file", "", "File containing the GPG key passphrase (default: $"+passphraseEnv+", prompted if neither is set)")
+	addBackupFlags(cmd)
+
+	return cmd
 }
 
-func runExtend(cmd *cobra.Command, args []string) error {
+func runExtend(cmd *cobra.Command, args []string) (err error) {
 	gpgSvc, _, backupSvc := getServices()
 	ctx := cmd.Context()
+	op := newJournalOp("extend")
+	defer func() { op.finish(err) }()
 
 	ui.PrintHeader("Extend Key Expiration")
 
@@ -30,25 +45,39 @@ func runExtend(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
 
-	for _, key := range keys {
-		fmt.Printf("  %s %s", key.Type, key.KeyID)
-		if key.Expires != "" {
-			fmt.Printf(" expires: %s", key.Expires)
-		}
-		fmt.Println()
-	}
+	printKeyExpiry(keys)
 	fmt.Println()
 
-	newExpiry, err := ui.Prompt("Enter new expiration (e.g., '5y' for 5 years, '2035-01-01' for specific date): ")
-	if err != nil {
-		return err
+	newExpiry, _ := cmd.Flags().GetString("expires")
+	if newExpiry == "" {
+		newExpiry, err = ui.Prompt("Enter new expiration (e.g., '5y' for 5 years, '2035-01-01' for specific date): ")
+		if err != nil {
+			return err
+		}
 	}
 	if newExpiry == "" {
 		return fmt.Errorf("no expiration provided")
 	}
+	expires, err := gpg.ParseExpiry(newExpiry)
+	if err != nil {
+		return err
+	}
+
+	targets := extendableKeys(keys, time.Now())
+	var targetIDs, subkeyIDs []string
+	for _, key := range targets {
+		targetIDs = append(targetIDs, key.KeyID)
+		if key.Type == "ssb" {
+			subkeyIDs = append(subkeyIDs, key.Fingerprint)
+		}
+	}
+	if skipped := len(keys) - len(targets); skipped > 0 {
+		ui.LogInfo("Skipping %d revoked or expired subkey(s)", skipped)
+	}
+	ui.LogInfo("The primary key and %d subkey(s) will expire on %s", len(subkeyIDs), expires)
 
 	// Create backup
-	backupPath, err := backupSvc.CreateBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir)
+	backupPath, err := createBackup(ctx, backupSvc)
 	if err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
@@ -63,44 +92,25 @@ func runExtend(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if _, err := os.Stat(masterKeyPath); err != nil {
-		return fmt.Errorf("master key file not found: %w", err)
-	}
-
 	// Import master key
-	ui.LogInfo("Importing master key...")
-	exec := executor.NewRealExecutor()
-	_, err = exec.Run(ctx, "gpg", "--import", masterKeyPath)
-	if err != nil {
-		return fmt.Errorf("failed to import master key: %w", err)
+	if _, err := importMasterKey(ctx, gpgSvc, masterKeyPath); err != nil {
+		return err
 	}
-	ui.LogSuccess("Master key imported")
-
-	// Interactive expiration extension
-	fmt.Println()
-	fmt.Println("To extend expiration:")
-	fmt.Println()
-	fmt.Println("1. First, extend the PRIMARY key:")
-	fmt.Println("   - Type: expire")
-	fmt.Printf("   - Enter: %s\n", newExpiry)
-	fmt.Println()
-	fmt.Println("2. Then extend EACH subkey:")
-	fmt.Println("   - Type: key 1")
-	fmt.Println("   - Type: expire")
-	fmt.Printf("   - Enter: %s\n", newExpiry)
-	fmt.Println("   - Type: key 1 (to deselect)")
-	fmt.Println("   - Repeat for key 2, key 3, etc.")
-	fmt.Println()
-	fmt.Println("3. Type: save")
-	fmt.Println()
 
-	_, err = ui.Prompt("Press Enter to continue: ")
+	passphrase, err := secretOrPrompt(cmd, "passphrase-file", passphraseEnv, "GPG key passphrase (leave empty if none): ", false)
 	if err != nil {
 		return err
 	}
 
-	if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
-		return fmt.Errorf("failed to edit key: %w", err)
+	ui.LogInfo("Extending expiration...")
+	op.start()
+	op.addKeys(targetIDs...)
+	if err := gpgSvc.ExtendExpiration(ctx, cfg.PrimaryKeyID, subkeyIDs, expires, passphrase); err != nil {
+		// Don't leave the master key behind when the edit fails
+		if removeErr := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); removeErr != nil {
+			ui.LogWarning("Failed to remove master key: %v", removeErr)
+		}
+		return err
 	}
 
 	// Clean up
@@ -108,33 +118,78 @@ func runExtend(cmd *cobra.Command, args []string) error {
 		ui.LogWarning("Failed to remove master key: %v", err)
 	}
 
-	// Upload
-	if ui.Confirm(fmt.Sprintf("Upload updated public key to %s?", cfg.Keyserver)) {
-		ui.LogInfo("Uploading to keyserver...")
-		_, err := exec.Run(ctx, "gpg", "--keyserver", cfg.Keyserver, "--send-keys", cfg.PrimaryKeyID)
-		if err != nil {
-			ui.LogWarning("Failed to upload to keyserver: %v", err)
-		} else {
-			ui.LogSuccess("Public key uploaded to %s", cfg.Keyserver)
+	// Re-list to confirm the new expiration took effect
+	fmt.Println()
+	fmt.Println("Updated expiration status:")
+	keys, err = gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+	printKeyExpiry(keys)
+	fmt.Println()
+
+	var extended []gpg.Key
+	for _, key := range keys {
+		if contains(targetIDs, key.KeyID) {
+			extended = append(extended, key)
 		}
 	}
+	if stale := keysNotExpiringOn(extended, expires); len(stale) > 0 {
+		ui.LogWarning("Some keys do not expire on %s: %s", expires, strings.Join(stale, ", "))
+		return fmt.Errorf("expiration not extended for %d key(s)", len(stale))
+	}
+	ui.LogSuccess("Key expiration extended to %s", expires)
 
-	fmt.Println()
-	ui.LogSuccess("Key expiration extended")
+	// Upload
+	uploadPublicKey(ctx, gpgSvc)
 
-	// Show new status
-	fmt.Println()
-	fmt.Println("Updated expiration status:")
-	keys, err = gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
-	if err == nil {
-		for _, key := range keys {
-			fmt.Printf("  %s %s", key.Type, key.KeyID)
-			if key.Expires != "" {
-				fmt.Printf(" expires: %s", key.Expires)
+	return nil
+}
+
+// printKeyExpiry prints each key with its expiration date.
+func printKeyExpiry(keys []gpg.Key) {
+	for _, key := range keys {
+		fmt.Printf("  %s %s", key.Type, key.KeyID)
+		if key.Expires != "" {
+			fmt.Printf(" expires: %s", key.Expires)
+		}
+		fmt.Println()
+	}
+}
+
+// extendableKeys returns the keys whose expiration extend changes: the
+// primary key and its subkeys except revoked ones and ones that have
+// already expired, e.g. from a retired YubiKey, which must stay dead.
+func extendableKeys(keys []gpg.Key, now time.Time) []gpg.Key {
+	var result []gpg.Key
+	for _, key := range keys {
+		if key.Type == "ssb" {
+			if key.Revoked {
+				continue
+			}
+			if expires, err := gpg.ParseKeyDate(key.Expires); err == nil && expires.Before(now) {
+				continue
 			}
-			fmt.Println()
 		}
+		result = append(result, key)
 	}
+	return result
+}
 
-	return nil
+// keysNotExpiringOn returns the IDs of keys whose expiration isn't date.
+// A day either way is accepted since gpg turns the date into a timestamp in
+// the local timezone while listings report it in UTC.
+func keysNotExpiringOn(keys []gpg.Key, date string) []string {
+	want, err := time.Parse(gpg.KeyDateFormat, date)
+	if err != nil {
+		return nil
+	}
+	var stale []string
+	for _, key := range keys {
+		got, err := time.Parse(gpg.KeyDateFormat, key.Expires)
+		if err != nil || got.Sub(want).Abs() > 24*time.Hour {
+			stale = append(stale, key.KeyID)
+		}
+	}
+	return stale
 }
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"testing"
+	"time"
 
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -10,4 +12,40 @@ func TestNewExtendCmd(t *testing.T) {
 	cmd := newExtendCmd()
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "extend", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("expires"))
+}
+
+func TestKeysNotExpiringOn(t *testing.T) {
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Expires: "2030-09-05"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Expires: "2030-09-04"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Expires: "2027-09-05"},
+		{Type: "ssb", KeyID: "116DB85718F8B287"},
+	}
+
+	assert.Equal(t, []string{"0257F6B8152D7F35", "116DB85718F8B287"}, keysNotExpiringOn(keys, "2030-09-05"))
+}
+
+func TestExtendableKeys(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	keys := []gpg.Key{
+		// An expired primary key is what extend is for
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Expires: "2026-01-01"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Expires: "2030-09-04"},
+		{Type: "ssb", KeyID: "116DB85718F8B287"},
+	}
+
+	t.Run("active subkeys", func(t *testing.T) {
+		assert.Equal(t, keys, extendableKeys(keys, now))
+	})
+
+	t.Run("revoked subkey", func(t *testing.T) {
+		revoked := gpg.Key{Type: "ssb", KeyID: "2222222222222222", Expires: "2030-09-04", Revoked: true}
+		assert.Equal(t, keys, extendableKeys(append(keys, revoked), now))
+	})
+
+	t.Run("expired subkey", func(t *testing.T) {
+		expired := gpg.Key{Type: "ssb", KeyID: "3333333333333333", Expires: "2025-09-04"}
+		assert.Equal(t, keys, extendableKeys(append(keys, expired), now))
+	})
 }
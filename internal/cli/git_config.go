@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/internal/git"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newGitConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git-config",
+		Short: "Configure git to sign commits and tags with your key",
+		Long: `Configure git to sign with your primary key's YubiKey subkeys:
+
+  user.signingkey  <fingerprint>!
+  commit.gpgsign   true
+  tag.gpgsign      true
+  gpg.program      <path to gpg>
+
+Settings are written to ~/.gitconfig, or to the current repository with --local.
+The previous values are printed before anything is changed, along with the
+commands to restore them.`,
+		RunE: runGitConfig,
+	}
+
+	cmd.Flags().Bool("local", false, "Write to the current repository's config instead of the global config")
+
+	return cmd
+}
+
+// gitSetting is a git config key and the value git-config sets it to.
+type gitSetting struct {
+	key   string
+	value string
+}
+
+func runGitConfig(cmd *cobra.Command, args []string) error {
+	local, _ := cmd.Flags().GetBool("local")
+	scope := git.ScopeGlobal
+	if local {
+		scope = git.ScopeLocal
+	}
+
+	gitSvc := git.NewService(newExecutor())
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Configure Git Signing")
+
+	gpgProgram, err := resolveGPGProgram()
+	if err != nil {
+		return err
+	}
+
+	settings := gitSigningSettings(cfg.PrimaryKeyFingerprint, gpgProgram)
+	previous, err := readGitSettings(ctx, gitSvc, scope, settings)
+	if err != nil {
+		return err
+	}
+
+	ui.PrintSection(fmt.Sprintf("CURRENT %s VALUES", strings.ToUpper(string(scope))))
+	changed := 0
+	for _, setting := range settings {
+		old := previous[setting.key]
+		if old == setting.value {
+			ui.PrintKeyValue(setting.key, old+" (unchanged)")
+			continue
+		}
+		changed++
+		display := old
+		if display == "" {
+			display = "(unset)"
+		}
+		ui.PrintKeyValue(setting.key, fmt.Sprintf("%s -> %s", display, setting.value))
+	}
+	fmt.Println()
+
+	if changed == 0 {
+		ui.LogSuccess("Git is already configured for signing")
+		return nil
+	}
+
+	fmt.Println("To restore the previous values:")
+	for _, line := range gitRevertCommands(scope, settings, previous) {
+		fmt.Println("  " + line)
+	}
+	fmt.Println()
+
+	for _, setting := range settings {
+		if previous[setting.key] == setting.value {
+			continue
+		}
+		if err := gitSvc.SetConfig(ctx, scope, setting.key, setting.value); err != nil {
+			return err
+		}
+	}
+
+	ui.LogSuccess("Updated %d git %s setting(s)", changed, scope)
+	ui.LogInfo("Run 'ykgpg verify' to test signing")
+	return nil
+}
+
+// gitSigningSettings returns the git settings needed to sign with the key.
+func gitSigningSettings(fingerprint, gpgProgram string) []gitSetting {
+	return []gitSetting{
+		{key: "user.signingkey", value: fingerprint + "!"},
+		{key: "commit.gpgsign", value: "true"},
+		{key: "tag.gpgsign", value: "true"},
+		{key: "gpg.program", value: gpgProgram},
+	}
+}
+
+// readGitSettings returns the current value of each setting's key.
+func readGitSettings(ctx context.Context, gitSvc git.GitService, scope git.Scope, settings []gitSetting) (map[string]string, error) {
+	values := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		value, err := gitSvc.GetConfig(ctx, scope, setting.key)
+		if err != nil {
+			return nil, err
+		}
+		values[setting.key] = value
+	}
+	return values, nil
+}
+
+// gitRevertCommands returns the git commands that restore the previous values
+// of every setting that is about to change.
+func gitRevertCommands(scope git.Scope, settings []gitSetting, previous map[string]string) []string {
+	var commands []string
+	for _, setting := range settings {
+		old := previous[setting.key]
+		switch {
+		case old == setting.value:
+			continue
+		case old == "":
+			commands = append(commands, fmt.Sprintf("git config --%s --unset %s", scope, setting.key))
+		default:
+			commands = append(commands, fmt.Sprintf("git config --%s %s %s", scope, setting.key, shellQuote(old)))
+		}
+	}
+	return commands
+}
+
+// shellQuote quotes s for safe use as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// resolveGPGProgram returns the absolute path of the configured gpg binary.
+func resolveGPGProgram() (string, error) {
+	path, err := exec.LookPath(gpgBinary())
+	if err != nil {
+		return "", fmt.Errorf("%s not found on PATH: %w", gpgBinary(), err)
+	}
+	return filepath.Abs(path)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitConfigCmd(t *testing.T) {
+	cmd := newGitConfigCmd()
+	assert.Equal(t, "git-config", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("local"))
+}
+
+func TestGitSigningSettings(t *testing.T) {
+	settings := gitSigningSettings("ABCDEF1234567890ABCDEF12ABC123DEF4567890", "/usr/bin/gpg")
+
+	assert.Equal(t, []gitSetting{
+		{key: "user.signingkey", value: "ABCDEF1234567890ABCDEF12ABC123DEF4567890!"},
+		{key: "commit.gpgsign", value: "true"},
+		{key: "tag.gpgsign", value: "true"},
+		{key: "gpg.program", value: "/usr/bin/gpg"},
+	}, settings)
+}
+
+func TestReadGitSettings(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("git config --local --get user.signingkey", []byte("OLDKEY\n"))
+	mockExec.SetOutput("git config --local --get commit.gpgsign", []byte("true\n"))
+
+	values, err := readGitSettings(context.Background(), git.NewService(mockExec), git.ScopeLocal, gitSigningSettings("FPR", "/usr/bin/gpg"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "OLDKEY", values["user.signingkey"])
+	assert.Equal(t, "true", values["commit.gpgsign"])
+	assert.Equal(t, "", values["tag.gpgsign"])
+}
+
+func TestGitRevertCommands(t *testing.T) {
+	settings := gitSigningSettings("FPR", "/usr/bin/gpg")
+	previous := map[string]string{
+		"user.signingkey": "it's old",
+		"commit.gpgsign":  "true",
+	}
+
+	commands := gitRevertCommands(git.ScopeGlobal, settings, previous)
+
+	assert.Equal(t, []string{
+		`git config --global user.signingkey 'it'\''s old'`,
+		"git config --global --unset tag.gpgsign",
+		"git config --global --unset gpg.program",
+	}, commands)
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// confOption is a gpg.conf or gpg-agent.conf option that gets in the way of
+// using a YubiKey with ykgpg.
+type confOption struct {
+	file   string
+	option string
+	// value, if set, is the only value of the option that is a problem
+	value   string
+	problem string
+}
+
+// problematicConfOptions are the options reported by confFindings.
+var problematicConfOptions = []confOption{
+	{file: "gpg.conf", option: "pinentry-mode", problem: "overrides the pinentry mode gpg-agent and ykgpg choose, so PIN prompts may not appear"},
+	{file: "gpg.conf", option: "no-autostart", problem: "gpg won't start gpg-agent, so the YubiKey can't be used until the agent is started by hand"},
+	{file: "gpg.conf", option: "keyid-format", value: "short", problem: "short key IDs are easy to forge; use 'keyid-format long'"},
+	{file: "gpg-agent.conf", option: "disable-scdaemon", problem: "gpg-agent can't talk to the YubiKey"},
+	{file: "gpg-agent.conf", option: "no-allow-loopback-pinentry", problem: "ykgpg can't pass the Admin PIN to scripted card operations"},
+}
+
+// gnupgHome returns the GnuPG home directory: --gnupg-home, $GNUPGHOME or
+// ~/.gnupg.
+func gnupgHome() string {
+	if home := toolConfig().GnuPGHome; home != "" {
+		return home
+	}
+	if home := os.Getenv("GNUPGHOME"); home != "" {
+		return home
+	}
+	return filepath.Join(os.Getenv("HOME"), ".gnupg")
+}
+
+// confFindings returns a line for each problematic option set in gpg.conf
+// or gpg-agent.conf in home, e.g.
+// "gpg.conf:3: no-autostart: gpg won't start gpg-agent, ...". A file that
+// doesn't exist or can't be read has no findings.
+func confFindings(home string) []string {
+	var findings []string
+	for _, file := range []string{"gpg.conf", "gpg-agent.conf"} {
+		f, err := os.Open(filepath.Join(home, file))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			value := strings.Join(fields[1:], " ")
+			for _, opt := range problematicConfOptions {
+				if opt.file != file || opt.option != fields[0] || (opt.value != "" && !strings.EqualFold(opt.value, value)) {
+					continue
+				}
+				findings = append(findings, fmt.Sprintf("%s:%d: %s: %s", file, lineNo, strings.Join(fields, " "), opt.problem))
+			}
+		}
+		f.Close()
+	}
+	return findings
+}
@@ -3,16 +3,284 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/bobbydams/yubikey-manager/internal/backup"
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
 )
 
+// requireInteractive refuses to run a command that hands the terminal to
+// gpg or ykman when --non-interactive is set. hint, if not empty, names a
+// scriptable alternative.
+func requireInteractive(command, hint string) error {
+	if !ui.IsNonInteractive() {
+		return nil
+	}
+	msg := fmt.Sprintf("%s needs an interactive session and can't run with --non-interactive", command)
+	if hint != "" {
+		msg += "; " + hint
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// minQuickAddKeyVersion is the first gpg release with --quick-add-key.
+var minQuickAddKeyVersion = [3]int{2, 1, 0}
+
+// requireGPGVersion returns an error if the gpg program is older than
+// minimum, naming the feature that needs the newer version.
+func requireGPGVersion(ctx context.Context, gpgSvc gpg.GPGService, minimum [3]int, feature string) error {
+	major, minor, patch, err := gpgSvc.GPGVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check the gpg version: %w", err)
+	}
+	version := [3]int{major, minor, patch}
+	for i := range version {
+		if version[i] != minimum[i] {
+			if version[i] > minimum[i] {
+				return nil
+			}
+			return fmt.Errorf("%s needs GnuPG %d.%d.%d or later, but gpg is %d.%d.%d; upgrade GnuPG or use 'ykgpg setup', which creates the subkey interactively",
+				feature, minimum[0], minimum[1], minimum[2], major, minor, patch)
+		}
+	}
+	return nil
+}
+
+// createBackup creates a backup of the primary key, encrypting it with a
+// prompted passphrase when encrypt_backup is enabled.
+// Returns the path to the backup directory or encrypted archive.
+func createBackup(ctx context.Context, backupSvc backup.BackupService) (string, error) {
+	warnUnencryptedSubkeys()
+	if !cfg.EncryptBackup {
+		return backupSvc.CreateBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir)
+	}
+
+	passphrase, err := ui.PromptSecretRequired("Backup encryption passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := ui.PromptSecret("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+
+	return backupSvc.CreateEncryptedBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir, passphrase)
+}
+
+// addBackupFlags adds --encrypt-backup and --include-subkeys, which bindFlags
+// binds to encrypt_backup and backup_include_subkeys, to a command that
+// creates a backup.
+func addBackupFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("encrypt-backup", false, "Encrypt the backup with a passphrase into a single .tar.gpg file")
+	cmd.Flags().Bool("include-subkeys", false, "Also save the secret subkeys in the backup (use with --encrypt-backup)")
+}
+
+// warnUnencryptedSubkeys warns when backups will hold the secret subkeys
+// in plain files.
+func warnUnencryptedSubkeys() {
+	if cfg.BackupIncludeSubkeys && !cfg.EncryptBackup {
+		ui.LogWarning("The backup will contain your secret subkeys unencrypted; use --include-subkeys with --encrypt-backup")
+	}
+}
+
+// warnIfNoEd25519 warns when the card's firmware is known to be too old for
+// ed25519/cv25519 keys. Returns true if a warning was printed.
+func warnIfNoEd25519(cardInfo *gpg.CardInfo) bool {
+	if cardInfo.FirmwareVersion == "" || yubikey.FirmwareAtLeast(cardInfo.FirmwareVersion, yubikey.MinEd25519Firmware) {
+		return false
+	}
+	ui.LogWarning("This YubiKey's firmware (%s) does not support ed25519/cv25519 keys; %s or later is required.",
+		cardInfo.FirmwareVersion, yubikey.MinEd25519Firmware)
+	ui.LogWarning("Use an RSA subkey with this YubiKey instead.")
+	return true
+}
+
+// formatKeyAttributes formats the card's per-slot key attributes in slot
+// order, e.g. "Signature: ed25519, Encryption: cv25519, Authentication: ed25519".
+func formatKeyAttributes(attrs map[string]string) string {
+	var parts []string
+	for slot := 1; slot <= len(gpg.CardSlots); slot++ {
+		name := gpg.CardSlots[slot]
+		if attr, ok := attrs[name]; ok {
+			parts = append(parts, name+": "+attr)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// confirmMasterKeyFile shows the primary key in the master key backup at path
+// without importing it, and checks that its fingerprint matches the configured
+// primary key. Unless assumeYes is set, the user must confirm it is the right key.
+func confirmMasterKeyFile(ctx context.Context, gpgSvc gpg.GPGService, path string, assumeYes bool) error {
+	key, err := gpgSvc.InspectKeyFile(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to inspect master key file: %w", err)
+	}
+
+	ui.PrintSection("MASTER KEY BACKUP")
+	ui.PrintKeyValueKey("Fingerprint", key.Fingerprint)
+	if key.UserID != "" {
+		ui.PrintKeyValue("User ID", key.UserID)
+	}
+	for _, subkey := range key.Subkeys {
+		ui.PrintKeyValue("Subkey", formatKeySummary(subkey))
+	}
+	fmt.Println()
+
+	if !strings.EqualFold(key.Fingerprint, cfg.PrimaryKeyFingerprint) {
+		ui.LogError("This backup does not contain your configured primary key (%s)", cfg.PrimaryKeyFingerprint)
+		return fmt.Errorf("master key fingerprint %s does not match primary_key_fingerprint", key.Fingerprint)
+	}
+	ui.LogSuccess("Fingerprint matches the configured primary key")
+
+	if !assumeYes && !ui.Confirm("Import this key?") {
+		return fmt.Errorf("import cancelled")
+	}
+	return nil
+}
+
+// formatKeySummary describes a key in one line, e.g.
+// "ed25519/DC47D1B090A51498 [S] expires: 2030-09-04".
+func formatKeySummary(key gpg.Key) string {
+	summary := fmt.Sprintf("%s/%s [%s]", key.Algorithm, key.KeyID, strings.Join(key.Capabilities, ""))
+	if key.Expires != "" {
+		summary += " expires: " + key.Expires
+	}
+	return summary
+}
+
+// importMasterKey imports the master key backup at path, checking first that
+// it contains the configured primary key.
+func importMasterKey(ctx context.Context, gpgSvc gpg.GPGService, path string) (*gpg.ImportResult, error) {
+	ui.LogInfo("Importing master key...")
+	result, err := gpgSvc.ImportKeyFile(ctx, path, cfg.PrimaryKeyFingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import master key: %w", err)
+	}
+	ui.LogSuccess("Master key imported: %s", formatImportResult(result))
+	return result, nil
+}
+
+// formatImportResult describes an import result in one line.
+func formatImportResult(result *gpg.ImportResult) string {
+	return fmt.Sprintf("%d key(s) processed, %d secret key(s) imported, %d unchanged, %d new subkey(s)",
+		result.Processed, result.SecretImported, result.SecretUnchanged, result.NewSubkeys)
+}
+
+// uploadPublicKey offers to upload the public key to every configured
+// keyserver and reports the result for each one.
+func uploadPublicKey(ctx context.Context, gpgSvc *gpg.Service) {
+	servers := cfg.KeyserverList()
+	if len(servers) == 0 {
+		return
+	}
+	if !ui.Confirm(fmt.Sprintf("Upload updated public key to %s?", strings.Join(servers, ", "))) {
+		return
+	}
+
+	spinner := ui.StartSpinner("Uploading to keyserver...")
+	errs := gpgSvc.PublishKey(ctx, cfg.PrimaryKeyID, servers)
+	spinner.Stop()
+	if reportPublishResults(servers, errs) > 0 {
+		ui.LogWarning("Visit https://keys.openpgp.org/upload to upload manually.")
+	}
+}
+
+// reportPublishResults logs the upload result for each server and returns
+// the number of failed uploads.
+func reportPublishResults(servers []string, errs []error) int {
+	failed := 0
+	for i, server := range servers {
+		if errs[i] != nil {
+			ui.LogWarning("Failed to upload to %s: %v", server, errs[i])
+			failed++
+		} else {
+			ui.LogSuccess("Public key uploaded to %s", server)
+		}
+	}
+	return failed
+}
+
+// selectCard asks which YubiKey to use when more than one is connected and
+// switches gpg and ykman to it. With one or no YubiKey, or if they can't be
+// listed (e.g. ykman isn't installed), it does nothing.
+func selectCard(ctx context.Context, yubikeySvc yubikey.YubiKeyService) error {
+	serials, err := yubikeySvc.ListCards(ctx)
+	if err != nil || len(serials) < 2 {
+		return nil
+	}
+
+	ui.LogInfo("%d YubiKeys are connected:", len(serials))
+	for i, serial := range serials {
+		fmt.Printf("  %d. %s\n", i+1, serialWithLabel(serial))
+	}
+	choice, err := ui.Prompt("Select a YubiKey by serial: ")
+	if err != nil {
+		return err
+	}
+	serial, err := matchCardChoice(serials, choice)
+	if err != nil {
+		return err
+	}
+
+	if err := yubikeySvc.SelectCard(ctx, serial); err != nil {
+		return err
+	}
+	ui.LogSuccess("Using YubiKey %s", serialWithLabel(serial))
+	fmt.Println()
+	return nil
+}
+
+// useCard points yubikeySvc at the YubiKey with the given serial after it
+// was inserted. With other YubiKeys connected, gpg-agent is switched to it;
+// on its own, any earlier selection is cleared so ykman doesn't look for a
+// YubiKey that was removed.
+func useCard(ctx context.Context, yubikeySvc *yubikey.Service, serial string) error {
+	serials, err := yubikeySvc.ListCards(ctx)
+	if err != nil {
+		return err
+	}
+	if len(serials) > 1 {
+		return yubikeySvc.SelectCard(ctx, serial)
+	}
+	yubikeySvc.Device = ""
+	return nil
+}
+
+// matchCardChoice returns the serial the user picked from serials, either
+// by typing the serial or its number in the list.
+func matchCardChoice(serials []string, choice string) (string, error) {
+	choice = strings.TrimSpace(choice)
+	for _, serial := range serials {
+		if gpg.NormalizeCardSerial(serial) == gpg.NormalizeCardSerial(choice) {
+			return serial, nil
+		}
+	}
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(serials) {
+		return serials[n-1], nil
+	}
+	return "", fmt.Errorf("no connected YubiKey with serial %q", choice)
+}
+
+// confirmRemoveMasterKey asks the user to type the primary key ID to
+// confirm removing the master key from the local keyring.
+func confirmRemoveMasterKey() bool {
+	return ui.ConfirmMatch("Remove master key from local machine? Make sure your master key backup is safe first.", cfg.PrimaryKeyID)
+}
+
 // removeMasterKey removes the master key from the local keyring.
 func removeMasterKey(ctx context.Context, gpgSvc *gpg.Service, fingerprint string) error {
+	// The long key ID is the last 16 hex digits of the fingerprint
 	keyID := fingerprint
 	if len(fingerprint) > 16 {
-		keyID = fingerprint[:16]
+		keyID = fingerprint[len(fingerprint)-16:]
 	}
 
 	// Check if master key is actually on the machine
@@ -21,16 +289,7 @@ func removeMasterKey(ctx context.Context, gpgSvc *gpg.Service, fingerprint strin
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
 
-	hasMasterOnMachine := false
-	for _, key := range keys {
-		// "sec" (not "sec#") means master key is on machine
-		if key.Type == "sec" && !strings.HasSuffix(key.Type, "#") {
-			hasMasterOnMachine = true
-			break
-		}
-	}
-
-	if !hasMasterOnMachine {
+	if _, onMachine := gpg.MasterKeyOnMachine(keys, fingerprint); !onMachine {
 		// Master key is already offline (sec#), nothing to remove
 		return nil
 	}
@@ -94,3 +353,41 @@ func containsString(s, substr string) bool {
 	}
 	return false
 }
+
+// selectSubkeyStep returns the edit-key instruction for selecting the signing
+// subkey that still needs to be moved to a card, e.g. "Type: key 4 (subkey ABC...)".
+// Falls back to generic instructions if the subkey can't be identified.
+func selectSubkeyStep(ctx context.Context, gpgSvc gpg.GPGService) string {
+	return selectMovableSubkeyStep(ctx, gpgSvc, "S")
+}
+
+// newSubkeyID returns the ID of the subkey with the given capability that
+// is not yet on a card, i.e. the one just created, or "" if there is none.
+func newSubkeyID(ctx context.Context, gpgSvc gpg.GPGService, capability string) string {
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return ""
+	}
+	subkey, err := gpg.FindMovableSubkey(keys, capability)
+	if err != nil {
+		return ""
+	}
+	return subkey.KeyID
+}
+
+// selectMovableSubkeyStep is selectSubkeyStep for a subkey with the given
+// capability ("S", "E" or "A").
+func selectMovableSubkeyStep(ctx context.Context, gpgSvc gpg.GPGService, capability string) string {
+	name := gpg.CapabilityNames[capability]
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err == nil {
+		var subkey *gpg.Key
+		subkey, err = gpg.FindMovableSubkey(keys, capability)
+		if err == nil {
+			return fmt.Sprintf("Type: key %d (selects %s subkey %s)", gpg.SubkeyIndex(keys, subkey.KeyID), name, subkey.KeyID)
+		}
+	}
+
+	ui.LogWarning("Could not identify the subkey to move: %v", err)
+	return fmt.Sprintf("Type: key N (where N is the number of the %s subkey without a card-no)", name)
+}
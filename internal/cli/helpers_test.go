@@ -3,11 +3,17 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/bobbydams/yubikey-manager/internal/config"
 	"github.com/bobbydams/yubikey-manager/internal/executor"
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestContains(t *testing.T) {
@@ -136,31 +142,50 @@ func TestRemoveMasterKey(t *testing.T) {
 	shortKeyID := keyID[:16]
 
 	// Mock output for ListSecretKeys showing master key IS on machine (type "sec", not "sec#")
-	// Format: gpg --list-secret-keys --keyid-format=long KEYID
-	masterKeyOnMachineOutput := `sec   ed25519/ABC123DEF4567890 2025-09-05 [SC] [expires: 2030-09-04]
-      Key fingerprint = FA57 C851 31F1 1B28 EE23  6A4F ABC1 23DE F456 7890
-uid                 [ultimate] Test User <test@example.com>
-ssb   cv25519/1234567890ABCDEF 2025-09-05 [E] [expires: 2030-09-04]
+	// Format: gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint KEYID
+	masterKeyOnMachineOutput := `sec:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4FABC123DEF4567890:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:18:1234567890ABCDEF:1757030400:1914710400:::::e:::+::cv25519::
 `
-	// Mock output for ListSecretKeys showing master key is OFFLINE (type "sec#")
-	masterKeyOfflineOutput := `sec#  ed25519/ABC123DEF4567890 2025-09-05 [SC] [expires: 2030-09-04]
-      Key fingerprint = FA57 C851 31F1 1B28 EE23  6A4F ABC1 23DE F456 7890
-uid                 [ultimate] Test User <test@example.com>
-ssb>  cv25519/1234567890ABCDEF 2025-09-05 [E] [expires: 2030-09-04]
+	// Mock output for ListSecretKeys showing master key is OFFLINE (token "#")
+	masterKeyOfflineOutput := `sec:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4FABC123DEF4567890:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:18:1234567890ABCDEF:1757030400:1914710400:::::e:::D2760001240103040006123456780000::cv25519::
 `
 
 	t.Run("master key already offline - returns success", func(t *testing.T) {
 		mockExecutor := executor.NewMockExecutor()
-		mockExecutor.SetOutput("gpg --list-secret-keys --keyid-format=long "+shortKeyID, []byte(masterKeyOfflineOutput))
+		mockExecutor.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint "+shortKeyID, []byte(masterKeyOfflineOutput))
 		gpgSvc := gpg.NewService(mockExecutor)
 
 		err := removeMasterKey(ctx, gpgSvc, keyID)
 		assert.NoError(t, err) // Should succeed without doing anything
+		assert.False(t, mockExecutor.VerifyCall("gpg", "--batch", "--yes", "--delete-secret-keys", keyID))
+	})
+
+	t.Run("another key on machine - configured primary offline", func(t *testing.T) {
+		// The listing matched two keys; only the configured primary counts
+		output := `sec:u:255:22:1111222233334444:1757030400:1914710400::u:::scESC:::+::ed25519:::0:
+fpr:::::::::0000000000000000000000001111222233334444:
+sec:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4FABC123DEF4567890:
+`
+		mockExecutor := executor.NewMockExecutor()
+		mockExecutor.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint ABC123DEF4567890", []byte(output))
+		gpgSvc := gpg.NewService(mockExecutor)
+		fingerprint := "FA57C85131F11B28EE236A4FABC123DEF4567890"
+
+		err := removeMasterKey(ctx, gpgSvc, fingerprint)
+		assert.NoError(t, err)
+		assert.False(t, mockExecutor.VerifyCall("gpg", "--batch", "--yes", "--delete-secret-keys", fingerprint))
+		assert.Len(t, mockExecutor.Calls, 1, "only the listing runs")
 	})
 
 	t.Run("error on list secret keys", func(t *testing.T) {
 		mockExecutor := executor.NewMockExecutor()
-		mockExecutor.SetError("gpg --list-secret-keys --keyid-format=long "+shortKeyID, fmt.Errorf("list failed"))
+		mockExecutor.SetError("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint "+shortKeyID, fmt.Errorf("list failed"))
 		gpgSvc := gpg.NewService(mockExecutor)
 
 		err := removeMasterKey(ctx, gpgSvc, keyID)
@@ -171,7 +196,7 @@ ssb>  cv25519/1234567890ABCDEF 2025-09-05 [E] [expires: 2030-09-04]
 	t.Run("error on export public key", func(t *testing.T) {
 		mockExecutor := executor.NewMockExecutor()
 		// ListSecretKeys succeeds - master key is on machine
-		mockExecutor.SetOutput("gpg --list-secret-keys --keyid-format=long "+shortKeyID, []byte(masterKeyOnMachineOutput))
+		mockExecutor.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint "+shortKeyID, []byte(masterKeyOnMachineOutput))
 		// ExportSecretSubkeys can fail (we handle this gracefully)
 		mockExecutor.SetError("gpg --export-secret-subkeys "+shortKeyID, fmt.Errorf("export subkeys failed"))
 		// ExportPublicKey fails
@@ -186,7 +211,7 @@ ssb>  cv25519/1234567890ABCDEF 2025-09-05 [E] [expires: 2030-09-04]
 	t.Run("error on delete secret key", func(t *testing.T) {
 		mockExecutor := executor.NewMockExecutor()
 		// ListSecretKeys succeeds - master key is on machine
-		mockExecutor.SetOutput("gpg --list-secret-keys --keyid-format=long "+shortKeyID, []byte(masterKeyOnMachineOutput))
+		mockExecutor.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint "+shortKeyID, []byte(masterKeyOnMachineOutput))
 		// ExportSecretSubkeys succeeds
 		mockExecutor.SetOutput("gpg --export-secret-subkeys "+shortKeyID, []byte("subkey data"))
 		// ExportPublicKey succeeds
@@ -203,7 +228,7 @@ ssb>  cv25519/1234567890ABCDEF 2025-09-05 [E] [expires: 2030-09-04]
 	t.Run("success - full removal flow", func(t *testing.T) {
 		mockExecutor := executor.NewMockExecutor()
 		// ListSecretKeys succeeds - master key is on machine
-		mockExecutor.SetOutput("gpg --list-secret-keys --keyid-format=long "+shortKeyID, []byte(masterKeyOnMachineOutput))
+		mockExecutor.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint "+shortKeyID, []byte(masterKeyOnMachineOutput))
 		// ExportSecretSubkeys succeeds
 		mockExecutor.SetOutput("gpg --export-secret-subkeys "+shortKeyID, []byte("subkey data"))
 		// ExportPublicKey succeeds
@@ -218,3 +243,164 @@ ssb>  cv25519/1234567890ABCDEF 2025-09-05 [E] [expires: 2030-09-04]
 		assert.NoError(t, err)
 	})
 }
+
+func TestSelectSubkeyStep(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+	cfg = &config.Config{PrimaryKeyID: "07AAA1E535650AF5"}
+	listKey := "gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5"
+
+	t.Run("names the movable subkey", func(t *testing.T) {
+		mockExecutor := executor.NewMockExecutor()
+		mockExecutor.SetOutput(listKey, []byte(`sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::+::ed25519:::0:
+ssb:u:255:18:116DB85718F8B287:1757030400:1914710400:::::e:::D2760001240103040006123456780000::cv25519::
+ssb:u:255:22:0257F6B8152D7F35:1759276800:1916956800:::::s:::+::ed25519::
+`))
+
+		step := selectSubkeyStep(context.Background(), gpg.NewService(mockExecutor))
+		assert.Equal(t, "Type: key 2 (selects signing subkey 0257F6B8152D7F35)", step)
+	})
+
+	t.Run("falls back to generic instructions", func(t *testing.T) {
+		mockExecutor := executor.NewMockExecutor()
+		mockExecutor.SetError(listKey, fmt.Errorf("list failed"))
+
+		step := selectSubkeyStep(context.Background(), gpg.NewService(mockExecutor))
+		assert.Contains(t, step, "key N")
+	})
+}
+
+func TestConfirmMasterKeyFile(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+	cfg = &config.Config{PrimaryKeyFingerprint: "FA57C85131F11B28EE236A4F07AAA1E535650AF5"}
+
+	path := filepath.Join(t.TempDir(), "master.asc")
+	require.NoError(t, os.WriteFile(path, []byte("key"), 0600))
+	showOnlyKey := "gpg --batch --with-colons --import-options show-only --import " + path
+
+	t.Run("matching fingerprint", func(t *testing.T) {
+		mockExecutor := executor.NewMockExecutor()
+		mockExecutor.SetOutput(showOnlyKey, []byte(`sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::cESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+`))
+
+		err := confirmMasterKeyFile(context.Background(), gpg.NewService(mockExecutor), path, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("different key", func(t *testing.T) {
+		mockExecutor := executor.NewMockExecutor()
+		mockExecutor.SetOutput(showOnlyKey, []byte(`sec:u:255:22:1234567890ABCDEF:1757030400:1914710400::u:::cESC:::+::ed25519:::0:
+fpr:::::::::ABCDEF1234567890ABCDEF121234567890ABCDEF:
+`))
+
+		err := confirmMasterKeyFile(context.Background(), gpg.NewService(mockExecutor), path, true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+}
+
+func TestFormatKeySummary(t *testing.T) {
+	key := gpg.Key{Algorithm: "ed25519", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}, Expires: "2030-09-04"}
+	assert.Equal(t, "ed25519/DC47D1B090A51498 [S] expires: 2030-09-04", formatKeySummary(key))
+}
+
+func TestRequireInteractive(t *testing.T) {
+	assert.NoError(t, requireInteractive("setup", ""))
+
+	ui.SetNonInteractive(true)
+	defer ui.SetNonInteractive(false)
+
+	err := requireInteractive("setup", "use 'ykgpg setup-auto' instead")
+	require.Error(t, err)
+	assert.Equal(t, "setup needs an interactive session and can't run with --non-interactive; use 'ykgpg setup-auto' instead", err.Error())
+	assert.EqualError(t, requireInteractive("revoke", ""), "revoke needs an interactive session and can't run with --non-interactive")
+}
+
+func TestRequireGPGVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"gpg (GnuPG) 2.4.5", false},
+		{"gpg (GnuPG) 2.1.0", false},
+		{"gpg (GnuPG) 2.0.30", true},
+		{"gpg (GnuPG) 1.4.23", true},
+	}
+
+	for _, tt := range tests {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("gpg --version", []byte(tt.version+"\n"))
+
+		err := requireGPGVersion(context.Background(), gpg.NewService(mockExec), minQuickAddKeyVersion, "creating the subkey")
+		if tt.wantErr {
+			require.Error(t, err, tt.version)
+			assert.Contains(t, err.Error(), "creating the subkey needs GnuPG 2.1.0 or later")
+		} else {
+			assert.NoError(t, err, tt.version)
+		}
+	}
+
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetError("gpg --version", fmt.Errorf("exit status 2"))
+	assert.Error(t, requireGPGVersion(context.Background(), gpg.NewService(mockExec), minQuickAddKeyVersion, "creating the subkey"))
+}
+
+func TestFormatKeyAttributes(t *testing.T) {
+	attrs := map[string]string{"Authentication": "ed25519", "Signature": "ed25519", "Encryption": "cv25519"}
+	assert.Equal(t, "Signature: ed25519, Encryption: cv25519, Authentication: ed25519", formatKeyAttributes(attrs))
+	assert.Empty(t, formatKeyAttributes(nil))
+}
+
+func TestReportPublishResults(t *testing.T) {
+	servers := []string{"hkps://keys.openpgp.org", "hkps://keyserver.ubuntu.com", "hkp://keys.example.com"}
+
+	assert.Equal(t, 0, reportPublishResults(servers, make([]error, 3)))
+	assert.Equal(t, 1, reportPublishResults(servers, []error{nil, fmt.Errorf("keyserver send failed"), nil}))
+}
+
+func TestMatchCardChoice(t *testing.T) {
+	serials := []string{"12345678", "23456789"}
+
+	serial, err := matchCardChoice(serials, "23456789")
+	require.NoError(t, err)
+	assert.Equal(t, "23456789", serial)
+
+	serial, err = matchCardChoice(serials, " 1 ")
+	require.NoError(t, err)
+	assert.Equal(t, "12345678", serial)
+
+	_, err = matchCardChoice(serials, "3")
+	assert.Error(t, err)
+	_, err = matchCardChoice(serials, "")
+	assert.Error(t, err)
+}
+
+func TestSelectCard_SingleCard(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("ykman list --serials", []byte("12345678\n"))
+	yubikeySvc := yubikey.NewService(gpg.NewService(mockExec), mockExec)
+
+	require.NoError(t, selectCard(context.Background(), yubikeySvc))
+	assert.Empty(t, yubikeySvc.Device)
+	assert.Len(t, mockExec.Calls, 1)
+}
+
+func TestUseCard(t *testing.T) {
+	ctx := context.Background()
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("ykman list --serials", []byte("12345678\n23456789\n"))
+	mockExec.SetOutput("gpg-connect-agent scd getinfo card_list /bye", []byte(
+		"S SERIALNO D2760001240103040006123456780000\nS SERIALNO D2760001240103040006234567890000\nOK\n"))
+	mockExec.SetOutput("gpg-connect-agent scd switchcard D2760001240103040006234567890000 /bye", []byte("OK\n"))
+	yubikeySvc := yubikey.NewService(gpg.NewService(mockExec), mockExec)
+
+	require.NoError(t, useCard(ctx, yubikeySvc, "23456789"))
+	assert.Equal(t, "23456789", yubikeySvc.Device)
+
+	// The previous YubiKey was swapped for the next one
+	mockExec.SetOutput("ykman list --serials", []byte("34567890\n"))
+	require.NoError(t, useCard(ctx, yubikeySvc, "34567890"))
+	assert.Empty(t, yubikeySvc.Device, "a YubiKey on its own needs no --device")
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newImportMasterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-master [path]",
+		Short: "Import the master key backup into the keyring",
+		Long: `Import the master secret key backup into the local keyring.
+
+The file is inspected before importing: its primary key, user ID and
+subkeys are shown, and its fingerprint must match the configured primary
+key (primary_key_fingerprint). You are asked to confirm unless --yes is
+given. If no path is given, master_key_path from the config is used.
+
+Remember to remove the master key again with 'ykgpg cleanup' when done.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runImportMaster,
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+
+	return cmd
+}
+
+func runImportMaster(cmd *cobra.Command, args []string) error {
+	gpgSvc, _, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Import Master Key")
+
+	masterKeyPath := cfg.MasterKeyPath
+	if len(args) > 0 {
+		masterKeyPath = args[0]
+	}
+	if masterKeyPath == "" {
+		return fmt.Errorf("specify the master key path or set master_key_path in the config")
+	}
+
+	assumeYes, _ := cmd.Flags().GetBool("yes")
+	if err := confirmMasterKeyFile(ctx, gpgSvc, masterKeyPath, assumeYes); err != nil {
+		return err
+	}
+
+	if _, err := importMasterKey(ctx, gpgSvc, masterKeyPath); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	ui.LogWarning("The master key is now on this machine.")
+	ui.LogWarning("Run 'ykgpg cleanup' to remove it when you are done.")
+
+	return nil
+}
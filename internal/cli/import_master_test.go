@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImportMasterCmd(t *testing.T) {
+	cmd := newImportMasterCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "import-master [path]", cmd.Use)
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+}
+
+func TestFormatImportResult(t *testing.T) {
+	result := &gpg.ImportResult{Processed: 1, SecretImported: 1, NewSubkeys: 2}
+	assert.Equal(t, "1 key(s) processed, 1 secret key(s) imported, 0 unchanged, 2 new subkey(s)", formatImportResult(result))
+}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
@@ -18,9 +21,16 @@ func newInitCmd() *cobra.Command {
 3. Setting key attributes (RSA vs ECC/ed25519)
 4. Optionally setting cardholder name
 
+With --ecc, all three key slots are set to Curve 25519 (ed25519 for
+signing and authentication, cv25519 for encryption) without a card-edit
+session. The Admin PIN is prompted for once, or read from --admin-pin-file
+or $YKGPG_ADMIN_PIN. If that fails, the manual steps are shown instead.
+
 Run this command on a new or factory-reset YubiKey before using it for GPG keys.`,
 		RunE: runInit,
 	}
+	cmd.Flags().Bool("ecc", false, "Set all key slots to Curve 25519 (ed25519/cv25519) automatically")
+	cmd.Flags().String("admin-pin-file", "", "File containing the YubiKey Admin PIN (default: $"+adminPINEnv+")")
 	// Skip PersistentPreRunE validation for init command
 	// This command should work even without a valid config file
 	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
@@ -35,6 +45,10 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	ui.PrintHeader("Initialize YubiKey for OpenPGP")
 
+	if err := selectCard(ctx, yubikeySvc); err != nil {
+		return err
+	}
+
 	// Check YubiKey presence
 	present, err := yubikeySvc.IsPresent(ctx)
 	if err != nil {
@@ -58,9 +72,15 @@ func runInit(cmd *cobra.Command, args []string) error {
 	ui.PrintSection("CURRENT CARD STATUS")
 	fmt.Printf("  Serial:      %s\n", cardInfo.Serial)
 	fmt.Printf("  Cardholder:  %s\n", valueOrDefault(cardInfo.Cardholder, "[not set]"))
+	if cardInfo.Model != "" {
+		fmt.Printf("  Model:       %s\n", cardInfo.Model)
+	}
+	if cardInfo.FirmwareVersion != "" {
+		fmt.Printf("  Firmware:    %s\n", cardInfo.FirmwareVersion)
+	}
 
 	if len(cardInfo.KeyAttributes) > 0 {
-		fmt.Printf("  Key types:   %v\n", cardInfo.KeyAttributes)
+		fmt.Printf("  Key types:   %s\n", formatKeyAttributes(cardInfo.KeyAttributes))
 	}
 
 	// Show existing keys
@@ -151,39 +171,37 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	if len(cardInfo.KeyAttributes) > 0 {
-		fmt.Printf("  Current configuration: %v\n", cardInfo.KeyAttributes)
+		fmt.Printf("  Current configuration: %s\n", formatKeyAttributes(cardInfo.KeyAttributes))
 		fmt.Println()
 	}
-
-	if ui.Confirm("Change key algorithm to ed25519/cv25519? (Recommended for new keys)") {
-		fmt.Println()
-		ui.LogInfo("Launching GPG card editor to change key attributes...")
-		fmt.Println()
-		fmt.Println("Steps to configure for ed25519:")
-		fmt.Println("  1. Type: admin")
-		fmt.Println("  2. Type: key-attr")
-		fmt.Println("  3. For Signature key:")
-		fmt.Println("     - Select (2) ECC")
-		fmt.Println("     - Select (1) Curve 25519")
-		fmt.Println("  4. For Encryption key:")
-		fmt.Println("     - Select (2) ECC")
-		fmt.Println("     - Select (1) Curve 25519")
-		fmt.Println("  5. For Authentication key:")
-		fmt.Println("     - Select (2) ECC")
-		fmt.Println("     - Select (1) Curve 25519")
-		fmt.Println("  6. Enter Admin PIN when prompted")
-		fmt.Println("  7. Type: quit")
-		fmt.Println()
-		ui.LogWarning("Note: You'll be prompted for Admin PIN (default: 12345678)")
+	noECC := warnIfNoEd25519(cardInfo)
+	if noECC {
 		fmt.Println()
+	}
 
-		_, err = ui.Prompt("Press Enter to continue: ")
-		if err != nil {
+	ecc, _ := cmd.Flags().GetBool("ecc")
+	switch {
+	case ecc && noECC:
+		ui.LogWarning("Not setting the key attributes to Curve 25519; configure RSA 4096 instead")
+		if err := keyAttrGuide(ctx, yubikeySvc, true); err != nil {
 			return err
 		}
-
-		if err := yubikeySvc.EditCard(ctx); err != nil {
-			ui.LogWarning("Card edit session ended: %v", err)
+	case ecc:
+		if err := configureECC(ctx, cmd, yubikeySvc, cardInfo); err != nil {
+			ui.LogWarning("Could not set the key attributes automatically: %v", err)
+			if err := keyAttrGuide(ctx, yubikeySvc, false); err != nil {
+				return err
+			}
+		}
+	case noECC:
+		if ui.Confirm("Change key algorithm to RSA 4096?") {
+			if err := keyAttrGuide(ctx, yubikeySvc, true); err != nil {
+				return err
+			}
+		}
+	case ui.Confirm("Change key algorithm to ed25519/cv25519? (Recommended for new keys)"):
+		if err := keyAttrGuide(ctx, yubikeySvc, false); err != nil {
+			return err
 		}
 	}
 
@@ -191,25 +209,31 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	if ui.Confirm("Set cardholder name on the card? (Helps identify which key is which)") {
 		fmt.Println()
-		ui.LogInfo("Launching GPG card editor to set cardholder info...")
-		fmt.Println()
-		fmt.Println("Steps to set cardholder name:")
-		fmt.Println("  1. Type: admin")
-		fmt.Println("  2. Type: name")
-		fmt.Println("     - Enter surname (last name)")
-		fmt.Println("     - Enter given name (first name)")
-		fmt.Println("  3. Type: lang")
-		fmt.Println("     - Enter 'en' for English")
-		fmt.Println("  4. Type: quit")
-		fmt.Println()
+		if err := promptCardholderName(ctx, yubikeySvc); err == nil {
+			ui.LogSuccess("Cardholder name set")
+		} else {
+			ui.LogWarning("Could not set cardholder name directly: %v", err)
+			fmt.Println()
+			ui.LogInfo("Launching GPG card editor to set cardholder info...")
+			fmt.Println()
+			fmt.Println("Steps to set cardholder name:")
+			fmt.Println("  1. Type: admin")
+			fmt.Println("  2. Type: name")
+			fmt.Println("     - Enter surname (last name)")
+			fmt.Println("     - Enter given name (first name)")
+			fmt.Println("  3. Type: lang")
+			fmt.Println("     - Enter 'en' for English")
+			fmt.Println("  4. Type: quit")
+			fmt.Println()
 
-		_, err = ui.Prompt("Press Enter to continue: ")
-		if err != nil {
-			return err
-		}
+			_, err = ui.Prompt("Press Enter to continue: ")
+			if err != nil {
+				return err
+			}
 
-		if err := yubikeySvc.EditCard(ctx); err != nil {
-			ui.LogWarning("Card edit session ended: %v", err)
+			if err := yubikeySvc.EditCard(ctx); err != nil {
+				ui.LogWarning("Card edit session ended: %v", err)
+			}
 		}
 	}
 
@@ -223,7 +247,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Serial:      %s\n", cardInfoFinal.Serial)
 		fmt.Printf("  Cardholder:  %s\n", valueOrDefault(cardInfoFinal.Cardholder, "[not set]"))
 		if len(cardInfoFinal.KeyAttributes) > 0 {
-			fmt.Printf("  Key types:   %v\n", cardInfoFinal.KeyAttributes)
+			fmt.Printf("  Key types:   %s\n", formatKeyAttributes(cardInfoFinal.KeyAttributes))
 		}
 	}
 
@@ -234,6 +258,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Println("  1. Run 'ykgpg setup' to create a new signing subkey and move it to this YubiKey")
 	fmt.Println("  2. Or run 'ykgpg move-subkey' if you already have a subkey to move")
 	fmt.Println("  3. Label this YubiKey physically with its serial number: " + cardInfo.Serial)
+	fmt.Println("     and record the label: ykgpg label " + cardInfo.Serial + " \"Key B\"")
 	fmt.Println()
 
 	return nil
@@ -247,3 +272,92 @@ func valueOrDefault(value, defaultValue string) string {
 	return value
 }
 
+// promptCardholderName asks for the cardholder name and Admin PIN and sets the
+// name on the card without an interactive card-edit session.
+func promptCardholderName(ctx context.Context, yubikeySvc yubikey.YubiKeyService) error {
+	surname, err := ui.PromptRequired("Cardholder surname (last name): ")
+	if err != nil {
+		return err
+	}
+	given, err := ui.Prompt("Cardholder given name (first name): ")
+	if err != nil {
+		return err
+	}
+	adminPIN, err := ui.PromptSecretRequired("YubiKey Admin PIN (default: 12345678): ")
+	if err != nil {
+		return err
+	}
+	return yubikeySvc.SetCardholderName(ctx, given, surname, adminPIN)
+}
+
+// eccKeyAttributes are the key types init --ecc sets for each slot.
+var eccKeyAttributes = map[string]string{
+	"Signature":      "ed25519",
+	"Encryption":     "cv25519",
+	"Authentication": "ed25519",
+}
+
+// configureECC sets each slot not already configured for Curve 25519 to
+// its eccKeyAttributes type, asking for the Admin PIN only if needed.
+func configureECC(ctx context.Context, cmd *cobra.Command, yubikeySvc yubikey.YubiKeyService, cardInfo *gpg.CardInfo) error {
+	var adminPIN string
+	for n := 1; n <= len(gpg.CardSlots); n++ {
+		slot := gpg.CardSlots[n]
+		algo := eccKeyAttributes[slot]
+		if cardInfo.KeyAttributes[slot] == algo {
+			continue
+		}
+
+		if adminPIN == "" {
+			var err error
+			adminPIN, err = secretOrPrompt(cmd, "admin-pin-file", adminPINEnv, "YubiKey Admin PIN (default: 12345678): ", true)
+			if err != nil {
+				return err
+			}
+		}
+		ui.LogInfo("Setting the %s slot to %s...", slot, algo)
+		if err := yubikeySvc.SetKeyAttributes(ctx, slot, algo, adminPIN); err != nil {
+			return err
+		}
+	}
+
+	ui.LogSuccess("All key slots are configured for Curve 25519")
+	return nil
+}
+
+// keyAttrGuide prints the key-attr steps for Curve 25519, or for RSA 4096
+// if rsa is set (firmware without ECC support), and opens an interactive
+// gpg --card-edit session.
+func keyAttrGuide(ctx context.Context, yubikeySvc yubikey.YubiKeyService, rsa bool) error {
+	kind, choice, target := "Select (2) ECC", "Select (1) Curve 25519", "ed25519"
+	if rsa {
+		kind, choice, target = "Select (1) RSA", "Enter 4096 as the key size", "RSA 4096"
+	}
+
+	fmt.Println()
+	ui.LogInfo("Launching GPG card editor to change key attributes...")
+	fmt.Println()
+	fmt.Printf("Steps to configure for %s:\n", target)
+	fmt.Println("  1. Type: admin")
+	fmt.Println("  2. Type: key-attr")
+	for i, slot := range []string{"Signature", "Encryption", "Authentication"} {
+		fmt.Printf("  %d. For %s key:\n", i+3, slot)
+		fmt.Printf("     - %s\n", kind)
+		fmt.Printf("     - %s\n", choice)
+	}
+	fmt.Println("  6. Enter Admin PIN when prompted")
+	fmt.Println("  7. Type: quit")
+	fmt.Println()
+	ui.LogWarning("Note: You'll be prompted for Admin PIN (default: 12345678)")
+	fmt.Println()
+
+	_, err := ui.Prompt("Press Enter to continue: ")
+	if err != nil {
+		return err
+	}
+
+	if err := yubikeySvc.EditCard(ctx); err != nil {
+		ui.LogWarning("Card edit session ended: %v", err)
+	}
+	return nil
+}
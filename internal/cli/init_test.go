@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInitCmd(t *testing.T) {
+	cmd := newInitCmd()
+	assert.Equal(t, "init", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("ecc"))
+	assert.NotNil(t, cmd.Flags().Lookup("admin-pin-file"))
+}
+
+func TestConfigureECC_AlreadyConfigured(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	yubikeySvc := yubikey.NewService(gpg.NewService(mockExec), mockExec)
+	cardInfo := &gpg.CardInfo{KeyAttributes: map[string]string{
+		"Signature": "ed25519", "Encryption": "cv25519", "Authentication": "ed25519",
+	}}
+
+	require.NoError(t, configureECC(context.Background(), newInitCmd(), yubikeySvc, cardInfo))
+	assert.Empty(t, mockExec.Calls, "no PIN prompt or card edit when every slot is already Curve 25519")
+}
+
+func TestConfigureECC_NotApplied(t *testing.T) {
+	t.Setenv(adminPINEnv, "12345678")
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --card-status", []byte("Card firmware ....: 5.4.3\nKey attributes ...: rsa2048 rsa2048 rsa2048\n"))
+	yubikeySvc := yubikey.NewService(gpg.NewService(mockExec), mockExec)
+	cardInfo := &gpg.CardInfo{KeyAttributes: map[string]string{
+		"Signature": "rsa2048", "Encryption": "rsa2048", "Authentication": "rsa2048",
+	}}
+
+	// The card still reports RSA afterwards, so the caller falls back to the guide
+	err := configureECC(context.Background(), newInitCmd(), yubikeySvc, cardInfo)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Signature slot key attributes were not updated")
+}
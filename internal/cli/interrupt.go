@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+)
+
+// interruptGracePeriod is how long a command has to wind down after Ctrl+C
+// before ykgpg exits anyway, e.g. when it is blocked reading a prompt. It is
+// a variable so tests can shorten it.
+var interruptGracePeriod = 5 * time.Second
+
+// exitProcess is os.Exit. It is a variable so tests can replace it.
+var exitProcess = os.Exit
+
+// runInterruptible calls run with a context that is cancelled on SIGINT or
+// SIGTERM. Cancelling it kills the external commands in flight, so the
+// command returns with their "was cancelled" error, mapped to
+// ExitInterrupted. A command that returns nil handled the signal itself,
+// e.g. `status --watch` stopping on Ctrl+C, and succeeds. If run hasn't
+// returned within interruptGracePeriod, or a second signal arrives, the
+// terminal is restored and the process exits.
+func runInterruptible(parent context.Context, run func(context.Context) error) error {
+	ui.SaveTerminalState()
+
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		if parent.Err() != nil {
+			return
+		}
+
+		// Listen again, so a second signal exits straight away
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		timer := time.NewTimer(interruptGracePeriod)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return
+		case <-signals:
+		case <-timer.C:
+		}
+		ui.RestoreTerminal()
+		fmt.Fprintln(os.Stderr, "\nInterrupted")
+		exitProcess(ExitInterrupted)
+	}()
+
+	err := run(ctx)
+	if err == nil || ctx.Err() == nil || parent.Err() != nil {
+		return err
+	}
+
+	ui.RestoreTerminal()
+	return &ExitCodeError{Code: ExitInterrupted, Err: err}
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+
+package cli
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInterruptible(t *testing.T) {
+	t.Run("returns the command's result", func(t *testing.T) {
+		assert.NoError(t, runInterruptible(context.Background(), func(ctx context.Context) error {
+			return nil
+		}))
+	})
+
+	t.Run("signal cancels the context", func(t *testing.T) {
+		err := runInterruptible(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		})
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, ExitInterrupted, ExitCode(err))
+	})
+
+	t.Run("command that stops on the signal succeeds", func(t *testing.T) {
+		assert.NoError(t, runInterruptible(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+			<-ctx.Done()
+			return nil
+		}))
+	})
+
+	t.Run("exits if the command doesn't stop", func(t *testing.T) {
+		oldGrace, oldExit := interruptGracePeriod, exitProcess
+		defer func() { interruptGracePeriod, exitProcess = oldGrace, oldExit }()
+		interruptGracePeriod = 50 * time.Millisecond
+		exited := make(chan int, 1)
+		exitProcess = func(code int) { exited <- code }
+
+		_ = runInterruptible(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+			select {
+			case code := <-exited:
+				assert.Equal(t, ExitInterrupted, code)
+			case <-time.After(5 * time.Second):
+				t.Error("process did not exit after the grace period")
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/journal"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newJournalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "journal",
+		Short: "Show what was done to each key and YubiKey",
+		Long: `Show the journal of operations that changed keys or YubiKeys: setup,
+setup-batch, setup-encryption, setup-auth, setup-auto, move-subkey, revoke
+and extend each add an entry with the time, the YubiKey serial, the keys
+affected and whether the operation succeeded.
+
+The journal is a JSON lines file at journal_file, by default
+~/.local/share/ykgpg/journal.jsonl. PINs and passphrases are never recorded.`,
+		Args: cobra.NoArgs,
+		RunE: runJournal,
+	}
+	// Skip PersistentPreRunE validation for journal command
+	// Reading the journal doesn't require a configured primary key
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	cmd.Flags().String("serial", "", "Only show entries for the YubiKey with this serial")
+
+	return cmd
+}
+
+func runJournal(cmd *cobra.Command, args []string) error {
+	path := journalFile()
+	entries, err := journal.Read(path)
+	if err != nil {
+		return err
+	}
+	if serial, _ := cmd.Flags().GetString("serial"); serial != "" {
+		entries = journalEntriesFor(entries, serial)
+	}
+
+	ui.PrintHeader("Journal")
+	if len(entries) == 0 {
+		ui.LogInfo("No entries in %s", path)
+		return nil
+	}
+	for _, entry := range entries {
+		fmt.Println(formatJournalEntry(entry))
+	}
+	return nil
+}
+
+// journalFile returns the configured journal, or the default one.
+func journalFile() string {
+	if path := toolConfig().JournalFile; path != "" {
+		return path
+	}
+	return config.DefaultJournalFile()
+}
+
+// journalEntriesFor returns the entries for the YubiKey with the given
+// serial, compared in normalized form.
+func journalEntriesFor(entries []journal.Entry, serial string) []journal.Entry {
+	serial = gpg.NormalizeCardSerial(serial)
+	var matching []journal.Entry
+	for _, entry := range entries {
+		if entry.Serial != "" && gpg.NormalizeCardSerial(entry.Serial) == serial {
+			matching = append(matching, entry)
+		}
+	}
+	return matching
+}
+
+// formatJournalEntry returns entry as one line, e.g.
+// "  ✓ 2026-10-16 09:30  setup-batch  YubiKey 12345678  keys DC47D1B090A51498".
+func formatJournalEntry(entry journal.Entry) string {
+	mark := "✓"
+	if !entry.Success {
+		mark = "✗"
+	}
+	parts := []string{"  " + mark + " " + entry.Time.Local().Format("2006-01-02 15:04"), entry.Command}
+	if entry.Serial != "" {
+		parts = append(parts, "YubiKey "+serialWithLabel(entry.Serial))
+	}
+	if len(entry.KeyIDs) > 0 {
+		parts = append(parts, "keys "+strings.Join(entry.KeyIDs, ", "))
+	}
+	if !entry.Success {
+		parts = append(parts, "failed: "+entry.Error)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// journalOp collects what a command does to keys and YubiKeys, and records
+// it in the journal when the command returns.
+type journalOp struct {
+	command string
+	serial  string
+	keyIDs  []string
+	started bool
+}
+
+// newJournalOp returns an operation of the given command that isn't
+// recorded until it is started.
+func newJournalOp(command string) *journalOp {
+	return &journalOp{command: command}
+}
+
+// start marks the point from which the command changes keys or the card.
+// A command that returns before it, e.g. because the user declined a
+// prompt, leaves no entry.
+func (op *journalOp) start() {
+	op.started = true
+}
+
+// addKeys records keys affected by the operation, skipping duplicates.
+func (op *journalOp) addKeys(keyIDs ...string) {
+	for _, keyID := range keyIDs {
+		if keyID != "" && !contains(op.keyIDs, keyID) {
+			op.keyIDs = append(op.keyIDs, keyID)
+		}
+	}
+}
+
+// finish appends the operation to the journal, as failed with err if err
+// isn't nil. Only identifiers and the error message are written; a journal
+// that can't be written is reported as a warning. Nothing is recorded in
+// --dry-run mode, where nothing was changed.
+func (op *journalOp) finish(err error) {
+	if !op.started || dryRun {
+		return
+	}
+
+	entry := journal.Entry{
+		Time:    time.Now().UTC(),
+		Command: op.command,
+		Serial:  gpg.NormalizeCardSerial(op.serial),
+		KeyIDs:  op.keyIDs,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := journal.Append(journalFile(), entry); err != nil {
+		ui.LogWarning("Failed to record the operation in the journal: %v", err)
+	}
+}
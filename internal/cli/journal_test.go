@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJournalCmd(t *testing.T) {
+	cmd := newJournalCmd()
+	assert.Equal(t, "journal", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("serial"))
+	assert.NoError(t, cmd.PersistentPreRunE(cmd, nil), "journal must run without a valid config")
+}
+
+func TestJournalOp(t *testing.T) {
+	oldCfg, oldDryRun := cfg, dryRun
+	defer func() { cfg, dryRun = oldCfg, oldDryRun }()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	cfg = &config.Config{JournalFile: path}
+
+	declined := newJournalOp("setup")
+	declined.serial = "12345678"
+	declined.finish(nil)
+
+	dryRun = true
+	dry := newJournalOp("extend")
+	dry.start()
+	dry.finish(nil)
+	dryRun = false
+
+	entries, err := journal.Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is recorded before start or in dry-run mode")
+
+	op := newJournalOp("move-subkey")
+	op.serial = "0006 12345678"
+	op.start()
+	op.addKeys("DC47D1B090A51498", "", "DC47D1B090A51498")
+	op.finish(fmt.Errorf("failed to edit key: exit status 2"))
+
+	entries, err = journal.Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "move-subkey", entries[0].Command)
+	assert.Equal(t, "12345678", entries[0].Serial)
+	assert.Equal(t, []string{"DC47D1B090A51498"}, entries[0].KeyIDs)
+	assert.False(t, entries[0].Success)
+	assert.Equal(t, "failed to edit key: exit status 2", entries[0].Error)
+	assert.WithinDuration(t, time.Now(), entries[0].Time, time.Minute)
+}
+
+func TestJournalEntriesFor(t *testing.T) {
+	entries := []journal.Entry{
+		{Command: "setup", Serial: "12345678"},
+		{Command: "extend"},
+		{Command: "revoke", Serial: "87654321"},
+	}
+
+	matching := journalEntriesFor(entries, "0006 12345678")
+	require.Len(t, matching, 1)
+	assert.Equal(t, "setup", matching[0].Command)
+	assert.Empty(t, journalEntriesFor(entries, "11111111"))
+}
+
+func TestFormatJournalEntry(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+	cfg = &config.Config{}
+
+	when := time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local)
+	line := formatJournalEntry(journal.Entry{
+		Time:    when,
+		Command: "setup-batch",
+		Serial:  "12345678",
+		KeyIDs:  []string{"DC47D1B090A51498"},
+		Success: true,
+	})
+	assert.Equal(t, "  ✓ 2026-10-16 09:30  setup-batch  YubiKey 12345678  keys DC47D1B090A51498", line)
+
+	line = formatJournalEntry(journal.Entry{Time: when, Command: "extend", Error: "expiration not extended for 1 key(s)"})
+	assert.Equal(t, "  ✗ 2026-10-16 09:30  extend  failed: expiration not extended for 1 key(s)", line)
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newLabelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label <serial> <label>",
+		Short: "Record the physical label of a YubiKey",
+		Long: `Record the label you put on a YubiKey, and optionally where it is kept,
+in the yubikeys: section of the config file. status, verify and
+list-subkeys then show the label next to the serial.
+
+  ykgpg label 12345678 "Key B" --location "work laptop"
+
+Running it again for the same serial replaces the entry.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeLabelArgs,
+		RunE:              runLabel,
+	}
+	// Skip PersistentPreRunE validation for label command
+	// Labelling a YubiKey doesn't require a configured primary key
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	cmd.Flags().String("location", "", "Where the YubiKey is kept, e.g. \"work laptop\"")
+	cmd.Flags().String("notes", "", "Free-form notes about the YubiKey")
+
+	return cmd
+}
+
+func runLabel(cmd *cobra.Command, args []string) error {
+	serial := gpg.NormalizeCardSerial(args[0])
+	location, _ := cmd.Flags().GetString("location")
+	notes, _ := cmd.Flags().GetString("notes")
+	yubikey := config.YubiKey{Label: args[1], Location: location, Notes: notes}
+
+	path := configFilePath()
+	if err := config.SetYubiKey(path, serial, yubikey); err != nil {
+		return err
+	}
+
+	ui.LogSuccess("YubiKey %s labelled %q in %s", serial, yubikey.String(), path)
+	return nil
+}
+
+// configFilePath returns the config file in use, or the default location
+// if there is none yet.
+func configFilePath() string {
+	_, _ = config.Load()
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path
+	}
+	return config.DefaultPath()
+}
+
+// yubikeyLabel returns the recorded label of the YubiKey with the given
+// serial, e.g. "Key B (work laptop)", or "" if it has none.
+func yubikeyLabel(serial string) string {
+	yubikey, ok := toolConfig().YubiKeyFor(serial)
+	if !ok {
+		return ""
+	}
+	return yubikey.String()
+}
+
+// serialWithLabel returns the serial followed by its recorded label, if any.
+func serialWithLabel(serial string) string {
+	if label := yubikeyLabel(serial); label != "" {
+		return fmt.Sprintf("%s — %s", serial, label)
+	}
+	return serial
+}
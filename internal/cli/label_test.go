@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLabelCmd(t *testing.T) {
+	cmd := newLabelCmd()
+	assert.Equal(t, "label <serial> <label>", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("location"))
+	assert.NotNil(t, cmd.Flags().Lookup("notes"))
+	assert.Error(t, cmd.Args(cmd, []string{"12345678"}))
+}
+
+func TestRunLabel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(home)
+	viper.Reset()
+	defer viper.Reset()
+
+	cmd := newLabelCmd()
+	require.NoError(t, cmd.Flags().Set("location", "work laptop"))
+	require.NoError(t, runLabel(cmd, []string{"0006 12345678", "Key B"}))
+
+	data, err := os.ReadFile(filepath.Join(home, ".config", "ykgpg", "config.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"12345678":`)
+	assert.Contains(t, string(data), "label: Key B")
+	assert.Contains(t, string(data), "location: work laptop")
+}
+
+func TestSerialWithLabel(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+
+	cfg = &config.Config{YubiKeys: map[string]config.YubiKey{
+		"12345678": {Label: "Key B", Location: "work laptop"},
+	}}
+
+	assert.Equal(t, "12345678 — Key B (work laptop)", serialWithLabel("12345678"))
+	assert.Equal(t, "Key B (work laptop)", yubikeyLabel("0006 12345678"))
+	assert.Equal(t, "23456789", serialWithLabel("23456789"))
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/spf13/cobra"
+)
+
+func newListSubkeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list-subkeys",
+		Short: "List signing subkeys and the YubiKey each one is on",
+		Long: `List every signing subkey of the primary key with its key ID, creation
+and expiry dates, and the serial of the YubiKey it is stored on. Subkeys
+without a serial are stored locally or offline.
+
+Labels recorded with 'ykgpg label' are shown next to each serial. Use
+--label to add or override labels for a single run:
+
+  ykgpg list-subkeys --label 12345678="Key A" --label 23456789="Key B"`,
+		RunE: runListSubkeys,
+	}
+
+	cmd.Flags().StringToString("label", nil, "Label for a YubiKey serial, as serial=label (repeatable)")
+
+	return cmd
+}
+
+func runListSubkeys(cmd *cobra.Command, args []string) error {
+	gpgSvc, _, _ := getServices()
+	ctx := cmd.Context()
+
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	subkeys := signingSubkeys(keys)
+	if len(subkeys) == 0 {
+		fmt.Println("No signing subkeys found.")
+		return nil
+	}
+
+	labels := make(map[string]string)
+	for serial, yubikey := range cfg.YubiKeys {
+		labels[gpg.NormalizeCardSerial(serial)] = yubikey.String()
+	}
+	flagLabels, _ := cmd.Flags().GetStringToString("label")
+	for serial, label := range flagLabels {
+		labels[gpg.NormalizeCardSerial(serial)] = label
+	}
+
+	fmt.Print(formatSubkeyTable(subkeys, labels))
+	return nil
+}
+
+// signingSubkeys returns the subkeys with signing capability.
+func signingSubkeys(keys []gpg.Key) []gpg.Key {
+	var subkeys []gpg.Key
+	for _, key := range keys {
+		if key.Type == "ssb" && contains(key.Capabilities, "S") {
+			subkeys = append(subkeys, key)
+		}
+	}
+	return subkeys
+}
+
+// formatSubkeyTable renders subkeys as a table. labels maps normalized card
+// serials to labels; the LABEL column is only shown when labels is not empty.
+func formatSubkeyTable(subkeys []gpg.Key, labels map[string]string) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	header := "KEY ID\tCREATED\tEXPIRES\tCARD SERIAL"
+	if len(labels) > 0 {
+		header += "\tLABEL"
+	}
+	fmt.Fprintln(w, header)
+
+	for _, key := range subkeys {
+		expires := key.Expires
+		switch {
+		case key.Revoked:
+			expires = "revoked"
+		case expires == "":
+			expires = "never"
+		}
+		serial := key.CardSerial
+		if serial == "" {
+			serial = "-"
+		}
+		row := fmt.Sprintf("%s\t%s\t%s\t%s", key.KeyID, key.Created, expires, serial)
+		if len(labels) > 0 {
+			row += "\t" + labels[key.CardSerial]
+		}
+		fmt.Fprintln(w, row)
+	}
+
+	_ = w.Flush()
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewListSubkeysCmd(t *testing.T) {
+	cmd := newListSubkeysCmd()
+	assert.Equal(t, "list-subkeys", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("label"))
+}
+
+func TestSigningSubkeys(t *testing.T) {
+	keys := []gpg.Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}},
+		{Type: "ssb", KeyID: "116DB85718F8B287", Capabilities: []string{"E"}},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Capabilities: []string{"S"}},
+	}
+
+	subkeys := signingSubkeys(keys)
+
+	if assert.Len(t, subkeys, 2) {
+		assert.Equal(t, "DC47D1B090A51498", subkeys[0].KeyID)
+		assert.Equal(t, "0257F6B8152D7F35", subkeys[1].KeyID)
+	}
+}
+
+func TestFormatSubkeyTable(t *testing.T) {
+	subkeys := []gpg.Key{
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Created: "2025-09-05", Expires: "2030-09-04", CardSerial: "12345678"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Created: "2025-10-01"},
+	}
+
+	table := formatSubkeyTable(subkeys, nil)
+	assert.Equal(t, `KEY ID            CREATED     EXPIRES     CARD SERIAL
+DC47D1B090A51498  2025-09-05  2030-09-04  12345678
+0257F6B8152D7F35  2025-10-01  never       -
+`, table)
+
+	table = formatSubkeyTable(subkeys, map[string]string{"12345678": "Key A"})
+	assert.Contains(t, table, "LABEL")
+	assert.Contains(t, table, "12345678     Key A")
+
+	revoked := []gpg.Key{{Type: "ssb", KeyID: "DC47D1B090A51498", Created: "2025-09-05", CardSerial: "12345678", Revoked: true}}
+	assert.Contains(t, formatSubkeyTable(revoked, nil), "2025-09-05  revoked  12345678")
+}
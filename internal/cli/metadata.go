@@ -1,22 +1,62 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
 func newMetadataCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:     "set-metadata",
 		Aliases: []string{"metadata"},
-		Short:   "Set cardholder name and URL on YubiKey",
-		RunE:    runMetadata,
+		Short:   "Set cardholder name, URL and login data on YubiKey",
+		Long: `Set the cardholder name, public key URL and login data on a YubiKey.
+
+The card URL defaults to the keys.openpgp.org URL for the configured
+fingerprint and is applied directly; --name "Surname/Given" sets the
+cardholder name and --login the login data (e.g. a user name) the same
+way. You are asked for the Admin PIN once (or it is read from
+--admin-pin-file or $YKGPG_ADMIN_PIN), and each change is verified by
+re-reading the card status.
+
+Use --interactive for a guided gpg --card-edit session instead.
+
+With --all, the same metadata is set on every YubiKey recorded with
+'ykgpg label' and every one connected, one after another: you are asked to
+insert each YubiKey that isn't connected, and the Admin PIN is asked for
+once and used for all of them. The serials done are saved, so running the
+same command again after an interruption skips them.`,
+		RunE: runMetadata,
 	}
+
+	cmd.Flags().String("name", "", `Cardholder name as "Surname/Given"`)
+	cmd.Flags().String("url", "", "Public key URL (default: keys.openpgp.org URL for the configured fingerprint)")
+	cmd.Flags().String("login", "", "Login data to store on the card, e.g. a user name")
+	cmd.Flags().Bool("interactive", false, "Set metadata in an interactive gpg --card-edit session")
+	cmd.Flags().Bool("all", false, "Set the metadata on every registered or connected YubiKey in turn")
+	cmd.Flags().String("admin-pin-file", "", "File containing the YubiKey Admin PIN (default: $"+adminPINEnv+", prompted if neither is set)")
+
+	return cmd
 }
 
 func runMetadata(cmd *cobra.Command, args []string) error {
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		return runMetadataAll(cmd)
+	}
+
 	_, yubikeySvc, _ := getServices()
 	ctx := cmd.Context()
 
@@ -39,6 +79,25 @@ func runMetadata(cmd *cobra.Command, args []string) error {
 
 	ui.LogInfo("Configuring YubiKey with serial: %s", cardInfo.Serial)
 
+	name, _ := cmd.Flags().GetString("name")
+	url, _ := cmd.Flags().GetString("url")
+	login, _ := cmd.Flags().GetString("login")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	if url == "" && cfg.PrimaryKeyFingerprint != "" {
+		url = yubikey.KeyserverURL(cfg.PrimaryKeyFingerprint)
+	}
+
+	if !interactive && (name != "" || url != "" || login != "") {
+		adminPIN, _, err := readSecret(cmd, "admin-pin-file", adminPINEnv)
+		if err != nil {
+			return err
+		}
+		return applyMetadata(ctx, yubikeySvc, cardInfo, name, url, login, adminPIN)
+	}
+	if err := requireInteractive("set-metadata --interactive", "pass --name, --url or --login instead"); err != nil {
+		return err
+	}
+
 	fmt.Println()
 	fmt.Println("This will set the cardholder name and other metadata on your YubiKey.")
 	fmt.Println("This helps identify which YubiKey is which.")
@@ -47,7 +106,7 @@ func runMetadata(cmd *cobra.Command, args []string) error {
 	fmt.Println("1. Type: admin")
 	fmt.Println("2. Type: name (then enter surname, then given name)")
 	fmt.Println("3. Type: lang (then enter 'en')")
-	fmt.Printf("4. Type: url (then enter: https://keys.openpgp.org/vks/v1/by-fingerprint/%s)\n", cfg.PrimaryKeyFingerprint)
+	fmt.Printf("4. Type: url (then enter: %s)\n", yubikey.KeyserverURL(cfg.PrimaryKeyFingerprint))
 	fmt.Println("5. Type: quit")
 	fmt.Println()
 
@@ -64,3 +123,252 @@ func runMetadata(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runMetadataAll sets the metadata on each target of metadataTargets in
+// turn, waiting for each YubiKey that isn't connected. Progress is saved
+// after every YubiKey and removed once all are done.
+func runMetadataAll(cmd *cobra.Command) error {
+	if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+		return fmt.Errorf("--all can't be combined with --interactive")
+	}
+	_, yubikeySvc, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Set Metadata on All YubiKeys")
+
+	name, _ := cmd.Flags().GetString("name")
+	url, _ := cmd.Flags().GetString("url")
+	login, _ := cmd.Flags().GetString("login")
+	if url == "" && cfg.PrimaryKeyFingerprint != "" {
+		url = yubikey.KeyserverURL(cfg.PrimaryKeyFingerprint)
+	}
+	if name == "" && url == "" && login == "" {
+		return fmt.Errorf("specify --name, --url or --login")
+	}
+
+	connected, err := yubikeySvc.ListCards(ctx)
+	if err != nil {
+		return fmt.Errorf("--all needs ykman to detect each YubiKey: %w", err)
+	}
+	serials := metadataTargets(toolConfig().YubiKeys, connected)
+	if len(serials) == 0 {
+		return fmt.Errorf("no YubiKeys registered or connected; record them with 'ykgpg label'")
+	}
+
+	path := metadataProgressFile()
+	progress, err := loadMetadataProgress(path)
+	if err != nil {
+		return err
+	}
+	wanted := metadataProgress{Name: name, URL: url, Login: login}
+	if !progress.sameMetadata(wanted) {
+		if len(progress.Done) > 0 {
+			ui.LogInfo("Starting over: the metadata differs from the unfinished run")
+		}
+		progress = wanted
+	} else if len(progress.Done) > 0 {
+		ui.LogInfo("Resuming: %d YubiKey(s) already done", len(progress.Done))
+	}
+
+	adminPIN, _, err := readSecret(cmd, "admin-pin-file", adminPINEnv)
+	if err != nil {
+		return err
+	}
+	if adminPIN == "" {
+		adminPIN, err = ui.PromptSecretRequired("YubiKey Admin PIN (default: 12345678): ")
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, serial := range serials {
+		fmt.Println()
+		ui.PrintSection(fmt.Sprintf("YubiKey %d of %d: %s", i+1, len(serials), serialWithLabel(serial)))
+		if contains(progress.Done, serial) {
+			ui.LogInfo("Already done")
+			continue
+		}
+
+		if err := insertCard(ctx, yubikeySvc, serial); err != nil {
+			return err
+		}
+		cardInfo, err := yubikeySvc.GetCardInfo(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get card info: %w", err)
+		}
+		if gpg.NormalizeCardSerial(cardInfo.Serial) != serial {
+			return fmt.Errorf("gpg is using YubiKey %s instead of %s; remove the other YubiKeys and run set-metadata --all again", cardInfo.Serial, serial)
+		}
+		if err := applyMetadata(ctx, yubikeySvc, cardInfo, name, url, login, adminPIN); err != nil {
+			return fmt.Errorf("YubiKey %s: %w", serial, err)
+		}
+
+		progress.Done = append(progress.Done, serial)
+		if err := saveMetadataProgress(path, progress); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		ui.LogWarning("Failed to remove %s: %v", path, err)
+	}
+	fmt.Println()
+	ui.LogSuccess("Metadata set on %d YubiKey(s)", len(serials))
+	return nil
+}
+
+// metadataTargets returns the serials of the registered and connected
+// YubiKeys, normalized and sorted.
+func metadataTargets(registered map[string]config.YubiKey, connected []string) []string {
+	var serials []string
+	add := func(serial string) {
+		serial = gpg.NormalizeCardSerial(serial)
+		if serial != "" && !contains(serials, serial) {
+			serials = append(serials, serial)
+		}
+	}
+	for serial := range registered {
+		add(serial)
+	}
+	for _, serial := range connected {
+		add(serial)
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+// insertCard waits until the YubiKey with the given serial is connected,
+// asking for it if it isn't, and selects it.
+func insertCard(ctx context.Context, yubikeySvc *yubikey.Service, serial string) error {
+	isSerial := func(candidate string) bool { return gpg.NormalizeCardSerial(candidate) == serial }
+	if connected, err := yubikeySvc.ListCards(ctx); err != nil || !slices.ContainsFunc(connected, isSerial) {
+		ui.LogInfo("Insert YubiKey %s (Ctrl+C to stop; run the command again to resume)", serialWithLabel(serial))
+	}
+	if _, err := waitForCard(ctx, yubikeySvc.ListCards, isSerial, "Waiting for YubiKey "+serial+"...", cardPollInterval); err != nil {
+		return err
+	}
+	return useCard(ctx, yubikeySvc, serial)
+}
+
+// metadataProgress records the metadata set-metadata --all is applying and
+// the serials of the YubiKeys it has finished.
+type metadataProgress struct {
+	Name  string   `json:"name,omitempty"`
+	URL   string   `json:"url,omitempty"`
+	Login string   `json:"login,omitempty"`
+	Done  []string `json:"done,omitempty"`
+}
+
+// sameMetadata reports whether p and other apply the same metadata.
+func (p metadataProgress) sameMetadata(other metadataProgress) bool {
+	return p.Name == other.Name && p.URL == other.URL && p.Login == other.Login
+}
+
+// metadataProgressFile returns where set-metadata --all saves its progress.
+func metadataProgressFile() string {
+	return filepath.Join(config.DataDir(), "set-metadata-progress.json")
+}
+
+// loadMetadataProgress reads the progress saved at path. No file means
+// nothing has been done yet.
+func loadMetadataProgress(path string) (metadataProgress, error) {
+	var progress metadataProgress
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return progress, fmt.Errorf("failed to read progress: %w", err)
+	}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return progress, fmt.Errorf("invalid progress file %s: %w", path, err)
+	}
+	return progress, nil
+}
+
+// saveMetadataProgress writes progress to path, creating its directory if
+// needed.
+func saveMetadataProgress(path string, progress metadataProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to encode progress: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// applyMetadata sets the cardholder name (if given) and the card URL and
+// login data (if they differ from the current ones) using a single Admin
+// PIN, which is prompted for if adminPIN is empty.
+func applyMetadata(ctx context.Context, yubikeySvc yubikey.YubiKeyService, cardInfo *gpg.CardInfo, name, url, login, adminPIN string) error {
+	var given, surname string
+	if name != "" {
+		var err error
+		given, surname, err = parseCardholderName(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if url == cardInfo.URL {
+		ui.LogInfo("Card URL already set to: %s", url)
+		url = ""
+	}
+	if login != "" && login == cardInfo.LoginData {
+		ui.LogInfo("Login data already set to: %s", login)
+		login = ""
+	}
+	if name == "" && url == "" && login == "" {
+		return nil
+	}
+
+	if adminPIN == "" {
+		var err error
+		adminPIN, err = ui.PromptSecretRequired("YubiKey Admin PIN (default: 12345678): ")
+		if err != nil {
+			return err
+		}
+	}
+
+	if name != "" {
+		if err := yubikeySvc.SetCardholderName(ctx, given, surname, adminPIN); err != nil {
+			return err
+		}
+		ui.LogSuccess("Cardholder name set to: %s", strings.TrimSpace(given+" "+surname))
+	}
+
+	if url != "" {
+		if err := yubikeySvc.SetCardURL(ctx, url, adminPIN); err != nil {
+			return err
+		}
+		ui.LogSuccess("Card URL set to: %s", url)
+	}
+
+	if login != "" {
+		if err := yubikeySvc.SetLoginData(ctx, login, adminPIN); err != nil {
+			return err
+		}
+		ui.LogSuccess("Login data set to: %s", login)
+	}
+
+	return nil
+}
+
+// parseCardholderName splits a "Surname/Given" name into its parts.
+// The given name may be empty ("Surname" or "Surname/").
+func parseCardholderName(name string) (given, surname string, err error) {
+	parts := strings.SplitN(name, "/", 2)
+	surname = strings.TrimSpace(parts[0])
+	if len(parts) == 2 {
+		given = strings.TrimSpace(parts[1])
+	}
+	if surname == "" {
+		return "", "", fmt.Errorf(`invalid cardholder name %q: expected "Surname/Given"`, name)
+	}
+	return given, surname, nil
+}
@@ -1,13 +1,109 @@
 package cli
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewMetadataCmd(t *testing.T) {
 	cmd := newMetadataCmd()
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "set-metadata", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("name"))
+	assert.NotNil(t, cmd.Flags().Lookup("url"))
+	assert.NotNil(t, cmd.Flags().Lookup("login"))
+	assert.NotNil(t, cmd.Flags().Lookup("interactive"))
+	assert.NotNil(t, cmd.Flags().Lookup("all"))
+}
+
+func TestMetadataTargets(t *testing.T) {
+	registered := map[string]config.YubiKey{
+		"22222222": {Label: "Key B"},
+		"11111111": {Label: "Key A"},
+	}
+
+	assert.Equal(t, []string{"11111111", "22222222", "33333333"}, metadataTargets(registered, []string{"0006 22222222", "33333333"}))
+	assert.Empty(t, metadataTargets(nil, nil))
+}
+
+func TestMetadataProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ykgpg", "set-metadata-progress.json")
+
+	progress, err := loadMetadataProgress(path)
+	require.NoError(t, err)
+	assert.Empty(t, progress.Done, "nothing is done before the first run")
+
+	saved := metadataProgress{Name: "Doe/Jane", URL: "https://example.com/key.asc", Done: []string{"11111111"}}
+	require.NoError(t, saveMetadataProgress(path, saved))
+	progress, err = loadMetadataProgress(path)
+	require.NoError(t, err)
+	assert.Equal(t, saved, progress)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.True(t, progress.sameMetadata(metadataProgress{Name: "Doe/Jane", URL: "https://example.com/key.asc"}))
+	assert.False(t, progress.sameMetadata(metadataProgress{Name: "Doe/John", URL: "https://example.com/key.asc"}))
+}
+
+func TestApplyMetadata_URLAlreadySet(t *testing.T) {
+	url := "https://keys.openpgp.org/vks/v1/by-fingerprint/FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+	mockExecutor := executor.NewMockExecutor()
+	gpgSvc := gpg.NewService(mockExecutor)
+	yubikeySvc := yubikey.NewService(gpgSvc, mockExecutor)
+
+	err := applyMetadata(context.Background(), yubikeySvc, &gpg.CardInfo{URL: url}, "", url, "", "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, mockExecutor.Calls, "nothing should be written when the URL is unchanged")
+}
+
+func TestApplyMetadata_LoginAlreadySet(t *testing.T) {
+	url := "https://keys.openpgp.org/vks/v1/by-fingerprint/FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+	mockExecutor := executor.NewMockExecutor()
+	gpgSvc := gpg.NewService(mockExecutor)
+	yubikeySvc := yubikey.NewService(gpgSvc, mockExecutor)
+
+	err := applyMetadata(context.Background(), yubikeySvc, &gpg.CardInfo{URL: url, LoginData: "jdoe"}, "", url, "jdoe", "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, mockExecutor.Calls, "nothing should be written when the login data is unchanged")
+}
+
+func TestParseCardholderName(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedGiven   string
+		expectedSurname string
+		expectError     bool
+	}{
+		{input: "Doe/Jane", expectedGiven: "Jane", expectedSurname: "Doe"},
+		{input: " Doe / Jane Ann ", expectedGiven: "Jane Ann", expectedSurname: "Doe"},
+		{input: "Doe", expectedSurname: "Doe"},
+		{input: "/Jane", expectError: true},
+		{input: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			given, surname, err := parseCardholderName(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedGiven, given)
+			assert.Equal(t, tt.expectedSurname, surname)
+		})
+	}
 }
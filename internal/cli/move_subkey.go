@@ -1,16 +1,18 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/bobbydams/yubikey-manager/pkg/ykgpg"
 	"github.com/spf13/cobra"
 )
 
 func newMoveSubkeyCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "move-subkey",
 		Short: "Move an existing signing subkey to a YubiKey",
 		Long: `Move an existing signing subkey to a YubiKey. This command is useful when
@@ -21,17 +23,41 @@ This command will:
 1. Check for YubiKey presence
 2. Guide you through moving the subkey to the YubiKey
 3. Optionally remove the master key from your local machine
-4. Optionally upload the updated public key to a keyserver`,
+4. Optionally upload the updated public key to a keyserver
+
+With --subkey, the gpg --edit-key session is scripted: the subkey is selected
+automatically and you are only asked for the key passphrase and Admin PIN.
+They can instead be read from --passphrase-file and --admin-pin-file, or
+from $YKGPG_PASSPHRASE and $YKGPG_ADMIN_PIN.`,
 		RunE: runMoveSubkey,
 	}
+
+	cmd.Flags().String("subkey", "", "ID of the signing subkey to move (scripts keytocard instead of a manual edit-key session)")
+	cmd.Flags().Bool("force", false, "Move the subkey even if the Signature slot is configured for a different key type")
+	cmd.Flags().Bool("remove-master", false, "Remove the master key from the local keyring afterwards without asking")
+	addSecretFileFlags(cmd)
+	_ = cmd.RegisterFlagCompletionFunc("subkey", completeSubkeyIDs)
+
+	return cmd
 }
 
-func runMoveSubkey(cmd *cobra.Command, args []string) error {
+func runMoveSubkey(cmd *cobra.Command, args []string) (err error) {
+	if subkeyID, _ := cmd.Flags().GetString("subkey"); subkeyID == "" {
+		if err := requireInteractive("move-subkey without --subkey", "pass --subkey to script keytocard"); err != nil {
+			return err
+		}
+	}
 	gpgSvc, yubikeySvc, _ := getServices()
 	ctx := cmd.Context()
+	op := newJournalOp("move-subkey")
+	defer func() { op.finish(err) }()
 
 	ui.PrintHeader("Move Subkey to YubiKey")
 
+	if err := selectCard(ctx, yubikeySvc); err != nil {
+		return err
+	}
+
 	// Check YubiKey presence
 	present, err := yubikeySvc.IsPresent(ctx)
 	if err != nil {
@@ -75,10 +101,21 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	}
 
 	ui.LogInfo("Detected YubiKey with serial: %s", cardInfo.Serial)
+	op.serial = cardInfo.Serial
 
 	// Check PIN retry counter and warn if low or locked
 	// This is parsed from gpg --card-status output
-	// We'll check via ykman if available, or provide general guidance
+	switch cardInfo.AdminPINRetries {
+	case 0:
+		ui.LogError("The Admin PIN is BLOCKED (0 retries remaining). keytocard will fail.")
+		ui.LogInfo("Reset the card or unblock the Admin PIN before moving a key.")
+		return fmt.Errorf("admin PIN is blocked")
+	case 1:
+		ui.LogWarning("Only 1 Admin PIN attempt remains! A wrong PIN during keytocard will LOCK the card.")
+		if !ui.Confirm("Continue anyway?") {
+			return nil
+		}
+	}
 	fmt.Println()
 	ui.LogInfo("PIN Information:")
 	fmt.Println("  • Default User PIN: 123456")
@@ -88,31 +125,63 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	fmt.Println("  • OpenPGP PINs are set via 'gpg --card-edit' → 'admin' → 'passwd'")
 	fmt.Println()
 
-	// Check the card's key attributes (what key types it accepts)
-	if len(cardInfo.KeyAttributes) > 0 {
-		sigAttr := cardInfo.KeyAttributes[0] // First attribute is for signature key
+	// Find the subkey being moved so its algorithm can be checked against the card
+	subkeyID, _ := cmd.Flags().GetString("subkey")
+	force, _ := cmd.Flags().GetBool("force")
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+	var subkey *gpg.Key
+	if subkeyID != "" {
+		for i := range keys {
+			if keys[i].Type == "ssb" && (gpg.KeyIDMatches(keys[i].KeyID, subkeyID) || gpg.KeyIDMatches(keys[i].Fingerprint, subkeyID)) {
+				subkey = &keys[i]
+				break
+			}
+		}
+	} else {
+		subkey, _ = gpg.FindMovableSigningSubkey(keys)
+	}
+
+	if subkey != nil && isCurve25519(subkey.Algorithm) && warnIfNoEd25519(cardInfo) {
+		if !ui.Confirm("Continue anyway? (keytocard will fail)") {
+			return nil
+		}
+	}
+
+	// Check the Signature slot's key attributes (what key type it accepts)
+	if sigAttr, ok := cardInfo.KeyAttributes[gpg.CardSlots[1]]; ok {
 		fmt.Printf("  └─ Signature slot configured for: %s\n", sigAttr)
-		
-		// Check if the card is configured for RSA but we're trying to use ECC
-		isRSA := strings.HasPrefix(strings.ToLower(sigAttr), "rsa")
-		if isRSA {
-			ui.LogWarning("Your YubiKey is configured for RSA keys, but your signing subkey may be ECC (ed25519/cv25519).")
-			ui.LogWarning("You need to change the card's key attributes before moving an ECC key.")
+
+		if subkey != nil && subkey.Algorithm != "" {
+			fmt.Printf("  └─ Subkey %s algorithm: %s\n", subkey.KeyID, subkey.Algorithm)
+		}
+
+		if subkey != nil && keyAttrMismatch(subkey.Algorithm, sigAttr) {
+			ui.LogWarning("Your YubiKey's signature slot is configured for %s, but subkey %s is %s.", sigAttr, subkey.KeyID, subkey.Algorithm)
+			ui.LogWarning("You need to change the card's key attributes before moving this key.")
 			fmt.Println()
-			ui.LogInfo("To configure the card for ed25519 (required for ECC keys):")
+			ui.LogInfo("To configure the card for %s:", subkey.Algorithm)
 			fmt.Println("  1. Run: gpg --card-edit")
 			fmt.Println("  2. Type: admin")
 			fmt.Println("  3. Type: key-attr")
 			fmt.Println("  4. For Signature key, select: (1) RSA or (2) ECC")
-			fmt.Println("     → Select (2) ECC")
-			fmt.Println("  5. For curve, select: (1) Curve 25519")
+			if strings.HasPrefix(strings.ToLower(subkey.Algorithm), "rsa") {
+				fmt.Println("     → Select (1) RSA")
+				fmt.Printf("  5. For key size, enter: %s\n", strings.TrimPrefix(strings.ToLower(subkey.Algorithm), "rsa"))
+			} else {
+				fmt.Println("     → Select (2) ECC")
+				fmt.Println("  5. For curve, select: (1) Curve 25519")
+			}
 			fmt.Println("  6. Enter Admin PIN when prompted (default: 12345678)")
 			fmt.Println("  7. Repeat for Encryption and Authentication if needed")
 			fmt.Println("  8. Type: quit")
 			fmt.Println()
-			if !ui.Confirm("Continue anyway? (keytocard will fail if key types don't match)") {
-				return nil
+			if !force {
+				return fmt.Errorf("subkey %s is %s but the Signature slot is configured for %s; change the key attributes or pass --force", subkey.KeyID, subkey.Algorithm, sigAttr)
 			}
+			ui.LogWarning("Continuing because of --force; keytocard will fail if key types don't match")
 		}
 	}
 
@@ -125,18 +194,7 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	}
 
 	// Verify master key is available (needed for moving subkey)
-	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
-	if err != nil {
-		return fmt.Errorf("failed to list keys: %w", err)
-	}
-
-	hasMaster := false
-	for _, key := range keys {
-		if key.Type == "sec" && key.KeyID == cfg.PrimaryKeyID {
-			hasMaster = true
-			break
-		}
-	}
+	_, hasMaster := gpg.MasterKeyOnMachine(keys, cfg.PrimaryKeyID)
 
 	if !hasMaster {
 		ui.LogWarning("Master key not found in keyring. You may need to import it first.")
@@ -161,32 +219,47 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	if !ui.Confirm("Have you backed up your keys and are ready to proceed?") {
 		return nil
 	}
-	fmt.Println()
-	ui.LogInfo("Now we'll move the subkey to your YubiKey.")
-	fmt.Println()
-	fmt.Println("Steps to move the subkey to YubiKey:")
-	fmt.Println()
-	fmt.Println("1. Run: gpg --edit-key", cfg.PrimaryKeyID)
-	fmt.Println("2. Type: list (to see all subkeys with numbers)")
-	fmt.Println("3. Identify the signing subkey you want to move (the one without a card-no)")
-	fmt.Println("4. Type: key N (where N is the number of the subkey, e.g., 'key 4')")
-	fmt.Println("5. Type: keytocard")
-	fmt.Println("6. Select: (1) Signature key")
-	fmt.Println("7. Enter your GPG key PASSPHRASE when prompted (this decrypts your key)")
-	fmt.Println("8. Enter your YubiKey ADMIN PIN when prompted (default: 12345678)")
-	fmt.Println("9. Type: save")
-	fmt.Println()
-	ui.LogWarning("IMPORTANT: GPG won't show an error if the Admin PIN is wrong!")
-	ui.LogWarning("If 'save' says 'Key not changed', the Admin PIN was likely incorrect.")
-	fmt.Println()
-
-	_, err = ui.Prompt("Press Enter when ready to continue: ")
-	if err != nil {
-		return err
+	op.start()
+	if subkey != nil {
+		op.addKeys(subkey.KeyID)
 	}
+	if subkeyID != "" {
+		// Target the subkey by its full fingerprint when known, so a short
+		// --subkey can't select the wrong subkey
+		if subkey != nil && subkey.Fingerprint != "" {
+			subkeyID = subkey.Fingerprint
+		}
+		if err := moveSubkeyScripted(ctx, cmd, getManager(gpgSvc, yubikeySvc), subkeyID); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println()
+		ui.LogInfo("Now we'll move the subkey to your YubiKey.")
+		selectStep := selectSubkeyStep(ctx, gpgSvc)
+		fmt.Println()
+		fmt.Println("Steps to move the subkey to YubiKey:")
+		fmt.Println()
+		fmt.Println("1. Run: gpg --edit-key", cfg.PrimaryKeyID)
+		fmt.Println("2. Type: list (to see all subkeys with numbers)")
+		fmt.Println("3.", selectStep)
+		fmt.Println("4. Type: keytocard")
+		fmt.Println("5. Select: (1) Signature key")
+		fmt.Println("6. Enter your GPG key PASSPHRASE when prompted (this decrypts your key)")
+		fmt.Println("7. Enter your YubiKey ADMIN PIN when prompted (default: 12345678)")
+		fmt.Println("8. Type: save")
+		fmt.Println()
+		ui.LogWarning("IMPORTANT: GPG won't show an error if the Admin PIN is wrong!")
+		ui.LogWarning("If 'save' says 'Key not changed', the Admin PIN was likely incorrect.")
+		fmt.Println()
+
+		_, err = ui.Prompt("Press Enter when ready to continue: ")
+		if err != nil {
+			return err
+		}
 
-	if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
-		return fmt.Errorf("failed to edit key: %w", err)
+		if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
+			return fmt.Errorf("failed to edit key: %w", err)
+		}
 	}
 
 	// Verify the key was actually moved to the YubiKey
@@ -218,7 +291,7 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 
 	// Clean up master key
 	fmt.Println()
-	if ui.Confirm("Remove master key from local machine?") {
+	if removeMaster, _ := cmd.Flags().GetBool("remove-master"); removeMaster || confirmRemoveMasterKey() {
 		if err := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); err != nil {
 			ui.LogWarning("Failed to remove master key: %v", err)
 		} else {
@@ -229,17 +302,7 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	}
 
 	// Upload to keyserver
-	if ui.Confirm(fmt.Sprintf("Upload updated public key to %s?", cfg.Keyserver)) {
-		exec := executor.NewRealExecutor()
-		ui.LogInfo("Uploading to keyserver...")
-		_, err := exec.Run(ctx, "gpg", "--keyserver", cfg.Keyserver, "--send-keys", cfg.PrimaryKeyID)
-		if err != nil {
-			ui.LogWarning("Failed to upload to keyserver: %v", err)
-			ui.LogWarning("Visit https://keys.openpgp.org/upload to upload manually.")
-		} else {
-			ui.LogSuccess("Public key uploaded to %s", cfg.Keyserver)
-		}
-	}
+	uploadPublicKey(ctx, gpgSvc)
 
 	fmt.Println()
 	ui.LogSuccess("Subkey move complete!")
@@ -247,6 +310,7 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	fmt.Println("Next steps:")
 	fmt.Println("  1. Label this YubiKey physically (e.g., 'Key B - " + cardInfo.Serial + "')")
+	fmt.Println("     and record the label: ykgpg label " + cardInfo.Serial + " \"Key B\"")
 	fmt.Println("  2. Test signing: echo 'test' | gpg --sign --armor")
 	fmt.Println("  3. Register this YubiKey with GitHub/GitLab if not already done")
 	fmt.Println()
@@ -254,3 +318,42 @@ func runMoveSubkey(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// moveSubkeyScripted reads or prompts for the key passphrase and Admin PIN
+// and moves the subkey to the card's signature slot without an interactive
+// edit-key session.
+func moveSubkeyScripted(ctx context.Context, cmd *cobra.Command, manager *ykgpg.Manager, subkeyID string) error {
+	fmt.Println()
+	ui.LogInfo("Moving subkey %s to the YubiKey's signature slot.", subkeyID)
+
+	passphrase, err := secretOrPrompt(cmd, "passphrase-file", passphraseEnv, "GPG key passphrase (leave empty if none): ", false)
+	if err != nil {
+		return err
+	}
+	adminPIN, err := secretOrPrompt(cmd, "admin-pin-file", adminPINEnv, "YubiKey Admin PIN (default: 12345678): ", true)
+	if err != nil {
+		return err
+	}
+
+	if err := manager.MoveSubkey(ctx, subkeyID, 1, passphrase, adminPIN); err != nil {
+		return err
+	}
+	ui.LogSuccess("Subkey %s moved to the YubiKey", subkeyID)
+
+	return nil
+}
+
+// isCurve25519 reports whether an algorithm is ed25519 or cv25519.
+func isCurve25519(algorithm string) bool {
+	algorithm = strings.ToLower(algorithm)
+	return algorithm == "ed25519" || algorithm == "cv25519"
+}
+
+// keyAttrMismatch reports whether a subkey's algorithm (e.g. "ed25519",
+// "rsa4096") differs from the card slot's configured key attribute.
+// An unknown algorithm or attribute is not treated as a mismatch.
+func keyAttrMismatch(algorithm, cardAttr string) bool {
+	if algorithm == "" || cardAttr == "" {
+		return false
+	}
+	return !strings.EqualFold(algorithm, cardAttr)
+}
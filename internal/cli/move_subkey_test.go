@@ -3,6 +3,8 @@ package cli
 import (
 	"testing"
 
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,5 +15,29 @@ func TestNewMoveSubkeyCmd(t *testing.T) {
 	assert.Contains(t, cmd.Short, "Move")
 	assert.Contains(t, cmd.Short, "subkey")
 	assert.Contains(t, cmd.Short, "YubiKey")
+	assert.NotNil(t, cmd.Flags().Lookup("subkey"))
+	assert.NotNil(t, cmd.Flags().Lookup("force"))
+	assert.NotNil(t, cmd.Flags().Lookup("remove-master"))
+}
+
+func TestKeyAttrMismatch(t *testing.T) {
+	assert.False(t, keyAttrMismatch("ed25519", "ed25519"))
+	assert.False(t, keyAttrMismatch("rsa4096", "RSA4096"))
+	assert.True(t, keyAttrMismatch("ed25519", "rsa2048"))
+	assert.True(t, keyAttrMismatch("rsa4096", "rsa2048"))
+	assert.False(t, keyAttrMismatch("", "rsa2048"))
+	assert.False(t, keyAttrMismatch("ed25519", ""))
 }
 
+func TestIsCurve25519(t *testing.T) {
+	assert.True(t, isCurve25519("ed25519"))
+	assert.True(t, isCurve25519("CV25519"))
+	assert.False(t, isCurve25519("rsa4096"))
+	assert.False(t, isCurve25519(""))
+}
+
+func TestWarnIfNoEd25519(t *testing.T) {
+	assert.False(t, warnIfNoEd25519(&gpg.CardInfo{}), "unknown firmware gives no warning")
+	assert.False(t, warnIfNoEd25519(&gpg.CardInfo{FirmwareVersion: "5.4.3"}))
+	assert.True(t, warnIfNoEd25519(&gpg.CardInfo{FirmwareVersion: "4.3.7"}))
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newPinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pin",
+		Short: "Manage YubiKey OpenPGP PINs",
+		Long:  "Commands for managing the OpenPGP User and Admin PINs on a YubiKey",
+	}
+	// Skip PersistentPreRunE validation for pin commands
+	// Managing PINs doesn't require a configured primary key, only gpg
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return requireGPG()
+	}
+
+	cmd.AddCommand(newPinChangeCmd())
+	cmd.AddCommand(newPinUnblockCmd())
+
+	return cmd
+}
+
+func newPinChangeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "change",
+		Short: "Change the User and/or Admin PIN",
+		Long: `Change the OpenPGP User PIN (--user) and/or Admin PIN (--admin) with a
+scripted gpg --card-edit session. The PINs are passed to gpg on stdin, never
+as command-line arguments.
+
+The User PIN must be at least 6 characters and the Admin PIN at least 8.
+Defaults on a new card are 123456 (User) and 12345678 (Admin).`,
+		RunE: runPinChange,
+	}
+
+	cmd.Flags().Bool("user", false, "Change the User PIN")
+	cmd.Flags().Bool("admin", false, "Change the Admin PIN")
+
+	return cmd
+}
+
+func runPinChange(cmd *cobra.Command, args []string) error {
+	changeUser, _ := cmd.Flags().GetBool("user")
+	changeAdmin, _ := cmd.Flags().GetBool("admin")
+	if !changeUser && !changeAdmin {
+		return fmt.Errorf("specify --user and/or --admin")
+	}
+
+	_, yubikeySvc, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Change YubiKey PINs")
+
+	// Check YubiKey presence
+	present, err := yubikeySvc.IsPresent(ctx)
+	if err != nil {
+		ui.LogError("%v", err)
+		return err
+	}
+	if !present {
+		ui.LogError("No YubiKey detected. Please insert a YubiKey and try again.")
+		return fmt.Errorf("no YubiKey detected")
+	}
+
+	if changeUser {
+		ui.PrintSection("USER PIN")
+		oldPIN, newPIN, err := promptPINChange("User PIN", "123456")
+		if err != nil {
+			return err
+		}
+		if err := yubikeySvc.ChangeUserPIN(ctx, oldPIN, newPIN); err != nil {
+			return err
+		}
+		ui.LogSuccess("User PIN changed")
+	}
+
+	if changeAdmin {
+		ui.PrintSection("ADMIN PIN")
+		oldPIN, newPIN, err := promptPINChange("Admin PIN", "12345678")
+		if err != nil {
+			return err
+		}
+		if err := yubikeySvc.ChangeAdminPIN(ctx, oldPIN, newPIN); err != nil {
+			return err
+		}
+		ui.LogSuccess("Admin PIN changed")
+	}
+
+	return nil
+}
+
+// promptPINChange prompts for the current PIN and a confirmed new PIN.
+func promptPINChange(label, defaultPIN string) (string, string, error) {
+	oldPIN, err := ui.PromptSecretRequired(fmt.Sprintf("Current %s (default: %s): ", label, defaultPIN))
+	if err != nil {
+		return "", "", err
+	}
+
+	newPIN, err := promptNewPIN(label)
+	if err != nil {
+		return "", "", err
+	}
+
+	return oldPIN, newPIN, nil
+}
+
+// promptNewPIN prompts for a new PIN twice and checks the entries match.
+func promptNewPIN(label string) (string, error) {
+	newPIN, err := ui.PromptSecretRequired(fmt.Sprintf("New %s: ", label))
+	if err != nil {
+		return "", err
+	}
+
+	confirmPIN, err := ui.PromptSecretRequired(fmt.Sprintf("Confirm new %s: ", label))
+	if err != nil {
+		return "", err
+	}
+
+	if newPIN != confirmPIN {
+		return "", fmt.Errorf("new %s entries do not match", label)
+	}
+
+	return newPIN, nil
+}
+
+func newPinUnblockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unblock",
+		Short: "Unblock the User PIN with the Reset Code",
+		Long: `Set a new User PIN with the Reset Code after too many wrong User PIN
+entries have blocked it, with a scripted gpg --card-edit session. The Reset
+Code and new PIN are passed to gpg on stdin.
+
+The PIN retry counters are checked first: if the User PIN still has attempts
+left, unblocking isn't necessary and you are asked before going ahead. If no
+Reset Code is set, or its attempts are used up, unblock the PIN with the
+Admin PIN instead ('gpg --card-edit', then 'admin' and 'passwd').`,
+		RunE: runPinUnblock,
+	}
+
+	return cmd
+}
+
+func runPinUnblock(cmd *cobra.Command, args []string) error {
+	_, yubikeySvc, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Unblock User PIN")
+
+	present, err := yubikeySvc.IsPresent(ctx)
+	if err != nil {
+		ui.LogError("%v", err)
+		return err
+	}
+	if !present {
+		ui.LogError("No YubiKey detected. Please insert a YubiKey and try again.")
+		return fmt.Errorf("no YubiKey detected")
+	}
+
+	cardInfo, err := yubikeySvc.GetCardInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+
+	blocked, err := userPINBlocked(cardInfo)
+	if err != nil {
+		return err
+	}
+	switch {
+	case blocked:
+		ui.LogWarning("The User PIN is blocked; %d Reset Code attempt(s) left.", cardInfo.ResetCodeRetries)
+	case cardInfo.PINRetries < 0:
+		ui.LogWarning("Could not read the PIN retry counters; the User PIN may not be blocked.")
+	default:
+		ui.LogInfo("The User PIN is not blocked (%d attempt(s) left), so unblocking isn't necessary.", cardInfo.PINRetries)
+		if !ui.Confirm("Set a new User PIN with the Reset Code anyway?") {
+			return nil
+		}
+	}
+
+	resetCode, err := ui.PromptSecretRequired("Reset Code: ")
+	if err != nil {
+		return err
+	}
+	newPIN, err := promptNewPIN("User PIN")
+	if err != nil {
+		return err
+	}
+
+	if err := yubikeySvc.UnblockPIN(ctx, resetCode, newPIN); err != nil {
+		return err
+	}
+	ui.LogSuccess("User PIN unblocked")
+
+	return nil
+}
+
+// userPINBlocked reports whether the card's User PIN is blocked, from its
+// retry counters. It is an error if the PIN can't be unblocked with the
+// Reset Code because no Reset Code attempts are left. Unknown counters
+// (-1) don't count as blocked.
+func userPINBlocked(info *gpg.CardInfo) (bool, error) {
+	if info.ResetCodeRetries == 0 {
+		return info.PINRetries == 0, fmt.Errorf("no Reset Code is set or its attempts are used up; unblock the User PIN with the Admin PIN instead: run 'gpg --card-edit', then 'admin' and 'passwd'")
+	}
+	return info.PINRetries == 0, nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPinCmd(t *testing.T) {
+	cmd := newPinCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "pin", cmd.Use)
+
+	changeCmd, _, err := cmd.Find([]string{"change"})
+	assert.NoError(t, err)
+	assert.Equal(t, "change", changeCmd.Use)
+	assert.NotNil(t, changeCmd.Flags().Lookup("user"))
+	assert.NotNil(t, changeCmd.Flags().Lookup("admin"))
+}
+
+func TestNewPinUnblockCmd(t *testing.T) {
+	unblockCmd, _, err := newPinCmd().Find([]string{"unblock"})
+	assert.NoError(t, err)
+	assert.Equal(t, "unblock", unblockCmd.Use)
+}
+
+func TestUserPINBlocked(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        *gpg.CardInfo
+		wantBlocked bool
+		wantErr     bool
+	}{
+		{"blocked", &gpg.CardInfo{PINRetries: 0, ResetCodeRetries: 3, AdminPINRetries: 3}, true, false},
+		{"attempts left", &gpg.CardInfo{PINRetries: 2, ResetCodeRetries: 3, AdminPINRetries: 3}, false, false},
+		{"unknown counters", &gpg.CardInfo{PINRetries: -1, ResetCodeRetries: -1, AdminPINRetries: -1}, false, false},
+		{"no reset code", &gpg.CardInfo{PINRetries: 0, ResetCodeRetries: 0, AdminPINRetries: 3}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocked, err := userPINBlocked(tt.info)
+			assert.Equal(t, tt.wantBlocked, blocked)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "Admin PIN")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
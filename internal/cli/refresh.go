@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newRefreshCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Update the local public key from the keyserver",
+		Long: `Fetch the public key from each configured keyserver and merge it into
+the local keyring, so revocations, new subkeys and new expiry dates made
+on another machine show up here. The key is downloaded if it isn't in
+the keyring yet.
+
+Reports for each keyserver whether the local copy changed.`,
+		RunE: runRefresh,
+	}
+
+	return cmd
+}
+
+func runRefresh(cmd *cobra.Command, args []string) error {
+	gpgSvc, _, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Refresh Public Key")
+
+	servers := cfg.KeyserverList()
+	if len(servers) == 0 {
+		return fmt.Errorf("no keyserver configured; set keyserver or pass --keyserver")
+	}
+
+	changed, failed := false, 0
+	for _, server := range servers {
+		spinner := ui.StartSpinner(fmt.Sprintf("Refreshing %s from %s...", cfg.PrimaryKeyID, server))
+		serverChanged, err := gpgSvc.RefreshKey(ctx, cfg.PrimaryKeyID, server)
+		spinner.Stop()
+		switch {
+		case err != nil:
+			ui.LogWarning("Failed to refresh from %s: %v", server, err)
+			failed++
+		case serverChanged:
+			ui.LogSuccess("Local key updated from %s", server)
+			changed = true
+		default:
+			ui.LogInfo("No changes from %s", server)
+		}
+	}
+
+	if failed == len(servers) {
+		return fmt.Errorf("could not refresh key from any keyserver")
+	}
+
+	fmt.Println()
+	if changed {
+		ui.LogSuccess("Local copy of %s updated", cfg.PrimaryKeyID)
+		fmt.Println("Run 'ykgpg status' to review the updated key.")
+	} else {
+		ui.LogSuccess("Local copy of %s is up to date", cfg.PrimaryKeyID)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRefreshCmd(t *testing.T) {
+	cmd := newRefreshCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "refresh", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate a diagnostics report for support tickets",
+		Long: `Gather non-secret diagnostics into a single report that can be attached
+to a bug report: tool version, gpg/ykman/scdaemon versions, operating system,
+the doctor check results, problematic options in gpg.conf and gpg-agent.conf,
+and a sanitized summary of key and YubiKey status.
+
+Key IDs, serial numbers, names, and email addresses are redacted, as are
+your home directory and user name in paths and keyserver hosts other than
+the well-known public ones. Secret key material is never included. The report file is only readable by
+you.`,
+		RunE: runReport,
+	}
+	// Skip PersistentPreRunE validation for report command
+	// A report is most useful precisely when the configuration is broken
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Output file path (default: print to stdout)")
+
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Load config leniently; a missing or invalid config is part of the report
+	reportCfg, cfgErr := config.Load()
+	if reportCfg == nil {
+		reportCfg = &config.Config{GPGTimeout: executor.DefaultTimeout}
+	}
+
+	exec := logCommands(newRealExecutor(reportCfg.GPGTimeout))
+	gpgSvc := gpg.NewService(exec)
+	gpgSvc.Binary = gpgBinary()
+	yubikeySvc := yubikey.NewService(gpgSvc, exec)
+	yubikeySvc.GPGBinary = gpgSvc.Binary
+	yubikeySvc.YkmanBinary = ykmanBinary()
+	yubikeySvc.CardRetries = toolConfig().CardRetries
+
+	report := buildReport(ctx, exec, gpgSvc, yubikeySvc, reportCfg, cfgErr)
+
+	outputFile, _ := cmd.Flags().GetString("output")
+	if outputFile == "" {
+		fmt.Print(report)
+		return nil
+	}
+
+	if err := os.WriteFile(outputFile, []byte(report), 0600); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	ui.LogSuccess("Report written to: %s", outputFile)
+
+	return nil
+}
+
+// buildReport assembles the diagnostics report text.
+func buildReport(ctx context.Context, exec executor.Executor, gpgSvc gpg.GPGService, yubikeySvc yubikey.YubiKeyService, reportCfg *config.Config, cfgErr error) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "ykgpg diagnostics report\n")
+	fmt.Fprintf(&b, "Generated: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "== Environment ==")
+	fmt.Fprintf(&b, "ykgpg version: %s\n", version)
+	fmt.Fprintf(&b, "OS/Arch:       %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Go runtime:    %s\n", runtime.Version())
+	fmt.Fprintf(&b, "gpg:           %s\n", probeVersion(ctx, exec, gpgBinary(), "--version"))
+	fmt.Fprintf(&b, "ykman:         %s\n", probeVersion(ctx, exec, ykmanBinary(), "--version"))
+	fmt.Fprintf(&b, "scdaemon:      %s\n", probeScdaemonVersion(ctx, exec))
+	fmt.Fprintf(&b, "GPG_TTY set:   %t\n", os.Getenv("GPG_TTY") != "")
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "== Doctor ==")
+	for _, check := range doctorChecks() {
+		status, detail := check.run(ctx, exec)
+		fmt.Fprintf(&b, "%-4s %s", status, check.name)
+		if detail != "" {
+			fmt.Fprintf(&b, " (%s)", redactHexIDs(detail))
+		}
+		fmt.Fprintln(&b)
+	}
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "== GnuPG configuration ==")
+	if findings := confFindings(gnupgHome()); len(findings) > 0 {
+		for _, finding := range findings {
+			fmt.Fprintln(&b, redactHexIDs(finding))
+		}
+	} else {
+		fmt.Fprintln(&b, "No problematic options in gpg.conf or gpg-agent.conf")
+	}
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "== Configuration ==")
+	if cfgErr != nil {
+		fmt.Fprintf(&b, "Load error:    %s\n", redactHexIDs(cfgErr.Error()))
+	}
+	if err := reportCfg.Validate(); err != nil {
+		fmt.Fprintf(&b, "Validation:    %s\n", redactHexIDs(err.Error()))
+	} else {
+		fmt.Fprintln(&b, "Validation:    OK")
+	}
+	fmt.Fprintf(&b, "Primary key:   %s\n", redactID(reportCfg.PrimaryKeyID))
+	var keyservers []string
+	for _, server := range reportCfg.KeyserverList() {
+		keyservers = append(keyservers, redactKeyserver(server))
+	}
+	fmt.Fprintf(&b, "Keyserver:     %s\n", strings.Join(keyservers, ", "))
+	fmt.Fprintf(&b, "Master key path set: %t\n", reportCfg.MasterKeyPath != "")
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "== Keys ==")
+	if reportCfg.PrimaryKeyID == "" {
+		fmt.Fprintln(&b, "(skipped: no primary key configured)")
+	} else if keys, err := gpgSvc.ListSecretKeys(ctx, reportCfg.PrimaryKeyID); err != nil {
+		fmt.Fprintf(&b, "Error listing keys: %s\n", redactHexIDs(err.Error()))
+	} else if len(keys) == 0 {
+		fmt.Fprintln(&b, "(no secret keys found for primary key)")
+	} else {
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s %s [%s]", key.Type, redactID(key.KeyID), strings.Join(key.Capabilities, " "))
+			if key.Expires != "" {
+				fmt.Fprintf(&b, " expires: %s", key.Expires)
+			}
+			if key.CardNo != "" {
+				fmt.Fprintf(&b, " on-card: %s", redactID(key.CardNo))
+			}
+			fmt.Fprintln(&b)
+		}
+	}
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "== YubiKey ==")
+	present, err := yubikeySvc.IsPresent(ctx)
+	switch {
+	case err != nil:
+		fmt.Fprintf(&b, "Detection error: %s\n", redactHexIDs(err.Error()))
+	case !present:
+		fmt.Fprintln(&b, "No YubiKey detected")
+	default:
+		cardInfo, err := yubikeySvc.GetCardInfo(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "Card info error: %s\n", redactHexIDs(err.Error()))
+			break
+		}
+		fmt.Fprintf(&b, "Serial:         %s\n", redactID(cardInfo.Serial))
+		fmt.Fprintf(&b, "Key attributes: %s\n", strings.Join(cardInfo.KeyAttributeList(), " "))
+		for _, slot := range []string{"Signature", "Encryption", "Authentication"} {
+			_, populated := cardInfo.Keys[slot]
+			fmt.Fprintf(&b, "%-15s %t\n", slot+" slot:", populated)
+		}
+	}
+
+	// Paths in doctor details and error messages usually name the user
+	home, _ := os.UserHomeDir()
+	return redactUser(b.String(), home, os.Getenv("USER"))
+}
+
+// probeVersion runs a version command and returns the first line of its output.
+func probeVersion(ctx context.Context, exec executor.Executor, name string, args ...string) string {
+	output, err := exec.Run(ctx, name, args...)
+	if err != nil {
+		return "not available"
+	}
+	line := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	if line == "" {
+		return "unknown"
+	}
+	return line
+}
+
+// probeScdaemonVersion locates scdaemon via gpgconf and reports its version.
+// scdaemon usually lives in a libexec directory that isn't on $PATH.
+func probeScdaemonVersion(ctx context.Context, exec executor.Executor) string {
+	output, err := exec.Run(ctx, "gpgconf", "--list-components")
+	if err != nil {
+		return "not available"
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		// Format: scdaemon:Smartcard Daemon:/usr/lib/gnupg/scdaemon
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) >= 3 && fields[0] == "scdaemon" {
+			return probeVersion(ctx, exec, fields[2], "--version")
+		}
+	}
+	return "not available"
+}
+
+// hexIDPattern matches key IDs, fingerprints and serials embedded in text.
+var hexIDPattern = regexp.MustCompile(`[0-9A-Fa-f]{8,}`)
+
+// redactHexIDs masks every key ID, fingerprint or serial in a message, such
+// as a validation error that quotes the configured key ID.
+func redactHexIDs(s string) string {
+	return hexIDPattern.ReplaceAllStringFunc(s, redactID)
+}
+
+// redactUser replaces the home directory in s with "~" and the user name
+// with "<user>". A user name shorter than 3 characters is left alone, since
+// it would also match unrelated text.
+func redactUser(s, home, username string) string {
+	if home != "" && home != "/" {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	if len(username) >= 3 {
+		s = strings.ReplaceAll(s, username, "<user>")
+	}
+	return s
+}
+
+// publicKeyservers are the keyserver hosts shown in full in reports. Any
+// other host may name a private server and is redacted.
+var publicKeyservers = []string{"keys.openpgp.org", "keyserver.ubuntu.com", "pgp.mit.edu", "keys.mailvelope.com"}
+
+// redactKeyserver returns the scheme and host of a keyserver URL, with the
+// host replaced by "(custom)" unless it is one of publicKeyservers. Any
+// credentials or path in the URL are dropped.
+func redactKeyserver(server string) string {
+	u, err := url.Parse(strings.TrimSpace(server))
+	if err != nil || u.Host == "" {
+		return "(custom)"
+	}
+	if slices.Contains(publicKeyservers, strings.ToLower(u.Hostname())) {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://(custom)"
+}
+
+// redactID masks all but the last four characters of an identifier such as a
+// key ID or card serial, so reports stay useful without exposing it in full.
+func redactID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "(not set)"
+	}
+	if len(id) <= 4 {
+		return strings.Repeat("*", len(id))
+	}
+	return strings.Repeat("*", len(id)-4) + id[len(id)-4:]
+}
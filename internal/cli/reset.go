@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// resetConfirmation is the text that must be typed to confirm a reset when
+// the card's serial can't be read.
+const resetConfirmation = "RESET"
+
+func newResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Reset the YubiKey's OpenPGP applet (DESTROYS all keys on the card)",
+		Long: `Reset the OpenPGP applet of the connected YubiKey using 'ykman openpgp reset'.
+
+This permanently deletes the signature, encryption and authentication keys
+stored on the card and restores the default PINs (User 123456, Admin 12345678).
+Keys that exist only on the card cannot be recovered.
+
+The reset is refused if keys are found on the card, or if the card can't be
+read to check, unless --force-with-keys is given. Requires ykman.`,
+		RunE: runReset,
+	}
+	// Skip PersistentPreRunE validation for reset command
+	// Resetting a card doesn't require a configured primary key, only ykman
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return requireYkman()
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompts")
+	cmd.Flags().Bool("force-with-keys", false, "Reset even if keys are present on the card")
+
+	return cmd
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+	forceWithKeys, _ := cmd.Flags().GetBool("force-with-keys")
+
+	_, yubikeySvc, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Reset YubiKey OpenPGP Applet")
+
+	// A card in a bad state may fail IsPresent with an error; that is
+	// exactly when a reset is needed, so only a clean "not present" stops us.
+	present, err := yubikeySvc.IsPresent(ctx)
+	if err == nil && !present {
+		ui.LogError("No YubiKey detected. Please insert a YubiKey and try again.")
+		return fmt.Errorf("no YubiKey detected")
+	}
+	if err != nil {
+		ui.LogWarning("%v", err)
+	}
+
+	supported, err := yubikeySvc.SupportsOpenPGP(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("this YubiKey does not support OpenPGP; nothing to reset")
+	}
+
+	cardInfo, readErr := yubikeySvc.GetCardInfo(ctx)
+	if readErr != nil {
+		ui.LogWarning("Could not read the card to check for keys: %v", readErr)
+	} else {
+		if cardInfo.Serial != "" {
+			ui.PrintKeyValue("Serial", cardInfo.Serial)
+		}
+		for _, slot := range populatedCardSlots(cardInfo) {
+			ui.PrintKeyValue(slot+" key", cardInfo.Keys[slot])
+		}
+	}
+	if err := checkResetAllowed(cardInfo, readErr, forceWithKeys); err != nil {
+		return err
+	}
+	if readErr != nil {
+		ui.LogWarning("Any keys on the card will be PERMANENTLY DELETED")
+	} else if len(populatedCardSlots(cardInfo)) > 0 {
+		fmt.Println()
+		ui.LogWarning("The keys above will be PERMANENTLY DELETED")
+	}
+
+	fmt.Println()
+	ui.LogWarning("This erases all OpenPGP keys on the card and resets the PINs to their defaults.")
+	if !yes && !ui.ConfirmMatch("Reset the OpenPGP applet on this YubiKey?", resetConfirmationText(cardInfo)) {
+		ui.LogInfo("Reset cancelled")
+		return nil
+	}
+
+	if err := yubikeySvc.ResetOpenPGP(ctx); err != nil {
+		return err
+	}
+
+	ui.LogSuccess("OpenPGP applet reset")
+	fmt.Println()
+	fmt.Println("Default PINs are now: User 123456, Admin 12345678")
+	fmt.Println("Next: run 'ykgpg init' to set new PINs and key attributes.")
+
+	return nil
+}
+
+// checkResetAllowed refuses a reset of a card that holds keys, or that
+// couldn't be read (readErr isn't nil) and so may hold keys, unless
+// forceWithKeys is set.
+func checkResetAllowed(cardInfo *gpg.CardInfo, readErr error, forceWithKeys bool) error {
+	if forceWithKeys {
+		return nil
+	}
+	if readErr != nil {
+		return fmt.Errorf("refusing to reset a card that couldn't be checked for keys; pass --force-with-keys to reset it anyway")
+	}
+	if slots := populatedCardSlots(cardInfo); len(slots) > 0 {
+		ui.LogError("The card holds keys in: %s", strings.Join(slots, ", "))
+		return fmt.Errorf("refusing to reset a card with keys on it; pass --force-with-keys to erase them")
+	}
+	return nil
+}
+
+// resetConfirmationText returns the text that must be typed to confirm a
+// reset: the card's serial, so the wrong YubiKey isn't reset by mistake, or
+// resetConfirmation if it is unknown.
+func resetConfirmationText(cardInfo *gpg.CardInfo) string {
+	if cardInfo == nil || cardInfo.Serial == "" {
+		return resetConfirmation
+	}
+	return cardInfo.Serial
+}
+
+// populatedCardSlots returns the key slots on the card that hold a key.
+func populatedCardSlots(cardInfo *gpg.CardInfo) []string {
+	var slots []string
+	for slot := 1; slot <= len(gpg.CardSlots); slot++ {
+		name := gpg.CardSlots[slot]
+		if key := cardInfo.Keys[name]; key != "" && key != "[none]" {
+			slots = append(slots, name)
+		}
+	}
+	return slots
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResetCmd(t *testing.T) {
+	cmd := newResetCmd()
+	assert.Equal(t, "reset", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("yes"))
+	assert.NotNil(t, cmd.Flags().Lookup("force-with-keys"))
+}
+
+func TestPopulatedCardSlots(t *testing.T) {
+	assert.Empty(t, populatedCardSlots(&gpg.CardInfo{Keys: map[string]string{}}))
+
+	cardInfo := &gpg.CardInfo{Keys: map[string]string{
+		"Authentication": "AAAA BBBB",
+		"Signature":      "CCCC DDDD",
+		"General":        "sub ed25519/DEADBEEF",
+	}}
+	assert.Equal(t, []string{"Signature", "Authentication"}, populatedCardSlots(cardInfo))
+}
+
+func TestCheckResetAllowed(t *testing.T) {
+	empty := &gpg.CardInfo{Keys: map[string]string{"Signature": "[none]"}}
+	withKeys := &gpg.CardInfo{Keys: map[string]string{"Signature": "CCCC DDDD"}}
+	readErr := fmt.Errorf("card error")
+
+	assert.NoError(t, checkResetAllowed(empty, nil, false))
+	assert.ErrorContains(t, checkResetAllowed(withKeys, nil, false), "card with keys on it")
+	assert.ErrorContains(t, checkResetAllowed(nil, readErr, false), "couldn't be checked for keys", "an unreadable card may hold keys")
+
+	assert.NoError(t, checkResetAllowed(withKeys, nil, true))
+	assert.NoError(t, checkResetAllowed(nil, readErr, true))
+}
+
+func TestResetConfirmationText(t *testing.T) {
+	assert.Equal(t, "12345678", resetConfirmationText(&gpg.CardInfo{Serial: "12345678"}))
+	assert.Equal(t, resetConfirmation, resetConfirmationText(&gpg.CardInfo{}))
+	assert.Equal(t, resetConfirmation, resetConfirmationText(nil))
+}
@@ -2,26 +2,34 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
-	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
 func newRevokeCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "revoke",
 		Short: "Revoke a subkey (for lost/compromised YubiKeys)",
 		Long: `Revoke a signing subkey, typically because a YubiKey was lost or compromised.
 This action CANNOT be undone!`,
 		RunE: runRevoke,
 	}
+	addBackupFlags(cmd)
+
+	return cmd
 }
 
-func runRevoke(cmd *cobra.Command, args []string) error {
+func runRevoke(cmd *cobra.Command, args []string) (err error) {
+	if err := requireInteractive("revoke", ""); err != nil {
+		return err
+	}
 	gpgSvc, _, backupSvc := getServices()
 	ctx := cmd.Context()
+	op := newJournalOp("revoke")
+	defer func() { op.finish(err) }()
 
 	ui.PrintHeader("Revoke Subkey (Lost/Compromised)")
 
@@ -37,16 +45,26 @@ func runRevoke(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
 
+	var revoked []string
 	for _, key := range keys {
-		if contains(key.Capabilities, "S") {
-			fmt.Printf("  %s %s", key.Type, key.KeyID)
-			if key.CardNo != "" {
-				fmt.Printf(" card-no: %s", key.CardNo)
-			}
-			fmt.Println()
+		if !contains(key.Capabilities, "S") {
+			continue
+		}
+		if key.Revoked {
+			revoked = append(revoked, key.KeyID)
+			continue
 		}
+		fmt.Printf("  %s %s", key.Type, key.KeyID)
+		if key.CardNo != "" {
+			fmt.Printf(" card-no: %s", key.CardNo)
+		}
+		fmt.Println()
 	}
 	fmt.Println()
+	if len(revoked) > 0 {
+		ui.LogInfo("Already revoked: %s", strings.Join(revoked, ", "))
+		fmt.Println()
+	}
 
 	fmt.Println("Identify the subkey to revoke by its key ID (the hex string after ed25519/).")
 	fmt.Println("If the YubiKey is lost, you can identify it by the card serial number.")
@@ -61,24 +79,28 @@ func runRevoke(cmd *cobra.Command, args []string) error {
 	}
 
 	// Verify key exists
-	found := false
-	for _, key := range keys {
-		if key.KeyID == keyToRevoke || key.Fingerprint == keyToRevoke {
-			found = true
+	var found *gpg.Key
+	for i := range keys {
+		if keys[i].KeyID == keyToRevoke || keys[i].Fingerprint == keyToRevoke {
+			found = &keys[i]
 			break
 		}
 	}
 
-	if !found {
+	if found == nil {
 		return fmt.Errorf("key ID not found: %s", keyToRevoke)
 	}
+	if found.Revoked {
+		return fmt.Errorf("key %s is already revoked", keyToRevoke)
+	}
 
-	if !ui.Confirm(fmt.Sprintf("Are you SURE you want to revoke key %s? This cannot be undone!", keyToRevoke)) {
+	if !ui.ConfirmMatch(fmt.Sprintf("Are you SURE you want to revoke key %s? This cannot be undone!", keyToRevoke), keyToRevoke) {
+		ui.LogInfo("Revocation cancelled")
 		return nil
 	}
 
 	// Create backup
-	backupPath, err := backupSvc.CreateBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir)
+	backupPath, err := createBackup(ctx, backupSvc)
 	if err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
@@ -93,18 +115,10 @@ func runRevoke(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if _, err := os.Stat(masterKeyPath); err != nil {
-		return fmt.Errorf("master key file not found: %w", err)
-	}
-
 	// Import master key
-	ui.LogInfo("Importing master key...")
-	exec := executor.NewRealExecutor()
-	_, err = exec.Run(ctx, "gpg", "--import", masterKeyPath)
-	if err != nil {
-		return fmt.Errorf("failed to import master key: %w", err)
+	if _, err := importMasterKey(ctx, gpgSvc, masterKeyPath); err != nil {
+		return err
 	}
-	ui.LogSuccess("Master key imported")
 
 	// Interactive revocation
 	fmt.Println()
@@ -125,6 +139,9 @@ func runRevoke(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	op.start()
+	op.serial = found.CardNo
+	op.addKeys(found.KeyID)
 	if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
 		return fmt.Errorf("failed to edit key: %w", err)
 	}
@@ -136,16 +153,7 @@ func runRevoke(cmd *cobra.Command, args []string) error {
 
 	// Upload revocation
 	ui.LogWarning("IMPORTANT: You must upload the updated key to propagate the revocation!")
-	if ui.Confirm(fmt.Sprintf("Upload updated public key to %s?", cfg.Keyserver)) {
-		ui.LogInfo("Uploading to keyserver...")
-		_, err := exec.Run(ctx, "gpg", "--keyserver", cfg.Keyserver, "--send-keys", cfg.PrimaryKeyID)
-		if err != nil {
-			ui.LogWarning("Failed to upload to keyserver: %v", err)
-			ui.LogWarning("Visit https://keys.openpgp.org/upload to upload manually.")
-		} else {
-			ui.LogSuccess("Public key uploaded to %s", cfg.Keyserver)
-		}
-	}
+	uploadPublicKey(ctx, gpgSvc)
 
 	fmt.Println()
 	ui.LogSuccess("Subkey revoked. The revocation has been published.")
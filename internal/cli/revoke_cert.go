@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newRevokeCertCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke-cert",
+		Short: "Generate and save a revocation certificate for the primary key",
+		Long: `Generate a revocation certificate for the primary key and save it to
+<backup-dir>/revocation-<keyid>.asc with 0600 permissions.
+
+Anyone holding this file can revoke your key. Store it offline (e.g. printed
+or on an encrypted USB drive), separately from your master key backup.
+The master key must be available in the keyring to generate it.`,
+		RunE: runRevokeCert,
+	}
+}
+
+func runRevokeCert(cmd *cobra.Command, args []string) error {
+	gpgSvc, _, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Generate Revocation Certificate")
+
+	outputFile := revocationCertPath(cfg.BackupDir, cfg.PrimaryKeyID)
+	if _, err := os.Stat(outputFile); err == nil {
+		ui.LogWarning("A revocation certificate already exists at %s", outputFile)
+		if !ui.Confirm("Overwrite it?") {
+			return nil
+		}
+	}
+
+	ui.LogInfo("Generating revocation certificate for %s...", cfg.PrimaryKeyID)
+	ui.LogInfo("You will be asked for your key passphrase.")
+	cert, err := gpgSvc.GenerateRevocationCertificate(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return err
+	}
+	if len(cert) == 0 {
+		if dryRun {
+			return nil
+		}
+		return fmt.Errorf("gpg did not produce a revocation certificate")
+	}
+
+	if err := os.MkdirAll(cfg.BackupDir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(outputFile, cert, 0600); err != nil {
+		return fmt.Errorf("failed to write revocation certificate: %w", err)
+	}
+
+	ui.LogSuccess("Revocation certificate saved to: %s", outputFile)
+	fmt.Println()
+	ui.LogWarning("IMPORTANT: Anyone with this file can REVOKE your key!")
+	ui.LogWarning("Move it to offline storage (printed copy or encrypted USB drive)")
+	ui.LogWarning("and delete it from this machine once it is stored safely.")
+	fmt.Println()
+	fmt.Println("To use it if your master key is lost or compromised:")
+	fmt.Println("  gpg --import", outputFile)
+	for _, server := range cfg.KeyserverList() {
+		fmt.Println("  gpg --keyserver", server, "--send-keys", cfg.PrimaryKeyID)
+	}
+
+	return nil
+}
+
+// revocationCertPath returns the path the revocation certificate for keyID is saved to.
+func revocationCertPath(backupDir, keyID string) string {
+	return filepath.Join(backupDir, fmt.Sprintf("revocation-%s.asc", keyID))
+}
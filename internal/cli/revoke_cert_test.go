@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRevokeCertCmd(t *testing.T) {
+	cmd := newRevokeCertCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "revoke-cert", cmd.Use)
+}
+
+func TestRevocationCertPath(t *testing.T) {
+	path := revocationCertPath("/backups", "ABC123DEF4567890")
+	assert.Equal(t, filepath.Join("/backups", "revocation-ABC123DEF4567890.asc"), path)
+}
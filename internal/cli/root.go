@@ -1,7 +1,12 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/bobbydams/yubikey-manager/internal/backup"
 	"github.com/bobbydams/yubikey-manager/internal/config"
@@ -9,6 +14,7 @@ import (
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/internal/yubikey"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/bobbydams/yubikey-manager/pkg/ykgpg"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,11 +23,21 @@ var (
 	cfg     *config.Config
 	rootCmd *cobra.Command
 	version = "dev"
+	// dryRun is set by --dry-run; see newExecutor.
+	dryRun bool
+	// quiet and verbose are set by --quiet and --verbose; see applyLogLevel.
+	quiet   bool
+	verbose bool
+	// logFilePath is set by --log-file; see logCommands.
+	logFilePath string
+	// nonInteractive is set by --non-interactive; see applyNonInteractive.
+	nonInteractive bool
 )
 
-// Execute runs the CLI application.
+// Execute runs the CLI application. Its context is cancelled on Ctrl+C or
+// SIGTERM; see runInterruptible.
 func Execute() error {
-	return rootCmd.Execute()
+	return runInterruptible(context.Background(), rootCmd.ExecuteContext)
 }
 
 // SetVersion sets the version string (used by build process).
@@ -44,12 +60,22 @@ It provides commands for:
   - Managing key backups
   - Verifying setup`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Dynamic completions must work with an incomplete config;
+			// they load it leniently through toolConfig
+			if isCompletionRequest(cmd) {
+				return nil
+			}
+
 			// Check for no-color flag first (before loading config)
 			noColor, _ := cmd.Flags().GetBool("no-color")
 			if noColor {
 				ui.SetColorEnabled(false)
 			}
 
+			if dryRun {
+				ui.LogWarning("Dry run: commands that modify keys or cards will be printed, not executed")
+			}
+
 			// Load configuration
 			var err error
 			cfg, err = config.Load()
@@ -71,6 +97,11 @@ It provides commands for:
 				ui.SetColorEnabled(false)
 			}
 
+			// Fail early with install hints rather than deep inside a command
+			if err := requireGPG(); err != nil {
+				return err
+			}
+
 			// Validate required config
 			if err := cfg.Validate(); err != nil {
 				return fmt.Errorf("invalid configuration: %w", err)
@@ -81,28 +112,74 @@ It provides commands for:
 	}
 
 	// Global flags
+	rootCmd.PersistentFlags().String("config", "", "Config file to use instead of ~/.config/ykgpg/config.yaml (default: $YKGPG_CONFIG)")
+	rootCmd.PersistentFlags().String("profile", "", "Named profile from the config file to use")
 	rootCmd.PersistentFlags().String("key-id", "", "Primary key ID (overrides config)")
 	rootCmd.PersistentFlags().String("fingerprint", "", "Primary key fingerprint (overrides config)")
 	rootCmd.PersistentFlags().String("name", "", "User name (overrides config)")
 	rootCmd.PersistentFlags().String("email", "", "User email (overrides config)")
-	rootCmd.PersistentFlags().String("keyserver", "", "Keyserver URL (overrides config)")
+	rootCmd.PersistentFlags().String("keyserver", "", "Keyserver URL, or several comma-separated (overrides config)")
+	rootCmd.PersistentFlags().String("keyserver-proxy", "", "HTTP proxy for keyserver operations, e.g. http://proxy:3128 (overrides config)")
 	rootCmd.PersistentFlags().String("master-key-path", "", "Path to master key backup (overrides config)")
 	rootCmd.PersistentFlags().String("backup-dir", "", "Backup directory (overrides config)")
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print commands that would modify keys or cards instead of running them")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print warnings and errors")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print each external command before running it")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Never prompt: answer yes to confirmations and fail where input is needed")
+	rootCmd.PersistentFlags().StringVar(&logFilePath, "log-file", "", "Append a JSON line for every external command run (PINs and passphrases are redacted)")
+	rootCmd.PersistentFlags().String("gpg-binary", "", "gpg program to run (overrides config, default \"gpg\")")
+	rootCmd.PersistentFlags().String("ykman-binary", "", "ykman program to run (overrides config, default \"ykman\")")
+	rootCmd.PersistentFlags().String("gnupg-home", "", "GnuPG home directory to use instead of $GNUPGHOME or ~/.gnupg")
+	// Bound here rather than in bindFlags so that commands which skip config
+	// validation (pin, touch, reset, doctor, ...) still honour them
+	_ = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
+	_ = viper.BindPFlag("gpg_binary", rootCmd.PersistentFlags().Lookup("gpg-binary"))
+	_ = viper.BindPFlag("ykman_binary", rootCmd.PersistentFlags().Lookup("ykman-binary"))
+	_ = viper.BindPFlag("gnupg_home", rootCmd.PersistentFlags().Lookup("gnupg-home"))
+	_ = viper.BindPFlag("keyserver_proxy", rootCmd.PersistentFlags().Lookup("keyserver-proxy"))
+	_ = rootCmd.RegisterFlagCompletionFunc("key-id", completeSecretKeyIDs)
+
+	// Replaced by newCompletionCmd, which doesn't need a valid config
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Applied via OnInitialize so it also covers commands that override PersistentPreRunE
+	cobra.OnInitialize(applyLogLevel, applyNonInteractive)
 
 	// Add subcommands
 	rootCmd.AddCommand(newStatusCmd())
 	rootCmd.AddCommand(newInitCmd())
 	rootCmd.AddCommand(newSetupCmd())
 	rootCmd.AddCommand(newSetupBatchCmd())
+	rootCmd.AddCommand(newSetupEncryptionCmd())
+	rootCmd.AddCommand(newSetupAuthCmd())
+	rootCmd.AddCommand(newSetupAutoCmd())
 	rootCmd.AddCommand(newMoveSubkeyCmd())
+	rootCmd.AddCommand(newListSubkeysCmd())
+	rootCmd.AddCommand(newLabelCmd())
 	rootCmd.AddCommand(newRevokeCmd())
+	rootCmd.AddCommand(newRevokeCertCmd())
 	rootCmd.AddCommand(newExtendCmd())
+	rootCmd.AddCommand(newJournalCmd())
+	rootCmd.AddCommand(newImportMasterCmd())
 	rootCmd.AddCommand(newCleanupCmd())
 	rootCmd.AddCommand(newMetadataCmd())
 	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newSSHExportCmd())
+	rootCmd.AddCommand(newRefreshCmd())
 	rootCmd.AddCommand(newVerifyCmd())
+	rootCmd.AddCommand(newTestSignCmd())
+	rootCmd.AddCommand(newAuditCmd())
+	rootCmd.AddCommand(newGitConfigCmd())
 	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newReportCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newPinCmd())
+	rootCmd.AddCommand(newResetCmd())
+	rootCmd.AddCommand(newTouchCmd())
+	rootCmd.AddCommand(newBackupCmd())
+	rootCmd.AddCommand(newCompletionCmd())
 
 	// Set version after command is created
 	rootCmd.Version = version
@@ -110,21 +187,154 @@ It provides commands for:
 
 // bindFlags binds Cobra flags to Viper.
 func bindFlags(cmd *cobra.Command) {
+	_ = viper.BindPFlag("profile", cmd.Flags().Lookup("profile"))
 	_ = viper.BindPFlag("primary_key_id", cmd.Flags().Lookup("key-id"))
 	_ = viper.BindPFlag("primary_key_fingerprint", cmd.Flags().Lookup("fingerprint"))
 	_ = viper.BindPFlag("user_name", cmd.Flags().Lookup("name"))
 	_ = viper.BindPFlag("user_email", cmd.Flags().Lookup("email"))
-	_ = viper.BindPFlag("keyserver", cmd.Flags().Lookup("keyserver"))
+	// --keyserver replaces the keyservers list, so one flag covers both config forms
+	_ = viper.BindPFlag("keyservers", cmd.Flags().Lookup("keyserver"))
 	_ = viper.BindPFlag("master_key_path", cmd.Flags().Lookup("master-key-path"))
 	_ = viper.BindPFlag("backup_dir", cmd.Flags().Lookup("backup-dir"))
 	_ = viper.BindPFlag("no_color", cmd.Flags().Lookup("no-color"))
+	// Only commands that create a backup have these; see addBackupFlags
+	if flag := cmd.Flags().Lookup("encrypt-backup"); flag != nil {
+		_ = viper.BindPFlag("encrypt_backup", flag)
+		_ = viper.BindPFlag("backup_include_subkeys", cmd.Flags().Lookup("include-subkeys"))
+	}
+}
+
+// applyLogLevel sets the ui log level from --quiet and --verbose.
+func applyLogLevel() {
+	switch {
+	case quiet:
+		ui.SetLogLevel(ui.LogLevelQuiet)
+	case verbose:
+		ui.SetLogLevel(ui.LogLevelVerbose)
+	default:
+		ui.SetLogLevel(ui.LogLevelNormal)
+	}
+}
+
+// applyNonInteractive puts the ui prompts in non-interactive mode for
+// --non-interactive.
+func applyNonInteractive() {
+	ui.SetNonInteractive(nonInteractive)
+}
+
+// newExecutor returns the executor used to run external commands.
+// Non-interactive commands are bounded by the configured gpg_timeout.
+// In --dry-run mode, commands that modify state are printed instead of run.
+func newExecutor() executor.Executor {
+	timeout := executor.DefaultTimeout
+	if cfg != nil {
+		timeout = cfg.GPGTimeout
+	}
+	exec := logCommands(newRealExecutor(timeout))
+	if dryRun {
+		exec = executor.NewDryRunExecutor(exec, os.Stdout)
+	}
+	return exec
+}
+
+// newRealExecutor returns a RealExecutor that prints each command in
+// --verbose mode and runs it with childEnv.
+func newRealExecutor(timeout time.Duration) *executor.RealExecutor {
+	realExec := executor.NewRealExecutorWithTimeout(timeout)
+	realExec.BeforeRun = func(name string, args []string) {
+		ui.LogDebug("Running: %s", executor.FormatCommand(name, executor.RedactArgs(args)))
+	}
+	realExec.Env = childEnv()
+	return realExec
+}
+
+var (
+	// commandLog is the --log-file, opened on first use by logCommands.
+	commandLog     *os.File
+	commandLogOnce sync.Once
+)
+
+// logCommands wraps exec so that every command is recorded in the --log-file,
+// if one was given. If the file can't be opened, a warning is printed once
+// and commands run unlogged.
+func logCommands(exec executor.Executor) executor.Executor {
+	if logFilePath == "" {
+		return exec
+	}
+	commandLogOnce.Do(func() {
+		f, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			ui.LogWarning("Failed to open log file: %v", err)
+			return
+		}
+		commandLog = f
+	})
+	if commandLog == nil {
+		return exec
+	}
+	return executor.NewLoggingExecutor(exec, commandLog)
+}
+
+// toolConfig returns the configuration that locates external programs.
+// Commands that skip validation leave cfg nil, so the config is loaded
+// leniently for them; a config that fails to load yields the defaults.
+func toolConfig() *config.Config {
+	if cfg != nil {
+		return cfg
+	}
+	if loaded, err := config.Load(); err == nil {
+		return loaded
+	}
+	return &config.Config{CardRetries: yubikey.DefaultCardRetries}
+}
+
+// gpgBinary returns the configured gpg program.
+func gpgBinary() string {
+	if binary := toolConfig().GPGBinary; binary != "" {
+		return binary
+	}
+	return gpg.DefaultBinary
+}
+
+// ykmanBinary returns the configured ykman program.
+func ykmanBinary() string {
+	if binary := toolConfig().YkmanBinary; binary != "" {
+		return binary
+	}
+	return yubikey.DefaultYkmanBinary
+}
+
+// childEnv returns the environment entries added to every external command,
+// such as GNUPGHOME when --gnupg-home is set.
+func childEnv() []string {
+	home := toolConfig().GnuPGHome
+	if home == "" {
+		return nil
+	}
+	// gpg resolves a relative home against its own working directory
+	if abs, err := filepath.Abs(home); err == nil {
+		home = abs
+	}
+	return []string{"GNUPGHOME=" + home}
 }
 
 // getServices creates and returns service instances.
 func getServices() (*gpg.Service, *yubikey.Service, *backup.Service) {
-	exec := executor.NewRealExecutor()
+	exec := newExecutor()
 	gpgSvc := gpg.NewService(exec)
+	gpgSvc.Binary = gpgBinary()
+	gpgSvc.KeyserverProxy = toolConfig().KeyserverProxy
 	yubikeySvc := yubikey.NewService(gpgSvc, exec)
+	yubikeySvc.GPGBinary = gpgSvc.Binary
+	yubikeySvc.YkmanBinary = ykmanBinary()
+	yubikeySvc.CardRetries = toolConfig().CardRetries
 	backupSvc := backup.NewService(gpgSvc)
+	backupSvc.IncludeSecretSubkeys = toolConfig().BackupIncludeSubkeys
 	return gpgSvc, yubikeySvc, backupSvc
 }
+
+// getManager returns a Manager that shares the given services, so it acts
+// on the YubiKey chosen by selectCard.
+func getManager(gpgSvc gpg.GPGService, yubikeySvc yubikey.YubiKeyService) *ykgpg.Manager {
+	return ykgpg.NewWithServices(cfg, newExecutor(), gpgSvc, yubikeySvc)
+}
@@ -1,12 +1,19 @@
 package cli
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/executor"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExecute(t *testing.T) {
@@ -51,10 +58,27 @@ func TestBindFlags(t *testing.T) {
 	cmd.Flags().String("backup-dir", "", "test")
 	cmd.Flags().Bool("no-color", false, "test")
 
-	// Test that bindFlags doesn't panic
+	// Test that bindFlags doesn't panic, with and without the backup flags
 	assert.NotPanics(t, func() {
 		bindFlags(cmd)
 	})
+	addBackupFlags(cmd)
+	assert.NotPanics(t, func() {
+		bindFlags(cmd)
+	})
+}
+
+func TestBackupFlags(t *testing.T) {
+	assert.Nil(t, rootCmd.PersistentFlags().Lookup("encrypt-backup"), "only commands that create a backup have --encrypt-backup")
+	assert.Nil(t, rootCmd.PersistentFlags().Lookup("include-subkeys"))
+
+	for _, cmd := range []*cobra.Command{newSetupCmd(), newSetupBatchCmd(), newSetupEncryptionCmd(), newSetupAuthCmd(), newSetupAutoCmd(), newExtendCmd(), newRevokeCmd()} {
+		assert.NotNil(t, cmd.Flags().Lookup("encrypt-backup"), cmd.Use)
+		assert.NotNil(t, cmd.Flags().Lookup("include-subkeys"), cmd.Use)
+	}
+	for _, cmd := range []*cobra.Command{newStatusCmd(), newVerifyCmd(), newMoveSubkeyCmd()} {
+		assert.Nil(t, cmd.Flags().Lookup("encrypt-backup"), cmd.Use)
+	}
 }
 
 func TestGetServices(t *testing.T) {
@@ -66,6 +90,66 @@ func TestGetServices(t *testing.T) {
 	assert.NotNil(t, backupSvc)
 }
 
+func TestNewExecutor(t *testing.T) {
+	oldDryRun := dryRun
+	defer func() { dryRun = oldDryRun }()
+
+	dryRun = false
+	assert.IsType(t, &executor.RealExecutor{}, newExecutor())
+
+	dryRun = true
+	assert.IsType(t, &executor.DryRunExecutor{}, newExecutor())
+	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("dry-run"))
+}
+
+func TestChildEnv(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+
+	cfg = &config.Config{}
+	assert.Empty(t, childEnv())
+
+	cfg = &config.Config{GnuPGHome: "/tmp/isolated-gnupg"}
+	assert.Equal(t, []string{"GNUPGHOME=/tmp/isolated-gnupg"}, childEnv())
+
+	cfg = &config.Config{GnuPGHome: "relative-home"}
+	env := childEnv()
+	if assert.Len(t, env, 1) {
+		assert.True(t, filepath.IsAbs(strings.TrimPrefix(env[0], "GNUPGHOME=")))
+	}
+
+	realExec, ok := newExecutor().(*executor.RealExecutor)
+	if assert.True(t, ok) {
+		assert.Equal(t, env, realExec.Env)
+	}
+}
+
+func TestLogCommands(t *testing.T) {
+	oldPath, oldLog := logFilePath, commandLog
+	defer func() {
+		logFilePath, commandLog = oldPath, oldLog
+		commandLogOnce = sync.Once{}
+	}()
+
+	mock := executor.NewMockExecutor()
+
+	logFilePath = ""
+	assert.Same(t, mock, logCommands(mock))
+
+	logFilePath = filepath.Join(t.TempDir(), "ykgpg.log")
+	commandLog = nil
+	commandLogOnce = sync.Once{}
+	exec := logCommands(mock)
+	_, err := exec.Run(context.Background(), "ykman", "openpgp", "access", "change-pin", "--pin", "123456", "--new-pin", "654321")
+	require.NoError(t, err)
+	require.NoError(t, commandLog.Close())
+
+	data, err := os.ReadFile(logFilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"command":"ykman"`)
+	assert.NotContains(t, string(data), "123456")
+}
+
 func TestRootCmdInitialization(t *testing.T) {
 	// Test that rootCmd is initialized
 	assert.NotNil(t, rootCmd)
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// Environment variables secrets are read from when the matching --*-file
+// flag isn't given. Secrets are never accepted as command-line values,
+// which would leak into shell history and the process list.
+const (
+	adminPINEnv         = "YKGPG_ADMIN_PIN"
+	passphraseEnv       = "YKGPG_PASSPHRASE"
+	backupPassphraseEnv = "YKGPG_BACKUP_PASSPHRASE"
+)
+
+// addSecretFileFlags adds the --admin-pin-file and --passphrase-file flags
+// read by readSecret.
+func addSecretFileFlags(cmd *cobra.Command) {
+	cmd.Flags().String("admin-pin-file", "", "File containing the YubiKey Admin PIN (default: $"+adminPINEnv+")")
+	cmd.Flags().String("passphrase-file", "", "File containing the GPG key passphrase (default: $"+passphraseEnv+")")
+}
+
+// readSecret returns the secret from the file named by the given flag,
+// without its trailing newline, or else from envVar. ok is false if neither
+// was provided. A file other users can read is rejected.
+func readSecret(cmd *cobra.Command, flag, envVar string) (secret string, ok bool, err error) {
+	path, _ := cmd.Flags().GetString(flag)
+	if path == "" {
+		secret, ok = os.LookupEnv(envVar)
+		return secret, ok, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read --%s: %w", flag, err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o004 != 0 {
+		return "", false, fmt.Errorf("%s is readable by all users; restrict it with 'chmod 600 %s'", path, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read --%s: %w", flag, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// secretOrPrompt returns the secret from readSecret, prompting for it when
+// it wasn't provided. A required secret can't be empty.
+func secretOrPrompt(cmd *cobra.Command, flag, envVar, prompt string, required bool) (string, error) {
+	secret, ok, err := readSecret(cmd, flag, envVar)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		if required && secret == "" {
+			return "", fmt.Errorf("the secret from --%s or $%s is empty", flag, envVar)
+		}
+		return secret, nil
+	}
+
+	if required {
+		return ui.PromptSecretRequired(prompt)
+	}
+	return ui.PromptSecret(prompt)
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSecret(t *testing.T) {
+	cmd := newSetupAutoCmd()
+
+	t.Setenv(adminPINEnv, "87654321")
+	pin, ok, err := readSecret(cmd, "admin-pin-file", adminPINEnv)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "87654321", pin, "falls back to the environment")
+
+	path := filepath.Join(t.TempDir(), "admin-pin")
+	require.NoError(t, os.WriteFile(path, []byte("12345678\n"), 0600))
+	require.NoError(t, cmd.Flags().Set("admin-pin-file", path))
+	pin, ok, err = readSecret(cmd, "admin-pin-file", adminPINEnv)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "12345678", pin, "the file wins and its newline is trimmed")
+
+	require.NoError(t, cmd.Flags().Set("admin-pin-file", filepath.Join(t.TempDir(), "missing")))
+	_, _, err = readSecret(cmd, "admin-pin-file", adminPINEnv)
+	assert.Error(t, err)
+}
+
+func TestReadSecret_NotProvided(t *testing.T) {
+	cmd := newSetupAutoCmd()
+	t.Setenv(passphraseEnv, "")
+	os.Unsetenv(passphraseEnv)
+
+	secret, ok, err := readSecret(cmd, "passphrase-file", passphraseEnv)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, secret)
+}
+
+func TestReadSecret_WorldReadable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	cmd := newSetupAutoCmd()
+	path := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(path, []byte("secret\n"), 0600))
+	require.NoError(t, os.Chmod(path, 0644))
+	require.NoError(t, cmd.Flags().Set("passphrase-file", path))
+
+	_, _, err := readSecret(cmd, "passphrase-file", passphraseEnv)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chmod 600")
+}
+
+func TestSecretOrPrompt_EmptyRequired(t *testing.T) {
+	cmd := newMoveSubkeyCmd()
+	t.Setenv(adminPINEnv, "")
+
+	_, err := secretOrPrompt(cmd, "admin-pin-file", adminPINEnv, "Admin PIN: ", true)
+	assert.Error(t, err)
+}
@@ -2,27 +2,34 @@ package cli
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
-	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
 func newSetupCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "setup",
 		Short: "Add a signing subkey to a new YubiKey (interactive)",
 		Long: `Setup a new YubiKey with a signing subkey. This command guides you through
 the interactive process of generating a new subkey and moving it to your YubiKey.`,
 		RunE: runSetup,
 	}
+	addBackupFlags(cmd)
+
+	return cmd
 }
 
-func runSetup(cmd *cobra.Command, args []string) error {
+func runSetup(cmd *cobra.Command, args []string) (err error) {
+	if err := requireInteractive("setup", "use 'ykgpg setup-auto' instead"); err != nil {
+		return err
+	}
 	gpgSvc, yubikeySvc, backupSvc := getServices()
 	ctx := cmd.Context()
+	op := newJournalOp("setup")
+	defer func() { op.finish(err) }()
 
 	ui.PrintHeader("Setup New YubiKey for Signing")
 
@@ -74,6 +81,10 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	}
 
 	ui.LogInfo("Detected YubiKey with serial: %s", cardInfo.Serial)
+	op.serial = cardInfo.Serial
+	if warnIfNoEd25519(cardInfo) && !ui.Confirm("Continue anyway? (only create an RSA subkey for this YubiKey)") {
+		return nil
+	}
 
 	// Check if YubiKey already has a signing key
 	if sigKey, ok := cardInfo.Keys["Signature"]; ok && sigKey != "" && sigKey != "[none]" {
@@ -85,7 +96,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 
 	// Create backup
 	ui.LogInfo("Creating backup before making changes...")
-	backupPath, err := backupSvc.CreateBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir)
+	backupPath, err := createBackup(ctx, backupSvc)
 	if err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
@@ -107,19 +118,13 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if _, err := os.Stat(masterKeyPath); err != nil {
-		return fmt.Errorf("master key file not found: %w", err)
+	// Confirm this is the right backup, then import it
+	if err := confirmMasterKeyFile(ctx, gpgSvc, masterKeyPath, false); err != nil {
+		return err
 	}
-
-	// Import master key
-	ui.LogInfo("Importing master key...")
-	exec := executor.NewRealExecutor()
-	// Import using gpg
-	_, err = exec.Run(ctx, "gpg", "--import", masterKeyPath)
-	if err != nil {
-		return fmt.Errorf("failed to import master key: %w", err)
+	if _, err := importMasterKey(ctx, gpgSvc, masterKeyPath); err != nil {
+		return err
 	}
-	ui.LogSuccess("Master key imported")
 
 	// Verify master key is available
 	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
@@ -127,13 +132,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list keys: %w", err)
 	}
 
-	hasMaster := false
-	for _, key := range keys {
-		if key.Type == "sec" && key.KeyID == cfg.PrimaryKeyID {
-			hasMaster = true
-			break
-		}
-	}
+	_, hasMaster := gpg.MasterKeyOnMachine(keys, cfg.PrimaryKeyID)
 
 	if !hasMaster {
 		return fmt.Errorf("master key still shows as unavailable. Import may have failed")
@@ -149,7 +148,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	fmt.Println("2. At the gpg> prompt, type: addkey")
 	fmt.Println("3. Select: (10) ECC (sign only)")
 	fmt.Println("4. Select: (1) Curve 25519")
-	fmt.Println("5. For expiration, enter: 5y")
+	fmt.Println("5. For expiration, enter:", subkeyExpiry(cmd))
 	fmt.Println("6. Confirm the creation")
 	fmt.Println("7. Type: save")
 	fmt.Println()
@@ -167,9 +166,11 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	}
 	// Empty response means continue
 
+	op.start()
 	if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
 		return fmt.Errorf("failed to edit key: %w", err)
 	}
+	op.addKeys(newSubkeyID(ctx, gpgSvc, "S"))
 
 	// Move subkey to YubiKey
 	fmt.Println()
@@ -186,18 +187,18 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 	ui.LogInfo("Now we'll move the new subkey to your YubiKey.")
+	selectStep := selectSubkeyStep(ctx, gpgSvc)
 	fmt.Println()
 	fmt.Println("Steps to move the subkey to YubiKey:")
 	fmt.Println()
 	fmt.Println("1. Run: gpg --edit-key", cfg.PrimaryKeyID)
 	fmt.Println("2. Type: list (to see all subkeys with numbers)")
-	fmt.Println("3. Identify the NEW signing subkey (the one without a card-no)")
-	fmt.Println("4. Type: key N (where N is the number of the new subkey)")
-	fmt.Println("5. Type: keytocard")
-	fmt.Println("6. Select: (1) Signature key")
-	fmt.Println("7. Enter your GPG key PASSPHRASE when prompted")
-	fmt.Println("8. Enter your YubiKey ADMIN PIN when prompted (default: 12345678)")
-	fmt.Println("9. Type: save")
+	fmt.Println("3.", selectStep)
+	fmt.Println("4. Type: keytocard")
+	fmt.Println("5. Select: (1) Signature key")
+	fmt.Println("6. Enter your GPG key PASSPHRASE when prompted")
+	fmt.Println("7. Enter your YubiKey ADMIN PIN when prompted (default: 12345678)")
+	fmt.Println("8. Type: save")
 	fmt.Println()
 	ui.LogWarning("If 'save' says 'Key not changed', the Admin PIN was likely incorrect.")
 	fmt.Println()
@@ -213,7 +214,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 
 	// Clean up master key
 	fmt.Println()
-	if ui.Confirm("Remove master key from local machine?") {
+	if confirmRemoveMasterKey() {
 		if err := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); err != nil {
 			ui.LogWarning("Failed to remove master key: %v", err)
 		} else {
@@ -224,17 +225,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	}
 
 	// Upload to keyserver
-	if ui.Confirm(fmt.Sprintf("Upload updated public key to %s?", cfg.Keyserver)) {
-		ui.LogInfo("Uploading to keyserver...")
-		exec := executor.NewRealExecutor()
-		_, err := exec.Run(ctx, "gpg", "--keyserver", cfg.Keyserver, "--send-keys", cfg.PrimaryKeyID)
-		if err != nil {
-			ui.LogWarning("Failed to upload to keyserver: %v", err)
-			ui.LogWarning("Visit https://keys.openpgp.org/upload to upload manually.")
-		} else {
-			ui.LogSuccess("Public key uploaded to %s", cfg.Keyserver)
-		}
-	}
+	uploadPublicKey(ctx, gpgSvc)
 
 	fmt.Println()
 	ui.LogSuccess("YubiKey setup complete!")
@@ -242,6 +233,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	fmt.Println("Next steps:")
 	fmt.Println("  1. Label this YubiKey physically (e.g., 'Key B - " + cardInfo.Serial + "')")
+	fmt.Println("     and record the label: ykgpg label " + cardInfo.Serial + " \"Key B\"")
 	fmt.Println("  2. Test signing: echo 'test' | gpg --sign --armor")
 	fmt.Println("  3. Register this YubiKey with GitHub/GitLab if not already done")
 	fmt.Println()
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// authSubkeySetup is the subkey created by setup-auth.
+var authSubkeySetup = subkeySetup{
+	Title:      "Setup Authentication Subkey (Automated Mode)",
+	Algorithm:  "ed25519",
+	Usage:      "auth",
+	Capability: "A",
+	Slot:       3,
+}
+
+func newSetupAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup-auth",
+		Short: "Add an SSH authentication subkey to a YubiKey (semi-automated)",
+		Long: `Create a new ed25519 authentication subkey and move it to the YubiKey's
+Authentication slot, for use with SSH through gpg-agent. Like setup-batch,
+the subkey is created automatically but moving it to the YubiKey requires
+interaction.
+
+Afterwards, run 'ykgpg ssh-export' to get the OpenSSH public key.`,
+		RunE: runSetupAuth,
+	}
+	addExpiresFlag(cmd)
+	addBackupFlags(cmd)
+
+	return cmd
+}
+
+func runSetupAuth(cmd *cobra.Command, args []string) error {
+	return runSubkeySetup(cmd, authSubkeySetup)
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/internal/backup"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newSetupAutoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup-auto",
+		Short: "Add a signing subkey to a new YubiKey without any prompts",
+		Long: `Create a new signing subkey and move it to the YubiKey's Signature slot
+without any interaction, for provisioning many YubiKeys from a script.
+
+The subkey is created with gpg --quick-add-key and moved with a scripted
+keytocard. Secrets are never taken on the command line; each one is read
+from a file or, if no file is given, an environment variable:
+
+  Admin PIN (required)         --admin-pin-file          $YKGPG_ADMIN_PIN
+  Master key passphrase        --passphrase-file         $YKGPG_PASSPHRASE
+  Backup passphrase            --backup-passphrase-file  $YKGPG_BACKUP_PASSPHRASE
+
+The backup passphrase is only needed when encrypt_backup is enabled, and
+master_key_path must be set in the config. The master key is removed from
+the keyring afterwards unless --keep-master is given.
+
+Use 'ykgpg setup' or 'ykgpg setup-batch' to be guided through the same
+steps interactively.`,
+		RunE: runSetupAuto,
+	}
+	addExpiresFlag(cmd)
+	addBackupFlags(cmd)
+	addSecretFileFlags(cmd)
+	cmd.Flags().String("backup-passphrase-file", "", "File containing the backup encryption passphrase (default: $"+backupPassphraseEnv+")")
+	cmd.Flags().Bool("force", false, "Replace a key already in the YubiKey's Signature slot")
+	cmd.Flags().Bool("keep-master", false, "Leave the master key in the local keyring afterwards")
+	cmd.Flags().Bool("upload", false, "Upload the updated public key to the configured keyservers")
+
+	return cmd
+}
+
+func runSetupAuto(cmd *cobra.Command, args []string) (err error) {
+	spec := signingSubkeySetup
+	spec.Title = "Setup New YubiKey (Non-Interactive)"
+	slotName := gpg.CardSlots[spec.Slot]
+	keyName := gpg.CapabilityNames[spec.Capability]
+
+	adminPIN, _, err := readSecret(cmd, "admin-pin-file", adminPINEnv)
+	if err != nil {
+		return err
+	}
+	if adminPIN == "" {
+		return fmt.Errorf("admin PIN required: pass --admin-pin-file or set %s", adminPINEnv)
+	}
+	passphrase, _, err := readSecret(cmd, "passphrase-file", passphraseEnv)
+	if err != nil {
+		return err
+	}
+	if cfg.MasterKeyPath == "" {
+		return fmt.Errorf("master_key_path must be set in the config for setup-auto")
+	}
+
+	expiryDate, err := gpg.ParseExpiry(subkeyExpiry(cmd))
+	if err != nil {
+		return err
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	keepMaster, _ := cmd.Flags().GetBool("keep-master")
+	upload, _ := cmd.Flags().GetBool("upload")
+
+	gpgSvc, yubikeySvc, backupSvc := getServices()
+	ctx := cmd.Context()
+	op := newJournalOp("setup-auto")
+	defer func() { op.finish(err) }()
+
+	ui.PrintHeader(spec.Title)
+
+	if err := requireGPGVersion(ctx, gpgSvc, minQuickAddKeyVersion, "creating the subkey with --quick-add-key"); err != nil {
+		return err
+	}
+
+	// Check YubiKey presence
+	present, err := yubikeySvc.IsPresent(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check YubiKey: %w", err)
+	}
+	if !present {
+		return fmt.Errorf("no YubiKey detected")
+	}
+
+	cardInfo, err := yubikeySvc.GetCardInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+	ui.LogInfo("Detected YubiKey with serial: %s", cardInfo.Serial)
+	op.serial = cardInfo.Serial
+
+	if isCurve25519(spec.Algorithm) && warnIfNoEd25519(cardInfo) {
+		return fmt.Errorf("YubiKey %s does not support %s keys", cardInfo.Serial, spec.Algorithm)
+	}
+	if slotAttr := cardInfo.KeyAttributes[slotName]; keyAttrMismatch(spec.Algorithm, slotAttr) {
+		return fmt.Errorf("the %s slot is configured for %s, but the new subkey is %s; change it with 'gpg --card-edit' → 'admin' → 'key-attr' first",
+			slotName, slotAttr, spec.Algorithm)
+	}
+	if existing, ok := cardInfo.Keys[slotName]; ok && existing != "" && existing != "[none]" && !force {
+		return fmt.Errorf("the %s slot already holds key %s; pass --force to replace it", slotName, existing)
+	}
+
+	// Create backup
+	backupPath, err := createBackupNonInteractive(ctx, cmd, backupSvc)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	ui.LogSuccess("Backup created at %s", backupPath)
+
+	// Import master key
+	if _, err := importMasterKey(ctx, gpgSvc, cfg.MasterKeyPath); err != nil {
+		return err
+	}
+	// Don't leave the master key behind when a later step fails; nobody may
+	// be watching an unattended run
+	masterHandled := false
+	defer func() {
+		if masterHandled || keepMaster {
+			return
+		}
+		if removeErr := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); removeErr != nil {
+			ui.LogWarning("Failed to remove master key: %v", removeErr)
+		}
+	}()
+
+	// Generate new subkey
+	ui.LogInfo("Generating new %s %s subkey expiring %s...", spec.Algorithm, keyName, expiryDate)
+	op.start()
+	if err := gpgSvc.AddSubkey(ctx, cfg.PrimaryKeyFingerprint, spec.Algorithm, spec.Usage, expiryDate, passphrase); err != nil {
+		return err
+	}
+
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return err
+	}
+	subkey, err := gpg.FindMovableSubkey(keys, spec.Capability)
+	if err != nil {
+		return fmt.Errorf("could not find the new subkey: %w", err)
+	}
+	ui.LogSuccess("New %s subkey created: %s", keyName, subkey.KeyID)
+	op.addKeys(subkey.KeyID)
+
+	// Move subkey to YubiKey
+	ui.LogInfo("Moving subkey %s to the YubiKey's %s slot...", subkey.KeyID, slotName)
+	if err := gpgSvc.MoveSubkeyToCard(ctx, cfg.PrimaryKeyID, subkey.KeyID, spec.Slot, passphrase, adminPIN); err != nil {
+		return fmt.Errorf("failed to move subkey: %w", err)
+	}
+	ui.LogSuccess("Subkey %s moved to the YubiKey", subkey.KeyID)
+
+	// Clean up
+	masterHandled = true
+	if keepMaster {
+		ui.LogWarning("Master key left on machine. Remember to remove it manually!")
+	} else if err := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); err != nil {
+		ui.LogWarning("Failed to remove master key: %v", err)
+	} else {
+		ui.LogSuccess("Master key removed from local keyring")
+	}
+
+	// Upload to keyserver
+	if servers := cfg.KeyserverList(); upload && len(servers) > 0 {
+		spinner := ui.StartSpinner("Uploading to keyserver...")
+		errs := gpgSvc.PublishKey(ctx, cfg.PrimaryKeyID, servers)
+		spinner.Stop()
+		reportPublishResults(servers, errs)
+	}
+
+	fmt.Println()
+	ui.LogSuccess("Setup complete for YubiKey %s", cardInfo.Serial)
+
+	return nil
+}
+
+// createBackupNonInteractive is createBackup with the encryption passphrase
+// read from --backup-passphrase-file or the environment instead of a prompt.
+func createBackupNonInteractive(ctx context.Context, cmd *cobra.Command, backupSvc backup.BackupService) (string, error) {
+	warnUnencryptedSubkeys()
+	if !cfg.EncryptBackup {
+		return backupSvc.CreateBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir)
+	}
+
+	passphrase, _, err := readSecret(cmd, "backup-passphrase-file", backupPassphraseEnv)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("encrypt_backup is enabled: pass --backup-passphrase-file or set %s", backupPassphraseEnv)
+	}
+	return backupSvc.CreateEncryptedBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir, passphrase)
+}
@@ -1,137 +1,165 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"time"
 
-	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
+// cardPollInterval is how often setup-batch --count looks for the next
+// YubiKey.
+const cardPollInterval = time.Second
+
 func newSetupBatchCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "setup-batch",
 		Short: "Add a signing subkey to a new YubiKey (semi-automated)",
 		Long: `Setup a new YubiKey with a signing subkey using semi-automated mode.
 This command creates the subkey automatically but still requires interaction
-to move it to the YubiKey.`,
+to move it to the YubiKey.
+
+With --count, it sets up several YubiKeys in a row: after each one it waits
+for the next to be inserted, and finally lists the serials it set up.`,
 		RunE: runSetupBatch,
 	}
+	addExpiresFlag(cmd)
+	addBackupFlags(cmd)
+	cmd.Flags().Int("count", 1, "Number of YubiKeys to set up one after another")
+
+	return cmd
 }
 
 func runSetupBatch(cmd *cobra.Command, args []string) error {
-	gpgSvc, yubikeySvc, backupSvc := getServices()
-	ctx := cmd.Context()
-
-	ui.PrintHeader("Setup New YubiKey (Automated Mode)")
-
-	// Check YubiKey presence
-	present, err := yubikeySvc.IsPresent(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to check YubiKey: %w", err)
-	}
-	if !present {
-		ui.LogError("No YubiKey detected. Please insert a YubiKey and try again.")
-		return fmt.Errorf("no YubiKey detected")
-	}
-
-	cardInfo, err := yubikeySvc.GetCardInfo(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get card info: %w", err)
-	}
-
-	ui.LogInfo("Detected YubiKey with serial: %s", cardInfo.Serial)
-
-	// Create backup
-	backupPath, err := backupSvc.CreateBackup(ctx, cfg.PrimaryKeyID, cfg.BackupDir)
-	if err != nil {
-		return fmt.Errorf("failed to create backup: %w", err)
+	count, _ := cmd.Flags().GetInt("count")
+	if count < 1 {
+		return fmt.Errorf("--count must be at least 1, got %d", count)
 	}
-	ui.LogSuccess("Backup created at %s", backupPath)
-
-	// Get master key
-	masterKeyPath := cfg.MasterKeyPath
-	if masterKeyPath == "" {
-		fmt.Println()
-		fmt.Println("Please enter the path to your master secret key backup.")
-		masterKeyPath, err = ui.PromptRequired("Master key path: ")
-		if err != nil {
-			return err
-		}
+	if count == 1 {
+		return runSubkeySetup(cmd, signingSubkeySetup)
 	}
+	return runSubkeySetupBatch(cmd, signingSubkeySetup, count)
+}
 
-	if _, err := os.Stat(masterKeyPath); err != nil {
-		return fmt.Errorf("master key file not found: %w", err)
+// runSubkeySetupBatch runs the subkey setup on count YubiKeys in turn. After
+// each one it asks for the next and waits until a YubiKey it hasn't seen
+// yet is connected. The serials set up are listed at the end, also when a
+// setup fails.
+func runSubkeySetupBatch(cmd *cobra.Command, spec subkeySetup, count int) error {
+	if err := requireInteractive(cmd.Name(), "use 'ykgpg setup-auto' for each YubiKey instead"); err != nil {
+		return err
 	}
+	gpgSvc, yubikeySvc, backupSvc := getServices()
+	ctx := cmd.Context()
 
-	// Import master key
-	ui.LogInfo("Importing master key...")
-	exec := executor.NewRealExecutor()
-	_, err = exec.Run(ctx, "gpg", "--import", masterKeyPath)
-	if err != nil {
-		return fmt.Errorf("failed to import master key: %w", err)
+	// Detecting the next YubiKey needs ykman; find out before the first setup
+	if _, err := yubikeySvc.ListCards(ctx); err != nil {
+		return fmt.Errorf("--count needs ykman to detect each YubiKey: %w", err)
 	}
-	ui.LogSuccess("Master key imported")
 
-	// Generate new signing subkey
-	ui.LogInfo("Generating new ed25519 signing subkey...")
+	var configured []string
+	defer func() { printBatchSummary(configured, count) }()
 
-	expiryDate := time.Now().AddDate(5, 0, 0).Format("2006-01-02")
-	_, err = exec.Run(ctx, "gpg", "--batch", "--passphrase-fd", "0", "--quick-add-key",
-		cfg.PrimaryKeyFingerprint, "ed25519", "sign", expiryDate)
-	if err != nil {
-		return fmt.Errorf("failed to create subkey: %w", err)
+	if err := selectCard(ctx, yubikeySvc); err != nil {
+		return err
 	}
+	for i := 1; i <= count; i++ {
+		if i > 1 {
+			// Ignore the YubiKeys already connected or set up, including
+			// the one just set up, until it is swapped for a new one
+			seen, err := yubikeySvc.ListCards(ctx)
+			if err != nil {
+				return err
+			}
+			seen = append(seen, configured...)
+			ui.LogInfo("Remove the YubiKey you just set up and insert YubiKey %d of %d", i, count)
+			serial, err := waitForNewCard(ctx, yubikeySvc.ListCards, seen, cardPollInterval)
+			if err != nil {
+				return err
+			}
+			// Select the new YubiKey, or clear the selection of the last one
+			if err := useCard(ctx, yubikeySvc, serial); err != nil {
+				return fmt.Errorf("%w; remove the other YubiKeys and run setup-batch again", err)
+			}
+			ui.LogSuccess("Found YubiKey %s", serialWithLabel(serial))
+			fmt.Println()
+		}
 
-	ui.LogSuccess("New signing subkey created")
-
-	// Move subkey to YubiKey (interactive)
-	fmt.Println()
-	ui.LogInfo("Moving new subkey to YubiKey...")
-	fmt.Println()
-	fmt.Println("The new subkey has been created. Now we need to move it to the YubiKey.")
-	fmt.Println("GPG requires interaction for this step.")
-	fmt.Println()
-	fmt.Println("1. In the gpg prompt, type: list")
-	fmt.Println("2. Find the newest [S] subkey (without a card-no line after it)")
-	fmt.Println("3. Type: key N (where N is that subkey's number, probably 4 or 5)")
-	fmt.Println("4. Type: keytocard")
-	fmt.Println("5. Select: (1) Signature key")
-	fmt.Println("6. Enter your PIN when prompted")
-	fmt.Println("7. Type: save")
-	fmt.Println()
-
-	_, err = ui.Prompt("Press Enter to continue: ")
-	if err != nil {
-		return err
+		ui.PrintHeader(fmt.Sprintf("YubiKey %d of %d", i, count))
+		serial, err := setupSubkeyOnCard(cmd, spec, gpgSvc, yubikeySvc, backupSvc)
+		if err != nil {
+			return fmt.Errorf("YubiKey %d of %d: %w", i, count, err)
+		}
+		if serial == "" {
+			ui.LogWarning("YubiKey %d of %d was skipped", i, count)
+		} else {
+			configured = append(configured, serial)
+		}
 	}
+	return nil
+}
 
-	if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
-		return fmt.Errorf("failed to edit key: %w", err)
+// waitForNewCard polls listCards every interval until a YubiKey whose
+// serial is not in seen is connected, and returns its serial. It stops
+// with an error when the context is cancelled or Ctrl+C is pressed.
+func waitForNewCard(ctx context.Context, listCards func(context.Context) ([]string, error), seen []string, interval time.Duration) (string, error) {
+	isNew := func(serial string) bool {
+		return !slices.ContainsFunc(seen, func(old string) bool {
+			return gpg.NormalizeCardSerial(old) == gpg.NormalizeCardSerial(serial)
+		})
 	}
+	return waitForCard(ctx, listCards, isNew, "Waiting for the next YubiKey...", interval)
+}
 
-	// Clean up
-	if ui.Confirm("Remove master key from local machine?") {
-		if err := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); err != nil {
-			ui.LogWarning("Failed to remove master key: %v", err)
+// waitForCard is waitForNewCard for the first connected YubiKey whose
+// serial satisfies match, showing message while it waits.
+func waitForCard(ctx context.Context, listCards func(context.Context) ([]string, error), match func(string) bool, message string, interval time.Duration) (string, error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	spinner := ui.StartSpinner(message)
+	defer spinner.Stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// A failed listing (e.g. while the YubiKey is being inserted) is
+		// retried on the next tick
+		if serials, err := listCards(ctx); err == nil {
+			for _, serial := range serials {
+				if match(serial) {
+					return serial, nil
+				}
+			}
 		}
-	}
 
-	// Upload to keyserver
-	if ui.Confirm(fmt.Sprintf("Upload updated public key to %s?", cfg.Keyserver)) {
-		ui.LogInfo("Uploading to keyserver...")
-		_, err := exec.Run(ctx, "gpg", "--keyserver", cfg.Keyserver, "--send-keys", cfg.PrimaryKeyID)
-		if err != nil {
-			ui.LogWarning("Failed to upload to keyserver: %v", err)
-		} else {
-			ui.LogSuccess("Public key uploaded to %s", cfg.Keyserver)
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("stopped waiting for the YubiKey")
+		case <-ticker.C:
 		}
 	}
+}
 
+// printBatchSummary lists the serials set up by setup-batch --count, so the
+// YubiKeys can be labeled.
+func printBatchSummary(configured []string, count int) {
 	fmt.Println()
-	ui.LogSuccess("Setup complete for YubiKey %s", cardInfo.Serial)
-
-	return nil
+	ui.PrintSection("YubiKeys Set Up")
+	if len(configured) == 0 {
+		fmt.Println("  none")
+	}
+	for i, serial := range configured {
+		fmt.Printf("  %d. %s\n", i+1, serialWithLabel(serial))
+	}
+	if len(configured) < count {
+		ui.LogWarning("%d of %d YubiKeys were set up", len(configured), count)
+	}
 }
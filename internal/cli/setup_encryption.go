@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// encryptionSubkeySetup is the subkey created by setup-encryption.
+var encryptionSubkeySetup = subkeySetup{
+	Title:      "Setup Encryption Subkey (Automated Mode)",
+	Algorithm:  "cv25519",
+	Usage:      "encrypt",
+	Capability: "E",
+	Slot:       2,
+}
+
+func newSetupEncryptionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup-encryption",
+		Short: "Add an encryption subkey to a YubiKey (semi-automated)",
+		Long: `Create a new cv25519 encryption subkey and move it to the YubiKey's
+Encryption slot. Like setup-batch, the subkey is created automatically but
+moving it to the YubiKey requires interaction.
+
+The card's Encryption slot must be configured for ECC (cv25519).`,
+		RunE: runSetupEncryption,
+	}
+	addExpiresFlag(cmd)
+	addBackupFlags(cmd)
+
+	return cmd
+}
+
+func runSetupEncryption(cmd *cobra.Command, args []string) error {
+	return runSubkeySetup(cmd, encryptionSubkeySetup)
+}
@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/bobbydams/yubikey-manager/internal/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSetupCmd(t *testing.T) {
@@ -16,4 +21,107 @@ func TestNewSetupBatchCmd(t *testing.T) {
 	cmd := newSetupBatchCmd()
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "setup-batch", cmd.Use)
+
+	expires := cmd.Flags().Lookup("expires")
+	if assert.NotNil(t, expires) {
+		assert.Equal(t, "", expires.DefValue)
+	}
+
+	count := cmd.Flags().Lookup("count")
+	if assert.NotNil(t, count) {
+		assert.Equal(t, "1", count.DefValue)
+	}
+	cmd.SetArgs([]string{"--count", "0"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	assert.ErrorContains(t, cmd.Execute(), "--count must be at least 1")
+}
+
+func TestWaitForNewCard(t *testing.T) {
+	// The YubiKey just set up is removed, listing fails while the next one
+	// is inserted, and then the new one appears next to an unrelated key
+	listings := [][]string{{"11111111", "99999999"}, {"99999999"}, nil, {"99999999", "22222222"}}
+	calls := 0
+	listCards := func(ctx context.Context) ([]string, error) {
+		listing := listings[min(calls, len(listings)-1)]
+		calls++
+		if listing == nil {
+			return nil, fmt.Errorf("no YubiKey detected")
+		}
+		return listing, nil
+	}
+
+	serial, err := waitForNewCard(context.Background(), listCards, []string{"11111111", "99999999"}, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "22222222", serial)
+	assert.Equal(t, 4, calls)
+
+	// A YubiKey set up earlier is recognized by its serial as gpg reports it
+	calls = 0
+	listings = [][]string{{"22222222"}, {"22222222", "33333333"}}
+	serial, err = waitForNewCard(context.Background(), listCards, []string{"0006 22222222"}, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "33333333", serial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = waitForNewCard(ctx, listCards, []string{"33333333", "22222222"}, time.Millisecond)
+	assert.ErrorContains(t, err, "stopped waiting")
+}
+
+func TestSubkeyExpiry(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+	cfg = &config.Config{DefaultSubkeyExpiry: "2y"}
+
+	cmd := newSetupBatchCmd()
+	assert.Equal(t, "2y", subkeyExpiry(cmd))
+
+	require.NoError(t, cmd.Flags().Set("expires", "18m"))
+	assert.Equal(t, "18m", subkeyExpiry(cmd))
+
+	// setup has no --expires flag
+	assert.Equal(t, "2y", subkeyExpiry(newSetupCmd()))
+
+	cfg = &config.Config{}
+	assert.Equal(t, config.DefaultSubkeyExpiry, subkeyExpiry(newSetupCmd()))
+}
+
+func TestNewSetupEncryptionCmd(t *testing.T) {
+	cmd := newSetupEncryptionCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "setup-encryption", cmd.Use)
+}
+
+func TestSubkeySetups(t *testing.T) {
+	assert.Equal(t, "sign", signingSubkeySetup.Usage)
+	assert.Equal(t, 1, signingSubkeySetup.Slot)
+
+	assert.Equal(t, "cv25519", encryptionSubkeySetup.Algorithm)
+	assert.Equal(t, "encrypt", encryptionSubkeySetup.Usage)
+	assert.Equal(t, "E", encryptionSubkeySetup.Capability)
+	assert.Equal(t, 2, encryptionSubkeySetup.Slot)
+	assert.True(t, keyAttrMismatch(encryptionSubkeySetup.Algorithm, "rsa2048"), "an RSA encryption slot must be flagged")
+	assert.False(t, keyAttrMismatch(encryptionSubkeySetup.Algorithm, "cv25519"))
+}
+
+func TestNewSetupAuthCmd(t *testing.T) {
+	cmd := newSetupAuthCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "setup-auth", cmd.Use)
+	assert.Equal(t, "auth", authSubkeySetup.Usage)
+	assert.Equal(t, "A", authSubkeySetup.Capability)
+	assert.Equal(t, 3, authSubkeySetup.Slot)
+}
+
+func TestNewSetupAutoCmd(t *testing.T) {
+	cmd := newSetupAutoCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "setup-auto", cmd.Use)
+	for _, flag := range []string{"expires", "admin-pin-file", "passphrase-file", "backup-passphrase-file", "force", "keep-master", "upload"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+	// Secrets must never be accepted as plain flag values
+	assert.Nil(t, cmd.Flags().Lookup("admin-pin"))
+	assert.Nil(t, cmd.Flags().Lookup("passphrase"))
 }
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newSSHExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-export",
+		Short: "Print the authentication subkey as an OpenSSH public key",
+		Long: `Print the primary key's authentication subkey as an OpenSSH public key
+(gpg --export-ssh-key), ready for ~/.ssh/authorized_keys or GitHub
+(Settings → SSH and GPG keys → New SSH key).
+
+Create an authentication subkey first with 'ykgpg setup-auth'. To use it for
+SSH, enable enable-ssh-support in ~/.gnupg/gpg-agent.conf and point
+SSH_AUTH_SOCK at $(gpgconf --list-dirs agent-ssh-socket).`,
+		RunE: runSSHExport,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Write the key to a file instead of stdout")
+
+	return cmd
+}
+
+func runSSHExport(cmd *cobra.Command, args []string) error {
+	gpgSvc, _, _ := getServices()
+	ctx := cmd.Context()
+
+	sshKey, err := gpgSvc.ExportSSHKey(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return err
+	}
+	line := strings.TrimSpace(string(sshKey))
+	if line == "" {
+		if dryRun {
+			return nil
+		}
+		return fmt.Errorf("gpg did not export an SSH key for %s", cfg.PrimaryKeyID)
+	}
+
+	outputFile, _ := cmd.Flags().GetString("output")
+	if outputFile == "" {
+		fmt.Println(line)
+		return nil
+	}
+
+	if err := os.WriteFile(outputFile, []byte(line+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write SSH key: %w", err)
+	}
+	ui.LogSuccess("SSH public key written to: %s", outputFile)
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSSHExportCmd(t *testing.T) {
+	cmd := newSSHExportCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "ssh-export", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("output"))
+}
@@ -1,26 +1,102 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/bobbydams/yubikey-manager/pkg/ykgpg"
 	"github.com/spf13/cobra"
 )
 
+// statusSchemaVersion is the version of the JSON document emitted by
+// `status --json`. Bump it on any incompatible change to the fields below.
+const statusSchemaVersion = 1
+
+// statusReport is the JSON document emitted by `status --json`.
+type statusReport struct {
+	Schema       int           `json:"schema"`
+	PrimaryKeyID string        `json:"primary_key_id"`
+	Keys         []statusKey   `json:"keys"`
+	YubiKey      statusYubiKey `json:"yubikey"`
+}
+
+// statusKey describes a primary key or subkey in the status JSON.
+type statusKey struct {
+	Type         string   `json:"type"`
+	KeyID        string   `json:"key_id"`
+	Algorithm    string   `json:"algorithm,omitempty"`
+	Capabilities []string `json:"capabilities"`
+	Expires      string   `json:"expires,omitempty"`
+	CardNo       string   `json:"card_no,omitempty"`
+	Revoked      bool     `json:"revoked,omitempty"`
+}
+
+// statusYubiKey describes the connected YubiKey in the status JSON.
+type statusYubiKey struct {
+	Present  bool              `json:"present"`
+	Serial   string            `json:"serial,omitempty"`
+	Label    string            `json:"label,omitempty"`
+	Model    string            `json:"model,omitempty"`
+	Firmware string            `json:"firmware,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Login    string            `json:"login_data,omitempty"`
+	Slots    map[string]string `json:"slots,omitempty"`
+	// TouchPolicies is keyed by slot ("sig", "enc", "aut"); omitted without ykman.
+	TouchPolicies map[string]string `json:"touch_policies,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
 func newStatusCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show current key and YubiKey status",
 		RunE:  runStatus,
 	}
+
+	cmd.Flags().Bool("json", false, "Output status as a versioned JSON document")
+	cmd.Flags().Bool("watch", false, "Keep polling the YubiKey and redraw its status as it is plugged in or removed")
+	cmd.Flags().Duration("interval", defaultWatchInterval, "How often --watch polls the YubiKey")
+	cmd.MarkFlagsMutuallyExclusive("json", "watch")
+
+	return cmd
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
 	gpgSvc, yubikeySvc, _ := getServices()
+	manager := getManager(gpgSvc, yubikeySvc)
 	ctx := cmd.Context()
 
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		status, err := manager.Status(ctx)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(buildStatusReport(status, cfg.PrimaryKeyID))
+	}
+
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+		if watchAvailable() {
+			return runStatusWatch(ctx, yubikeySvc, interval)
+		}
+		ui.LogInfo("Not a terminal; printing the status once instead of watching")
+	}
+
 	ui.PrintHeader("YubiKey GPG Manager Status")
 
+	if err := selectCard(ctx, yubikeySvc); err != nil {
+		return err
+	}
+
 	// Primary key info
 	ui.PrintSection("PRIMARY KEY")
 	ui.PrintKeyValueKey("Key ID", cfg.PrimaryKeyID)
@@ -28,21 +104,21 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Check if primary key exists
-	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	status, err := manager.Status(ctx)
 	if err != nil {
-		ui.LogError("Primary key not found in keyring: %v", err)
+		ui.LogError("%v", err)
+		printMissingKeyHint(err)
 		return err
 	}
-
-	if len(keys) == 0 {
-		ui.LogError("Primary key not found in keyring!")
-		return fmt.Errorf("primary key not found")
-	}
+	keys := status.Keys
 
 	// Show key details
 	ui.PrintSection("KEY DETAILS")
 	for _, key := range keys {
 		ui.PrintKey(key.Type + " ")
+		if key.Algorithm != "" {
+			ui.PrintValue(key.Algorithm + "/")
+		}
 		ui.PrintKey(key.KeyID)
 		// Format capabilities as [S C E A] instead of [S C E A]
 		if len(key.Capabilities) > 0 {
@@ -63,35 +139,124 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			fmt.Printf(" card-no: ")
 			ui.PrintValue(key.CardNo)
 		}
+		if key.Revoked {
+			ui.ErrorColor.Print(" [revoked]")
+		}
 		fmt.Println()
 	}
 	fmt.Println()
 
+	now := time.Now()
+	if warnPrimaryExpiry(keys, cfg.PrimaryKeyID, now, cfg.ExpiryWarnDays) {
+		fmt.Println()
+	}
+	printExpiringSubkeys(keys, now, cfg.ExpiryWarnDays)
+	warnExtraSigningSubkeys(keys, now, cfg.ExpectedSigningSubkeys)
+
 	// YubiKey status
 	ui.PrintSection("YUBIKEY STATUS")
-	present, err := yubikeySvc.IsPresent(ctx)
-	if err != nil {
-		ui.LogWarning("Failed to check YubiKey: %v", err)
-	} else if present {
-		cardInfo, err := yubikeySvc.GetCardInfo(ctx)
-		if err != nil {
-			ui.LogWarning("Failed to get card info: %v", err)
+	switch cardInfo := status.Card; {
+	case status.CardError != nil && !status.CardPresent:
+		ui.LogWarning("Failed to check YubiKey: %v", status.CardError)
+	case status.CardError != nil:
+		ui.LogWarning("Failed to get card info: %v", status.CardError)
+	case cardInfo != nil:
+		ui.LogSuccess("YubiKey detected!")
+		ui.PrintKeyValue("Serial", serialWithLabel(cardInfo.Serial))
+		if cardInfo.Model != "" {
+			ui.PrintKeyValue("Model", cardInfo.Model)
+		}
+		if cardInfo.FirmwareVersion != "" {
+			ui.PrintKeyValue("Firmware", cardInfo.FirmwareVersion)
+		}
+		ui.PrintKeyValue("Cardholder", valueOrDefault(cardInfo.Cardholder, "[not set]"))
+		if cardInfo.URL != "" {
+			ui.PrintKeyValue("URL", cardInfo.URL)
+		}
+		if cardInfo.LoginData != "" {
+			ui.PrintKeyValue("Login data", cardInfo.LoginData)
+		}
+		if cardInfo.PINRetries >= 0 {
+			ui.PrintKeyValue("PIN retries", fmt.Sprintf("User: %d, Reset code: %d, Admin: %d",
+				cardInfo.PINRetries, cardInfo.ResetCodeRetries, cardInfo.AdminPINRetries))
+		}
+		if status.SigningSubkey != nil {
+			ui.PrintKeyValueKey("Signing subkey", status.SigningSubkey.KeyID)
 		} else {
-			ui.LogSuccess("YubiKey detected!")
-			ui.PrintKeyValue("Serial", cardInfo.Serial)
-			ui.PrintKeyValue("Cardholder", cardInfo.Cardholder)
-			fmt.Println()
-			ui.PrintLabel("Keys on this YubiKey:\n")
-			for keyType, keyID := range cardInfo.Keys {
-				ui.PrintLabel("  " + keyType + ": ")
-				ui.PrintKey(keyID)
-				fmt.Println()
+			ui.PrintKeyValue("Signing subkey", "[not found]")
+			if status.OtherCardSerial != "" {
+				ui.LogWarning("A signing subkey exists on a different YubiKey: serial %s", serialWithLabel(status.OtherCardSerial))
 			}
 		}
-	} else {
+		fmt.Println()
+		ui.PrintLabel("Keys on this YubiKey:\n")
+		for keyType, keyID := range cardInfo.Keys {
+			ui.PrintLabel("  " + keyType + ": ")
+			ui.PrintKey(keyID)
+			fmt.Println()
+		}
+		// Touch policies are only available through ykman
+		if status.TouchPolicies != nil {
+			fmt.Println()
+			ui.PrintLabel("Touch policies:\n")
+			printTouchPolicies(status.TouchPolicies)
+		}
+	default:
 		ui.LogWarning("No YubiKey detected")
 	}
 	fmt.Println()
 
 	return nil
 }
+
+// buildStatusReport converts a Status into a statusReport.
+func buildStatusReport(status *ykgpg.Status, primaryKeyID string) *statusReport {
+	report := &statusReport{
+		Schema:       statusSchemaVersion,
+		PrimaryKeyID: primaryKeyID,
+		Keys:         make([]statusKey, 0, len(status.Keys)),
+	}
+	for _, key := range status.Keys {
+		capabilities := key.Capabilities
+		if capabilities == nil {
+			capabilities = []string{}
+		}
+		report.Keys = append(report.Keys, statusKey{
+			Type:         key.Type,
+			KeyID:        key.KeyID,
+			Algorithm:    key.Algorithm,
+			Capabilities: capabilities,
+			Expires:      key.Expires,
+			CardNo:       key.CardNo,
+			Revoked:      key.Revoked,
+		})
+	}
+
+	report.YubiKey.Present = status.CardPresent
+	if status.CardError != nil {
+		report.YubiKey.Error = status.CardError.Error()
+	}
+	if cardInfo := status.Card; cardInfo != nil {
+		report.YubiKey.Serial = cardInfo.Serial
+		report.YubiKey.Label = yubikeyLabel(cardInfo.Serial)
+		report.YubiKey.Model = cardInfo.Model
+		report.YubiKey.Firmware = cardInfo.FirmwareVersion
+		report.YubiKey.URL = cardInfo.URL
+		report.YubiKey.Login = cardInfo.LoginData
+		report.YubiKey.Slots = cardInfo.Keys
+		report.YubiKey.TouchPolicies = status.TouchPolicies
+	}
+
+	return report
+}
+
+// printMissingKeyHint suggests a fix for a primary key that Status couldn't
+// find in the secret keyring.
+func printMissingKeyHint(err error) {
+	switch {
+	case errors.Is(err, ykgpg.ErrOnlyPublicKey):
+		ui.LogInfo("Import the master key backup with 'gpg --import', or insert the YubiKey holding the subkeys and run 'gpg --card-status' to create the key stubs")
+	case errors.Is(err, ykgpg.ErrKeyNotFound):
+		ui.LogInfo("Check primary_key_id in your config ('ykgpg config show'); 'gpg --list-keys' lists the keys in your keyring")
+	}
+}
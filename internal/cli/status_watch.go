@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"golang.org/x/term"
+)
+
+// defaultWatchInterval is how often status --watch polls the card.
+const defaultWatchInterval = 2 * time.Second
+
+// watchCardTimeout bounds each poll, so a gpg waiting on the agent or a
+// card selection can't freeze the display.
+const watchCardTimeout = 3 * time.Second
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// cardSnapshot is the result of one status --watch poll.
+type cardSnapshot struct {
+	present bool
+	info    *gpg.CardInfo
+	err     error
+}
+
+// watchAvailable reports whether status --watch can redraw in place; on
+// any other output it prints the status once instead.
+func watchAvailable() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// runStatusWatch redraws the YubiKey's status every interval until the
+// context is cancelled or Ctrl+C is pressed.
+func runStatusWatch(ctx context.Context, yubikeySvc yubikey.YubiKeyService, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snapshot := pollCard(ctx, yubikeySvc)
+		if ctx.Err() == nil {
+			fmt.Print(clearScreen)
+			ui.PrintHeader("YubiKey Status (watching)")
+			printCardSnapshot(snapshot)
+			fmt.Printf("\nLast checked %s, every %s. Press Ctrl+C to stop.\n", time.Now().Format("15:04:05"), interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollCard checks for a YubiKey and reads its card status, giving up after
+// watchCardTimeout.
+func pollCard(ctx context.Context, yubikeySvc yubikey.YubiKeyService) cardSnapshot {
+	pollCtx, cancel := context.WithTimeout(ctx, watchCardTimeout)
+	defer cancel()
+
+	present, err := yubikeySvc.IsPresent(pollCtx)
+	if pollCtx.Err() == context.DeadlineExceeded {
+		return cardSnapshot{err: fmt.Errorf("timed out after %s (gpg may be waiting for input)", watchCardTimeout)}
+	}
+	if err != nil || !present {
+		return cardSnapshot{err: err}
+	}
+
+	info, err := yubikeySvc.GetCardInfo(pollCtx)
+	if pollCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s reading the card", watchCardTimeout)
+	}
+	return cardSnapshot{present: true, info: info, err: err}
+}
+
+// printCardSnapshot prints one poll's result.
+func printCardSnapshot(snapshot cardSnapshot) {
+	switch {
+	case !snapshot.present && snapshot.err != nil:
+		ui.LogWarning("Failed to check YubiKey: %v", snapshot.err)
+	case !snapshot.present:
+		ui.LogWarning("No YubiKey detected")
+	case snapshot.err != nil:
+		ui.LogWarning("YubiKey detected, but failed to get card info: %v", snapshot.err)
+	default:
+		info := snapshot.info
+		ui.LogSuccess("YubiKey detected!")
+		ui.PrintKeyValue("Serial", serialWithLabel(info.Serial))
+		if info.Model != "" {
+			ui.PrintKeyValue("Model", info.Model)
+		}
+		ui.PrintKeyValue("Cardholder", valueOrDefault(info.Cardholder, "[not set]"))
+		if info.PINRetries >= 0 {
+			ui.PrintKeyValue("PIN retries", fmt.Sprintf("User: %d, Reset code: %d, Admin: %d",
+				info.PINRetries, info.ResetCodeRetries, info.AdminPINRetries))
+		}
+		for i := 1; i <= len(gpg.CardSlots); i++ {
+			slot := gpg.CardSlots[i]
+			ui.PrintKeyValueKey(slot+" key", valueOrDefault(info.Keys[slot], "[none]"))
+		}
+	}
+}
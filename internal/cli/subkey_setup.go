@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/internal/backup"
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// subkeySetup describes a subkey that the semi-automated setup commands
+// create with gpg --quick-add-key and move to a card slot.
+type subkeySetup struct {
+	Title      string // Header shown when the command starts
+	Algorithm  string // --quick-add-key algorithm, e.g. "ed25519"
+	Usage      string // --quick-add-key usage: "sign", "encrypt" or "auth"
+	Capability string // Capability flag of the created subkey: "S", "E" or "A"
+	Slot       int    // keytocard slot: 1=Signature, 2=Encryption, 3=Authentication
+}
+
+// signingSubkeySetup is the subkey created by setup-batch.
+var signingSubkeySetup = subkeySetup{
+	Title:      "Setup New YubiKey (Automated Mode)",
+	Algorithm:  "ed25519",
+	Usage:      "sign",
+	Capability: "S",
+	Slot:       1,
+}
+
+// addExpiresFlag adds the --expires flag read by subkeyExpiry.
+func addExpiresFlag(cmd *cobra.Command) {
+	cmd.Flags().String("expires", "", "Expiration of the new subkey, e.g. 5y, 18m, 90d or 2035-01-01 (default: default_subkey_expiry, "+config.DefaultSubkeyExpiry+")")
+}
+
+// subkeyExpiry returns the expiration for a new subkey: --expires if the
+// command has it and it was given, otherwise default_subkey_expiry.
+func subkeyExpiry(cmd *cobra.Command) string {
+	if expires, _ := cmd.Flags().GetString("expires"); expires != "" {
+		return expires
+	}
+	if expires := toolConfig().DefaultSubkeyExpiry; expires != "" {
+		return expires
+	}
+	return config.DefaultSubkeyExpiry
+}
+
+// runSubkeySetup creates a new subkey as described by spec and guides the
+// user through moving it to the connected card.
+func runSubkeySetup(cmd *cobra.Command, spec subkeySetup) error {
+	if err := requireInteractive(cmd.Name(), "use 'ykgpg setup-auto' instead"); err != nil {
+		return err
+	}
+	gpgSvc, yubikeySvc, backupSvc := getServices()
+	_, err := setupSubkeyOnCard(cmd, spec, gpgSvc, yubikeySvc, backupSvc)
+	return err
+}
+
+// setupSubkeyOnCard creates a new subkey as described by spec and guides
+// the user through moving it to the card: it checks the card, creates a
+// backup, imports the master key, adds the subkey, opens gpg --edit-key for
+// keytocard, and then offers to remove the master key and upload the key.
+// It returns the serial of the card, or "" if the user stopped before the
+// subkey was created.
+func setupSubkeyOnCard(cmd *cobra.Command, spec subkeySetup, gpgSvc *gpg.Service, yubikeySvc *yubikey.Service, backupSvc *backup.Service) (serial string, err error) {
+	ctx := cmd.Context()
+	op := newJournalOp(cmd.Name())
+	defer func() { op.finish(err) }()
+	slotName := gpg.CardSlots[spec.Slot]
+	keyName := gpg.CapabilityNames[spec.Capability]
+
+	expiryDate, err := gpg.ParseExpiry(subkeyExpiry(cmd))
+	if err != nil {
+		return "", err
+	}
+
+	ui.PrintHeader(spec.Title)
+
+	if err := requireGPGVersion(ctx, gpgSvc, minQuickAddKeyVersion, "creating the subkey with --quick-add-key"); err != nil {
+		return "", err
+	}
+
+	// Check YubiKey presence
+	present, err := yubikeySvc.IsPresent(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to check YubiKey: %w", err)
+	}
+	if !present {
+		ui.LogError("No YubiKey detected. Please insert a YubiKey and try again.")
+		return "", fmt.Errorf("no YubiKey detected")
+	}
+
+	cardInfo, err := yubikeySvc.GetCardInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get card info: %w", err)
+	}
+
+	ui.LogInfo("Detected YubiKey with serial: %s", cardInfo.Serial)
+	op.serial = cardInfo.Serial
+
+	if isCurve25519(spec.Algorithm) && warnIfNoEd25519(cardInfo) && !ui.Confirm("Continue anyway? (keytocard will fail)") {
+		return "", nil
+	}
+
+	// Check the target slot accepts the new key type
+	if slotAttr := cardInfo.KeyAttributes[slotName]; keyAttrMismatch(spec.Algorithm, slotAttr) {
+		ui.LogWarning("Your YubiKey's %s slot is configured for %s, but the new subkey is %s.", slotName, slotAttr, spec.Algorithm)
+		ui.LogWarning("Change the slot's key attributes with 'gpg --card-edit' → 'admin' → 'key-attr' first.")
+		if !ui.Confirm("Continue anyway? (keytocard will fail if key types don't match)") {
+			return "", nil
+		}
+	}
+
+	// Check if the target slot is already in use
+	if existing, ok := cardInfo.Keys[slotName]; ok && existing != "" && existing != "[none]" {
+		ui.LogWarning("This YubiKey already has a %s key configured: %s", slotName, existing)
+		if !ui.Confirm(fmt.Sprintf("Continue anyway? This will replace the existing %s key.", slotName)) {
+			return "", nil
+		}
+	}
+
+	// Create backup
+	backupPath, err := createBackup(ctx, backupSvc)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup: %w", err)
+	}
+	ui.LogSuccess("Backup created at %s", backupPath)
+
+	// Get master key
+	masterKeyPath := cfg.MasterKeyPath
+	if masterKeyPath == "" {
+		fmt.Println()
+		fmt.Println("Please enter the path to your master secret key backup.")
+		masterKeyPath, err = ui.PromptRequired("Master key path: ")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Import master key
+	if _, err := importMasterKey(ctx, gpgSvc, masterKeyPath); err != nil {
+		return "", err
+	}
+
+	// Generate new subkey
+	ui.LogInfo("Generating new %s %s subkey expiring %s...", spec.Algorithm, keyName, expiryDate)
+
+	op.start()
+	if err := gpgSvc.AddSubkey(ctx, cfg.PrimaryKeyFingerprint, spec.Algorithm, spec.Usage, expiryDate, ""); err != nil {
+		return "", err
+	}
+
+	ui.LogSuccess("New %s subkey created", keyName)
+	op.addKeys(newSubkeyID(ctx, gpgSvc, spec.Capability))
+
+	// Move subkey to YubiKey (interactive)
+	fmt.Println()
+	ui.LogInfo("Moving new subkey to YubiKey...")
+	fmt.Println()
+	fmt.Println("The new subkey has been created. Now we need to move it to the YubiKey.")
+	fmt.Println("GPG requires interaction for this step.")
+	selectStep := selectMovableSubkeyStep(ctx, gpgSvc, spec.Capability)
+	fmt.Println()
+	fmt.Println("1. In the gpg prompt, type: list")
+	fmt.Println("2.", selectStep)
+	fmt.Println("3. Type: keytocard")
+	fmt.Printf("4. Select: (%d) %s key\n", spec.Slot, slotName)
+	fmt.Println("5. Enter your PIN when prompted")
+	fmt.Println("6. Type: save")
+	fmt.Println()
+
+	_, err = ui.Prompt("Press Enter to continue: ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := gpgSvc.EditKey(ctx, cfg.PrimaryKeyID); err != nil {
+		return "", fmt.Errorf("failed to edit key: %w", err)
+	}
+
+	// Clean up
+	if confirmRemoveMasterKey() {
+		if err := removeMasterKey(ctx, gpgSvc, cfg.PrimaryKeyFingerprint); err != nil {
+			ui.LogWarning("Failed to remove master key: %v", err)
+		}
+	}
+
+	// Upload to keyserver
+	uploadPublicKey(ctx, gpgSvc)
+
+	fmt.Println()
+	ui.LogSuccess("Setup complete for YubiKey %s", cardInfo.Serial)
+
+	return cardInfo.Serial, nil
+}
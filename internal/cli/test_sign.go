@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/bobbydams/yubikey-manager/pkg/ykgpg"
+	"github.com/spf13/cobra"
+)
+
+// defaultTestSignTimeout leaves time to touch the YubiKey when its
+// signature slot requires touch.
+const defaultTestSignTimeout = 15 * time.Second
+
+func newTestSignCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test-sign",
+		Short: "Check that the connected YubiKey can sign",
+		Long: `Sign a test payload with the signing subkey on the connected YubiKey and
+report whether it worked. The subkey is found the same way as in 'ykgpg
+verify', which runs this test among its other checks.
+
+Signing is first tried without prompting, each attempt limited by --timeout.
+If that fails you are offered an interactive attempt that asks for your PIN.`,
+		SilenceUsage: true,
+		RunE:         runTestSign,
+	}
+
+	cmd.Flags().String("pinentry-mode", ykgpg.PinentryModeAuto, "How the signing test invokes GPG: auto, loopback, or agent")
+	cmd.Flags().Bool("touch-hint", false, "Print a reminder to touch the YubiKey before signing")
+	cmd.Flags().Duration("timeout", defaultTestSignTimeout, "How long each non-interactive signing attempt may take")
+
+	return cmd
+}
+
+func runTestSign(cmd *cobra.Command, args []string) error {
+	pinentryMode, _ := cmd.Flags().GetString("pinentry-mode")
+	if err := ykgpg.ValidatePinentryMode(pinentryMode); err != nil {
+		return err
+	}
+	touchHint, _ := cmd.Flags().GetBool("touch-hint")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+
+	gpgSvc, yubikeySvc, _ := getServices()
+	manager := getManager(gpgSvc, yubikeySvc)
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Test Signing")
+
+	if err := selectCard(ctx, yubikeySvc); err != nil {
+		return err
+	}
+
+	cardCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	present, err := yubikeySvc.IsPresent(cardCtx)
+	if err != nil || !present {
+		return newExitCodeError(ExitNoCard, "no YubiKey detected")
+	}
+	cardInfo, err := yubikeySvc.GetCardInfo(cardCtx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+
+	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
+	if err != nil {
+		return err
+	}
+	subkey, err := gpg.FindSigningSubkeyOnCard(keys, cardInfo)
+	if err != nil {
+		return newExitCodeError(ExitSigningFailed, "%v", err)
+	}
+	if otherSerial := gpg.OtherCardSerial(subkey, cardInfo); otherSerial != "" {
+		return newExitCodeError(ExitSigningFailed, "no signing subkey on YubiKey %s; a signing subkey exists on a different YubiKey: serial %s",
+			cardInfo.Serial, serialWithLabel(otherSerial))
+	}
+	subkeyID := subkey.KeyID
+	ui.LogInfo("Signing subkey on YubiKey: %s", subkeyID)
+	if gpg.GuessedSigningSubkey(subkey, cardInfo) {
+		ui.LogInfo("Using the most recent signing subkey on a card, as none matches this YubiKey's serial")
+	}
+	keySpec := gpg.SubkeySpec(keys, subkeyID)
+
+	if touchHint {
+		ui.LogWarning("Touch your YubiKey now")
+	}
+	if err := manager.TrySign(ctx, keySpec, pinentryMode, timeout); err == nil {
+		ui.LogSuccess("Signed the test payload with subkey %s", subkeyID)
+		reportSignatureCounter(ctx, manager, cardInfo)
+		return nil
+	}
+
+	ui.LogInfo("Signing without a prompt failed; the PIN may not be cached.")
+	if ui.IsNonInteractive() || !ui.Confirm("Run interactive signing test? (You'll need to enter your PIN)") {
+		return newExitCodeError(ExitSigningFailed, "signing test failed")
+	}
+	if touchHint {
+		ui.LogWarning("Enter your PIN, then touch your YubiKey when it blinks")
+	}
+	if err := signInteractively(keySpec); err != nil {
+		return newExitCodeError(ExitSigningFailed, "signing test failed: %v", err)
+	}
+	ui.LogSuccess("Signed the test payload with subkey %s", subkeyID)
+	reportSignatureCounter(ctx, manager, cardInfo)
+	return nil
+}
@@ -0,0 +1,18 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTestSignCmd(t *testing.T) {
+	cmd := newTestSignCmd()
+	assert.Equal(t, "test-sign", cmd.Use)
+	assert.True(t, cmd.SilenceUsage)
+
+	for _, flag := range []string{"pinentry-mode", "touch-hint", "timeout"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), "missing --%s", flag)
+	}
+	assert.Equal(t, defaultTestSignTimeout.String(), cmd.Flags().Lookup("timeout").DefValue)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// requireGPG returns a friendly error if the configured gpg program can't
+// be found, instead of the raw exec error a command would fail with later.
+func requireGPG() error {
+	return requireProgram("gpg", gpgBinary(), gpgInstallHint(runtime.GOOS))
+}
+
+// requireYkman is requireGPG for ykman, which the touch and reset
+// commands need.
+func requireYkman() error {
+	return requireProgram("ykman", ykmanBinary(), ykmanInstallHint(runtime.GOOS))
+}
+
+// requireProgram checks that binary is on $PATH (or, if it is a path, that
+// it exists and is executable).
+func requireProgram(name, binary, hint string) error {
+	if _, err := lookPath(binary); err != nil {
+		if binary != name {
+			return fmt.Errorf("%s not found (configured as %q); %s", name, binary, hint)
+		}
+		return fmt.Errorf("%s not found; %s", name, hint)
+	}
+	return nil
+}
+
+// gpgInstallHint tells the user how to install GnuPG on the given OS.
+func gpgInstallHint(goos string) string {
+	switch goos {
+	case "darwin":
+		return "install GnuPG with 'brew install gnupg'"
+	case "windows":
+		return "install GnuPG with Gpg4win from https://gpg4win.org"
+	case "linux":
+		return "install GnuPG with your package manager, e.g. 'sudo apt install gnupg scdaemon' or 'sudo dnf install gnupg2'"
+	default:
+		return "install GnuPG from https://gnupg.org/download/"
+	}
+}
+
+// ykmanInstallHint tells the user how to install ykman on the given OS.
+func ykmanInstallHint(goos string) string {
+	switch goos {
+	case "darwin":
+		return "install it with 'brew install ykman'"
+	case "windows":
+		return "install YubiKey Manager from https://www.yubico.com/support/download/yubikey-manager/"
+	case "linux":
+		return "install it with your package manager, e.g. 'sudo apt install yubikey-manager', or 'pipx install yubikey-manager'"
+	default:
+		return "see https://github.com/Yubico/yubikey-manager"
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireGPG(t *testing.T) {
+	oldCfg, oldLookPath := cfg, lookPath
+	defer func() { cfg, lookPath = oldCfg, oldLookPath }()
+
+	var looked string
+	lookPath = func(file string) (string, error) {
+		looked = file
+		return "", fmt.Errorf("not found")
+	}
+
+	cfg = &config.Config{}
+	err := requireGPG()
+	require.Error(t, err)
+	assert.Equal(t, "gpg", looked)
+	assert.Contains(t, err.Error(), "gpg not found; install GnuPG")
+
+	cfg = &config.Config{GPGBinary: "/opt/gnupg/bin/gpg2"}
+	err = requireGPG()
+	require.Error(t, err)
+	assert.Equal(t, "/opt/gnupg/bin/gpg2", looked)
+	assert.Contains(t, err.Error(), `configured as "/opt/gnupg/bin/gpg2"`)
+
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	assert.NoError(t, requireGPG())
+}
+
+func TestRequireYkman(t *testing.T) {
+	oldCfg, oldLookPath := cfg, lookPath
+	defer func() { cfg, lookPath = oldCfg, oldLookPath }()
+	cfg = &config.Config{}
+
+	lookPath = func(string) (string, error) { return "", fmt.Errorf("not found") }
+	err := requireYkman()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ykman not found")
+
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	assert.NoError(t, requireYkman())
+}
+
+func TestInstallHints(t *testing.T) {
+	assert.Contains(t, gpgInstallHint("darwin"), "brew install gnupg")
+	assert.Contains(t, gpgInstallHint("linux"), "apt install gnupg")
+	assert.Contains(t, gpgInstallHint("windows"), "Gpg4win")
+	assert.Contains(t, gpgInstallHint("plan9"), "gnupg.org")
+
+	assert.Contains(t, ykmanInstallHint("darwin"), "brew install ykman")
+	assert.Contains(t, ykmanInstallHint("linux"), "apt install yubikey-manager")
+	assert.Contains(t, ykmanInstallHint("windows"), "yubico.com")
+	assert.Contains(t, ykmanInstallHint("plan9"), "github.com/Yubico/yubikey-manager")
+}
+
+func TestYkmanCommandsRequireYkman(t *testing.T) {
+	oldCfg, oldLookPath := cfg, lookPath
+	defer func() { cfg, lookPath = oldCfg, oldLookPath }()
+	cfg = &config.Config{}
+	lookPath = func(string) (string, error) { return "", fmt.Errorf("not found") }
+
+	for _, cmd := range []*cobra.Command{newTouchCmd(), newResetCmd()} {
+		err := cmd.PersistentPreRunE(cmd, nil)
+		require.Error(t, err, cmd.Name())
+		assert.Contains(t, err.Error(), "ykman not found")
+	}
+
+	// The pin commands drive gpg --card-edit and only need gpg
+	cmd := newPinCmd()
+	err := cmd.PersistentPreRunE(cmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gpg not found")
+}
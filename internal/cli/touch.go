@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// touchSlotNames are the display names of the touch policy slots.
+var touchSlotNames = map[string]string{
+	"sig": "Signature",
+	"enc": "Encryption",
+	"aut": "Authentication",
+}
+
+func newTouchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "touch",
+		Short: "Show or change the YubiKey touch policies",
+		Long: `Show the touch policy of each OpenPGP key slot, or change one with
+'ykgpg touch set <slot> <policy>'. Requires ykman and a YubiKey 4 or 5.
+
+Slots: sig, enc, aut
+Policies:
+  off           No touch required
+  on            Touch required for every operation
+  fixed         Like "on", but can only be turned off by resetting the applet
+  cached        Touch required, cached for 15 seconds
+  cached-fixed  Like "cached", but can only be turned off by resetting the applet`,
+		RunE: runTouchShow,
+	}
+	// Skip PersistentPreRunE validation for touch commands
+	// Touch policies don't require a configured primary key, only ykman
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return requireYkman()
+	}
+
+	cmd.AddCommand(newTouchSetCmd())
+
+	return cmd
+}
+
+func newTouchSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <slot> <policy>",
+		Short: "Set the touch policy of a key slot",
+		Example: `  ykgpg touch set sig on
+  ykgpg touch set aut cached`,
+		Args: cobra.ExactArgs(2),
+		RunE: runTouchSet,
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation before setting a fixed policy")
+
+	return cmd
+}
+
+func runTouchShow(cmd *cobra.Command, args []string) error {
+	_, yubikeySvc, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("YubiKey Touch Policies")
+
+	policies, err := yubikeySvc.GetTouchPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	printTouchPolicies(policies)
+
+	return nil
+}
+
+func runTouchSet(cmd *cobra.Command, args []string) error {
+	slot := strings.ToLower(args[0])
+	policy := strings.ToLower(args[1])
+	yes, _ := cmd.Flags().GetBool("yes")
+	if err := requireInteractive("touch set", "ykman prompts for the Admin PIN"); err != nil {
+		return err
+	}
+
+	_, yubikeySvc, _ := getServices()
+	ctx := cmd.Context()
+
+	ui.PrintHeader("Set YubiKey Touch Policy")
+
+	if strings.HasSuffix(policy, "fixed") && !yes {
+		ui.LogWarning("A %q policy can only be changed again by resetting the OpenPGP applet,", policy)
+		ui.LogWarning("which deletes all keys on the card.")
+		if !ui.Confirm(fmt.Sprintf("Set the %s touch policy to %s?", slot, policy)) {
+			ui.LogInfo("Cancelled")
+			return nil
+		}
+	}
+
+	ui.LogInfo("ykman will ask for your Admin PIN (default: 12345678)")
+	if err := yubikeySvc.SetTouchPolicy(ctx, slot, policy); err != nil {
+		return err
+	}
+	ui.LogSuccess("%s touch policy set to %s", touchSlotNames[slot], policy)
+
+	return nil
+}
+
+// printTouchPolicies prints the touch policy of each slot in display order.
+func printTouchPolicies(policies map[string]string) {
+	for _, slot := range yubikey.TouchSlots {
+		policy, ok := policies[slot]
+		if !ok {
+			policy = "(unknown)"
+		}
+		ui.PrintKeyValue(fmt.Sprintf("%s (%s)", touchSlotNames[slot], slot), policy)
+	}
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTouchCmd(t *testing.T) {
+	cmd := newTouchCmd()
+	assert.Equal(t, "touch", cmd.Use)
+
+	setCmd, _, err := cmd.Find([]string{"set"})
+	assert.NoError(t, err)
+	assert.NotNil(t, setCmd.Flags().Lookup("yes"))
+	assert.Error(t, setCmd.Args(setCmd, []string{"sig"}))
+}
+
+func TestTouchSlotNames(t *testing.T) {
+	for _, slot := range yubikey.TouchSlots {
+		assert.NotEmpty(t, touchSlotNames[slot], slot)
+	}
+}
@@ -3,294 +3,279 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/bobbydams/yubikey-manager/pkg/ui"
+	"github.com/bobbydams/yubikey-manager/pkg/ykgpg"
 	"github.com/spf13/cobra"
 )
 
 func newVerifyCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:          "verify",
 		Aliases:      []string{"check"},
 		Short:        "Verify GPG and YubiKey setup",
 		SilenceUsage: true, // Don't print usage on errors
 		RunE:         runVerify,
 	}
+
+	cmd.Flags().String("pinentry-mode", ykgpg.PinentryModeAuto, "How the signing test invokes GPG: auto, loopback, or agent")
+	cmd.Flags().Bool("json", false, "Output the check results as a versioned JSON document (never prompts)")
+	cmd.Flags().Bool("check-default-pins", false, "Check whether the factory default Admin PIN is still set; costs one Admin PIN attempt if it isn't")
+	cmd.Flags().Bool("local", false, "Also check the current repository's git config, which overrides the global config")
+	cmd.Flags().Bool("strict", false, "Fail, rather than warn, when git doesn't sign tags and pushes")
+	cmd.Flags().Bool("test-encrypt", false, "Require an encryption key on the YubiKey and test encrypting and decrypting with it")
+
+	return cmd
+}
+
+// verifySchemaVersion is the version of the JSON document emitted by
+// `verify --json`. Bump it on any incompatible change to the fields below.
+const verifySchemaVersion = 1
+
+// Check statuses reported by verify, audit and doctor.
+const (
+	CheckPass = ykgpg.CheckPass
+	CheckWarn = ykgpg.CheckWarn
+	CheckFail = ykgpg.CheckFail
+)
+
+// CheckResult is the outcome of one check.
+type CheckResult = ykgpg.CheckResult
+
+// verifyReport is the JSON document emitted by `verify --json`.
+type verifyReport struct {
+	Schema  int                 `json:"schema"`
+	Result  string              `json:"result"`
+	Summary ykgpg.VerifySummary `json:"summary"`
+	Checks  []CheckResult       `json:"checks"`
 }
 
 func runVerify(cmd *cobra.Command, args []string) error {
+	pinentryMode, _ := cmd.Flags().GetString("pinentry-mode")
+	if err := ykgpg.ValidatePinentryMode(pinentryMode); err != nil {
+		return err
+	}
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	checkDefaultPINs, _ := cmd.Flags().GetBool("check-default-pins")
+	localGitConfig, _ := cmd.Flags().GetBool("local")
+	strict, _ := cmd.Flags().GetBool("strict")
+	testEncrypt, _ := cmd.Flags().GetBool("test-encrypt")
+
 	gpgSvc, yubikeySvc, _ := getServices()
+	manager := getManager(gpgSvc, yubikeySvc)
 	ctx := cmd.Context()
 
-	ui.PrintHeader("Verify GPG/YubiKey Setup")
+	if !jsonOutput {
+		ui.PrintHeader("Verify GPG/YubiKey Setup")
 
-	errors := 0
+		if err := selectCard(ctx, yubikeySvc); err != nil {
+			return err
+		}
+	}
 
-	// Check GPG key exists
-	fmt.Print("Checking primary key exists... ")
-	keys, err := gpgSvc.ListSecretKeys(ctx, cfg.PrimaryKeyID)
-	if err == nil && len(keys) > 0 {
-		fmt.Print("OK\n")
-	} else {
-		fmt.Print("FAILED\n")
-		errors++
+	opts := ykgpg.VerifyOptions{
+		PinentryMode:     pinentryMode,
+		SigningTimeout:   defaultSigningTimeout,
+		CheckDefaultPINs: checkDefaultPINs,
+		DryRun:           dryRun,
+		LocalGitConfig:   localGitConfig,
+		Strict:           strict,
+		TestEncrypt:      testEncrypt,
+	}
+	result, err := manager.Verify(ctx, opts)
+	if err != nil {
+		return err
 	}
 
-	// Check master key is NOT on machine
-	fmt.Print("Checking master key is offline... ")
-	hasMaster := false
-	for _, key := range keys {
-		if key.Type == "sec" {
-			hasMaster = true
-			break
+	// Without gpg-agent the card checks fail; offer to start it and verify again
+	if result.AgentNotRunning && !jsonOutput && !ui.IsNonInteractive() && launchAgent(ctx, gpgSvc) {
+		if result, err = manager.Verify(ctx, opts); err != nil {
+			return err
 		}
 	}
-	if !hasMaster {
-		fmt.Print("OK (sec# = offline)\n")
-	} else {
-		fmt.Print("WARNING (master key may be on machine)\n")
+
+	// Signing without a prompt failed; offer to sign with a PIN prompt
+	if result.SigningNeedsPIN && !jsonOutput && !ui.IsNonInteractive() {
+		retrySigningInteractively(ctx, manager, result)
 	}
 
-	// Check YubiKey and find the signing subkey on it
-	var signingSubkeyID string
-	var cardInfo *gpg.CardInfo
-	fmt.Print("Checking YubiKey presence... ")
-
-	// Create a context with timeout for YubiKey detection to prevent hanging
-	yubikeyCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
-
-	present, err := yubikeySvc.IsPresent(yubikeyCtx)
-	if err == nil && present {
-		// Use the same timeout context for getting card info
-		cardInfo, err = yubikeySvc.GetCardInfo(yubikeyCtx)
-		if err == nil {
-			fmt.Printf("OK (serial: %s)\n", cardInfo.Serial)
-			// Try to get signature key ID from card info first
-			if sigKey, ok := cardInfo.Keys["Signature"]; ok && sigKey != "" && sigKey != "[none]" {
-				fmt.Printf("  └─ Signature key on YubiKey: %s\n", sigKey)
-				signingSubkeyID = sigKey
-			} else {
-				// If card info doesn't have the signature key, find it by matching card serial
-				// The card serial format in GPG key listing is "0006 XXXXXXXX" where XXXXXXXX is the serial
-				cardSerialFormatted := fmt.Sprintf("0006 %s", cardInfo.Serial)
-				// Also try without space (some formats might differ)
-				cardSerialFormattedAlt := fmt.Sprintf("0006%s", cardInfo.Serial)
-
-				// First, try to find by card-no matching
-				for _, key := range keys {
-					// Look for signing subkeys (ssb) with S capability that are on this card
-					if key.Type == "ssb" && contains(key.Capabilities, "S") {
-						// Check if this key is on the current card by matching card-no
-						if key.CardNo == cardSerialFormatted || key.CardNo == cardSerialFormattedAlt {
-							// This is the signing subkey on the current YubiKey
-							signingSubkeyID = key.KeyID
-							fmt.Printf("  └─ Found signing subkey on YubiKey: %s\n", signingSubkeyID)
-							break
-						}
-					}
-				}
-
-				// If still not found, try to use the most recent signing subkey that's on a card
-				// This is a fallback when card-no doesn't match (e.g., after moving a key)
-				if signingSubkeyID == "" {
-					// Look for the most recent signing subkey that's on a card (has CardNo set)
-					var latestSigningKey *gpg.Key
-					for i := range keys {
-						key := &keys[i]
-						if key.Type == "ssb" && contains(key.Capabilities, "S") && key.CardNo != "" {
-							// This is a signing subkey on a card
-							if latestSigningKey == nil {
-								latestSigningKey = key
-							}
-						}
-					}
-					if latestSigningKey != nil {
-						signingSubkeyID = latestSigningKey.KeyID
-						fmt.Printf("  └─ Using signing subkey on card: %s\n", signingSubkeyID)
-						ui.LogInfo("  └─ Note: Using most recent signing subkey on a card. If this is wrong, specify the key ID manually.")
-					}
-				}
-			}
-		} else {
-			// Check if it was a timeout
-			if yubikeyCtx.Err() == context.DeadlineExceeded {
-				fmt.Print("TIMEOUT (GPG may be waiting for input)\n")
-				ui.LogWarning("  └─ gpg --card-status timed out. This may indicate:")
-				ui.LogWarning("  └─ 1. GPG is waiting for PIN entry")
-				ui.LogWarning("  └─ 2. Multiple YubiKeys detected and GPG is waiting for card selection")
-				ui.LogWarning("  └─ 3. YubiKey needs to be touched/activated")
-				ui.LogInfo("  └─ Try running 'gpg --card-status' manually to see what's happening")
-			} else {
-				fmt.Print("OK (unable to get card info)\n")
-			}
+	// Nothing is printed until every check has run
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(newVerifyReport(result)); err != nil {
+			return err
 		}
 	} else {
-		// Check if it was a timeout
-		if yubikeyCtx.Err() == context.DeadlineExceeded {
-			fmt.Print("TIMEOUT (GPG may be waiting for input)\n")
-			ui.LogWarning("  └─ YubiKey detection timed out. GPG may be waiting for user interaction.")
-		} else {
-			fmt.Print("NOT PRESENT\n")
-		}
+		printVerifyResult(result)
 	}
 
-	// Check Git config
-	fmt.Print("Checking Git signing key config... ")
-	gitKey := getGitConfig("user.signingkey")
-	if gitKey != "" && (containsString(gitKey, cfg.PrimaryKeyID) || containsString(gitKey, cfg.PrimaryKeyFingerprint)) {
-		fmt.Print("OK\n")
-	} else {
-		fmt.Printf("MISMATCH (configured: %s)\n", gitKey)
-	}
+	return verifyError(result)
+}
 
-	// Check commit signing enabled
-	fmt.Print("Checking Git commit signing enabled... ")
-	gitSign := getGitConfig("commit.gpgsign")
-	if gitSign == "true" {
-		fmt.Print("OK\n")
-	} else {
-		fmt.Print("NOT ENABLED\n")
+// launchAgent offers to start gpg-agent, which isn't running, and reports
+// whether it was started.
+func launchAgent(ctx context.Context, gpgSvc gpg.GPGService) bool {
+	ui.LogWarning("gpg-agent is not running; gpg needs it to use the YubiKey")
+	if !ui.Confirm("Start gpg-agent now?") {
+		return false
+	}
+	if err := gpgSvc.LaunchAgent(ctx); err != nil {
+		ui.LogError("%v", err)
+		return false
 	}
+	ui.LogSuccess("gpg-agent started")
+	return true
+}
 
-	// Test signing with the specific subkey ID from the current YubiKey
-	fmt.Print("Testing GPG signing... ")
-	if signingSubkeyID == "" {
-		// If we couldn't get the subkey ID from the card, try to find it by card serial
-		// This handles the case where the card status shows "[none]" but the key is actually on the card
-		if present && cardInfo != nil {
-			cardSerialFormatted := fmt.Sprintf("0006 %s", cardInfo.Serial)
-			cardSerialFormattedAlt := fmt.Sprintf("0006%s", cardInfo.Serial)
-			for _, key := range keys {
-				if key.Type == "ssb" && contains(key.Capabilities, "S") {
-					if key.CardNo == cardSerialFormatted || key.CardNo == cardSerialFormattedAlt {
-						signingSubkeyID = key.KeyID
-						break
-					}
-				}
-			}
-		}
-		// If still not found, we can't test signing without knowing which subkey to use
-		// Don't fall back to primary key ID as it will prompt for card selection
-		if signingSubkeyID == "" {
-			fmt.Print("SKIPPED (unable to identify signing subkey on YubiKey)\n")
-			ui.LogInfo("  └─ Could not find the signing subkey on the current YubiKey.")
-			ui.LogInfo("  └─ This may happen if the subkey was recently moved to the YubiKey.")
-			ui.LogInfo("  └─ Try running 'gpg --card-status' to verify the key is on the card.")
-		}
+// retrySigningInteractively offers to redo the signing test of result with
+// a PIN prompt, and replaces its "signing test" check with the outcome.
+func retrySigningInteractively(ctx context.Context, manager *ykgpg.Manager, result *ykgpg.VerifyResult) {
+	ui.LogInfo("Automated signing test requires PIN entry.")
+	if !ui.Confirm("Run interactive signing test? (You'll need to enter your PIN)") {
+		return
 	}
 
-	// Only test signing if we found a signing subkey ID
-	if signingSubkeyID != "" {
-		// Use echo to provide input to gpg with explicit key ID from the current card
-		// Try to use the full fingerprint if available, as it's more specific
-		keyIDForSigning := signingSubkeyID
-		for _, key := range keys {
-			if key.KeyID == signingSubkeyID && key.Fingerprint != "" {
-				// Use full fingerprint for more specificity
-				keyIDForSigning = key.Fingerprint
-				break
-			}
-		}
+	// The outcome is reported with the other checks
+	ui.LogInfo("Testing signing (enter PIN when prompted)...")
+	if err := signInteractively(result.SigningKeySpec); err != nil {
+		result.SetCheck(CheckResult{Name: "signing test", Status: CheckFail, Message: fmt.Sprintf("%v; this might be due to PIN entry issues. Try manually: %s",
+			err, ykgpg.ManualSigningCommand(result.SigningKeySpec))})
+		result.SigningFailed = true
+		return
+	}
+	result.SetCheck(manager.SignatureCounterCheck(ctx, result.Card))
+}
 
-		// Create a context with timeout for the signing test
-		// This prevents hanging if GPG prompts for PIN or card selection
-		signingCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-		defer cancel()
-
-		// First try non-interactive mode (works if PIN is cached or using GUI pinentry)
-		testCmd := exec.CommandContext(signingCtx, "sh", "-c", fmt.Sprintf("echo 'test' | gpg --batch --pinentry-mode=loopback --default-key %s --sign --armor > /dev/null 2>&1", keyIDForSigning))
-		if err := testCmd.Run(); err == nil {
-			fmt.Print("OK\n")
-		} else {
-			// Non-interactive failed - offer interactive test
-			fmt.Print("INTERACTIVE\n")
-			ui.LogInfo("  └─ Automated test requires PIN entry.")
-
-			if ui.Confirm("  └─ Run interactive signing test? (You'll need to enter your PIN)") {
-				// Create a temporary file with test data to sign
-				// This allows pinentry to use stdin/TTY for PIN entry
-				tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("ykgpg-test-%d.txt", time.Now().Unix()))
-				if err := os.WriteFile(tmpFile, []byte("test\n"), 0644); err != nil {
-					fmt.Print("  └─ Testing signing... FAILED\n")
-					ui.LogInfo("  └─ Error creating temp file: %v", err)
-					errors++
-				} else {
-					defer os.Remove(tmpFile) // Clean up temp file
-
-					fmt.Print("  └─ Testing signing (enter PIN when prompted)... ")
-					// Flush stdout to ensure the prompt is visible before GPG runs
-					os.Stdout.Sync()
-
-					// Sign the file - this allows pinentry to use the TTY
-					// Use --quiet to suppress most informational messages
-					interactiveCmd := exec.Command("gpg", "--quiet", "--default-key", keyIDForSigning, "--sign", "--armor", "--output", "/dev/null", tmpFile)
-					// Connect stdin for pinentry
-					interactiveCmd.Stdin = os.Stdin
-					// Capture stderr to filter out informational messages, but pinentry uses TTY directly
-					var stderrBuf bytes.Buffer
-					interactiveCmd.Stderr = &stderrBuf
-					// Redirect stdout to /dev/null to avoid GPG output mixing with our formatting
-					devNull, _ := os.OpenFile("/dev/null", os.O_WRONLY, 0)
-					defer devNull.Close()
-					interactiveCmd.Stdout = devNull
-
-					// Ensure GPG_TTY is set for pinentry
-					if tty := os.Getenv("GPG_TTY"); tty == "" {
-						// Try to get TTY from /dev/tty
-						if ttyFile, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
-							ttyFile.Close()
-							interactiveCmd.Env = append(os.Environ(), "GPG_TTY=/dev/tty")
-						}
-					}
-
-					if err := interactiveCmd.Run(); err == nil {
-						fmt.Print("OK\n")
-					} else {
-						fmt.Print("FAILED\n")
-						// Only show stderr if it contains actual errors (not just informational messages)
-						stderrStr := stderrBuf.String()
-						if stderrStr != "" && !containsString(stderrStr, "using") {
-							ui.LogInfo("  └─ GPG error: %s", stderrStr)
-						}
-						ui.LogInfo("  └─ Error: %v", err)
-						ui.LogInfo("  └─ This might be due to PIN entry issues. Try manually:")
-						ui.LogInfo("  └─   echo 'test' | gpg --default-key %s --sign --armor", keyIDForSigning)
-						errors++
-					}
-				}
-			} else {
-				ui.LogInfo("  └─ To test manually: echo 'test' | gpg --default-key %s --sign --armor", keyIDForSigning)
-			}
-		}
+// printVerifyResult prints each check with printCheckLine, followed by the
+// summary.
+func printVerifyResult(result *ykgpg.VerifyResult) {
+	for _, check := range result.Checks {
+		printCheckLine(check)
 	}
 
 	fmt.Println()
-	if errors == 0 {
+	summary := result.Summary()
+	if summary.Failed == 0 {
 		ui.LogSuccess("All checks passed!")
 	} else {
-		ui.LogError("%d check(s) failed", errors)
+		ui.LogError("%d check(s) failed", summary.Failed)
+	}
+	ui.LogInfo("%d passed, %d warning(s), %d failed", summary.Passed, summary.Warnings, summary.Failed)
+}
+
+// printCheckLine prints a check as "Checking <name>... <STATUS> (<message>)".
+func printCheckLine(check CheckResult) {
+	fmt.Printf("Checking %s... ", check.Name)
+	switch check.Status {
+	case CheckPass:
+		fmt.Print("OK")
+	case CheckWarn:
+		fmt.Print("WARNING")
+	default:
+		fmt.Print("FAILED")
 	}
+	if check.Message != "" {
+		fmt.Printf(" (%s)", check.Message)
+	}
+	fmt.Println()
+}
+
+// newVerifyReport returns the JSON document for result.
+func newVerifyReport(result *ykgpg.VerifyResult) verifyReport {
+	summary := result.Summary()
+	checks := result.Checks
+	if checks == nil {
+		checks = []CheckResult{}
+	}
+	return verifyReport{Schema: verifySchemaVersion, Result: summary.Result, Summary: summary, Checks: checks}
+}
 
-	if errors > 0 {
+// verifyError returns nil if every check passed. Otherwise it returns an
+// error whose exit code reflects the most fundamental failure: a missing
+// card makes the signing test meaningless, and signing matters more than
+// git settings.
+func verifyError(result *ykgpg.VerifyResult) error {
+	switch {
+	case result.Failures() == 0:
+		return nil
+	case result.NoCard:
+		return newExitCodeError(ExitNoCard, "verification failed: no YubiKey detected")
+	case result.SigningFailed:
+		return newExitCodeError(ExitSigningFailed, "verification failed: signing test failed")
+	case result.GitMisconfigured:
+		return newExitCodeError(ExitGitMisconfigured, "verification failed: git is not configured for signing")
+	case result.EncryptionFailed:
+		return fmt.Errorf("verification failed: encryption test failed")
+	default:
 		return fmt.Errorf("verification failed")
 	}
+}
+
+// defaultSigningTimeout bounds each non-interactive signing attempt in verify.
+const defaultSigningTimeout = ykgpg.DefaultSigningTimeout
+
+// signInteractively signs a test payload with keyID, letting pinentry prompt
+// for the PIN on the terminal. The error includes gpg's diagnostics, if any.
+func signInteractively(keyID string) error {
+	// Sign a temporary file rather than stdin, so pinentry can use the TTY
+	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("ykgpg-test-%d.txt", time.Now().Unix()))
+	if err := os.WriteFile(tmpFile, []byte(ykgpg.SigningTestPayload), 0644); err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile)
+
+	// Flush stdout to ensure the prompt is visible before GPG runs
+	os.Stdout.Sync()
+
+	// Use --quiet to suppress most informational messages
+	interactiveCmd := exec.Command(gpgBinary(), "--quiet", "--default-key", keyID, "--sign", "--armor", "--output", "/dev/null", tmpFile)
+	interactiveCmd.Env = append(os.Environ(), childEnv()...)
+	// Connect stdin for pinentry
+	interactiveCmd.Stdin = os.Stdin
+	// Capture stderr to filter out informational messages, but pinentry uses TTY directly
+	var stderrBuf bytes.Buffer
+	interactiveCmd.Stderr = &stderrBuf
+	// Redirect stdout to /dev/null to avoid GPG output mixing with our formatting
+	devNull, _ := os.OpenFile("/dev/null", os.O_WRONLY, 0)
+	defer devNull.Close()
+	interactiveCmd.Stdout = devNull
 
+	// Ensure GPG_TTY is set for pinentry
+	if tty := os.Getenv("GPG_TTY"); tty == "" {
+		if ttyFile, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+			ttyFile.Close()
+			interactiveCmd.Env = append(interactiveCmd.Env, "GPG_TTY=/dev/tty")
+		}
+	}
+
+	if err := interactiveCmd.Run(); err != nil {
+		// Only include stderr if it contains actual errors (not just informational messages)
+		if stderrStr := strings.TrimSpace(stderrBuf.String()); stderrStr != "" && !containsString(stderrStr, "using") {
+			return fmt.Errorf("%w: %s", err, stderrStr)
+		}
+		return err
+	}
 	return nil
 }
 
-// getGitConfig retrieves a git config value.
-func getGitConfig(key string) string {
-	cmd := exec.Command("git", "config", "--global", key)
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
+// reportSignatureCounter re-reads the card after a successful signing test and
+// shows whether its signature counter went up, which confirms the on-card
+// key made the signature.
+func reportSignatureCounter(ctx context.Context, manager *ykgpg.Manager, before *gpg.CardInfo) {
+	switch check := manager.SignatureCounterCheck(ctx, before); {
+	case check.Message == "":
+	case check.Status == CheckPass:
+		ui.LogInfo("  └─ %s", check.Message)
+	default:
+		ui.LogWarning("  └─ %s", check.Message)
 	}
-	return string(output[:len(output)-1]) // Remove trailing newline
 }
@@ -4,11 +4,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
 	"github.com/spf13/viper"
 )
 
+// DefaultSubkeyExpiry is the expiration of subkeys created by the setup
+// commands unless default_subkey_expiry or --expires says otherwise.
+const DefaultSubkeyExpiry = "5y"
+
+// DefaultExpiryWarnDays is how many days before a key expires status warns
+// about it by default.
+const DefaultExpiryWarnDays = 30
+
 // Config holds all configuration values for the application.
 type Config struct {
 	PrimaryKeyID          string `mapstructure:"primary_key_id"`
@@ -19,25 +31,168 @@ type Config struct {
 	MasterKeyPath         string `mapstructure:"master_key_path"`
 	BackupDir             string `mapstructure:"backup_dir"`
 	NoColor               bool   `mapstructure:"no_color"`
+	EncryptBackup         bool   `mapstructure:"encrypt_backup"`
+	// BackupIncludeSubkeys makes backups also save the secret subkeys (never
+	// the master key). Combine it with EncryptBackup.
+	BackupIncludeSubkeys bool `mapstructure:"backup_include_subkeys"`
+	// GPGTimeout limits how long a non-interactive gpg/ykman call may run.
+	// Zero disables the timeout.
+	GPGTimeout time.Duration `mapstructure:"gpg_timeout"`
+	// GPGBinary and YkmanBinary are the gpg and ykman programs to run, for
+	// systems where they are installed as e.g. gpg2 or outside $PATH.
+	GPGBinary   string `mapstructure:"gpg_binary"`
+	YkmanBinary string `mapstructure:"ykman_binary"`
+	// CardRetries is how many times a transient card error, such as a
+	// YubiKey that hasn't settled after insertion, is retried.
+	CardRetries int `mapstructure:"card_retries"`
+	// GnuPGHome, if set, is passed to every external command as GNUPGHOME.
+	GnuPGHome string `mapstructure:"gnupg_home"`
+	// Keyservers lists every keyserver to upload to. It takes precedence
+	// over Keyserver; use KeyserverList to get the effective list.
+	Keyservers []string `mapstructure:"keyservers"`
+	// KeyserverProxy is the HTTP proxy for keyserver uploads and refreshes,
+	// e.g. http://proxy.example.com:3128. Empty means no proxy option.
+	KeyserverProxy string `mapstructure:"keyserver_proxy"`
+	// ExpiryWarnDays is how many days before a key expires status starts
+	// warning about it.
+	ExpiryWarnDays int `mapstructure:"expiry_warn_days"`
+	// DefaultSubkeyExpiry is the expiration given to new subkeys by the
+	// setup commands, in any form gpg.ParseExpiry accepts (e.g. "2y").
+	DefaultSubkeyExpiry string `mapstructure:"default_subkey_expiry"`
+	// ExpectedSigningSubkeys is how many unexpired signing subkeys status
+	// expects, e.g. one per YubiKey in use. Zero disables the check.
+	ExpectedSigningSubkeys int `mapstructure:"expected_signing_subkeys"`
+	// JournalFile is where setup, move-subkey, revoke and extend record
+	// what they did to each key and YubiKey.
+	JournalFile string `mapstructure:"journal_file"`
+
+	// YubiKeys maps YubiKey serial numbers to the label and location the
+	// user gave each physical key. Written by 'ykgpg label'.
+	YubiKeys map[string]YubiKey `mapstructure:"yubikeys"`
+
+	// Profile is the name of the active profile, if any.
+	Profile string `mapstructure:"profile"`
+	// Profiles holds named identities from the config file. The selected
+	// profile's values replace the top-level values from the file.
+	Profiles map[string]Profile `mapstructure:"profiles"`
+}
+
+// Profile holds the per-identity settings of a named profile.
+type Profile struct {
+	PrimaryKeyID          string `mapstructure:"primary_key_id"`
+	PrimaryKeyFingerprint string `mapstructure:"primary_key_fingerprint"`
+	UserName              string `mapstructure:"user_name"`
+	UserEmail             string `mapstructure:"user_email"`
+}
+
+// YubiKey holds what the user recorded about a physical YubiKey.
+type YubiKey struct {
+	Label    string `mapstructure:"label" yaml:"label"`
+	Location string `mapstructure:"location" yaml:"location,omitempty"`
+	Notes    string `mapstructure:"notes" yaml:"notes,omitempty"`
+}
+
+// String returns the label with the location, if set, e.g. "Key B (work laptop)".
+func (y YubiKey) String() string {
+	if y.Location == "" {
+		return y.Label
+	}
+	return fmt.Sprintf("%s (%s)", y.Label, y.Location)
+}
+
+// KeyserverList returns the keyservers to upload to: Keyservers if set,
+// otherwise Keyserver, which may hold several comma-separated URLs.
+// Blank entries and duplicates are dropped.
+func (c *Config) KeyserverList() []string {
+	values := c.Keyservers
+	if len(values) == 0 {
+		values = []string{c.Keyserver}
+	}
+
+	var servers []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, server := range strings.Split(value, ",") {
+			server = strings.TrimSpace(server)
+			if server == "" || seen[server] {
+				continue
+			}
+			seen[server] = true
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// Dir returns the directory holding ykgpg's config file:
+// $XDG_CONFIG_HOME/ykgpg, or ~/.config/ykgpg if XDG_CONFIG_HOME isn't set.
+func Dir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ykgpg")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "ykgpg")
+}
+
+// DefaultBackupDir returns where backups go unless backup_dir is set:
+// $XDG_DATA_HOME/ykgpg/backups if XDG_DATA_HOME is set, otherwise
+// ~/.gnupg/backups, where earlier versions always put them.
+func DefaultBackupDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ykgpg", "backups")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".gnupg", "backups")
+}
+
+// DefaultJournalFile returns where the journal is kept unless journal_file
+// is set: journal.jsonl in DataDir().
+func DefaultJournalFile() string {
+	return filepath.Join(DataDir(), "journal.jsonl")
+}
+
+// DataDir returns the directory holding the state ykgpg keeps between runs:
+// $XDG_DATA_HOME/ykgpg, or ~/.local/share/ykgpg if XDG_DATA_HOME isn't set.
+func DataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ykgpg")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".local", "share", "ykgpg")
 }
 
 // Load reads configuration from multiple sources with the following priority:
 // 1. CLI flags (highest priority)
 // 2. Environment variables
-// 3. Config file
-// 4. Defaults (lowest priority)
+// 3. Selected profile from the config file
+// 4. Config file
+// 5. Defaults (lowest priority)
+//
+// The config file is config.yaml in Dir() or the current directory, unless
+// the "config" key (--config or YKGPG_CONFIG) names one explicitly.
+//
+// The profile is selected with the "profile" key (--profile, YKGPG_PROFILE,
+// or a top-level profile: entry in the config file).
 func Load() (*Config, error) {
 	// Set defaults
 	viper.SetDefault("keyserver", "hkps://keys.openpgp.org")
-	viper.SetDefault("backup_dir", filepath.Join(os.Getenv("HOME"), ".gnupg", "backups"))
+	// No servers by default; Keyserver applies. Registering the key lets
+	// YKGPG_KEYSERVERS be picked up from the environment.
+	viper.SetDefault("keyservers", []string{})
+	viper.SetDefault("keyserver_proxy", "")
+	viper.SetDefault("backup_dir", DefaultBackupDir())
+	viper.SetDefault("gpg_timeout", executor.DefaultTimeout)
+	viper.SetDefault("gpg_binary", "gpg")
+	viper.SetDefault("ykman_binary", "ykman")
+	viper.SetDefault("card_retries", 3)
+	viper.SetDefault("expiry_warn_days", DefaultExpiryWarnDays)
+	viper.SetDefault("expected_signing_subkeys", 0)
+	viper.SetDefault("default_subkey_expiry", DefaultSubkeyExpiry)
+	viper.SetDefault("journal_file", DefaultJournalFile())
 
 	// Set config file name and paths
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 
 	// Add config paths (in order of precedence)
-	configDir := filepath.Join(os.Getenv("HOME"), ".config", "ykgpg")
-	viper.AddConfigPath(configDir)
+	viper.AddConfigPath(Dir())
 	viper.AddConfigPath(".")
 
 	// Environment variables
@@ -45,6 +200,15 @@ func Load() (*Config, error) {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	// An explicit config file (--config or YKGPG_CONFIG) must exist; it
+	// replaces the search paths above
+	if path := ExplicitPath(); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("config file %s not found: %w", path, err)
+		}
+		viper.SetConfigFile(path)
+	}
+
 	// Read config file (optional - won't error if not found)
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -53,14 +217,46 @@ func Load() (*Config, error) {
 		// Config file not found is OK, we'll use defaults/env/flags
 	}
 
+	if err := applyProfile(viper.GetString("profile")); err != nil {
+		return nil, err
+	}
+
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// Catch a bad expiry now rather than halfway through a setup
+	if _, err := gpg.ParseExpiry(cfg.DefaultSubkeyExpiry); err != nil {
+		return nil, fmt.Errorf("invalid default_subkey_expiry: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// ExplicitPath returns the config file named by --config or YKGPG_CONFIG,
+// or "" if the default locations are searched.
+func ExplicitPath() string {
+	if path := viper.GetString("config"); path != "" {
+		return path
+	}
+	return os.Getenv("YKGPG_CONFIG")
+}
+
+// applyProfile merges the named profile over the config file values.
+// Merging into the config layer keeps env vars and flags higher priority.
+func applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	// Viper lower-cases keys, so profile names are case-insensitive
+	key := "profiles." + strings.ToLower(name)
+	if !viper.IsSet(key) {
+		return fmt.Errorf("profile %q not found in config file", name)
+	}
+	return viper.MergeConfigMap(viper.GetStringMap(key))
+}
+
 // BindFlag binds a single CLI flag to viper configuration.
 // This is a helper function to be called from Cobra command setup.
 func BindFlag(flagName, viperKey string) error {
@@ -68,7 +264,28 @@ func BindFlag(flagName, viperKey string) error {
 	return nil
 }
 
-// Validate checks that required configuration values are set.
+var (
+	// keyIDPattern matches a long (16) or short (8) hex key ID.
+	keyIDPattern = regexp.MustCompile(`^([0-9A-F]{16}|[0-9A-F]{8})$`)
+	// fingerprintPattern matches a v4 key fingerprint.
+	fingerprintPattern = regexp.MustCompile(`^[0-9A-F]{40}$`)
+)
+
+// NormalizeKeyID upper-cases a key ID and strips an optional 0x prefix.
+func NormalizeKeyID(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	return strings.TrimPrefix(id, "0X")
+}
+
+// NormalizeFingerprint upper-cases a fingerprint and removes the spaces gpg
+// uses to group it.
+func NormalizeFingerprint(fpr string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(fpr), " ", ""))
+}
+
+// Validate checks that required configuration values are set and that the
+// key ID and fingerprint are well-formed and agree. The key ID and
+// fingerprint are normalized in place.
 func (c *Config) Validate() error {
 	if c.PrimaryKeyID == "" {
 		return fmt.Errorf("primary_key_id is required")
@@ -82,5 +299,21 @@ func (c *Config) Validate() error {
 	if c.UserEmail == "" {
 		return fmt.Errorf("user_email is required")
 	}
+
+	keyID := NormalizeKeyID(c.PrimaryKeyID)
+	if !keyIDPattern.MatchString(keyID) {
+		return fmt.Errorf("primary_key_id %q must be 16 (or 8) hexadecimal characters", c.PrimaryKeyID)
+	}
+	fingerprint := NormalizeFingerprint(c.PrimaryKeyFingerprint)
+	if !fingerprintPattern.MatchString(fingerprint) {
+		return fmt.Errorf("primary_key_fingerprint %q must be exactly 40 hexadecimal characters", c.PrimaryKeyFingerprint)
+	}
+	if !strings.HasSuffix(fingerprint, keyID) {
+		return fmt.Errorf("primary_key_id %s does not match primary_key_fingerprint %s: the key ID should be the last %d characters of the fingerprint (%s)",
+			keyID, fingerprint, len(keyID), fingerprint[len(fingerprint)-len(keyID):])
+	}
+
+	c.PrimaryKeyID = keyID
+	c.PrimaryKeyFingerprint = fingerprint
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +21,7 @@ func TestConfig_Validate(t *testing.T) {
 			name: "valid config",
 			config: &Config{
 				PrimaryKeyID:          "ABC123DEF4567890",
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserName:              "Test User",
 				UserEmail:             "test@example.com",
 			},
@@ -29,7 +30,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "missing primary key ID",
 			config: &Config{
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserName:              "Test User",
 				UserEmail:             "test@example.com",
 			},
@@ -48,7 +49,7 @@ func TestConfig_Validate(t *testing.T) {
 			name: "missing user name",
 			config: &Config{
 				PrimaryKeyID:          "ABC123DEF4567890",
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserEmail:             "test@example.com",
 			},
 			wantErr: true,
@@ -57,7 +58,7 @@ func TestConfig_Validate(t *testing.T) {
 			name: "missing user email",
 			config: &Config{
 				PrimaryKeyID:          "ABC123DEF4567890",
-				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12",
+				PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
 				UserName:              "Test User",
 			},
 			wantErr: true,
@@ -84,7 +85,7 @@ func TestLoad_WithConfigFile(t *testing.T) {
 
 	configFile := filepath.Join(tmpDir, "config.yaml")
 	configContent := `primary_key_id: "ABC123DEF4567890"
-primary_key_fingerprint: "ABCDEF1234567890ABCDEF1234567890ABCDEF12"
+primary_key_fingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890"
 user_name: "Test User"
 user_email: "test@example.com"
 keyserver: "hkps://keys.openpgp.org"
@@ -107,7 +108,333 @@ keyserver: "hkps://keys.openpgp.org"
 
 	require.NoError(t, err)
 	assert.Equal(t, "ABC123DEF4567890", cfg.PrimaryKeyID)
-	assert.Equal(t, "ABCDEF1234567890ABCDEF1234567890ABCDEF12", cfg.PrimaryKeyFingerprint)
+	assert.Equal(t, "ABCDEF1234567890ABCDEF12ABC123DEF4567890", cfg.PrimaryKeyFingerprint)
 	assert.Equal(t, "Test User", cfg.UserName)
 	assert.Equal(t, "test@example.com", cfg.UserEmail)
 }
+
+func TestLoad_GPGTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, cfg.GPGTimeout)
+
+	t.Setenv("YKGPG_GPG_TIMEOUT", "30s")
+	viper.Reset()
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.GPGTimeout)
+}
+
+func TestLoad_Binaries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "gpg", cfg.GPGBinary)
+	assert.Equal(t, "ykman", cfg.YkmanBinary)
+
+	t.Setenv("YKGPG_GPG_BINARY", "/usr/local/bin/gpg2")
+	t.Setenv("YKGPG_YKMAN_BINARY", "/opt/ykman")
+	viper.Reset()
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/local/bin/gpg2", cfg.GPGBinary)
+	assert.Equal(t, "/opt/ykman", cfg.YkmanBinary)
+}
+
+func TestConfig_KeyserverList(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected []string
+	}{
+		{
+			name:     "single keyserver",
+			cfg:      Config{Keyserver: "hkps://keys.openpgp.org"},
+			expected: []string{"hkps://keys.openpgp.org"},
+		},
+		{
+			name:     "comma-separated keyserver",
+			cfg:      Config{Keyserver: "hkps://keys.openpgp.org, hkps://keyserver.ubuntu.com,"},
+			expected: []string{"hkps://keys.openpgp.org", "hkps://keyserver.ubuntu.com"},
+		},
+		{
+			name: "keyservers list takes precedence",
+			cfg: Config{
+				Keyserver:  "hkps://keys.openpgp.org",
+				Keyservers: []string{"hkps://keyserver.ubuntu.com", "hkp://keys.example.com", "hkps://keyserver.ubuntu.com"},
+			},
+			expected: []string{"hkps://keyserver.ubuntu.com", "hkp://keys.example.com"},
+		},
+		{
+			name:     "nothing set",
+			cfg:      Config{},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cfg.KeyserverList())
+		})
+	}
+}
+
+func TestLoad_ExpiryWarnDays(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultExpiryWarnDays, cfg.ExpiryWarnDays)
+
+	t.Setenv("YKGPG_EXPIRY_WARN_DAYS", "60")
+	viper.Reset()
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 60, cfg.ExpiryWarnDays)
+}
+
+func TestLoad_ExpectedSigningSubkeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ExpectedSigningSubkeys)
+
+	t.Setenv("YKGPG_EXPECTED_SIGNING_SUBKEYS", "2")
+	viper.Reset()
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.ExpectedSigningSubkeys)
+}
+
+func TestLoad_DefaultSubkeyExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "5y", cfg.DefaultSubkeyExpiry)
+
+	t.Setenv("YKGPG_DEFAULT_SUBKEY_EXPIRY", "2y")
+	viper.Reset()
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "2y", cfg.DefaultSubkeyExpiry)
+
+	t.Setenv("YKGPG_DEFAULT_SUBKEY_EXPIRY", "two years")
+	viper.Reset()
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid default_subkey_expiry")
+}
+
+func TestLoad_Keyservers(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `keyservers:
+  - hkps://keys.openpgp.org
+  - hkps://keyserver.ubuntu.com
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(configContent), 0644))
+	t.Setenv("HOME", tmpDir)
+
+	viper.Reset()
+	viper.AddConfigPath(tmpDir)
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hkps://keys.openpgp.org", "hkps://keyserver.ubuntu.com"}, cfg.KeyserverList())
+
+	t.Setenv("YKGPG_KEYSERVERS", "hkp://keys.example.com,hkps://keys.openpgp.org")
+	viper.Reset()
+	viper.AddConfigPath(tmpDir)
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hkp://keys.example.com", "hkps://keys.openpgp.org"}, cfg.KeyserverList())
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	assert.Equal(t, filepath.Join("/home/test", ".config", "ykgpg"), Dir())
+	assert.Equal(t, filepath.Join("/home/test", ".config", "ykgpg", "config.yaml"), DefaultPath())
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	assert.Equal(t, filepath.Join("/xdg/config", "ykgpg"), Dir())
+	assert.Equal(t, filepath.Join("/xdg/config", "ykgpg", "config.yaml"), DefaultPath())
+}
+
+func TestDefaultBackupDir(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+
+	t.Setenv("XDG_DATA_HOME", "")
+	assert.Equal(t, filepath.Join("/home/test", ".gnupg", "backups"), DefaultBackupDir())
+
+	t.Setenv("XDG_DATA_HOME", "/xdg/data")
+	assert.Equal(t, filepath.Join("/xdg/data", "ykgpg", "backups"), DefaultBackupDir())
+}
+
+func TestDefaultJournalFile(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+
+	t.Setenv("XDG_DATA_HOME", "")
+	assert.Equal(t, filepath.Join("/home/test", ".local", "share", "ykgpg", "journal.jsonl"), DefaultJournalFile())
+
+	t.Setenv("XDG_DATA_HOME", "/xdg/data")
+	assert.Equal(t, filepath.Join("/xdg/data", "ykgpg", "journal.jsonl"), DefaultJournalFile())
+}
+
+func TestLoad_XDGDirs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configHome := t.TempDir()
+	dataHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	path := filepath.Join(configHome, "ykgpg", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("primary_key_id: \"XDGKEY0000000000\"\n"), 0644))
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "XDGKEY0000000000", cfg.PrimaryKeyID)
+	assert.Equal(t, path, viper.ConfigFileUsed())
+	assert.Equal(t, filepath.Join(dataHome, "ykgpg", "backups"), cfg.BackupDir)
+}
+
+func TestLoad_ExplicitConfigFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "project.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("primary_key_id: \"PROJECTKEY000000\"\n"), 0644))
+
+	t.Setenv("YKGPG_CONFIG", path)
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "PROJECTKEY000000", cfg.PrimaryKeyID)
+	assert.Equal(t, path, viper.ConfigFileUsed())
+
+	t.Setenv("YKGPG_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	viper.Reset()
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.yaml not found")
+}
+
+func TestLoad_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `primary_key_id: "DEFAULTKEY0000000"
+primary_key_fingerprint: "DEFAULTFPR"
+user_name: "Default User"
+user_email: "default@example.com"
+profiles:
+  work:
+    primary_key_id: "WORKKEY000000000"
+    primary_key_fingerprint: "WORKFPR"
+    user_email: "me@work.example.com"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(configContent), 0644))
+	t.Setenv("HOME", tmpDir)
+
+	load := func() (*Config, error) {
+		viper.Reset()
+		viper.AddConfigPath(tmpDir)
+		return Load()
+	}
+
+	t.Run("no profile uses top-level values", func(t *testing.T) {
+		cfg, err := load()
+		require.NoError(t, err)
+		assert.Equal(t, "", cfg.Profile)
+		assert.Equal(t, "DEFAULTKEY0000000", cfg.PrimaryKeyID)
+		assert.Contains(t, cfg.Profiles, "work")
+	})
+
+	t.Run("profile overrides file values", func(t *testing.T) {
+		t.Setenv("YKGPG_PROFILE", "work")
+		cfg, err := load()
+		require.NoError(t, err)
+		assert.Equal(t, "work", cfg.Profile)
+		assert.Equal(t, "WORKKEY000000000", cfg.PrimaryKeyID)
+		assert.Equal(t, "WORKFPR", cfg.PrimaryKeyFingerprint)
+		assert.Equal(t, "me@work.example.com", cfg.UserEmail)
+		// Not set in the profile, so the top-level value remains
+		assert.Equal(t, "Default User", cfg.UserName)
+	})
+
+	t.Run("env still overrides profile", func(t *testing.T) {
+		t.Setenv("YKGPG_PROFILE", "work")
+		t.Setenv("YKGPG_USER_EMAIL", "env@example.com")
+		cfg, err := load()
+		require.NoError(t, err)
+		assert.Equal(t, "env@example.com", cfg.UserEmail)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		t.Setenv("YKGPG_PROFILE", "missing")
+		_, err := load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `profile "missing" not found`)
+	})
+}
+
+func TestConfig_Validate_Format(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			PrimaryKeyID:          "ABC123DEF4567890",
+			PrimaryKeyFingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890",
+			UserName:              "Test User",
+			UserEmail:             "test@example.com",
+		}
+	}
+
+	t.Run("normalizes case, spaces and 0x prefix", func(t *testing.T) {
+		cfg := base()
+		cfg.PrimaryKeyID = "0xabc123def4567890"
+		cfg.PrimaryKeyFingerprint = "abcd ef12 3456 7890 abcd  ef12 abc1 23de f456 7890"
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, "ABC123DEF4567890", cfg.PrimaryKeyID)
+		assert.Equal(t, "ABCDEF1234567890ABCDEF12ABC123DEF4567890", cfg.PrimaryKeyFingerprint)
+	})
+
+	t.Run("short key ID", func(t *testing.T) {
+		cfg := base()
+		cfg.PrimaryKeyID = "F4567890"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	tests := []struct {
+		name        string
+		keyID       string
+		fingerprint string
+		wantErr     string
+	}{
+		{"key ID wrong length", "ABC123DEF456789", "", "must be 16 (or 8) hexadecimal characters"},
+		{"key ID not hex", "XYZ123DEF4567890", "", "must be 16 (or 8) hexadecimal characters"},
+		{"fingerprint too short", "", "ABCDEF1234567890ABCDEF12ABC123DEF456789", "must be exactly 40 hexadecimal characters"},
+		{"fingerprint not hex", "", "GBCDEF1234567890ABCDEF12ABC123DEF4567890", "must be exactly 40 hexadecimal characters"},
+		{"key ID does not match fingerprint", "1111222233334444", "", "the key ID should be the last 16 characters of the fingerprint (ABC123DEF4567890)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			if tt.keyID != "" {
+				cfg.PrimaryKeyID = tt.keyID
+			}
+			if tt.fingerprint != "" {
+				cfg.PrimaryKeyFingerprint = tt.fingerprint
+			}
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
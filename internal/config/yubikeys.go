@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath returns the config file written by 'ykgpg config init'.
+func DefaultPath() string {
+	return filepath.Join(Dir(), "config.yaml")
+}
+
+// YubiKeyFor returns what is recorded about the YubiKey with the given
+// serial. Serials are compared in normalized form, so "0006 12345678" and
+// "12345678" find the same entry.
+func (c *Config) YubiKeyFor(serial string) (YubiKey, bool) {
+	serial = gpg.NormalizeCardSerial(serial)
+	if serial == "" {
+		return YubiKey{}, false
+	}
+	for recorded, yubikey := range c.YubiKeys {
+		if gpg.NormalizeCardSerial(recorded) == serial {
+			return yubikey, true
+		}
+	}
+	return YubiKey{}, false
+}
+
+// SetYubiKey records yubikey under serial in the yubikeys: section of the
+// config file at path, creating the file if needed. The rest of the file,
+// including comments, is left as it is.
+func SetYubiKey(path, serial string, yubikey YubiKey) error {
+	serial = gpg.NormalizeCardSerial(serial)
+	if serial == "" {
+		return fmt.Errorf("serial must not be empty")
+	}
+	if yubikey.Label == "" {
+		return fmt.Errorf("label must not be empty")
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	case os.IsNotExist(err):
+	default:
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+
+	var entry yaml.Node
+	if err := entry.Encode(yubikey); err != nil {
+		return fmt.Errorf("failed to encode YubiKey entry: %w", err)
+	}
+	yubikeys := mappingValue(root, "yubikeys", 0)
+	if yubikeys.Kind != yaml.MappingNode {
+		*yubikeys = yaml.Node{Kind: yaml.MappingNode}
+	}
+	// Quote the serial so YAML doesn't read it as a number and drop leading zeros
+	key := mappingValue(yubikeys, serial, yaml.DoubleQuotedStyle)
+	*key = entry
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a YAML mapping, appending
+// an empty entry with the key written in style if the key is missing.
+func mappingValue(mapping *yaml.Node, key string, style yaml.Style) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key, Style: style}
+	valueNode := &yaml.Node{}
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
+	return valueNode
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYubiKey_String(t *testing.T) {
+	assert.Equal(t, "Key A", YubiKey{Label: "Key A"}.String())
+	assert.Equal(t, "Key B (work laptop)", YubiKey{Label: "Key B", Location: "work laptop"}.String())
+}
+
+func TestConfig_YubiKeyFor(t *testing.T) {
+	cfg := &Config{YubiKeys: map[string]YubiKey{
+		"12345678": {Label: "Key A"},
+	}}
+
+	yubikey, ok := cfg.YubiKeyFor("0006 12345678")
+	assert.True(t, ok)
+	assert.Equal(t, "Key A", yubikey.Label)
+
+	_, ok = cfg.YubiKeyFor("23456789")
+	assert.False(t, ok)
+	_, ok = cfg.YubiKeyFor("")
+	assert.False(t, ok)
+}
+
+func TestSetYubiKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ykgpg", "config.yaml")
+
+	require.NoError(t, SetYubiKey(path, "12345678", YubiKey{Label: "Key A"}))
+	require.NoError(t, SetYubiKey(path, "0006 23456789", YubiKey{Label: "Key B", Location: "work laptop", Notes: "blue keyring"}))
+	// Relabelling replaces the entry
+	require.NoError(t, SetYubiKey(path, "12345678", YubiKey{Label: "Key A2", Location: "safe"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `yubikeys:
+    "12345678":
+        label: Key A2
+        location: safe
+    "23456789":
+        label: Key B
+        location: work laptop
+        notes: blue keyring
+`, string(data))
+}
+
+func TestSetYubiKey_KeepsExistingConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".config", "ykgpg", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`# my settings
+primary_key_id: ABC123DEF4567890
+yubikeys:
+  12345678:
+    label: Key A
+`), 0644))
+
+	require.NoError(t, SetYubiKey(path, "23456789", YubiKey{Label: "Key B"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# my settings")
+	assert.Contains(t, string(data), "primary_key_id: ABC123DEF4567890")
+
+	viper.Reset()
+	cfg, err := Load()
+	require.NoError(t, err)
+	a, ok := cfg.YubiKeyFor("12345678")
+	assert.True(t, ok)
+	assert.Equal(t, "Key A", a.Label)
+	b, ok := cfg.YubiKeyFor("23456789")
+	assert.True(t, ok)
+	assert.Equal(t, "Key B", b.Label)
+}
+
+func TestSetYubiKey_Validation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Error(t, SetYubiKey(path, "", YubiKey{Label: "Key A"}))
+	assert.Error(t, SetYubiKey(path, "12345678", YubiKey{}))
+}
@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// DryRunExecutor wraps another Executor for --dry-run mode.
+// Read-only commands (key listings, card status, exports) are passed through
+// so commands can still inspect state; every other command is printed
+// instead of being executed.
+type DryRunExecutor struct {
+	inner Executor
+	out   io.Writer
+}
+
+// NewDryRunExecutor creates a DryRunExecutor that reports skipped commands to out.
+func NewDryRunExecutor(inner Executor, out io.Writer) *DryRunExecutor {
+	return &DryRunExecutor{inner: inner, out: out}
+}
+
+// Run executes read-only commands and prints all others without running them.
+func (e *DryRunExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if IsReadOnly(name, args...) {
+		return e.inner.Run(ctx, name, args...)
+	}
+	e.report(name, args, "")
+	return []byte{}, nil
+}
+
+// RunWithInput executes read-only commands and prints all others without running them.
+// The input is never printed since it usually holds a passphrase or PIN.
+func (e *DryRunExecutor) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	if IsReadOnly(name, args...) {
+		return e.inner.RunWithInput(ctx, input, name, args...)
+	}
+	e.report(name, args, " (with scripted input on stdin)")
+	return []byte{}, nil
+}
+
+// RunInteractive prints the interactive command without launching it.
+func (e *DryRunExecutor) RunInteractive(ctx context.Context, name string, args ...string) error {
+	e.report(name, args, " (interactive)")
+	return nil
+}
+
+// report prints a command that was skipped.
+func (e *DryRunExecutor) report(name string, args []string, note string) {
+	fmt.Fprintf(e.out, "[DRY-RUN] would run: %s%s\n", FormatCommand(name, RedactArgs(args)), note)
+}
+
+// readOnlyArgs are arguments that mark a command as not modifying any state.
+// Signing only bumps the card's signature counter, so the signing tests in
+// verify and test-sign still run, as does the encryption test in verify.
+var readOnlyArgs = map[string][]string{
+	"gpg": {"--list-secret-keys", "--list-keys", "--list-sigs", "--card-status", "show-only",
+		"--export", "--export-ownertrust", "--export-secret-subkeys", "--export-ssh-key", "--version", "--sign",
+		"--encrypt", "--decrypt"},
+	"gpgconf":           {"--list-components", "--list-dirs"},
+	"gpg-connect-agent": {"scd getinfo card_list", "--no-autostart"},
+	"ykman":             {"info", "list", "--version"},
+	"git":               {"--get", "--version"},
+}
+
+// IsReadOnly reports whether a command only reads state and is safe to run in dry-run mode.
+func IsReadOnly(name string, args ...string) bool {
+	for _, readOnly := range readOnlyArgs[ProgramName(name)] {
+		for _, arg := range args {
+			if arg == readOnly {
+				return true
+			}
+		}
+	}
+	return false
+}
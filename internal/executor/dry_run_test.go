@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunExecutor_PassesThroughReadOnlyCommands(t *testing.T) {
+	mock := NewMockExecutor()
+	mock.SetOutput("gpg --card-status", []byte("card"))
+	var out bytes.Buffer
+	dryRun := NewDryRunExecutor(mock, &out)
+
+	output, err := dryRun.Run(context.Background(), "gpg", "--card-status")
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte("card"), output)
+	assert.True(t, mock.VerifyCall("gpg", "--card-status"))
+	assert.Empty(t, out.String())
+}
+
+func TestDryRunExecutor_SkipsModifyingCommands(t *testing.T) {
+	mock := NewMockExecutor()
+	var out bytes.Buffer
+	dryRun := NewDryRunExecutor(mock, &out)
+
+	_, err := dryRun.Run(context.Background(), "gpg", "--batch", "--yes", "--delete-secret-keys", "ABC123")
+	require.NoError(t, err)
+
+	_, err = dryRun.RunWithInput(context.Background(), []byte("s3cret\n"), "gpg", "--command-fd", "0", "--edit-key", "ABC123")
+	require.NoError(t, err)
+
+	err = dryRun.RunInteractive(context.Background(), "gpg", "--edit-key", "ABC123")
+	require.NoError(t, err)
+
+	assert.Empty(t, mock.Calls)
+	assert.Empty(t, mock.InteractiveCalls)
+	assert.Contains(t, out.String(), "[DRY-RUN] would run: gpg --batch --yes --delete-secret-keys ABC123")
+	assert.Contains(t, out.String(), "gpg --edit-key ABC123 (interactive)")
+	assert.NotContains(t, out.String(), "s3cret", "stdin input must never be printed")
+}
+
+func TestIsReadOnly(t *testing.T) {
+	assert.True(t, IsReadOnly("gpg", "--list-secret-keys", "--keyid-format=long", "ABC123"))
+	assert.True(t, IsReadOnly("ykman", "info"))
+	assert.True(t, IsReadOnly("git", "config", "--global", "--get", "user.signingkey"))
+	assert.True(t, IsReadOnly("gpg", "--batch", "--default-key", "ABC123!", "--sign", "--armor"))
+	assert.True(t, IsReadOnly("gpg-connect-agent", "scd getinfo card_list", "/bye"))
+	assert.False(t, IsReadOnly("gpg-connect-agent", "scd switchcard D2760001240103040006123456780000", "/bye"))
+	assert.True(t, IsReadOnly("gpg-connect-agent", "--no-autostart", "/bye"))
+	assert.False(t, IsReadOnly("gpg", "--import", "key.asc"))
+	assert.False(t, IsReadOnly("git", "config", "--global", "commit.gpgsign", "true"))
+	assert.False(t, IsReadOnly("sh", "-c", "rm -rf /"))
+}
+
+func TestIsReadOnly_ConfiguredBinary(t *testing.T) {
+	assert.True(t, IsReadOnly("/opt/homebrew/bin/gpg2", "--card-status"))
+	assert.True(t, IsReadOnly("/usr/local/bin/ykman", "list"))
+	assert.False(t, IsReadOnly("/usr/bin/gpg2", "--import", "key.asc"))
+}
+
+func TestProgramName(t *testing.T) {
+	assert.Equal(t, "gpg", ProgramName("gpg"))
+	assert.Equal(t, "gpg", ProgramName("gpg2"))
+	assert.Equal(t, "gpg", ProgramName("/usr/local/bin/gpg2"))
+	assert.Equal(t, "gpg", ProgramName("gpg.exe"))
+	assert.Equal(t, "ykman", ProgramName("/usr/bin/ykman"))
+}
@@ -1,12 +1,25 @@
 package executor
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// DefaultTimeout bounds how long a non-interactive command may run before it
+// is killed. It is generous because some gpg operations wait on pinentry.
+const DefaultTimeout = 2 * time.Minute
+
+// waitDelay is how long to wait for output pipes to close after the command
+// has been killed, in case a grandchild process is still holding them open.
+const waitDelay = 5 * time.Second
+
 // Executor provides an interface for executing external commands.
 // This abstraction allows for easy mocking in tests.
 type Executor interface {
@@ -14,27 +27,141 @@ type Executor interface {
 	// Returns an error if the command fails or if stdout cannot be captured.
 	Run(ctx context.Context, name string, args ...string) ([]byte, error)
 
+	// RunWithInput executes a command with the given data on stdin and returns its stdout output.
+	// This is used for commands that read passphrases or scripted input from a file descriptor.
+	RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error)
+
 	// RunInteractive executes a command with interactive I/O (stdin/stdout/stderr).
 	// This is used for commands that require user interaction like gpg --edit-key.
 	RunInteractive(ctx context.Context, name string, args ...string) error
 }
 
 // RealExecutor implements Executor using the os/exec package.
-type RealExecutor struct{}
+type RealExecutor struct {
+	// Timeout limits Run and RunWithInput. Zero disables the timeout.
+	// RunInteractive is never timed out since it waits on the user.
+	Timeout time.Duration
+
+	// BeforeRun, if set, is called with each command before it is started.
+	// It is used by --verbose to show the commands being executed.
+	BeforeRun func(name string, args []string)
+
+	// Env holds extra KEY=VALUE entries added to the inherited environment of
+	// every command, including interactive ones. It is used by --gnupg-home.
+	Env []string
+}
 
-// NewRealExecutor creates a new RealExecutor instance.
+// NewRealExecutor creates a new RealExecutor instance using DefaultTimeout.
 func NewRealExecutor() *RealExecutor {
-	return &RealExecutor{}
+	return &RealExecutor{Timeout: DefaultTimeout}
+}
+
+// NewRealExecutorWithTimeout creates a new RealExecutor with the given timeout.
+// A timeout of zero or less disables it.
+func NewRealExecutorWithTimeout(timeout time.Duration) *RealExecutor {
+	if timeout < 0 {
+		timeout = 0
+	}
+	return &RealExecutor{Timeout: timeout}
 }
 
 // Run executes a command and returns its stdout output.
 func (e *RealExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	e.beforeRun(name, args)
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	cmd := newKillableCommand(ctx, name, args...)
+	cmd.Env = e.environ()
+	return e.runOutput(ctx, cmd)
+}
+
+// RunWithInput executes a command with the given data on stdin and returns its stdout output.
+func (e *RealExecutor) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	e.beforeRun(name, args)
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	cmd := newKillableCommand(ctx, name, args...)
+	cmd.Env = e.environ()
+	cmd.Stdin = bytes.NewReader(input)
+	return e.runOutput(ctx, cmd)
+}
+
+// FormatCommand renders a command and its arguments as a single line for display.
+func FormatCommand(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
+
+// ProgramName returns the tool a command name refers to, so that configured
+// binaries such as "/opt/homebrew/bin/gpg2" or "gpg.exe" are treated as "gpg".
+func ProgramName(name string) string {
+	base := strings.TrimSuffix(filepath.Base(name), ".exe")
+	if base == "gpg2" {
+		return "gpg"
+	}
+	return base
+}
+
+// beforeRun calls the BeforeRun hook, if any.
+func (e *RealExecutor) beforeRun(name string, args []string) {
+	if e.BeforeRun != nil {
+		e.BeforeRun(name, args)
+	}
+}
+
+// environ returns the environment for a command: nil to inherit the current
+// environment unchanged, or the current environment followed by Env.
+func (e *RealExecutor) environ() []string {
+	if len(e.Env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), e.Env...)
+}
+
+// withTimeout derives a context bounded by the executor's timeout, if any.
+func (e *RealExecutor) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, e.Timeout)
+}
+
+// newKillableCommand creates a command that runs in its own process group,
+// so that cancelling ctx kills the command and any helpers it spawned.
+func newKillableCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// newInteractiveCommand creates a command for RunInteractive. It stays in
+// the terminal's foreground process group so it can read from it, and so
+// already receives Ctrl+C from the terminal itself; cancelling ctx asks it
+// to stop and kills it if it hasn't exited after waitDelay.
+func newInteractiveCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setInteractiveCancel(cmd)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// runOutput runs a prepared command and returns its stdout output,
+// including stderr in the error message when the command fails.
+func (e *RealExecutor) runOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
 	output, err := cmd.Output()
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return output, fmt.Errorf("%s timed out after %s: %w", cmd.Args[0], e.Timeout, ctx.Err())
+		}
+		if ctx.Err() != nil {
+			return output, fmt.Errorf("%s was cancelled: %w", cmd.Args[0], ctx.Err())
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			// Include stderr in the error message for better diagnostics
-			stderr := string(exitErr.Stderr)
+			// Include stderr in the error message for better diagnostics,
+			// with any PINs or passphrases it echoes scrubbed out
+			stderr := RedactSecrets(string(exitErr.Stderr))
 			if stderr != "" {
 				return output, fmt.Errorf("command failed with exit code %d: %s: %w", exitErr.ExitCode(), stderr, err)
 			}
@@ -47,7 +174,8 @@ func (e *RealExecutor) Run(ctx context.Context, name string, args ...string) ([]
 
 // RunInteractive executes a command with interactive I/O.
 func (e *RealExecutor) RunInteractive(ctx context.Context, name string, args ...string) error {
-	cmd := exec.CommandContext(ctx, name, args...)
+	e.beforeRun(name, args)
+	cmd := newInteractiveCommand(ctx, name, args...)
 	// Connect to the terminal for interactive I/O
 	// This is essential for pinentry to work correctly
 	cmd.Stdin = os.Stdin
@@ -56,24 +184,28 @@ func (e *RealExecutor) RunInteractive(ctx context.Context, name string, args ...
 
 	// Set GPG_TTY for pinentry to find the terminal
 	// This is required for terminal-based pinentry programs
+	env := append(os.Environ(), e.Env...)
 	if tty, err := os.Readlink("/dev/fd/0"); err == nil {
-		cmd.Env = append(os.Environ(), "GPG_TTY="+tty)
+		cmd.Env = append(env, "GPG_TTY="+tty)
 	} else {
 		// Fallback: try to get TTY from environment or use /dev/tty
 		if tty := os.Getenv("GPG_TTY"); tty != "" {
-			cmd.Env = append(os.Environ(), "GPG_TTY="+tty)
+			cmd.Env = append(env, "GPG_TTY="+tty)
 		} else {
-			cmd.Env = append(os.Environ(), "GPG_TTY=/dev/tty")
+			cmd.Env = append(env, "GPG_TTY=/dev/tty")
 		}
 	}
 
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s was cancelled: %w", name, ctx.Err())
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			exitCode := exitErr.ExitCode()
 			// GPG's "save" command returns exit code 2 when there are no changes to save.
 			// This is a success case, not an error. For example, when a key is already
 			// saved or when "save" is called but no changes were made.
-			if name == "gpg" && exitCode == 2 {
+			if ProgramName(name) == "gpg" && exitCode == 2 {
 				return nil
 			}
 			return fmt.Errorf("command failed with exit code %d: %w", exitCode, err)
@@ -59,3 +59,16 @@ func TestMockExecutor_Reset(t *testing.T) {
 	assert.Len(t, mock.Outputs, 0)
 	assert.Len(t, mock.Errors, 0)
 }
+
+func TestMockExecutor_RunWithInput(t *testing.T) {
+	mock := NewMockExecutor()
+	mock.SetOutput("gpg --passphrase-fd 0 --symmetric", []byte("encrypted"))
+
+	output, err := mock.RunWithInput(context.Background(), []byte("secret\n"), "gpg", "--passphrase-fd", "0", "--symmetric")
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte("encrypted"), output)
+	require.Len(t, mock.Calls, 1)
+	assert.Equal(t, []byte("secret\n"), mock.Calls[0].Input)
+	assert.True(t, mock.VerifyCall("gpg", "--passphrase-fd", "0", "--symmetric"))
+}
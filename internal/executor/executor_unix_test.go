@@ -0,0 +1,129 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealExecutor_Run_Timeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	e := NewRealExecutorWithTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	_, err := e.Run(context.Background(), "sleep", "10")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRealExecutor_Run_CancelKillsProcessGroup(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	e := NewRealExecutorWithTimeout(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// The background sleep inherits stdout; if only sh were killed,
+	// Run would block until the sleep exits or WaitDelay expires.
+	start := time.Now()
+	_, err := e.Run(ctx, "sh", "-c", "sleep 10 & wait")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestRealExecutor_RunInteractive_Cancel(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	e := NewRealExecutor()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := e.RunInteractive(ctx, "sleep", "10")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sleep was cancelled")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestRealExecutor_RunWithInput_NoTimeout(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+
+	e := NewRealExecutorWithTimeout(-1)
+	assert.Equal(t, time.Duration(0), e.Timeout)
+
+	output, err := e.RunWithInput(context.Background(), []byte("hello"), "cat")
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+}
+
+func TestRealExecutor_BeforeRun(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true not available")
+	}
+
+	var ran []string
+	e := NewRealExecutor()
+	e.BeforeRun = func(name string, args []string) {
+		ran = append(ran, FormatCommand(name, args))
+	}
+
+	_, err := e.Run(context.Background(), "true", "--ignored")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"true --ignored"}, ran)
+}
+
+func TestRealExecutor_Env(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	e := NewRealExecutorWithTimeout(0)
+	e.Env = []string{"GNUPGHOME=/tmp/ykgpg-test-home"}
+
+	output, err := e.Run(context.Background(), "sh", "-c", "echo $GNUPGHOME")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/ykgpg-test-home\n", string(output))
+
+	output, err = e.RunWithInput(context.Background(), nil, "sh", "-c", "echo $GNUPGHOME")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/ykgpg-test-home\n", string(output))
+}
+
+func TestRealExecutor_Run_RedactsStderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	e := NewRealExecutorWithTimeout(0)
+
+	_, err := e.Run(context.Background(), "sh", "-c", "echo 'gpg: bad option --passphrase hunter2' >&2; exit 2")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 2")
+	assert.Contains(t, err.Error(), "--passphrase [REDACTED]")
+	assert.NotContains(t, err.Error(), "hunter2")
+}
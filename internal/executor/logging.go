@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// LoggingExecutor wraps another Executor and records every command it runs
+// as a JSON line, for --log-file. Values of PIN and passphrase arguments are
+// redacted, and stdin input is never recorded.
+type LoggingExecutor struct {
+	inner Executor
+	out   io.Writer
+	mu    sync.Mutex
+	// now returns the current time; tests replace it.
+	now func() time.Time
+}
+
+// NewLoggingExecutor creates a LoggingExecutor that writes log entries to out.
+func NewLoggingExecutor(inner Executor, out io.Writer) *LoggingExecutor {
+	return &LoggingExecutor{inner: inner, out: out, now: time.Now}
+}
+
+// LogEntry is a single command record written by LoggingExecutor.
+type LogEntry struct {
+	Time        time.Time `json:"time"`
+	Command     string    `json:"command"`
+	Args        []string  `json:"args"`
+	Stdin       bool      `json:"stdin,omitempty"`
+	Interactive bool      `json:"interactive,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	// ExitCode is -1 when the command could not be started or was killed.
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Run runs the command and logs it.
+func (e *LoggingExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	start := e.now()
+	output, err := e.inner.Run(ctx, name, args...)
+	e.log(LogEntry{Command: name, Args: args}, start, err)
+	return output, err
+}
+
+// RunWithInput runs the command and logs it. The input is not logged since it
+// usually holds a passphrase, PIN or scripted edit commands.
+func (e *LoggingExecutor) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	start := e.now()
+	output, err := e.inner.RunWithInput(ctx, input, name, args...)
+	e.log(LogEntry{Command: name, Args: args, Stdin: true}, start, err)
+	return output, err
+}
+
+// RunInteractive runs the interactive command and logs it once it exits.
+func (e *LoggingExecutor) RunInteractive(ctx context.Context, name string, args ...string) error {
+	start := e.now()
+	err := e.inner.RunInteractive(ctx, name, args...)
+	e.log(LogEntry{Command: name, Args: args, Interactive: true}, start, err)
+	return err
+}
+
+// log completes entry with its timing and result and writes it.
+// Write errors are ignored so logging never breaks a command.
+func (e *LoggingExecutor) log(entry LogEntry, start time.Time, err error) {
+	entry.Time = start.UTC()
+	entry.Args = RedactArgs(entry.Args)
+	entry.DurationMS = e.now().Sub(start).Milliseconds()
+	entry.ExitCode = exitCode(err)
+	if err != nil {
+		entry.Error = RedactSecrets(err.Error())
+	}
+
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, _ = e.out.Write(append(line, '\n'))
+}
+
+// exitCode returns the exit code carried by a command error: 0 for success
+// and -1 if the command didn't exit normally.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
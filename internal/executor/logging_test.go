@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readLogEntries parses the JSON lines written by a LoggingExecutor.
+func readLogEntries(t *testing.T, out *bytes.Buffer) []LogEntry {
+	t.Helper()
+	var entries []LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry LogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoggingExecutor_LogsCommands(t *testing.T) {
+	mock := NewMockExecutor()
+	mock.SetOutput("gpg --card-status", []byte("card"))
+	mock.SetError("gpg --edit-key ABC123", fmt.Errorf("failed to execute command: not found"))
+	var out bytes.Buffer
+	logger := NewLoggingExecutor(mock, &out)
+	clock := time.Date(2025, 9, 5, 12, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time {
+		clock = clock.Add(50 * time.Millisecond)
+		return clock
+	}
+
+	output, err := logger.Run(context.Background(), "gpg", "--card-status")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("card"), output)
+
+	_, err = logger.RunWithInput(context.Background(), []byte("s3cret\n"), "gpg", "--batch", "--passphrase-fd", "0", "--import", "master.asc")
+	require.NoError(t, err)
+
+	err = logger.RunInteractive(context.Background(), "gpg", "--edit-key", "ABC123")
+	require.Error(t, err)
+
+	entries := readLogEntries(t, &out)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "gpg", entries[0].Command)
+	assert.Equal(t, []string{"--card-status"}, entries[0].Args)
+	assert.Equal(t, int64(50), entries[0].DurationMS)
+	assert.Equal(t, 0, entries[0].ExitCode)
+	assert.Empty(t, entries[0].Error)
+
+	assert.True(t, entries[1].Stdin)
+	assert.NotContains(t, out.String(), "s3cret", "stdin input must never be logged")
+
+	assert.True(t, entries[2].Interactive)
+	assert.Equal(t, -1, entries[2].ExitCode)
+	assert.Contains(t, entries[2].Error, "not found")
+}
+
+func TestLoggingExecutor_RedactsSecretArgs(t *testing.T) {
+	mock := NewMockExecutor()
+	var out bytes.Buffer
+	logger := NewLoggingExecutor(mock, &out)
+
+	_, err := logger.Run(context.Background(), "ykman", "openpgp", "access", "change-pin", "--pin", "123456", "--new-pin", "654321")
+	require.NoError(t, err)
+
+	assert.NotContains(t, out.String(), "123456")
+	assert.NotContains(t, out.String(), "654321")
+	// The real command still gets the PINs
+	assert.True(t, mock.VerifyCall("ykman", "openpgp", "access", "change-pin", "--pin", "123456", "--new-pin", "654321"))
+}
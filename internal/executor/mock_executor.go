@@ -19,8 +19,9 @@ type MockExecutor struct {
 
 // CommandCall represents a single command invocation.
 type CommandCall struct {
-	Name string
-	Args []string
+	Name  string
+	Args  []string
+	Input []byte
 }
 
 // NewMockExecutor creates a new MockExecutor instance.
@@ -61,6 +62,22 @@ func (m *MockExecutor) Run(ctx context.Context, name string, args ...string) ([]
 	return []byte{}, nil
 }
 
+// RunWithInput executes a mocked command, recording the stdin data it was given.
+func (m *MockExecutor) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	key := m.buildKey(name, args...)
+	m.Calls = append(m.Calls, CommandCall{Name: name, Args: args, Input: input})
+
+	if err, ok := m.Errors[key]; ok {
+		return nil, err
+	}
+
+	if output, ok := m.Outputs[key]; ok {
+		return output, nil
+	}
+
+	return []byte{}, nil
+}
+
 // RunInteractive executes a mocked interactive command.
 func (m *MockExecutor) RunInteractive(ctx context.Context, name string, args ...string) error {
 	key := m.buildKey(name, args...)
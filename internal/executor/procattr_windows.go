@@ -0,0 +1,13 @@
+//go:build windows
+
+package executor
+
+import "os/exec"
+
+// setProcessGroup is a no-op on Windows; exec.CommandContext already kills
+// the process when the context is cancelled.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// setInteractiveCancel is a no-op on Windows, which has no SIGTERM; the
+// process is killed when the context is cancelled.
+func setInteractiveCancel(cmd *exec.Cmd) {}
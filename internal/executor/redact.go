@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secret values in logs and error messages.
+const Redacted = "[REDACTED]"
+
+// secretFlags are arguments whose value is a PIN, passphrase or key.
+var secretFlags = map[string]bool{
+	"--pin":            true,
+	"--new-pin":        true,
+	"--admin-pin":      true,
+	"--reset-code":     true,
+	"--new-reset-code": true,
+	"--passphrase":     true,
+	"--management-key": true,
+}
+
+// RedactArgs returns a copy of args with the values of PIN and passphrase
+// flags, in both "--pin 123456" and "--pin=123456" form, replaced by Redacted.
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && secretFlags[args[i-1]]:
+			redacted[i] = Redacted
+		case strings.Contains(arg, "=") && secretFlags[strings.SplitN(arg, "=", 2)[0]]:
+			redacted[i] = strings.SplitN(arg, "=", 2)[0] + "=" + Redacted
+		default:
+			redacted[i] = arg
+		}
+	}
+	return redacted
+}
+
+var (
+	// secretFlagPattern matches a secret flag and its value in free text,
+	// e.g. "--passphrase hunter2" or "--pin=123456". The --pinentry-mode
+	// value is scrubbed too since it follows the passphrase in gpg errors.
+	secretFlagPattern = regexp.MustCompile(`(--(?:passphrase|pinentry-mode|pin|new-pin|admin-pin|reset-code|new-reset-code|management-key))(=|\s+)\S+`)
+	// secretValuePattern matches "label: value" pairs for PINs and
+	// passphrases, e.g. "Admin PIN: 12345678" or "passphrase=hunter2".
+	secretValuePattern = regexp.MustCompile(`(?i)\b((?:user |admin )?pin|passphrase|reset code)(\s*[:=]\s*)\S+`)
+)
+
+// RedactSecrets scrubs PIN and passphrase values from text such as command
+// stderr before it is put into an error message or log.
+func RedactSecrets(s string) string {
+	s = secretFlagPattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+	return secretValuePattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactArgs(t *testing.T) {
+	args := []string{"--pin", "123456", "--admin-pin=12345678", "--passphrase", "hunter2", "--batch", "--pinentry-mode", "loopback"}
+
+	assert.Equal(t, []string{"--pin", Redacted, "--admin-pin=" + Redacted, "--passphrase", Redacted, "--batch", "--pinentry-mode", "loopback"}, RedactArgs(args))
+	assert.Equal(t, "123456", args[1], "the original slice must not be modified")
+}
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "passphrase flag",
+			input:    "gpg: invalid option --passphrase hunter2",
+			expected: "gpg: invalid option --passphrase [REDACTED]",
+		},
+		{
+			name:     "passphrase flag with equals",
+			input:    "unknown argument --passphrase=hunter2 --batch",
+			expected: "unknown argument --passphrase=[REDACTED] --batch",
+		},
+		{
+			name:     "pinentry mode",
+			input:    "gpg: --pinentry-mode loopback not allowed",
+			expected: "gpg: --pinentry-mode [REDACTED] not allowed",
+		},
+		{
+			name:     "ykman pin flags",
+			input:    "Error: ykman openpgp access change-pin --pin 123456 --new-pin 654321 failed",
+			expected: "Error: ykman openpgp access change-pin --pin [REDACTED] --new-pin [REDACTED] failed",
+		},
+		{
+			name:     "labelled values",
+			input:    "Admin PIN: 12345678\npassphrase=correct-horse",
+			expected: "Admin PIN: [REDACTED]\npassphrase=[REDACTED]",
+		},
+		{
+			name:     "no secrets",
+			input:    "gpg: signing failed: Bad PIN\nPIN retry counter : 3 0 3",
+			expected: "gpg: signing failed: Bad PIN\nPIN retry counter : 3 0 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RedactSecrets(tt.input))
+		})
+	}
+}
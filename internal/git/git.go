@@ -0,0 +1,62 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+)
+
+// Scope selects which git config file is read or written.
+type Scope string
+
+const (
+	// ScopeGlobal is the user's ~/.gitconfig.
+	ScopeGlobal Scope = "global"
+	// ScopeLocal is the current repository's .git/config.
+	ScopeLocal Scope = "local"
+)
+
+// GitService provides operations for reading and writing git configuration.
+type GitService interface {
+	// GetConfig returns the value of key in the given scope, or "" if it is unset.
+	GetConfig(ctx context.Context, scope Scope, key string) (string, error)
+
+	// SetConfig sets key to value in the given scope.
+	SetConfig(ctx context.Context, scope Scope, key, value string) error
+}
+
+// Service implements GitService.
+type Service struct {
+	exec executor.Executor
+}
+
+// NewService creates a new git service.
+func NewService(exec executor.Executor) *Service {
+	return &Service{exec: exec}
+}
+
+// GetConfig returns the value of key in the given scope, or "" if it is unset.
+func (s *Service) GetConfig(ctx context.Context, scope Scope, key string) (string, error) {
+	output, err := s.exec.Run(ctx, "git", "config", "--"+string(scope), "--get", key)
+	if err != nil {
+		// git config exits with 1 when the key is not set
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read git config %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetConfig sets key to value in the given scope.
+func (s *Service) SetConfig(ctx context.Context, scope Scope, key, value string) error {
+	if _, err := s.exec.Run(ctx, "git", "config", "--"+string(scope), key, value); err != nil {
+		return fmt.Errorf("failed to set git config %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exitError returns a real *exec.ExitError with exit code 1.
+func exitError(t *testing.T) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", "exit 1").Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Skip("sh not available")
+	}
+	return fmt.Errorf("command failed with exit code 1: %w", err)
+}
+
+func TestService_GetConfig(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("value set", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("git config --global --get user.signingkey", []byte("ABCDEF1234567890\n"))
+
+		value, err := NewService(mockExec).GetConfig(ctx, ScopeGlobal, "user.signingkey")
+
+		require.NoError(t, err)
+		assert.Equal(t, "ABCDEF1234567890", value)
+	})
+
+	t.Run("unset key is empty", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError("git config --local --get commit.gpgsign", exitError(t))
+
+		value, err := NewService(mockExec).GetConfig(ctx, ScopeLocal, "commit.gpgsign")
+
+		require.NoError(t, err)
+		assert.Equal(t, "", value)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError("git config --global --get user.signingkey", fmt.Errorf("failed to execute command: %w", exec.ErrNotFound))
+
+		_, err := NewService(mockExec).GetConfig(ctx, ScopeGlobal, "user.signingkey")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read git config user.signingkey")
+	})
+}
+
+func TestService_SetConfig(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+
+	err := NewService(mockExec).SetConfig(context.Background(), ScopeLocal, "commit.gpgsign", "true")
+
+	require.NoError(t, err)
+	assert.True(t, mockExec.VerifyCall("git", "config", "--local", "commit.gpgsign", "true"))
+}
@@ -2,8 +2,13 @@ package gpg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bobbydams/yubikey-manager/internal/executor"
 )
@@ -13,12 +18,29 @@ type GPGService interface {
 	// ListSecretKeys lists secret keys matching the given key ID.
 	ListSecretKeys(ctx context.Context, keyID string) ([]Key, error)
 
+	// ListAllSecretKeys lists every secret key in the keyring, with fingerprints.
+	ListAllSecretKeys(ctx context.Context) ([]Key, error)
+
+	// ListPublicKeys lists public keys matching the given key ID.
+	ListPublicKeys(ctx context.Context, keyID string) ([]Key, error)
+
 	// CardStatus returns information about the currently connected YubiKey.
 	CardStatus(ctx context.Context) (*CardInfo, error)
 
 	// ExportPublicKey exports the public key in armored format.
 	ExportPublicKey(ctx context.Context, keyID string) ([]byte, error)
 
+	// ExportPublicKeyMinimal exports the public key in armored format without
+	// third-party signatures, keeping only the latest self-signatures.
+	ExportPublicKeyMinimal(ctx context.Context, keyID string) ([]byte, error)
+
+	// ExportPublicKeyBinary exports the public key without ASCII armor,
+	// optionally stripped like ExportPublicKeyMinimal.
+	ExportPublicKeyBinary(ctx context.Context, keyID string, minimal bool) ([]byte, error)
+
+	// ExportSSHKey exports the key's authentication subkey as an OpenSSH public key.
+	ExportSSHKey(ctx context.Context, keyID string) ([]byte, error)
+
 	// ExportSecretSubkeys exports secret subkeys (not the master key).
 	ExportSecretSubkeys(ctx context.Context, keyID string) ([]byte, error)
 
@@ -28,59 +50,226 @@ type GPGService interface {
 	// ImportKey imports a key from the given data.
 	ImportKey(ctx context.Context, keyData []byte) error
 
+	// ImportKeyFile imports a key file after checking it contains the primary
+	// key with the given fingerprint.
+	ImportKeyFile(ctx context.Context, path, fingerprint string) (*ImportResult, error)
+
+	// InspectKeyFile returns the primary key in a key file, with its user ID
+	// and subkeys, without modifying the keyring.
+	InspectKeyFile(ctx context.Context, path string) (*Key, error)
+
+	// ImportKeyIsolated imports a key file into a throwaway GnuPG home and
+	// returns the primary keys it then holds, leaving the keyring untouched.
+	ImportKeyIsolated(ctx context.Context, path string) ([]Key, error)
+
 	// ExportOwnerTrust exports the ownertrust database.
 	ExportOwnerTrust(ctx context.Context) ([]byte, error)
 
+	// ImportOwnerTrust imports ownertrust data as written by ExportOwnerTrust.
+	ImportOwnerTrust(ctx context.Context, data []byte) error
+
 	// CheckTrustDB checks and updates the trust database.
 	CheckTrustDB(ctx context.Context) error
 
 	// EditKey starts an interactive GPG edit session.
 	EditKey(ctx context.Context, keyID string) error
+
+	// EncryptSymmetric encrypts a file with a passphrase using AES256.
+	EncryptSymmetric(ctx context.Context, inputPath, outputPath, passphrase string) error
+
+	// GPGVersion returns the version of the gpg program, e.g. 2, 4, 5.
+	GPGVersion(ctx context.Context) (major, minor, patch int, err error)
+
+	// PingAgent checks that gpg-agent is running, without starting it.
+	PingAgent(ctx context.Context) error
+
+	// LaunchAgent starts gpg-agent if it isn't running and checks that it
+	// answers.
+	LaunchAgent(ctx context.Context) error
+
+	// AddSubkey creates a subkey under the primary key with the given
+	// fingerprint (algorithm e.g. "ed25519", usage "sign", "encrypt" or "auth").
+	AddSubkey(ctx context.Context, fingerprint, algorithm, usage, expiry, passphrase string) error
+
+	// MoveSubkeyToCard moves a subkey to the given card slot (1=Signature, 2=Encryption, 3=Authentication).
+	MoveSubkeyToCard(ctx context.Context, keyID, subkeyID string, slot int, passphrase, adminPIN string) error
+
+	// GenerateRevocationCertificate generates an armored revocation certificate for the key.
+	GenerateRevocationCertificate(ctx context.Context, keyID string) ([]byte, error)
+
+	// ExtendExpiration sets the expiration of the primary key and the given
+	// subkeys to expiry (e.g. "2030-09-05" or "5y").
+	ExtendExpiration(ctx context.Context, keyID string, subkeyIDs []string, expiry string, passphrase string) error
+
+	// PublishKey uploads the public key to each keyserver. The returned
+	// slice has one entry per server: nil on success, the error otherwise.
+	PublishKey(ctx context.Context, keyID string, servers []string) []error
+
+	// RefreshKey updates the public key from a keyserver, fetching it if it
+	// isn't in the keyring yet. It reports whether the local copy changed.
+	RefreshKey(ctx context.Context, keyID, server string) (changed bool, err error)
+
+	// ExportForWKD exports the key for email as a minimal binary key for a
+	// Web Key Directory, with the hashed local part used as its file name.
+	ExportForWKD(ctx context.Context, email string) (hashedLocalPart string, data []byte, err error)
+}
+
+// CardSlots maps keytocard slot numbers to the slot names reported by gpg --card-status.
+var CardSlots = map[int]string{
+	1: "Signature",
+	2: "Encryption",
+	3: "Authentication",
 }
 
 // Key represents a GPG key (primary or subkey).
 type Key struct {
 	Type         string // "sec", "ssb", etc.
 	KeyID        string
+	Algorithm    string // e.g. "rsa4096", "ed25519", "cv25519"
 	Fingerprint  string
 	Capabilities []string // [S], [E], [A], etc.
+	Created      string   // Creation date, e.g. "2023-01-01"
 	Expires      string
-	CardNo       string // If key is on a card
+	CardNo       string // If key is on a card, as listed by gpg, e.g. "0006 12345678"
+	CardSerial   string // CardNo normalized with NormalizeCardSerial, for comparing with CardInfo.Serial
+	Secret       string // Where the secret key is: SecretLocal, SecretStub or SecretOnCard; "" if unknown
+	Revoked      bool   // The key has been revoked
+	UserID       string // Primary user ID; only set for primary keys read from --with-colons output
+	Subkeys      []Key  // Subkeys of a primary key; only set by InspectKeyFile
 }
 
+// Secret key locations, from a secret key listing.
+const (
+	// SecretLocal means the full secret key is in the local keyring ("sec", "ssb").
+	SecretLocal = "local"
+	// SecretStub means only a stub is present and the secret key is offline ("sec#", "ssb#").
+	SecretStub = "stub"
+	// SecretOnCard means the secret key is on a smartcard ("sec>", "ssb>").
+	SecretOnCard = "card"
+)
+
 // CardInfo contains information about a YubiKey card.
 type CardInfo struct {
-	Serial        string
-	Cardholder    string
-	Keys          map[string]string   // "Signature", "Encryption", "Authentication" -> key ID
-	KeyAttributes []string            // Key types for each slot, e.g., ["rsa2048", "rsa2048", "rsa2048"]
+	Serial     string
+	Cardholder string
+	URL        string // "URL of public key", empty if not set
+	LoginData  string // "Login data", e.g. a user name; empty if not set
+	Reader     string // Smartcard reader name, e.g. "Yubico YubiKey OTP FIDO CCID 00 00"
+	// FirmwareVersion is the YubiKey firmware version, e.g. "5.4.3". The
+	// "Version" line of gpg --card-status is the OpenPGP spec version, not
+	// the firmware, so this is only set from "Card firmware" or ykman.
+	FirmwareVersion string
+	// Model is the device model, e.g. "YubiKey 5 NFC", from ykman or the reader name.
+	Model         string
+	Keys          map[string]string // "Signature", "Encryption", "Authentication" -> key ID
+	KeyAttributes map[string]string // Slot name -> configured key type, e.g. "Signature" -> "ed25519"
+	// PIN retry counters from the "PIN retry counter : 3 0 3" line.
+	// Each is -1 if the card status didn't report it.
+	PINRetries       int
+	ResetCodeRetries int
+	AdminPINRetries  int
+	// SignatureCounter is the number of signatures the card has made,
+	// from "Signature counter : 42". It is -1 if not reported.
+	SignatureCounter int
 }
 
+// KeyAttributeList returns the configured key types in slot order, e.g.
+// ["ed25519", "cv25519", "ed25519"]. Slots without an attribute are skipped.
+func (c *CardInfo) KeyAttributeList() []string {
+	var attrs []string
+	for slot := 1; slot <= len(CardSlots); slot++ {
+		if attr, ok := c.KeyAttributes[CardSlots[slot]]; ok {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// ImportResult summarizes a gpg import, from its IMPORT_RES status line.
+type ImportResult struct {
+	Processed       int // Keys read from the file
+	Imported        int // Public keys that were new to the keyring
+	Unchanged       int // Public keys already in the keyring
+	NewUserIDs      int // User IDs added to existing keys
+	NewSubkeys      int // Subkeys added to existing keys
+	NewSignatures   int // Signatures added to existing keys
+	NewRevocations  int // Revocations added to existing keys
+	SecretRead      int // Secret keys read from the file
+	SecretImported  int // Secret keys that were new to the keyring
+	SecretUnchanged int // Secret keys already in the keyring
+}
+
+// Changed reports whether the import added anything to the keyring.
+func (r *ImportResult) Changed() bool {
+	return r.Imported+r.NewUserIDs+r.NewSubkeys+r.NewSignatures+r.NewRevocations > 0
+}
+
+// DefaultBinary is the gpg program run when no other is configured.
+const DefaultBinary = "gpg"
+
 // Service implements GPGService using an executor.
 type Service struct {
 	exec executor.Executor
+	// Binary is the gpg program to run, e.g. "gpg2" or an absolute path.
+	Binary string
+	// KeyserverProxy, if set, is passed to every keyserver operation as
+	// --keyserver-options http-proxy=<url>.
+	KeyserverProxy string
+
+	// version caches the result of GPGVersion.
+	versionOnce sync.Once
+	version     [3]int
+	versionErr  error
 }
 
-// NewService creates a new GPG service.
+// NewService creates a new GPG service that runs DefaultBinary.
 func NewService(exec executor.Executor) *Service {
-	return &Service{exec: exec}
+	return &Service{exec: exec, Binary: DefaultBinary}
 }
 
-// ListSecretKeys lists secret keys matching the given key ID.
+// ListSecretKeys lists secret keys matching the given key ID, each with its
+// own fingerprint. It uses the --with-colons format, which is stable across
+// gpg versions and locales, unlike the human-readable listing.
 func (s *Service) ListSecretKeys(ctx context.Context, keyID string) ([]Key, error) {
-	args := []string{"--list-secret-keys", "--keyid-format=long", keyID}
-	output, err := s.exec.Run(ctx, "gpg", args...)
+	args := []string{"--list-secret-keys", "--with-colons", "--fingerprint", "--with-subkey-fingerprint", keyID}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret keys: %w", err)
+	}
+
+	return parseColonKeyList(output), nil
+}
+
+// ListAllSecretKeys lists every secret key in the keyring.
+// Subkey fingerprints are included so keys can be deleted in batch mode,
+// which gpg only allows when the key is specified by fingerprint.
+func (s *Service) ListAllSecretKeys(ctx context.Context) ([]Key, error) {
+	args := []string{"--list-secret-keys", "--with-colons", "--with-subkey-fingerprint"}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list secret keys: %w", err)
 	}
 
-	return parseKeyList(output), nil
+	return parseColonKeyList(output), nil
+}
+
+// ListPublicKeys lists public keys matching the given key ID, each with its
+// own fingerprint, for telling a key whose secret parts aren't imported
+// apart from one that isn't in the keyring at all.
+func (s *Service) ListPublicKeys(ctx context.Context, keyID string) ([]Key, error) {
+	args := []string{"--list-keys", "--with-colons", "--fingerprint", "--with-subkey-fingerprint", keyID}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list public keys: %w", err)
+	}
+
+	return parseColonKeyList(output), nil
 }
 
 // CardStatus returns information about the currently connected YubiKey.
 func (s *Service) CardStatus(ctx context.Context) (*CardInfo, error) {
 	args := []string{"--card-status"}
-	output, err := s.exec.Run(ctx, "gpg", args...)
+	output, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get card status: %w", err)
 	}
@@ -91,7 +280,38 @@ func (s *Service) CardStatus(ctx context.Context) (*CardInfo, error) {
 // ExportPublicKey exports the public key in armored format.
 func (s *Service) ExportPublicKey(ctx context.Context, keyID string) ([]byte, error) {
 	args := []string{"--export", "--armor", keyID}
-	output, err := s.exec.Run(ctx, "gpg", args...)
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export public key: %w", err)
+	}
+
+	return output, nil
+}
+
+// ExportPublicKeyMinimal exports the public key in armored format with
+// export-minimal and export-clean, which drop third-party signatures and
+// superseded self-signatures. The result is much smaller for keys that
+// have collected years of cross-signatures.
+func (s *Service) ExportPublicKeyMinimal(ctx context.Context, keyID string) ([]byte, error) {
+	args := []string{"--export", "--armor", "--export-options", "export-minimal,export-clean", keyID}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export public key: %w", err)
+	}
+
+	return output, nil
+}
+
+// ExportPublicKeyBinary exports the public key as binary OpenPGP packets,
+// for upload targets that expect a .gpg file. With minimal the same
+// export-minimal and export-clean options as ExportPublicKeyMinimal apply.
+func (s *Service) ExportPublicKeyBinary(ctx context.Context, keyID string, minimal bool) ([]byte, error) {
+	args := []string{"--export"}
+	if minimal {
+		args = append(args, "--export-options", "export-minimal,export-clean")
+	}
+	args = append(args, keyID)
+	output, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to export public key: %w", err)
 	}
@@ -99,10 +319,88 @@ func (s *Service) ExportPublicKey(ctx context.Context, keyID string) ([]byte, er
 	return output, nil
 }
 
+// ExportForWKD exports the key with a user ID for email in binary form for
+// a Web Key Directory. Third-party signatures and the other user IDs are
+// stripped (export-minimal, keep-uid=mbox=<email>). It also returns the
+// WKD file name: the z-base-32 hash of the email's local part.
+func (s *Service) ExportForWKD(ctx context.Context, email string) (string, []byte, error) {
+	localPart, domain, ok := strings.Cut(email, "@")
+	if !ok || localPart == "" || domain == "" {
+		return "", nil, fmt.Errorf("invalid email address: %q", email)
+	}
+
+	args := []string{"--export", "--export-options", "export-minimal",
+		"--export-filter", "keep-uid=mbox=" + strings.ToLower(email), "<" + email + ">"}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to export key for %s: %w", email, err)
+	}
+	if len(output) == 0 {
+		return "", nil, fmt.Errorf("no public key found for %s", email)
+	}
+
+	return WKDHash(localPart), output, nil
+}
+
+// PublishKey uploads the public key to each keyserver in turn. A failure
+// on one server doesn't stop the others; errs[i] is the result for servers[i].
+func (s *Service) PublishKey(ctx context.Context, keyID string, servers []string) []error {
+	errs := make([]error, len(servers))
+	for i, server := range servers {
+		args := append(s.keyserverArgs(server), "--send-keys", keyID)
+		if _, err := s.exec.Run(ctx, s.Binary, args...); err != nil {
+			errs[i] = fmt.Errorf("failed to upload key to %s: %w", server, err)
+		}
+	}
+	return errs
+}
+
+// RefreshKey updates the public key from server with --refresh-keys, or
+// fetches it with --recv-keys if it isn't in the keyring. Whether the local
+// copy changed is read from the IMPORT_RES status line.
+func (s *Service) RefreshKey(ctx context.Context, keyID, server string) (bool, error) {
+	action := "--refresh-keys"
+	if _, err := s.exec.Run(ctx, s.Binary, "--list-keys", "--with-colons", keyID); err != nil {
+		action = "--recv-keys"
+	}
+
+	args := append([]string{"--status-fd", "1"}, s.keyserverArgs(server)...)
+	args = append(args, action, keyID)
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh key from %s: %w", server, err)
+	}
+
+	return parseImportResult(output).Changed(), nil
+}
+
+// keyserverArgs returns the options selecting server, with the proxy
+// option when KeyserverProxy is set. Without it nothing is added, so gpg
+// (or dirmngr) falls back to its own http-proxy setting.
+func (s *Service) keyserverArgs(server string) []string {
+	args := []string{"--keyserver", server}
+	if s.KeyserverProxy != "" {
+		args = append(args, "--keyserver-options", "http-proxy="+s.KeyserverProxy)
+	}
+	return args
+}
+
+// ExportSSHKey exports the key's authentication subkey as an OpenSSH public
+// key line, suitable for ~/.ssh/authorized_keys.
+func (s *Service) ExportSSHKey(ctx context.Context, keyID string) ([]byte, error) {
+	args := []string{"--export-ssh-key", keyID}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export SSH key (does the key have an authentication subkey?): %w", err)
+	}
+
+	return output, nil
+}
+
 // ExportSecretSubkeys exports secret subkeys (not the master key).
 func (s *Service) ExportSecretSubkeys(ctx context.Context, keyID string) ([]byte, error) {
 	args := []string{"--export-secret-subkeys", keyID}
-	output, err := s.exec.Run(ctx, "gpg", args...)
+	output, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to export secret subkeys: %w", err)
 	}
@@ -113,7 +411,7 @@ func (s *Service) ExportSecretSubkeys(ctx context.Context, keyID string) ([]byte
 // DeleteSecretKey deletes a secret key from the keyring.
 func (s *Service) DeleteSecretKey(ctx context.Context, fingerprint string) error {
 	args := []string{"--batch", "--yes", "--delete-secret-keys", fingerprint}
-	_, err := s.exec.Run(ctx, "gpg", args...)
+	_, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete secret key: %w", err)
 	}
@@ -138,7 +436,7 @@ func (s *Service) ImportKey(ctx context.Context, keyData []byte) error {
 
 	// Import from the temp file
 	args := []string{"--import", tmpFile.Name()}
-	_, err = s.exec.Run(ctx, "gpg", args...)
+	_, err = s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return fmt.Errorf("failed to import key: %w", err)
 	}
@@ -146,10 +444,101 @@ func (s *Service) ImportKey(ctx context.Context, keyData []byte) error {
 	return nil
 }
 
+// ImportKeyFile imports a key file after checking it contains the primary
+// key with the given fingerprint. The file is first listed with
+// --import-options show-only so a wrong or corrupt backup is rejected
+// before anything is written to the keyring.
+func (s *Service) ImportKeyFile(ctx context.Context, path, fingerprint string) (*ImportResult, error) {
+	keys, err := s.showKeyFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fingerprint != "" {
+		var found []string
+		for _, key := range keys {
+			found = append(found, key.Fingerprint)
+		}
+		if !slices.Contains(found, strings.ToUpper(fingerprint)) {
+			return nil, fmt.Errorf("%s does not contain primary key %s (found: %s)",
+				path, fingerprint, strings.Join(found, ", "))
+		}
+	}
+
+	args := []string{"--status-fd", "1", "--import", path}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import key: %w", err)
+	}
+
+	return parseImportResult(output), nil
+}
+
+// InspectKeyFile returns the primary key in a key file, with its user ID
+// and subkeys, without modifying the keyring. If the file holds several
+// keys, the first is returned.
+func (s *Service) InspectKeyFile(ctx context.Context, path string) (*Key, error) {
+	keys, err := s.showKeyFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return &keys[0], nil
+}
+
+// ImportKeyIsolated imports a key file into a temporary GnuPG home, which
+// is removed afterwards, and lists the primary keys it then holds. Unlike
+// InspectKeyFile it proves the file actually imports, without touching the
+// user's keyring.
+func (s *Service) ImportKeyIsolated(ctx context.Context, path string) ([]Key, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("key file not found: %w", err)
+	}
+
+	home, err := os.MkdirTemp("", "ykgpg-home-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary GnuPG home: %w", err)
+	}
+	defer os.RemoveAll(home)
+
+	if _, err := s.exec.Run(ctx, s.Binary, "--homedir", home, "--batch", "--import", path); err != nil {
+		return nil, fmt.Errorf("failed to import key: %w", err)
+	}
+	output, err := s.exec.Run(ctx, s.Binary, "--homedir", home, "--batch", "--with-colons", "--fingerprint", "--list-keys")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imported keys: %w", err)
+	}
+
+	keys := parseColonKeys(output)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys imported from %s", path)
+	}
+	return keys, nil
+}
+
+// showKeyFile lists the primary keys in a key file using
+// --import-options show-only, which reads the file without importing it.
+func (s *Service) showKeyFile(ctx context.Context, path string) ([]Key, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("key file not found: %w", err)
+	}
+
+	args := []string{"--batch", "--with-colons", "--import-options", "show-only", "--import", path}
+	output, err := s.exec.Run(ctx, s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	keys := parseColonKeys(output)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys found in %s", path)
+	}
+	return keys, nil
+}
+
 // ExportOwnerTrust exports the ownertrust database.
 func (s *Service) ExportOwnerTrust(ctx context.Context) ([]byte, error) {
 	args := []string{"--export-ownertrust"}
-	output, err := s.exec.Run(ctx, "gpg", args...)
+	output, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to export ownertrust: %w", err)
 	}
@@ -157,10 +546,21 @@ func (s *Service) ExportOwnerTrust(ctx context.Context) ([]byte, error) {
 	return output, nil
 }
 
+// ImportOwnerTrust imports ownertrust data as written by ExportOwnerTrust,
+// passing it to gpg --import-ownertrust on stdin.
+func (s *Service) ImportOwnerTrust(ctx context.Context, data []byte) error {
+	args := []string{"--import-ownertrust"}
+	if _, err := s.exec.RunWithInput(ctx, data, s.Binary, args...); err != nil {
+		return fmt.Errorf("failed to import ownertrust: %w", err)
+	}
+
+	return nil
+}
+
 // CheckTrustDB checks and updates the trust database.
 func (s *Service) CheckTrustDB(ctx context.Context) error {
 	args := []string{"--check-trustdb"}
-	_, err := s.exec.Run(ctx, "gpg", args...)
+	_, err := s.exec.Run(ctx, s.Binary, args...)
 	if err != nil {
 		return fmt.Errorf("failed to check trustdb: %w", err)
 	}
@@ -171,5 +571,487 @@ func (s *Service) CheckTrustDB(ctx context.Context) error {
 // EditKey starts an interactive GPG edit session.
 func (s *Service) EditKey(ctx context.Context, keyID string) error {
 	args := []string{"--edit-key", keyID}
-	return s.exec.RunInteractive(ctx, "gpg", args...)
+	return s.exec.RunInteractive(ctx, s.Binary, args...)
+}
+
+// EncryptSymmetric encrypts a file with a passphrase using AES256.
+// The passphrase is passed on stdin so it never appears in the process list.
+func (s *Service) EncryptSymmetric(ctx context.Context, inputPath, outputPath, passphrase string) error {
+	args := []string{"--batch", "--yes", "--pinentry-mode", "loopback", "--passphrase-fd", "0",
+		"--symmetric", "--cipher-algo", "AES256", "--output", outputPath, inputPath}
+	_, err := s.exec.RunWithInput(ctx, []byte(passphrase+"\n"), s.Binary, args...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt file: %w", err)
+	}
+
+	return nil
+}
+
+// GPGVersion returns the version of the gpg program from the first line of
+// gpg --version, e.g. "gpg (GnuPG) 2.4.5". gpg is only run once; later
+// calls return the cached result.
+func (s *Service) GPGVersion(ctx context.Context) (major, minor, patch int, err error) {
+	s.versionOnce.Do(func() {
+		output, err := s.exec.Run(ctx, s.Binary, "--version")
+		if err != nil {
+			s.versionErr = fmt.Errorf("failed to run %s --version: %w", s.Binary, err)
+			return
+		}
+		version, ok := parseGPGVersion(output)
+		if !ok {
+			line, _, _ := strings.Cut(string(output), "\n")
+			s.versionErr = fmt.Errorf("could not parse the gpg version from %q", strings.TrimSpace(line))
+			return
+		}
+		s.version = version
+	})
+	return s.version[0], s.version[1], s.version[2], s.versionErr
+}
+
+// PingAgent checks that gpg-agent is running and answers. Unlike most gpg
+// tools, it never starts the agent, so the result shows whether card
+// operations would first have to.
+func (s *Service) PingAgent(ctx context.Context) error {
+	if _, err := s.exec.Run(ctx, "gpg-connect-agent", "--no-autostart", "/bye"); err != nil {
+		return fmt.Errorf("gpg-agent is not running or not reachable: %w", err)
+	}
+	return nil
+}
+
+// LaunchAgent starts gpg-agent with gpgconf --launch, which does nothing
+// if it is already running, and checks that it answers.
+func (s *Service) LaunchAgent(ctx context.Context) error {
+	if _, err := s.exec.Run(ctx, "gpgconf", "--launch", "gpg-agent"); err != nil {
+		return fmt.Errorf("failed to launch gpg-agent: %w", err)
+	}
+	return s.PingAgent(ctx)
+}
+
+// forgetPassphrases makes gpg-agent forget the cached passphrases of keyID
+// and its subkeys. Scripts that feed the passphrase on stdin rely on gpg
+// asking for it: if the agent answered from its cache instead, the
+// passphrase line would be read as the next answer, e.g. the Admin PIN.
+// Nothing is cached when the agent isn't running.
+func (s *Service) forgetPassphrases(ctx context.Context, keyID string) error {
+	if s.PingAgent(ctx) != nil {
+		return nil
+	}
+	output, err := s.exec.Run(ctx, s.Binary, "--list-secret-keys", "--with-colons", "--with-keygrip", keyID)
+	if err != nil {
+		return fmt.Errorf("failed to list keygrips: %w", err)
+	}
+	for _, keygrip := range parseKeygrips(output) {
+		if _, err := s.exec.Run(ctx, "gpg-connect-agent", "CLEAR_PASSPHRASE --mode=normal "+keygrip, "/bye"); err != nil {
+			return fmt.Errorf("failed to clear the cached passphrase: %w", err)
+		}
+	}
+	return nil
+}
+
+// AddSubkey creates a subkey with gpg --quick-add-key. A non-empty
+// passphrase is fed on stdin via loopback pinentry; with an empty one gpg
+// asks for the primary key's passphrase through pinentry if it needs it.
+func (s *Service) AddSubkey(ctx context.Context, fingerprint, algorithm, usage, expiry, passphrase string) error {
+	var err error
+	if passphrase == "" {
+		_, err = s.exec.Run(ctx, s.Binary, "--batch", "--quick-add-key", fingerprint, algorithm, usage, expiry)
+	} else {
+		args := []string{"--batch", "--pinentry-mode", "loopback", "--passphrase-fd", "0",
+			"--quick-add-key", fingerprint, algorithm, usage, expiry}
+		_, err = s.exec.RunWithInput(ctx, []byte(passphrase+"\n"), s.Binary, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create subkey: %w", err)
+	}
+	return nil
+}
+
+// MoveSubkeyToCard moves a subkey to the YubiKey by scripting a gpg --edit-key
+// session through --command-fd, so the right subkey is selected automatically.
+// The key passphrase and Admin PIN are fed on stdin via loopback pinentry; pass
+// an empty passphrase for a key that isn't protected by one. A cached
+// passphrase is cleared first so that gpg asks for it.
+//
+// If the slot already holds a key, gpg asks "Replace existing key?", which
+// the script answers yes; callers decide beforehand whether replacing is
+// wanted. gpg doesn't report a wrong passphrase or Admin PIN as an error
+// ("Key not changed so no update needed"), so its status output is checked
+// for which one was rejected, and the card status is checked afterwards to
+// confirm the slot now holds the subkey.
+func (s *Service) MoveSubkeyToCard(ctx context.Context, keyID, subkeyID string, slot int, passphrase, adminPIN string) error {
+	slotName, ok := CardSlots[slot]
+	if !ok {
+		return fmt.Errorf("invalid card slot %d (must be 1, 2 or 3)", slot)
+	}
+
+	keys, err := s.ListSecretKeys(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	index := SubkeyIndex(keys, subkeyID)
+	if index == 0 {
+		return fmt.Errorf("subkey %s not found under key %s", subkeyID, keyID)
+	}
+
+	before, err := s.CardStatus(ctx)
+	if err != nil {
+		return err
+	}
+	existing := before.Keys[slotName]
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "key %d\n", index)
+	fmt.Fprintln(&script, "keytocard")
+	fmt.Fprintf(&script, "%d\n", slot)
+	if existing != "" && existing != "[none]" {
+		// Replace existing key? (y/N)
+		fmt.Fprintln(&script, "y")
+	}
+	if passphrase != "" {
+		fmt.Fprintln(&script, passphrase)
+	}
+	fmt.Fprintln(&script, adminPIN)
+	fmt.Fprintln(&script, "save")
+
+	if passphrase != "" {
+		if err := s.forgetPassphrases(ctx, keyID); err != nil {
+			return err
+		}
+	}
+	args := []string{"--pinentry-mode", "loopback", "--command-fd", "0", "--status-fd", "1", "--edit-key", keyID}
+	output, err := s.exec.RunWithInput(ctx, []byte(script.String()), s.Binary, args...)
+	if err != nil {
+		if rejected := keytocardRejection(err.Error()); rejected != nil {
+			return rejected
+		}
+		return fmt.Errorf("failed to move subkey to card: %w", err)
+	}
+	if rejected := keytocardRejection(string(output)); rejected != nil {
+		return rejected
+	}
+
+	card, err := s.CardStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if !KeyIDMatches(card.Keys[slotName], subkeyID) {
+		return errAdminPINRejected
+	}
+
+	return nil
+}
+
+// GenerateRevocationCertificate generates an armored revocation certificate
+// for the key with reason 0 ("No reason specified"), answering gpg's prompts
+// via --command-fd. The key's passphrase is requested through pinentry.
+func (s *Service) GenerateRevocationCertificate(ctx context.Context, keyID string) ([]byte, error) {
+	// Create certificate? y / reason 0 / empty description / Is this okay? y
+	script := "y\n0\n\ny\n"
+	args := []string{"--armor", "--command-fd", "0", "--gen-revoke", keyID}
+	output, err := s.exec.RunWithInput(ctx, []byte(script), s.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate revocation certificate: %w", err)
+	}
+
+	return output, nil
+}
+
+// ExtendExpiration sets the expiration of the primary key and the given
+// subkeys to expiry in a single gpg --edit-key session scripted through
+// --command-fd. The key passphrase is fed on stdin via loopback pinentry the
+// first time gpg asks for it, after clearing a cached one so that gpg does
+// ask; pass an empty passphrase for a key that isn't protected by one.
+func (s *Service) ExtendExpiration(ctx context.Context, keyID string, subkeyIDs []string, expiry string, passphrase string) error {
+	keys, err := s.ListSecretKeys(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	var script strings.Builder
+	// With no subkey selected, expire changes the primary key
+	fmt.Fprintln(&script, "expire")
+	fmt.Fprintln(&script, expiry)
+	if passphrase != "" {
+		fmt.Fprintln(&script, passphrase)
+	}
+
+	if len(subkeyIDs) > 0 {
+		for _, subkeyID := range subkeyIDs {
+			index := SubkeyIndex(keys, subkeyID)
+			if index == 0 {
+				return fmt.Errorf("subkey %s not found under key %s", subkeyID, keyID)
+			}
+			fmt.Fprintf(&script, "key %d\n", index)
+		}
+		fmt.Fprintln(&script, "expire")
+		// gpg asks for confirmation when several subkeys are selected
+		if len(subkeyIDs) > 1 {
+			fmt.Fprintln(&script, "y")
+		}
+		fmt.Fprintln(&script, expiry)
+	}
+	fmt.Fprintln(&script, "save")
+
+	if passphrase != "" {
+		if err := s.forgetPassphrases(ctx, keyID); err != nil {
+			return err
+		}
+	}
+	args := []string{"--pinentry-mode", "loopback", "--command-fd", "0", "--status-fd", "1", "--edit-key", keyID}
+	output, err := s.exec.RunWithInput(ctx, []byte(script.String()), s.Binary, args...)
+	if err != nil {
+		return fmt.Errorf("failed to extend expiration: %w", err)
+	}
+	if strings.Contains(string(output), "BAD_PASSPHRASE") {
+		return fmt.Errorf("failed to extend expiration: passphrase rejected")
+	}
+
+	return nil
+}
+
+// Errors returned when keytocard finishes without moving the key.
+var (
+	errPassphraseRejected = errors.New("subkey was not moved to the card: the key passphrase was rejected")
+	errAdminPINRejected   = errors.New("subkey was not moved to the card: admin PIN likely incorrect")
+)
+
+// keytocardRejection returns why gpg output shows keytocard had no effect,
+// or nil if it doesn't. The key is unlocked with its passphrase before the
+// card is written with the Admin PIN, so a BAD_PASSPHRASE status ahead of
+// any SC_OP_FAILURE means the passphrase was wrong and the card was never
+// asked. A failure at the card, or gpg's plain "Key not changed", is
+// reported as a rejected Admin PIN.
+func keytocardRejection(output string) error {
+	badPassphrase := strings.Index(output, "BAD_PASSPHRASE")
+	cardFailure := strings.Index(output, "SC_OP_FAILURE")
+	switch {
+	case badPassphrase >= 0 && (cardFailure < 0 || badPassphrase < cardFailure):
+		return errPassphraseRejected
+	case cardFailure >= 0 || strings.Contains(output, "Key not changed"):
+		return errAdminPINRejected
+	}
+	return nil
+}
+
+// SubkeyIndex returns the number gpg --edit-key uses to select the subkey
+// matching subkeyID ("key N"), or 0 if no subkey matches.
+// gpg numbers subkeys from 1 in the order they are listed.
+func SubkeyIndex(keys []Key, subkeyID string) int {
+	index := 0
+	for _, key := range keys {
+		if key.Type != "ssb" {
+			continue
+		}
+		index++
+		if KeyIDMatches(key.KeyID, subkeyID) || KeyIDMatches(key.Fingerprint, subkeyID) {
+			return index
+		}
+	}
+	return 0
+}
+
+// CapabilityNames maps key capability flags to the names used in messages.
+var CapabilityNames = map[string]string{
+	"S": "signing",
+	"E": "encryption",
+	"A": "authentication",
+	"C": "certification",
+}
+
+// FindMovableSigningSubkey returns the signing subkey that isn't on a card yet.
+// It is an error if there is no such subkey or if more than one is a candidate.
+func FindMovableSigningSubkey(keys []Key) (*Key, error) {
+	return FindMovableSubkey(keys, "S")
+}
+
+// FindMovableSubkey returns the subkey with the given capability ("S", "E"
+// or "A") that isn't on a card yet. Only subkeys whose secret part is in the
+// local keyring are candidates; stubs, revoked and expired subkeys, e.g.
+// left over from an earlier rotation, are skipped. It is an error if there
+// is no such subkey or if more than one is a candidate.
+func FindMovableSubkey(keys []Key, capability string) (*Key, error) {
+	return findMovableSubkey(keys, capability, time.Now())
+}
+
+func findMovableSubkey(keys []Key, capability string, now time.Time) (*Key, error) {
+	var candidates []*Key
+	for i := range keys {
+		key := &keys[i]
+		if key.Type != "ssb" || key.Secret != SecretLocal || key.Revoked {
+			continue
+		}
+		if expires, err := ParseKeyDate(key.Expires); err == nil && expires.Before(now) {
+			continue
+		}
+		for _, c := range key.Capabilities {
+			if c == capability {
+				candidates = append(candidates, key)
+				break
+			}
+		}
+	}
+
+	name := CapabilityNames[capability]
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no %s subkey found that isn't already on a card", name)
+	case 1:
+		return candidates[0], nil
+	default:
+		ids := make([]string, len(candidates))
+		for i, key := range candidates {
+			ids[i] = key.KeyID
+		}
+		return nil, fmt.Errorf("multiple %s subkeys are not on a card: %s", name, strings.Join(ids, ", "))
+	}
+}
+
+// FindSigningSubkeyOnCard returns the signing subkey on the card described
+// by card. It tries, in order:
+//
+//   - the subkey in the card's Signature slot, per card status
+//   - the signing subkey whose card-no matches the card's serial
+//   - the most recently created signing subkey on any card, for when
+//     card-no doesn't match yet (e.g. right after moving a key)
+//
+// The last is a guess; callers can tell it was used because the returned
+// key matches neither the Signature slot nor the card's serial. It is an
+// error if no signing subkey is on a card.
+func FindSigningSubkeyOnCard(keys []Key, card *CardInfo) (*Key, error) {
+	if card == nil {
+		return nil, fmt.Errorf("no card information")
+	}
+
+	if slotKey := card.Keys[CardSlots[1]]; slotKey != "" && slotKey != "[none]" {
+		for i := range keys {
+			key := &keys[i]
+			if key.Type == "ssb" && (KeyIDMatches(key.Fingerprint, slotKey) || KeyIDMatches(key.KeyID, slotKey)) {
+				return key, nil
+			}
+		}
+	}
+
+	if serial := NormalizeCardSerial(card.Serial); serial != "" {
+		for i := range keys {
+			key := &keys[i]
+			if isSigningSubkey(key) && key.CardSerial == serial {
+				return key, nil
+			}
+		}
+	}
+
+	// gpg lists subkeys oldest first, so on equal dates the later one wins
+	var latest *Key
+	for i := range keys {
+		key := &keys[i]
+		if isSigningSubkey(key) && key.CardNo != "" && (latest == nil || key.Created >= latest.Created) {
+			latest = key
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no signing subkey found on card %s", card.Serial)
+	}
+	return latest, nil
+}
+
+// isSigningSubkey reports whether key is a subkey with the sign capability.
+func isSigningSubkey(key *Key) bool {
+	if key.Type != "ssb" {
+		return false
+	}
+	for _, c := range key.Capabilities {
+		if c == "S" {
+			return true
+		}
+	}
+	return false
+}
+
+// GuessedSigningSubkey reports whether FindSigningSubkeyOnCard fell back to
+// the most recent signing subkey on any card to find subkey.
+func GuessedSigningSubkey(subkey *Key, card *CardInfo) bool {
+	slotKey := card.Keys[CardSlots[1]]
+	if KeyIDMatches(subkey.Fingerprint, slotKey) || KeyIDMatches(subkey.KeyID, slotKey) {
+		return false
+	}
+	return subkey.CardSerial != NormalizeCardSerial(card.Serial)
+}
+
+// OtherCardSerial returns the serial of the YubiKey that gpg records subkey
+// on, if FindSigningSubkeyOnCard only guessed subkey and that is a
+// different card than card's. Otherwise it returns "".
+func OtherCardSerial(subkey *Key, card *CardInfo) string {
+	if subkey.CardSerial == "" || NormalizeCardSerial(card.Serial) == "" || !GuessedSigningSubkey(subkey, card) {
+		return ""
+	}
+	return subkey.CardSerial
+}
+
+// FindSubkey returns the subkey with the given key ID or fingerprint, or
+// nil if keys doesn't contain it.
+func FindSubkey(keys []Key, id string) *Key {
+	for i := range keys {
+		key := &keys[i]
+		if key.Type != "ssb" {
+			continue
+		}
+		if KeyIDMatches(key.Fingerprint, id) || KeyIDMatches(key.KeyID, id) {
+			return key
+		}
+	}
+	return nil
+}
+
+// SubkeySpec returns the gpg key specification that selects exactly the
+// subkey with the given key ID or fingerprint: its full fingerprint with a
+// "!" suffix, so gpg doesn't substitute another signing subkey. Falls back to
+// the ID as given if the subkey's fingerprint isn't known.
+func SubkeySpec(keys []Key, id string) string {
+	for _, key := range keys {
+		if key.Type != "ssb" || key.Fingerprint == "" {
+			continue
+		}
+		if KeyIDMatches(key.Fingerprint, id) {
+			return key.Fingerprint + "!"
+		}
+	}
+	return id
+}
+
+// MasterKeyOnMachine finds the primary key whose fingerprint or key ID
+// matches primary and reports whether its full secret key is in the local
+// keyring ("sec") rather than offline ("sec#") or on a card. Other keys in
+// the listing are ignored. found is false if the primary isn't listed.
+func MasterKeyOnMachine(keys []Key, primary string) (found, onMachine bool) {
+	for _, key := range keys {
+		if key.Type != "sec" {
+			continue
+		}
+		if KeyIDMatches(key.Fingerprint, primary) || KeyIDMatches(key.KeyID, primary) {
+			return true, key.Secret == SecretLocal
+		}
+	}
+	return false, false
+}
+
+// KeyIDMatches reports whether two key identifiers refer to the same key.
+// Key IDs are suffixes of fingerprints, so a long or short key ID matches the
+// full fingerprint. Spaces, a 0x prefix and case are ignored.
+func KeyIDMatches(a, b string) bool {
+	a = normalizeKeyID(a)
+	b = normalizeKeyID(b)
+	if a == "" || b == "" {
+		return false
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return strings.HasSuffix(a, b)
+}
+
+// normalizeKeyID strips spaces and a 0x prefix and upper-cases a key identifier.
+func normalizeKeyID(id string) string {
+	id = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(id), " ", ""))
+	return strings.TrimPrefix(id, "0X")
 }
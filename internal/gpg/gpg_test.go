@@ -2,7 +2,12 @@ package gpg
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bobbydams/yubikey-manager/internal/executor"
 	"github.com/stretchr/testify/assert"
@@ -21,10 +26,9 @@ func TestService_ListSecretKeys(t *testing.T) {
 		{
 			name:  "successful list",
 			keyID: "ABC123DEF4567890",
-			mockOutput: `sec   rsa4096/ABC123DEF4567890 2023-01-01 [SC] [expires: 2028-01-01]
-uid                 [ultimate] Test User <test@example.com>
-ssb   ed25519/ABC123DEF456 2023-01-01 [S] [expires: 2028-01-01]
-card-no: 0006 12345678
+			mockOutput: `sec:u:4096:1:ABC123DEF4567890:1672531200:1830297600::u:::scESC:::+:::::0:
+uid:u::::1672531200::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:22:ABC123DEF456:1672531200:1830297600:::::s:::D2760001240103040006123456780000::ed25519::
 `,
 			expectedKeys:  2,
 			expectedError: false,
@@ -40,12 +44,12 @@ card-no: 0006 12345678
 		{
 			name:  "keys on card (sec# and ssb>)",
 			keyID: "07AAA1E535650AF5",
-			mockOutput: `sec#  ed25519/07AAA1E535650AF5 2025-09-05 [SC] [expires: 2030-09-04]
-      FA57C85131F11B28EE236A4F07AAA1E535650AF5
-uid                 [ultimate] Test User <test@example.com>
-ssb>  cv25519/116DB85718F8B287 2025-09-05 [E] [expires: 2030-09-04]
-ssb>  ed25519/DC47D1B090A51498 2025-09-05 [S] [expires: 2030-09-04]
-ssb>  ed25519/0257F6B8152D7F35 2025-09-05 [A] [expires: 2030-09-04]
+			mockOutput: `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESCA:::#::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:18:116DB85718F8B287:1757030400:1914710400:::::e:::D2760001240103040006123456780000::cv25519::
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+ssb:u:255:22:0257F6B8152D7F35:1757030400:1914710400:::::a:::D2760001240103040006123456780000::ed25519::
 `,
 			expectedKeys:  4,
 			expectedError: false,
@@ -57,7 +61,7 @@ ssb>  ed25519/0257F6B8152D7F35 2025-09-05 [A] [expires: 2030-09-04]
 			mockExec := executor.NewMockExecutor()
 			svc := NewService(mockExec)
 
-			key := "gpg --list-secret-keys --keyid-format=long " + tt.keyID
+			key := "gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint " + tt.keyID
 			mockExec.SetOutput(key, []byte(tt.mockOutput))
 			if tt.mockError != nil {
 				mockExec.SetError(key, tt.mockError)
@@ -142,50 +146,216 @@ func TestService_ExportPublicKey(t *testing.T) {
 	assert.Equal(t, expectedOutput, output)
 }
 
-func TestParseKeyList(t *testing.T) {
+func TestService_PublishKey(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+	keyID := "ABC123DEF4567890"
+	servers := []string{"hkps://keys.openpgp.org", "hkps://keyserver.ubuntu.com", "hkp://keys.example.com"}
+	mockExec.SetError("gpg --keyserver hkps://keyserver.ubuntu.com --send-keys "+keyID, fmt.Errorf("keyserver send failed"))
+
+	errs := svc.PublishKey(context.Background(), keyID, servers)
+
+	require.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	require.Error(t, errs[1])
+	assert.Contains(t, errs[1].Error(), "keyserver.ubuntu.com")
+	assert.NoError(t, errs[2])
+	// A failure doesn't stop the remaining uploads
+	for _, server := range servers {
+		assert.True(t, mockExec.VerifyCall("gpg", "--keyserver", server, "--send-keys", keyID))
+	}
+}
+
+func TestService_PublishKey_Proxy(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+	svc.KeyserverProxy = "http://proxy.example.com:3128"
+
+	errs := svc.PublishKey(context.Background(), "ABC123DEF4567890", []string{"hkps://keys.openpgp.org"})
+
+	require.Len(t, errs, 1)
+	assert.NoError(t, errs[0])
+	assert.True(t, mockExec.VerifyCall("gpg", "--keyserver", "hkps://keys.openpgp.org",
+		"--keyserver-options", "http-proxy=http://proxy.example.com:3128", "--send-keys", "ABC123DEF4567890"))
+}
+
+func TestService_ExportForWKD(t *testing.T) {
+	email := "Joe.Doe@example.org"
+	exportKey := "gpg --export --export-options export-minimal --export-filter keep-uid=mbox=joe.doe@example.org <Joe.Doe@example.org>"
+
+	t.Run("exports minimal key", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(mockExec)
+		mockExec.SetOutput(exportKey, []byte{0x98, 0x33, 0x04})
+
+		hash, data, err := svc.ExportForWKD(context.Background(), email)
+
+		require.NoError(t, err)
+		assert.Equal(t, "iy9q119eutrkn8s1mk4r39qejnbu3n5q", hash)
+		assert.Equal(t, []byte{0x98, 0x33, 0x04}, data)
+	})
+
+	t.Run("no key for email", func(t *testing.T) {
+		svc := NewService(executor.NewMockExecutor())
+
+		_, _, err := svc.ExportForWKD(context.Background(), email)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no public key found")
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(mockExec)
+
+		_, _, err := svc.ExportForWKD(context.Background(), "joe.doe")
+
+		require.Error(t, err)
+		assert.Empty(t, mockExec.Calls)
+	})
+}
+
+func TestService_RefreshKey(t *testing.T) {
+	keyID := "07AAA1E535650AF5"
+	server := "hkps://keys.openpgp.org"
+	listKey := "gpg --list-keys --with-colons " + keyID
+
 	tests := []struct {
-		name        string
-		input       string
-		expectedLen int
-		checkCardNo bool
+		name         string
+		missing      bool
+		importStatus string
+		wantAction   string
+		wantChanged  bool
 	}{
 		{
-			name: "parse keys with card",
-			input: `sec   rsa4096/ABC123DEF4567890 2023-01-01 [SC] [expires: 2028-01-01]
-uid                 [ultimate] Test User <test@example.com>
-ssb   ed25519/ABC123DEF456 2023-01-01 [S] [expires: 2028-01-01]
-card-no: 0006 12345678
-`,
-			expectedLen: 2,
-			checkCardNo: true,
+			name:         "not changed",
+			importStatus: "[GNUPG:] IMPORT_OK 0 FA57C85131F11B28EE236A4F07AAA1E535650AF5\n[GNUPG:] IMPORT_RES 1 0 0 0 1 0 0 0 0 0 0 0 0 0 0\n",
+			wantAction:   "--refresh-keys",
 		},
 		{
-			name: "parse keys without card",
-			input: `sec   rsa4096/ABC123DEF4567890 2023-01-01 [SC] [expires: 2028-01-01]
-ssb   ed25519/ABC123DEF456 2023-01-01 [S] [expires: 2028-01-01]
-`,
-			expectedLen: 2,
-			checkCardNo: false,
+			name:         "new signatures",
+			importStatus: "[GNUPG:] IMPORT_OK 4 FA57C85131F11B28EE236A4F07AAA1E535650AF5\n[GNUPG:] IMPORT_RES 1 0 0 0 0 0 0 3 0 0 0 0 0 0 0\n",
+			wantAction:   "--refresh-keys",
+			wantChanged:  true,
+		},
+		{
+			name:         "new revocation",
+			importStatus: "[GNUPG:] IMPORT_RES 1 0 0 0 0 0 0 0 1 0 0 0 0 0 0\n",
+			wantAction:   "--refresh-keys",
+			wantChanged:  true,
+		},
+		{
+			name:         "missing key is fetched",
+			missing:      true,
+			importStatus: "[GNUPG:] IMPORT_OK 1 FA57C85131F11B28EE236A4F07AAA1E535650AF5\n[GNUPG:] IMPORT_RES 1 0 1 0 0 0 0 0 0 0 0 0 0 0 0\n",
+			wantAction:   "--recv-keys",
+			wantChanged:  true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			keys := parseKeyList([]byte(tt.input))
-			assert.Len(t, keys, tt.expectedLen)
-
-			if tt.checkCardNo {
-				foundCardNo := false
-				for _, key := range keys {
-					if key.CardNo != "" {
-						foundCardNo = true
-						break
-					}
-				}
-				assert.True(t, foundCardNo, "expected at least one key with card-no")
+			mockExec := executor.NewMockExecutor()
+			svc := NewService(mockExec)
+			if tt.missing {
+				mockExec.SetError(listKey, fmt.Errorf("gpg: error reading key: No public key"))
 			}
+			mockExec.SetOutput("gpg --status-fd 1 --keyserver "+server+" "+tt.wantAction+" "+keyID, []byte(tt.importStatus))
+
+			changed, err := svc.RefreshKey(context.Background(), keyID, server)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChanged, changed)
+			assert.True(t, mockExec.VerifyCall("gpg", "--status-fd", "1", "--keyserver", server, tt.wantAction, keyID))
 		})
 	}
+
+	t.Run("keyserver error", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(mockExec)
+		mockExec.SetError("gpg --status-fd 1 --keyserver "+server+" --refresh-keys "+keyID, fmt.Errorf("keyserver receive failed: No data"))
+
+		_, err := svc.RefreshKey(context.Background(), keyID, server)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), server)
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(mockExec)
+		svc.KeyserverProxy = "http://proxy.example.com:3128"
+		mockExec.SetError(listKey, fmt.Errorf("gpg: error reading key: No public key"))
+
+		_, err := svc.RefreshKey(context.Background(), keyID, server)
+
+		require.NoError(t, err)
+		assert.True(t, mockExec.VerifyCall("gpg", "--status-fd", "1", "--keyserver", server,
+			"--keyserver-options", "http-proxy=http://proxy.example.com:3128", "--recv-keys", keyID))
+	})
+}
+
+func TestService_ExportPublicKeyMinimal(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+
+	keyID := "ABC123DEF4567890"
+	expectedOutput := []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----")
+	mockExec.SetOutput("gpg --export --armor --export-options export-minimal,export-clean "+keyID, expectedOutput)
+
+	output, err := svc.ExportPublicKeyMinimal(context.Background(), keyID)
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedOutput, output)
+}
+
+func TestService_ExportPublicKeyBinary(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+
+	keyID := "ABC123DEF4567890"
+	expectedOutput := []byte{0x99, 0x00, 0x33}
+	mockExec.SetOutput("gpg --export "+keyID, expectedOutput)
+
+	output, err := svc.ExportPublicKeyBinary(context.Background(), keyID, false)
+	require.NoError(t, err)
+	assert.Equal(t, expectedOutput, output)
+
+	_, err = svc.ExportPublicKeyBinary(context.Background(), keyID, true)
+	require.NoError(t, err)
+	assert.True(t, mockExec.VerifyCall("gpg", "--export", "--export-options", "export-minimal,export-clean", keyID))
+}
+
+func TestService_GPGVersion(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --version", []byte("gpg (GnuPG) 2.4.5\nlibgcrypt 1.10.3\n"))
+	svc := NewService(mockExec)
+
+	major, minor, patch, err := svc.GPGVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 5}, []int{major, minor, patch})
+
+	// The version is only probed once
+	_, _, _, err = svc.GPGVersion(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockExec.Calls, 1)
+
+	mockExec.SetOutput("gpg --version", []byte("unexpected\n"))
+	_, _, _, err = NewService(mockExec).GPGVersion(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not parse the gpg version")
+}
+
+func TestService_CustomBinary(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+	svc.Binary = "/usr/local/bin/gpg2"
+
+	_, err := svc.ExportPublicKey(context.Background(), "ABC123DEF4567890")
+
+	require.NoError(t, err)
+	assert.True(t, mockExec.VerifyCall("/usr/local/bin/gpg2", "--export", "--armor", "ABC123DEF4567890"))
+	assert.False(t, mockExec.VerifyCall("gpg", "--export", "--armor", "ABC123DEF4567890"))
 }
 
 func TestParseCardStatus(t *testing.T) {
@@ -207,3 +377,762 @@ Authentication key: GHI789JKL0123456
 	assert.Equal(t, "DEF456GHI7890123", cardInfo.Keys["Encryption"])
 	assert.Equal(t, "GHI789JKL0123456", cardInfo.Keys["Authentication"])
 }
+
+func TestService_EncryptSymmetric(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+
+	err := svc.EncryptSymmetric(context.Background(), "/tmp/backup.tar", "/tmp/backup.tar.gpg", "s3cret")
+
+	require.NoError(t, err)
+	require.Len(t, mockExec.Calls, 1)
+	call := mockExec.Calls[0]
+	assert.Equal(t, "gpg", call.Name)
+	assert.Contains(t, call.Args, "--symmetric")
+	assert.Contains(t, call.Args, "AES256")
+	assert.NotContains(t, call.Args, "s3cret", "passphrase must not be passed as an argument")
+	assert.Equal(t, []byte("s3cret\n"), call.Input)
+}
+
+func TestService_ImportOwnerTrust(t *testing.T) {
+	trust := []byte("FA57C85131F11B28EE236A4F07AAA1E535650AF5:6:\n")
+
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+	require.NoError(t, svc.ImportOwnerTrust(context.Background(), trust))
+	require.Len(t, mockExec.Calls, 1)
+	assert.Equal(t, []string{"--import-ownertrust"}, mockExec.Calls[0].Args)
+	assert.Equal(t, trust, mockExec.Calls[0].Input)
+
+	mockExec.SetError("gpg --import-ownertrust", fmt.Errorf("exit status 2"))
+	err := svc.ImportOwnerTrust(context.Background(), trust)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to import ownertrust")
+}
+
+func TestService_LaunchAgent(t *testing.T) {
+	ctx := context.Background()
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+
+	require.NoError(t, svc.PingAgent(ctx))
+	assert.True(t, mockExec.VerifyCall("gpg-connect-agent", "--no-autostart", "/bye"))
+	assert.False(t, mockExec.VerifyCall("gpgconf", "--launch", "gpg-agent"), "pinging never starts the agent")
+
+	require.NoError(t, svc.LaunchAgent(ctx))
+	assert.True(t, mockExec.VerifyCall("gpgconf", "--launch", "gpg-agent"))
+
+	mockExec.SetError("gpg-connect-agent --no-autostart /bye", fmt.Errorf("exit status 1"))
+	assert.Error(t, svc.PingAgent(ctx))
+	assert.Error(t, svc.LaunchAgent(ctx), "the agent still doesn't answer")
+
+	mockExec.SetError("gpgconf --launch gpg-agent", fmt.Errorf("exit status 2"))
+	err := svc.LaunchAgent(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to launch gpg-agent")
+}
+
+func TestService_AddSubkey(t *testing.T) {
+	const fpr = "FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+	require.NoError(t, svc.AddSubkey(context.Background(), fpr, "ed25519", "sign", "2030-09-05", "secret"))
+	require.Len(t, mockExec.Calls, 1)
+	assert.Equal(t, []string{"--batch", "--pinentry-mode", "loopback", "--passphrase-fd", "0",
+		"--quick-add-key", fpr, "ed25519", "sign", "2030-09-05"}, mockExec.Calls[0].Args)
+	assert.Equal(t, "secret\n", string(mockExec.Calls[0].Input))
+
+	// Without a passphrase gpg uses pinentry
+	mockExec.Reset()
+	require.NoError(t, svc.AddSubkey(context.Background(), fpr, "ed25519", "sign", "2030-09-05", ""))
+	assert.True(t, mockExec.VerifyCall("gpg", "--batch", "--quick-add-key", fpr, "ed25519", "sign", "2030-09-05"))
+
+	mockExec.SetError("gpg --batch --quick-add-key "+fpr+" ed25519 sign 2030-09-05", fmt.Errorf("exit status 2"))
+	err := svc.AddSubkey(context.Background(), fpr, "ed25519", "sign", "2030-09-05", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create subkey")
+}
+
+// cardChangingExecutor is a MockExecutor whose gpg --card-status output
+// becomes after once a command has been run with input, as if a scripted
+// session changed the card.
+type cardChangingExecutor struct {
+	*executor.MockExecutor
+	after []byte
+}
+
+func (e *cardChangingExecutor) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	output, err := e.MockExecutor.RunWithInput(ctx, input, name, args...)
+	e.SetOutput("gpg --card-status", e.after)
+	return output, err
+}
+
+func TestService_MoveSubkeyToCard(t *testing.T) {
+	const keyList = `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+ssb:u:255:22:0257F6B8152D7F35:1759276800:1916956800:::::s:::+::ed25519::
+`
+	editKey := "gpg --pinentry-mode loopback --command-fd 0 --status-fd 1 --edit-key 07AAA1E535650AF5"
+
+	tests := []struct {
+		name          string
+		subkeyID      string
+		slot          int
+		passphrase    string
+		editOutput    string
+		cardBefore    string
+		cardStatus    string
+		expectedInput string
+		expectedError string
+	}{
+		{
+			name:          "moves second subkey",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			passphrase:    "passphrase",
+			cardStatus:    "Signature key ....: 0C1B 2E3F 4A5B 6C7D 8E9F  0A1B 0257 F6B8 152D 7F35\n",
+			expectedInput: "key 2\nkeytocard\n1\npassphrase\n12345678\nsave\n",
+		},
+		{
+			name:          "no passphrase line when key is unprotected",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			cardStatus:    "Signature key ....: 0257F6B8152D7F35\n",
+			expectedInput: "key 2\nkeytocard\n1\n12345678\nsave\n",
+		},
+		{
+			name:          "replaces the key in an occupied slot",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			passphrase:    "passphrase",
+			cardBefore:    "Signature key ....: 1111 2222 3333 4444 5555  6666 7777 8888 9999 AAAA\n",
+			cardStatus:    "Signature key ....: 0257F6B8152D7F35\n",
+			expectedInput: "key 2\nkeytocard\n1\ny\npassphrase\n12345678\nsave\n",
+		},
+		{
+			name:          "key not changed",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			editOutput:    "[GNUPG:] SC_OP_FAILURE 2\n",
+			expectedError: "admin PIN likely incorrect",
+		},
+		{
+			name:          "wrong passphrase",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			passphrase:    "wrong",
+			editOutput:    "[GNUPG:] NEED_PASSPHRASE 0257F6B8152D7F35 07AAA1E535650AF5 22 0\n[GNUPG:] BAD_PASSPHRASE 0257F6B8152D7F35\n",
+			expectedError: "the key passphrase was rejected",
+		},
+		{
+			name:          "wrong Admin PIN after the passphrase",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			passphrase:    "passphrase",
+			editOutput:    "[GNUPG:] NEED_PASSPHRASE 0257F6B8152D7F35 07AAA1E535650AF5 22 0\n[GNUPG:] GOOD_PASSPHRASE\n[GNUPG:] SC_OP_FAILURE 2\n",
+			expectedError: "admin PIN likely incorrect",
+		},
+		{
+			name:          "slot still empty after save",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          1,
+			cardStatus:    "Signature key ....: [none]\n",
+			expectedError: "admin PIN likely incorrect",
+		},
+		{
+			name:          "unknown subkey",
+			subkeyID:      "FFFFFFFFFFFFFFFF",
+			slot:          1,
+			expectedError: "not found",
+		},
+		{
+			name:          "invalid slot",
+			subkeyID:      "0257F6B8152D7F35",
+			slot:          4,
+			expectedError: "invalid card slot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExec := executor.NewMockExecutor()
+			mockExec.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5", []byte(keyList))
+			mockExec.SetOutput(editKey, []byte(tt.editOutput))
+			mockExec.SetOutput("gpg --card-status", []byte(tt.cardBefore))
+			svc := NewService(&cardChangingExecutor{MockExecutor: mockExec, after: []byte(tt.cardStatus)})
+
+			err := svc.MoveSubkeyToCard(context.Background(), "07AAA1E535650AF5", tt.subkeyID, tt.slot, tt.passphrase, "12345678")
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for _, call := range mockExec.Calls {
+				if call.Input != nil {
+					assert.Equal(t, tt.expectedInput, string(call.Input))
+					assert.NotContains(t, call.Args, "12345678", "admin PIN must not be passed as an argument")
+				}
+			}
+		})
+	}
+}
+
+func TestService_ForgetPassphrases(t *testing.T) {
+	const keygripList = `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+grp:::::::::1111111111111111111111111111111111111111:
+ssb:u:255:22:0257F6B8152D7F35:1759276800:1916956800:::::s:::+::ed25519::
+grp:::::::::2222222222222222222222222222222222222222:
+`
+	const editKey = "gpg --pinentry-mode loopback --command-fd 0 --status-fd 1 --edit-key 07AAA1E535650AF5"
+	const clearPrefix = "CLEAR_PASSPHRASE --mode=normal "
+
+	newMock := func() *executor.MockExecutor {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("gpg --list-secret-keys --with-colons --with-keygrip 07AAA1E535650AF5", []byte(keygripList))
+		return mockExec
+	}
+	// clearedBeforeEdit returns the keygrips cleared before the edit-key session.
+	clearedBeforeEdit := func(t *testing.T, calls []executor.CommandCall) []string {
+		var cleared []string
+		for _, call := range calls {
+			if call.Name+" "+strings.Join(call.Args, " ") == editKey {
+				return cleared
+			}
+			if call.Name == "gpg-connect-agent" && strings.HasPrefix(call.Args[0], clearPrefix) {
+				cleared = append(cleared, strings.TrimPrefix(call.Args[0], clearPrefix))
+			}
+		}
+		t.Fatal("no edit-key session")
+		return nil
+	}
+
+	t.Run("before keytocard", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5", []byte(keygripList))
+		svc := NewService(&cardChangingExecutor{MockExecutor: mockExec, after: []byte("Signature key ....: 0257F6B8152D7F35\n")})
+
+		require.NoError(t, svc.MoveSubkeyToCard(context.Background(), "07AAA1E535650AF5", "0257F6B8152D7F35", 1, "passphrase", "12345678"))
+		assert.Equal(t, []string{"1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222"}, clearedBeforeEdit(t, mockExec.Calls))
+	})
+
+	t.Run("before extending", func(t *testing.T) {
+		mockExec := newMock()
+		require.NoError(t, NewService(mockExec).ExtendExpiration(context.Background(), "07AAA1E535650AF5", nil, "2030-09-05", "passphrase"))
+		assert.Len(t, clearedBeforeEdit(t, mockExec.Calls), 2)
+	})
+
+	t.Run("not without a passphrase", func(t *testing.T) {
+		mockExec := newMock()
+		require.NoError(t, NewService(mockExec).ExtendExpiration(context.Background(), "07AAA1E535650AF5", nil, "2030-09-05", ""))
+		assert.Empty(t, clearedBeforeEdit(t, mockExec.Calls))
+	})
+
+	t.Run("not when the agent isn't running", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetError("gpg-connect-agent --no-autostart /bye", fmt.Errorf("no gpg-agent running"))
+		require.NoError(t, NewService(mockExec).ExtendExpiration(context.Background(), "07AAA1E535650AF5", nil, "2030-09-05", "passphrase"))
+		assert.Empty(t, clearedBeforeEdit(t, mockExec.Calls))
+	})
+
+	t.Run("failing to clear stops the session", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetError("gpg-connect-agent "+clearPrefix+"1111111111111111111111111111111111111111 /bye", fmt.Errorf("exit status 1"))
+		err := NewService(mockExec).ExtendExpiration(context.Background(), "07AAA1E535650AF5", nil, "2030-09-05", "passphrase")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cached passphrase")
+		assert.False(t, mockExec.VerifyCall("gpg", "--pinentry-mode", "loopback", "--command-fd", "0", "--status-fd", "1", "--edit-key", "07AAA1E535650AF5"))
+	})
+}
+
+func TestService_ExtendExpiration(t *testing.T) {
+	const keyList = `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+ssb:u:255:22:0257F6B8152D7F35:1759276800:1916956800:::::s:::+::ed25519::
+`
+	editKey := "gpg --pinentry-mode loopback --command-fd 0 --status-fd 1 --edit-key 07AAA1E535650AF5"
+
+	tests := []struct {
+		name          string
+		subkeyIDs     []string
+		passphrase    string
+		editOutput    string
+		expectedInput string
+		expectedError string
+	}{
+		{
+			name:          "primary and all subkeys",
+			subkeyIDs:     []string{"DC47D1B090A51498", "0257F6B8152D7F35"},
+			passphrase:    "passphrase",
+			expectedInput: "expire\n2030-09-05\npassphrase\nkey 1\nkey 2\nexpire\ny\n2030-09-05\nsave\n",
+		},
+		{
+			name:          "single subkey needs no confirmation",
+			subkeyIDs:     []string{"0257F6B8152D7F35"},
+			expectedInput: "expire\n2030-09-05\nkey 2\nexpire\n2030-09-05\nsave\n",
+		},
+		{
+			name:          "primary only",
+			expectedInput: "expire\n2030-09-05\nsave\n",
+		},
+		{
+			name:          "bad passphrase",
+			passphrase:    "wrong",
+			editOutput:    "[GNUPG:] BAD_PASSPHRASE 07AAA1E535650AF5\n",
+			expectedError: "passphrase rejected",
+		},
+		{
+			name:          "unknown subkey",
+			subkeyIDs:     []string{"FFFFFFFFFFFFFFFF"},
+			expectedError: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExec := executor.NewMockExecutor()
+			mockExec.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5", []byte(keyList))
+			mockExec.SetOutput(editKey, []byte(tt.editOutput))
+			svc := NewService(mockExec)
+
+			err := svc.ExtendExpiration(context.Background(), "07AAA1E535650AF5", tt.subkeyIDs, "2030-09-05", tt.passphrase)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for _, call := range mockExec.Calls {
+				if call.Input != nil {
+					assert.Equal(t, tt.expectedInput, string(call.Input))
+				}
+			}
+		})
+	}
+}
+
+func TestKeyIDMatches(t *testing.T) {
+	assert.True(t, KeyIDMatches("0257F6B8152D7F35", "0257f6b8152d7f35"))
+	assert.True(t, KeyIDMatches("0C1B 2E3F 4A5B 6C7D 8E9F  0A1B 0257 F6B8 152D 7F35", "0257F6B8152D7F35"))
+	assert.True(t, KeyIDMatches("0x0257F6B8152D7F35", "152D7F35"))
+	assert.False(t, KeyIDMatches("0257F6B8152D7F35", "DC47D1B090A51498"))
+	assert.False(t, KeyIDMatches("", "0257F6B8152D7F35"))
+}
+
+func TestFindMovableSigningSubkey(t *testing.T) {
+	primary := Key{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}}
+	onCard := Key{Type: "ssb", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}, CardNo: "0006 12345678"}
+	encryption := Key{Type: "ssb", KeyID: "116DB85718F8B287", Capabilities: []string{"E"}, Secret: SecretLocal}
+	movable := Key{Type: "ssb", KeyID: "0257F6B8152D7F35", Capabilities: []string{"S"}, Secret: SecretLocal}
+	another := Key{Type: "ssb", KeyID: "AAAABBBBCCCCDDDD", Capabilities: []string{"S"}, Secret: SecretLocal}
+
+	t.Run("single candidate", func(t *testing.T) {
+		keys := []Key{primary, onCard, encryption, movable}
+		key, err := FindMovableSigningSubkey(keys)
+		require.NoError(t, err)
+		assert.Equal(t, "0257F6B8152D7F35", key.KeyID)
+		assert.Equal(t, 3, SubkeyIndex(keys, key.KeyID))
+	})
+
+	t.Run("no candidate", func(t *testing.T) {
+		_, err := FindMovableSigningSubkey([]Key{primary, onCard, encryption})
+		assert.Error(t, err)
+	})
+
+	t.Run("multiple candidates", func(t *testing.T) {
+		_, err := FindMovableSigningSubkey([]Key{primary, movable, another})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "0257F6B8152D7F35")
+		assert.Contains(t, err.Error(), "AAAABBBBCCCCDDDD")
+	})
+}
+
+func TestService_GenerateRevocationCertificate(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	cert := []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\nComment: This is a revocation certificate\n")
+	mockExec.SetOutput("gpg --armor --command-fd 0 --gen-revoke ABC123DEF4567890", cert)
+	svc := NewService(mockExec)
+
+	output, err := svc.GenerateRevocationCertificate(context.Background(), "ABC123DEF4567890")
+
+	require.NoError(t, err)
+	assert.Equal(t, cert, output)
+	require.Len(t, mockExec.Calls, 1)
+	assert.Equal(t, "y\n0\n\ny\n", string(mockExec.Calls[0].Input))
+}
+
+func TestService_ImportKeyFile(t *testing.T) {
+	const fpr = "FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+	showOnly := `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::cESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::+::ed25519::
+fpr:::::::::111111111111111111111111DC47D1B090A51498:
+`
+	importStatus := `[GNUPG:] IMPORT_OK 1 FA57C85131F11B28EE236A4F07AAA1E535650AF5
+[GNUPG:] IMPORT_RES 1 0 0 0 1 0 0 0 0 1 1 0 0 0 0
+`
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		wantErr     string
+	}{
+		{name: "matching fingerprint", fingerprint: fpr},
+		{name: "lowercase fingerprint", fingerprint: "fa57c85131f11b28ee236a4f07aaa1e535650af5"},
+		{name: "wrong key", fingerprint: "ABCDEF1234567890ABCDEF12ABC123DEF4567890", wantErr: "does not contain primary key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "master.asc")
+			require.NoError(t, os.WriteFile(path, []byte("key"), 0600))
+
+			mockExec := executor.NewMockExecutor()
+			mockExec.SetOutput("gpg --batch --with-colons --import-options show-only --import "+path, []byte(showOnly))
+			mockExec.SetOutput("gpg --status-fd 1 --import "+path, []byte(importStatus))
+			svc := NewService(mockExec)
+
+			result, err := svc.ImportKeyFile(context.Background(), path, tt.fingerprint)
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Len(t, mockExec.Calls, 1, "must not import after a failed check")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &ImportResult{Processed: 1, Unchanged: 1, SecretRead: 1, SecretImported: 1}, result)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(mockExec)
+
+		_, err := svc.ImportKeyFile(context.Background(), filepath.Join(t.TempDir(), "missing.asc"), fpr)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key file not found")
+		assert.Empty(t, mockExec.Calls)
+	})
+
+	t.Run("no keys in file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.asc")
+		require.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(mockExec)
+
+		_, err := svc.ImportKeyFile(context.Background(), path, fpr)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no keys found")
+	})
+}
+
+func TestService_InspectKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.asc")
+	require.NoError(t, os.WriteFile(path, []byte("key"), 0600))
+
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --batch --with-colons --import-options show-only --import "+path, []byte(`sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::cESC:::+::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+uid:u::::1757030400::0123456789ABCDEF::Test User (work\x3a main) <test@example.com>::::::::::0:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::+::ed25519::
+fpr:::::::::111111111111111111111111DC47D1B090A51498:
+ssb:u:255:18:116DB85718F8B287:1757030400::::::e:::+::cv25519::
+fpr:::::::::222222222222222222222222116DB85718F8B287:
+`))
+	svc := NewService(mockExec)
+
+	key, err := svc.InspectKeyFile(context.Background(), path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "sec", key.Type)
+	assert.Equal(t, "07AAA1E535650AF5", key.KeyID)
+	assert.Equal(t, "FA57C85131F11B28EE236A4F07AAA1E535650AF5", key.Fingerprint)
+	assert.Equal(t, "Test User (work: main) <test@example.com>", key.UserID)
+	assert.Equal(t, "ed25519", key.Algorithm)
+	assert.Equal(t, []string{"C"}, key.Capabilities)
+	assert.Equal(t, "2025-09-05", key.Created)
+	assert.Equal(t, "2030-09-04", key.Expires)
+	require.Len(t, key.Subkeys, 2)
+	assert.Equal(t, "DC47D1B090A51498", key.Subkeys[0].KeyID)
+	assert.Equal(t, []string{"S"}, key.Subkeys[0].Capabilities)
+	assert.Equal(t, "cv25519", key.Subkeys[1].Algorithm)
+	assert.Equal(t, "", key.Subkeys[1].Expires)
+	assert.Equal(t, "222222222222222222222222116DB85718F8B287", key.Subkeys[1].Fingerprint)
+	assert.Len(t, mockExec.Calls, 1)
+}
+
+func TestFindMovableSubkey(t *testing.T) {
+	keys := []Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Capabilities: []string{"S"}, Secret: SecretLocal},
+		{Type: "ssb", KeyID: "116DB85718F8B287", Capabilities: []string{"E"}, CardNo: "0006 12345678", Secret: SecretOnCard},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Capabilities: []string{"E"}, Secret: SecretLocal},
+	}
+
+	key, err := FindMovableSubkey(keys, "E")
+	require.NoError(t, err)
+	assert.Equal(t, "0257F6B8152D7F35", key.KeyID)
+
+	_, err = FindMovableSubkey(keys, "A")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no authentication subkey")
+}
+
+func TestFindMovableSubkey_SkipsRetiredSubkeys(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	primary := Key{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}, Secret: SecretStub}
+	stub := Key{Type: "ssb", KeyID: "1111111111111111", Capabilities: []string{"S"}, Secret: SecretStub}
+	revoked := Key{Type: "ssb", KeyID: "2222222222222222", Capabilities: []string{"S"}, Secret: SecretLocal, Revoked: true}
+	expired := Key{Type: "ssb", KeyID: "3333333333333333", Capabilities: []string{"S"}, Secret: SecretLocal, Expires: "2025-01-01"}
+	unknown := Key{Type: "ssb", KeyID: "4444444444444444", Capabilities: []string{"S"}}
+	fresh := Key{Type: "ssb", KeyID: "0257F6B8152D7F35", Capabilities: []string{"S"}, Secret: SecretLocal, Expires: "2031-10-16"}
+
+	for _, retired := range []Key{stub, revoked, expired, unknown} {
+		t.Run(retired.KeyID, func(t *testing.T) {
+			key, err := findMovableSubkey([]Key{primary, retired, fresh}, "S", now)
+			require.NoError(t, err)
+			assert.Equal(t, "0257F6B8152D7F35", key.KeyID)
+
+			_, err = findMovableSubkey([]Key{primary, retired}, "S", now)
+			assert.Error(t, err, "a retired subkey is never a candidate")
+		})
+	}
+}
+
+func TestFindSigningSubkeyOnCard(t *testing.T) {
+	keys := []Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Capabilities: []string{"S", "C"}},
+		{Type: "ssb", KeyID: "116DB85718F8B287", Capabilities: []string{"E"}, CardNo: "0006 12345678", CardSerial: "12345678"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Fingerprint: "111111111111111111111111DC47D1B090A51498",
+			Capabilities: []string{"S"}, Created: "2024-03-01", CardNo: "0006 87654321", CardSerial: "87654321"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Fingerprint: "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35",
+			Capabilities: []string{"S"}, Created: "2025-06-01", CardNo: "0006 12345678", CardSerial: "12345678"},
+		{Type: "ssb", KeyID: "9A8B7C6D5E4F3A2B", Capabilities: []string{"S"}, Created: "2025-09-01"},
+	}
+
+	tests := []struct {
+		name    string
+		card    *CardInfo
+		wantKey string
+	}{
+		{
+			name:    "signature slot from card status",
+			card:    &CardInfo{Serial: "12345678", Keys: map[string]string{"Signature": "1111 1111 1111 1111 1111  1111 1111 1111 DC47 D1B0 90A5 1498"}},
+			wantKey: "DC47D1B090A51498",
+		},
+		{
+			name:    "empty slot matched by card serial",
+			card:    &CardInfo{Serial: "12345678", Keys: map[string]string{"Signature": "[none]"}},
+			wantKey: "0257F6B8152D7F35",
+		},
+		{
+			name:    "slot key not in keyring matched by card serial",
+			card:    &CardInfo{Serial: "0006 12345678", Keys: map[string]string{"Signature": "FFFFFFFFFFFFFFFF"}},
+			wantKey: "0257F6B8152D7F35",
+		},
+		{
+			name:    "no card-no match falls back to most recent on a card",
+			card:    &CardInfo{Serial: "11111111", Keys: map[string]string{}},
+			wantKey: "0257F6B8152D7F35",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := FindSigningSubkeyOnCard(keys, tt.card)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKey, key.KeyID)
+		})
+	}
+
+	t.Run("equal dates prefer the later subkey", func(t *testing.T) {
+		sameDay := []Key{
+			{Type: "ssb", KeyID: "AAAA000000000001", Capabilities: []string{"S"}, Created: "2025-01-01", CardNo: "0006 1"},
+			{Type: "ssb", KeyID: "AAAA000000000002", Capabilities: []string{"S"}, Created: "2025-01-01", CardNo: "0006 2"},
+		}
+		key, err := FindSigningSubkeyOnCard(sameDay, &CardInfo{Serial: "3"})
+		require.NoError(t, err)
+		assert.Equal(t, "AAAA000000000002", key.KeyID)
+	})
+
+	t.Run("no signing subkey on a card", func(t *testing.T) {
+		_, err := FindSigningSubkeyOnCard(keys[:2], &CardInfo{Serial: "12345678"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "12345678")
+	})
+
+	t.Run("no card info", func(t *testing.T) {
+		_, err := FindSigningSubkeyOnCard(keys, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestSubkeySpec(t *testing.T) {
+	keys := []Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: "FA57C85131F11B28EE236A4F07AAA1E535650AF5"},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Fingerprint: "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35"},
+		{Type: "ssb", KeyID: "DC47D1B090A51498"},
+	}
+
+	assert.Equal(t, "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35!", SubkeySpec(keys, "0257F6B8152D7F35"))
+	assert.Equal(t, "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35!", SubkeySpec(keys, "0C1B 2E3F 4A5B 6C7D 8E9F  0A1B 0257 F6B8 152D 7F35"))
+	assert.Equal(t, "DC47D1B090A51498", SubkeySpec(keys, "DC47D1B090A51498"), "falls back without a fingerprint")
+	assert.Equal(t, "07AAA1E535650AF5", SubkeySpec(keys, "07AAA1E535650AF5"), "primary keys are not subkeys")
+}
+
+func TestFindSubkey(t *testing.T) {
+	keys := []Key{
+		{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: "FA57C85131F11B28EE236A4F07AAA1E535650AF5", Secret: SecretStub},
+		{Type: "ssb", KeyID: "0257F6B8152D7F35", Fingerprint: "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35", Secret: SecretOnCard},
+		{Type: "ssb", KeyID: "DC47D1B090A51498", Secret: SecretLocal},
+	}
+
+	if key := FindSubkey(keys, "0C1B 2E3F 4A5B 6C7D 8E9F  0A1B 0257 F6B8 152D 7F35"); assert.NotNil(t, key) {
+		assert.Equal(t, SecretOnCard, key.Secret)
+	}
+	if key := FindSubkey(keys, "DC47D1B090A51498"); assert.NotNil(t, key) {
+		assert.Equal(t, SecretLocal, key.Secret, "a full local copy is detected")
+	}
+	assert.Nil(t, FindSubkey(keys, "07AAA1E535650AF5"), "primary keys are not subkeys")
+	assert.Nil(t, FindSubkey(keys, "1111111111111111"))
+}
+
+func TestGuessedSigningSubkey(t *testing.T) {
+	subkey := &Key{KeyID: "0257F6B8152D7F35", Fingerprint: "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35", CardSerial: "12345678"}
+
+	assert.False(t, GuessedSigningSubkey(subkey, &CardInfo{Serial: "12345678"}))
+	assert.False(t, GuessedSigningSubkey(subkey, &CardInfo{Serial: "11111111", Keys: map[string]string{"Signature": "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35"}}))
+	assert.True(t, GuessedSigningSubkey(subkey, &CardInfo{Serial: "11111111", Keys: map[string]string{"Signature": "[none]"}}))
+}
+
+func TestOtherCardSerial(t *testing.T) {
+	subkey := &Key{KeyID: "0257F6B8152D7F35", Fingerprint: "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35", CardSerial: "12345678"}
+
+	assert.Equal(t, "", OtherCardSerial(subkey, &CardInfo{Serial: "12345678"}), "subkey is on this card")
+	assert.Equal(t, "", OtherCardSerial(subkey, &CardInfo{Serial: "11111111", Keys: map[string]string{"Signature": "0257F6B8152D7F35"}}),
+		"the card's Signature slot holds the subkey")
+	assert.Equal(t, "12345678", OtherCardSerial(subkey, &CardInfo{Serial: "11111111", Keys: map[string]string{"Signature": "[none]"}}))
+	assert.Equal(t, "", OtherCardSerial(subkey, &CardInfo{Keys: map[string]string{"Signature": "[none]"}}), "this card's serial is unknown")
+
+	unknown := &Key{KeyID: "0257F6B8152D7F35", CardNo: "0006 12345678"}
+	assert.Equal(t, "", OtherCardSerial(unknown, &CardInfo{Serial: "11111111"}), "the subkey's card is unknown")
+}
+
+func TestMasterKeyOnMachine(t *testing.T) {
+	primaryFpr := "FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+	otherOnline := Key{Type: "sec", KeyID: "1111222233334444", Fingerprint: "0000000000000000000000001111222233334444", Secret: SecretLocal}
+	primaryOffline := Key{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: primaryFpr, Secret: SecretStub}
+	primaryOnline := Key{Type: "sec", KeyID: "07AAA1E535650AF5", Fingerprint: primaryFpr, Secret: SecretLocal}
+	subkey := Key{Type: "ssb", KeyID: "DC47D1B090A51498", Secret: SecretLocal}
+
+	tests := []struct {
+		name          string
+		keys          []Key
+		primary       string
+		wantFound     bool
+		wantOnMachine bool
+	}{
+		{name: "primary offline, other key online", keys: []Key{otherOnline, primaryOffline, subkey}, primary: primaryFpr, wantFound: true},
+		{name: "primary offline, matched by key ID", keys: []Key{otherOnline, primaryOffline}, primary: "07AAA1E535650AF5", wantFound: true},
+		{name: "primary online", keys: []Key{primaryOnline, otherOnline}, primary: primaryFpr, wantFound: true, wantOnMachine: true},
+		{name: "primary not listed", keys: []Key{otherOnline, subkey}, primary: primaryFpr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, onMachine := MasterKeyOnMachine(tt.keys, tt.primary)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantOnMachine, onMachine)
+		})
+	}
+}
+
+func TestService_ImportKeyIsolated(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "public-key.asc")
+	require.NoError(t, os.WriteFile(keyFile, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n"), 0644))
+
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(mockExec)
+
+	// Nothing is listed as the mock doesn't know the temporary home
+	_, err := svc.ImportKeyIsolated(context.Background(), keyFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no keys imported")
+
+	require.Len(t, mockExec.Calls, 2)
+	home := mockExec.Calls[0].Args[1]
+	assert.Equal(t, []string{"--homedir", home, "--batch", "--import", keyFile}, mockExec.Calls[0].Args)
+	assert.Equal(t, "--homedir", mockExec.Calls[1].Args[0])
+	assert.Equal(t, home, mockExec.Calls[1].Args[1])
+	assert.NoDirExists(t, home, "the temporary home is removed")
+
+	_, err = svc.ImportKeyIsolated(context.Background(), filepath.Join(t.TempDir(), "missing.asc"))
+	assert.Error(t, err)
+}
+
+func TestService_ExportSSHKey(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	sshKey := []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExample openpgp:0x152D7F35\n")
+	mockExec.SetOutput("gpg --export-ssh-key ABC123DEF4567890", sshKey)
+	svc := NewService(mockExec)
+
+	output, err := svc.ExportSSHKey(context.Background(), "ABC123DEF4567890")
+
+	require.NoError(t, err)
+	assert.Equal(t, sshKey, output)
+}
+
+func TestService_ListPublicKeys(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --list-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5", []byte(`pub:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+sub:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::::ed25519::
+fpr:::::::::111111111111111111111111DC47D1B090A51498:
+`))
+	svc := NewService(mockExec)
+
+	keys, err := svc.ListPublicKeys(context.Background(), "07AAA1E535650AF5")
+
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "pub", keys[0].Type)
+	assert.Equal(t, "FA57C85131F11B28EE236A4F07AAA1E535650AF5", keys[0].Fingerprint)
+	assert.Equal(t, "sub", keys[1].Type)
+
+	mockExec.SetError("gpg --list-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5", fmt.Errorf("exit status 2"))
+	_, err = svc.ListPublicKeys(context.Background(), "07AAA1E535650AF5")
+	assert.Error(t, err)
+}
+
+func TestService_ListSecretKeys_SubkeyFingerprints(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint 07AAA1E535650AF5", []byte(`sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+fpr:::::::::111111111111111111111111DC47D1B090A51498:
+ssb:u:255:22:0257F6B8152D7F35:1759276800:1916956800:::::s:::+::ed25519::
+fpr:::::::::0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35:
+`))
+	svc := NewService(mockExec)
+
+	keys, err := svc.ListSecretKeys(context.Background(), "07AAA1E535650AF5")
+
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.Equal(t, "FA57C85131F11B28EE236A4F07AAA1E535650AF5", keys[0].Fingerprint)
+	assert.Equal(t, "111111111111111111111111DC47D1B090A51498", keys[1].Fingerprint)
+	assert.Equal(t, "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35", keys[2].Fingerprint)
+	assert.Equal(t, 2, SubkeyIndex(keys, "0C1B2E3F4A5B6C7D8E9F0A1B0257F6B8152D7F35"))
+}
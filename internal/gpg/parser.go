@@ -1,63 +1,82 @@
 package gpg
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// parseKeyList parses the output of `gpg --list-secret-keys`.
-func parseKeyList(output []byte) []Key {
-	lines := strings.Split(string(output), "\n")
-	var keys []Key
-	var currentKey *Key
+// KeyDateFormat is the layout of the creation and expiry dates in key listings.
+const KeyDateFormat = "2006-01-02"
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+// gpgVersionPattern matches the version number in the first line of
+// gpg --version, e.g. "gpg (GnuPG) 2.4.5" or "gpg (GnuPG/MacGPG2) 2.2.41".
+var gpgVersionPattern = regexp.MustCompile(`\)\s+(\d+)\.(\d+)(?:\.(\d+))?`)
 
-		// Primary key: sec   rsa4096/ABC123DEF4567890 2023-01-01 [SC] [expires: 2028-01-01]
-		// Primary key on card: sec#  ed25519/ABC123... 2023-01-01 [SC] [expires: 2028-01-01]
-		// Subkey:      ssb   ed25519/ABC123... 2023-01-01 [S] [expires: 2028-01-01]
-		// Subkey on card: ssb>  ed25519/ABC123... 2023-01-01 [S] [expires: 2028-01-01]
-		// Card:         card-no: 0006 12345678
-		if strings.HasPrefix(line, "sec") || strings.HasPrefix(line, "ssb") {
-			key := parseKeyLine(line)
-			keys = append(keys, key)
-			currentKey = &keys[len(keys)-1]
-		} else if strings.HasPrefix(line, "card-no:") && currentKey != nil {
-			// Extract card number
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				currentKey.CardNo = strings.Join(parts[1:], " ")
-			}
-		}
-	}
+// relativeExpiryPattern matches a relative expiration such as "5y" or "90d".
+var relativeExpiryPattern = regexp.MustCompile(`^([1-9][0-9]*)([dwmy])$`)
 
-	return keys
-}
+// maxExpiryYears bounds how far in the future an expiration may be.
+const maxExpiryYears = 100
 
-// parseKeyLine parses a single key line from GPG output.
-func parseKeyLine(line string) Key {
-	key := Key{}
+// ParseExpiry normalizes a relative ("5y", "18m", "6w", "90d") or absolute
+// ("2035-01-01") expiration to the YYYY-MM-DD date that gpg's --quick-*
+// commands and the edit-key expire prompt accept. Relative expirations are
+// resolved against today so every key given the same input gets the same date.
+// The date must be within maxExpiryYears of today. "0" and "never", which gpg
+// takes as no expiration, are rejected: keys managed by ykgpg always expire.
+func ParseExpiry(input string) (string, error) {
+	return parseExpiry(input, time.Now())
+}
 
-	// Match: sec/ssb   algo/keyid   date   [capabilities] [expires: date]
-	// Also handles: sec# (key on card, not available), ssb> (subkey on card)
-	// The # and > are optional suffixes indicating card status
-	re := regexp.MustCompile(`^(sec|ssb)[#>]?\s+(\S+)/(\S+)\s+(\S+)\s+\[([^\]]+)\](?:\s+\[expires:\s+([^\]]+)\])?`)
-	matches := re.FindStringSubmatch(line)
+// parseExpiry implements ParseExpiry relative to now.
+func parseExpiry(input string, now time.Time) (string, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "0" || input == "never" || input == "none" {
+		return "", fmt.Errorf("invalid expiration %q: keys without an expiration are not supported; use e.g. 5y", input)
+	}
+	limit := now.AddDate(maxExpiryYears, 0, 0)
+	tooFar := fmt.Errorf("invalid expiration %q: too far in the future (at most %d years)", input, maxExpiryYears)
 
-	if len(matches) >= 6 {
-		key.Type = matches[1]
-		key.KeyID = matches[3]
-		key.Capabilities = parseCapabilities(matches[5])
-		if len(matches) >= 7 && matches[6] != "" {
-			key.Expires = matches[6]
+	var date time.Time
+	if match := relativeExpiryPattern.FindStringSubmatch(input); match != nil {
+		n, err := strconv.Atoi(match[1])
+		// Any count this large is beyond the limit in every unit; checking
+		// it first keeps the arithmetic below from overflowing
+		if err != nil || n > 1000000 {
+			return "", tooFar
+		}
+		switch match[2] {
+		case "d":
+			date = now.AddDate(0, 0, n)
+		case "w":
+			date = now.AddDate(0, 0, 7*n)
+		case "m":
+			date = now.AddDate(0, n, 0)
+		case "y":
+			date = now.AddDate(n, 0, 0)
+		}
+	} else {
+		parsed, err := time.Parse(KeyDateFormat, input)
+		if err != nil {
+			return "", fmt.Errorf("invalid expiration %q: use e.g. 5y, 18m, 90d or 2035-01-01", input)
+		}
+		if !parsed.After(now) {
+			return "", fmt.Errorf("expiration %s is in the past", input)
 		}
+		date = parsed
 	}
+	if date.After(limit) {
+		return "", tooFar
+	}
+	return date.Format(KeyDateFormat), nil
+}
 
-	return key
+// ParseKeyDate parses a creation or expiry date from a key listing.
+func ParseKeyDate(date string) (time.Time, error) {
+	return time.Parse(KeyDateFormat, strings.TrimSpace(date))
 }
 
 // parseCapabilities parses capability flags like "[SC]", "[S]", "[E]", "[A]".
@@ -78,11 +97,53 @@ func parseCapabilities(caps string) []string {
 	return result
 }
 
+// openPGPAIDPrefix starts every OpenPGP card application ID: the RID
+// D276000124 and application 01. A full AID is 32 hex digits:
+// prefix(12) version(4) manufacturer(4) serial(8) reserved(4).
+const openPGPAIDPrefix = "D27600012401"
+
+// formatCardNo formats a card application ID the way gpg's human-readable
+// listing shows card-no, e.g. "0006 12345678". Other values are returned as is.
+func formatCardNo(serial string) string {
+	aid := strings.ToUpper(serial)
+	if len(aid) == 32 && strings.HasPrefix(aid, openPGPAIDPrefix) {
+		return aid[16:20] + " " + aid[20:28]
+	}
+	return serial
+}
+
+// NormalizeCardSerial reduces the ways gpg reports a card serial to one
+// canonical form so they can be compared: the card-no of a key listing
+// ("0006 12345678", "0006000012345678"), the full application ID
+// ("D2760001240103040006123456780000") and the card status serial
+// ("12345678"). Spaces, the AID prefix, the 4-digit manufacturer ID and
+// leading zeros are removed. Returns "" for an empty serial.
+func NormalizeCardSerial(serial string) string {
+	serial = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(serial), " ", ""))
+	switch {
+	case len(serial) == 32 && strings.HasPrefix(serial, openPGPAIDPrefix):
+		serial = serial[20:28]
+	case len(serial) > 8:
+		// card-no is the manufacturer ID followed by the serial
+		serial = serial[4:]
+	}
+
+	trimmed := strings.TrimLeft(serial, "0")
+	if trimmed == "" && serial != "" {
+		return "0"
+	}
+	return trimmed
+}
+
 // parseCardStatus parses the output of `gpg --card-status`.
 func parseCardStatus(output []byte) *CardInfo {
 	info := &CardInfo{
-		Keys:          make(map[string]string),
-		KeyAttributes: []string{},
+		Keys:             make(map[string]string),
+		KeyAttributes:    make(map[string]string),
+		PINRetries:       -1,
+		ResetCodeRetries: -1,
+		AdminPINRetries:  -1,
+		SignatureCounter: -1,
 	}
 
 	lines := strings.Split(string(output), "\n")
@@ -92,6 +153,28 @@ func parseCardStatus(output []byte) *CardInfo {
 			continue
 		}
 
+		// Reader ...........: Yubico YubiKey OTP FIDO CCID 00 00
+		// Handled before the key slot lines below since "Yubikey" may contain "key"
+		if strings.HasPrefix(line, "Reader") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				info.Reader = strings.TrimSpace(parts[1])
+				if info.Model == "" {
+					info.Model = modelFromReader(info.Reader)
+				}
+			}
+			continue
+		}
+
+		// Card firmware ....: 5.4.3 (reported by newer GnuPG versions)
+		if strings.HasPrefix(line, "Card firmware") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				info.FirmwareVersion = strings.TrimSpace(parts[1])
+			}
+			continue
+		}
+
 		// Serial number: 12345678
 		if strings.HasPrefix(line, "Serial number") {
 			parts := strings.Fields(line)
@@ -101,11 +184,65 @@ func parseCardStatus(output []byte) *CardInfo {
 		}
 
 		// Name of cardholder: Test User
+		// Only the surrounding whitespace is trimmed: the name may contain
+		// colons, apostrophes and multibyte UTF-8. Handled before the key
+		// slot lines below since a name like "Mickey" contains "key"
 		if strings.HasPrefix(line, "Name of cardholder") {
 			parts := strings.SplitN(line, ":", 2)
 			if len(parts) == 2 {
-				info.Cardholder = strings.TrimSpace(parts[1])
+				name := strings.TrimSpace(parts[1])
+				if name != "[not set]" {
+					info.Cardholder = name
+				}
+			}
+			continue
+		}
+
+		// URL of public key : https://keys.openpgp.org/vks/v1/by-fingerprint/...
+		// Handled before the key slot lines below since it also contains "key"
+		if strings.HasPrefix(line, "URL of public key") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				url := strings.TrimSpace(parts[1])
+				if url != "[not set]" {
+					info.URL = url
+				}
 			}
+			continue
+		}
+
+		// Login data .......: jdoe
+		if strings.HasPrefix(line, "Login data") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				login := strings.TrimSpace(parts[1])
+				if login != "[not set]" {
+					info.LoginData = login
+				}
+			}
+			continue
+		}
+
+		// PIN retry counter : 3 0 3 (user PIN, reset code, admin PIN)
+		if strings.HasPrefix(line, "PIN retry counter") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				counters := strings.Fields(parts[1])
+				if len(counters) == 3 {
+					info.PINRetries = parseCounter(counters[0])
+					info.ResetCodeRetries = parseCounter(counters[1])
+					info.AdminPINRetries = parseCounter(counters[2])
+				}
+			}
+		}
+
+		// Signature counter : 42
+		if strings.HasPrefix(line, "Signature counter") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				info.SignatureCounter = parseCounter(strings.TrimSpace(parts[1]))
+			}
+			continue
 		}
 
 		// Key attributes ...: rsa2048 rsa2048 rsa2048
@@ -113,8 +250,11 @@ func parseCardStatus(output []byte) *CardInfo {
 		if strings.HasPrefix(line, "Key attributes") {
 			parts := strings.SplitN(line, ":", 2)
 			if len(parts) == 2 {
-				attrs := strings.Fields(strings.TrimSpace(parts[1]))
-				info.KeyAttributes = attrs
+				for i, attr := range strings.Fields(strings.TrimSpace(parts[1])) {
+					if slotName, ok := CardSlots[i+1]; ok {
+						info.KeyAttributes[slotName] = attr
+					}
+				}
 			}
 		}
 
@@ -142,3 +282,234 @@ func parseCardStatus(output []byte) *CardInfo {
 
 	return info
 }
+
+// readerInterfaces are the USB interface names that follow the model in a
+// YubiKey reader name, alone or joined with "+", e.g. "OTP+U2F+CCID".
+var readerInterfaces = map[string]bool{"OTP": true, "FIDO": true, "U2F": true, "CCID": true}
+
+// isReaderInterface reports whether a reader name field lists USB interfaces.
+func isReaderInterface(field string) bool {
+	for _, part := range strings.Split(field, "+") {
+		if !readerInterfaces[part] {
+			return false
+		}
+	}
+	return true
+}
+
+// modelFromReader derives the device model from a PC/SC reader name, e.g.
+// "Yubico YubiKey OTP FIDO CCID 00 00" -> "YubiKey". Returns "" for readers
+// that don't look like a YubiKey, such as gpg's "1050:0407:X:0" form.
+func modelFromReader(reader string) string {
+	fields := strings.Fields(reader)
+	if len(fields) < 2 || fields[0] != "Yubico" {
+		return ""
+	}
+	var model []string
+	for _, field := range fields[1:] {
+		if isReaderInterface(field) {
+			break
+		}
+		model = append(model, field)
+	}
+	return strings.Join(model, " ")
+}
+
+// parseCounter parses a single counter value, such as a PIN retry counter.
+// Returns -1 if the value isn't a number.
+func parseCounter(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// parseKeygrips returns the keygrips in gpg --with-colons --with-keygrip
+// output, one per key and subkey.
+func parseKeygrips(output []byte) []string {
+	var keygrips []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if fields[0] == "grp" && len(fields) > 9 && fields[9] != "" {
+			keygrips = append(keygrips, fields[9])
+		}
+	}
+	return keygrips
+}
+
+// parseColonKeys parses gpg --with-colons key listing output into primary
+// keys, each with its subkeys in Subkeys.
+func parseColonKeys(output []byte) []Key {
+	var keys []Key
+	var primary *Key
+	var current *Key
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		switch fields[0] {
+		case "pub", "sec":
+			keys = append(keys, parseColonKeyRecord(fields))
+			primary = &keys[len(keys)-1]
+			current = primary
+		case "sub", "ssb":
+			if primary == nil {
+				continue
+			}
+			primary.Subkeys = append(primary.Subkeys, parseColonKeyRecord(fields))
+			current = &primary.Subkeys[len(primary.Subkeys)-1]
+		case "fpr":
+			// The fingerprint is in field 10
+			if current != nil && current.Fingerprint == "" && len(fields) > 9 {
+				current.Fingerprint = strings.ToUpper(fields[9])
+			}
+		case "uid":
+			// The first uid is the primary user ID; colons in it are escaped
+			if primary != nil && primary.UserID == "" && len(fields) > 9 {
+				primary.UserID = strings.ReplaceAll(fields[9], `\x3a`, ":")
+			}
+		}
+	}
+
+	return keys
+}
+
+// parseColonKeyList parses gpg --with-colons key listing output into a flat
+// list of primary keys each followed by its subkeys.
+func parseColonKeyList(output []byte) []Key {
+	var keys []Key
+	for _, primary := range parseColonKeys(output) {
+		subkeys := primary.Subkeys
+		primary.Subkeys = nil
+		keys = append(keys, primary)
+		keys = append(keys, subkeys...)
+	}
+	return keys
+}
+
+// parseColonKeyRecord parses a pub/sec/sub/ssb record, e.g.
+// "ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240100000006123456780000::ed25519:".
+func parseColonKeyRecord(fields []string) Key {
+	field := func(i int) string {
+		if i >= len(fields) {
+			return ""
+		}
+		return fields[i]
+	}
+
+	key := Key{
+		Type:    field(0),
+		KeyID:   strings.ToUpper(field(4)),
+		Created: formatColonDate(field(5)),
+		Expires: formatColonDate(field(6)),
+		// The validity field is "r" for a revoked key
+		Revoked: field(1) == "r",
+	}
+
+	// Lowercase letters are the key's own capabilities; the uppercase ones on
+	// a primary key summarize the whole key
+	var caps strings.Builder
+	for _, char := range field(11) {
+		if char >= 'a' && char <= 'z' {
+			caps.WriteRune(char - 'a' + 'A')
+		}
+	}
+	key.Capabilities = parseCapabilities(caps.String())
+
+	// ECC keys (algorithms 18, 19 and 22) report the curve name instead of a length
+	switch field(3) {
+	case "18", "19", "22":
+		key.Algorithm = field(16)
+	case "16":
+		key.Algorithm = "elg" + field(2)
+	case "17":
+		key.Algorithm = "dsa" + field(2)
+	default:
+		key.Algorithm = "rsa" + field(2)
+	}
+
+	// The token serial is "+" if the secret key is available, "#" for a stub
+	// without the secret part, or the card's application ID if the key is
+	// on a card. Public key listings leave it empty.
+	switch serial := field(14); serial {
+	case "":
+	case "+":
+		key.Secret = SecretLocal
+	case "#":
+		key.Secret = SecretStub
+	default:
+		key.Secret = SecretOnCard
+		key.CardNo = formatCardNo(serial)
+		key.CardSerial = NormalizeCardSerial(serial)
+	}
+
+	return key
+}
+
+// formatColonDate converts a --with-colons timestamp (seconds since the
+// epoch) to KeyDateFormat. Returns "" for an empty or unparseable value.
+func formatColonDate(value string) string {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds == 0 {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format(KeyDateFormat)
+}
+
+// parseImportResult parses the IMPORT_RES status line from gpg --status-fd
+// output: "[GNUPG:] IMPORT_RES <count> <no_user_id> <imported> <imported_rsa>
+// <unchanged> <n_uids> <n_subk> <n_sigs> <n_revoc> <sec_read> <sec_imported>
+// <sec_dups> ...". Missing fields are left at zero.
+func parseImportResult(output []byte) *ImportResult {
+	result := &ImportResult{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "[GNUPG:]" || fields[1] != "IMPORT_RES" {
+			continue
+		}
+		values := fields[2:]
+		field := func(i int) int {
+			if i >= len(values) {
+				return 0
+			}
+			n, err := strconv.Atoi(values[i])
+			if err != nil {
+				return 0
+			}
+			return n
+		}
+		result.Processed = field(0)
+		result.Imported = field(2)
+		result.Unchanged = field(4)
+		result.NewUserIDs = field(5)
+		result.NewSubkeys = field(6)
+		result.NewSignatures = field(7)
+		result.NewRevocations = field(8)
+		result.SecretRead = field(9)
+		result.SecretImported = field(10)
+		result.SecretUnchanged = field(11)
+	}
+	return result
+}
+
+// parseGPGVersion parses the major, minor and patch version from the
+// output of gpg --version. A missing patch version is treated as zero.
+func parseGPGVersion(output []byte) ([3]int, bool) {
+	var version [3]int
+	line, _, _ := strings.Cut(string(output), "\n")
+	match := gpgVersionPattern.FindStringSubmatch(line)
+	if match == nil {
+		return version, false
+	}
+	for i, field := range match[1:] {
+		if field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
@@ -2,10 +2,81 @@ package gpg
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2025, 9, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected string
+		errMsg   string
+	}{
+		{input: "5y", expected: "2030-09-05"},
+		{input: "18m", expected: "2027-03-05"},
+		{input: "6w", expected: "2025-10-17"},
+		{input: "90d", expected: "2025-12-04"},
+		{input: " 2Y ", expected: "2027-09-05"},
+		{input: "2035-01-01", expected: "2035-01-01"},
+		{input: "2020-01-01", errMsg: "in the past"},
+		{input: "2035-13-01", errMsg: "invalid expiration"},
+		{input: "0y", errMsg: "invalid expiration"},
+		{input: "-5y", errMsg: "invalid expiration"},
+		{input: "5", errMsg: "invalid expiration"},
+		{input: "99999999999999999999d", errMsg: "too far"},
+		{input: "100y", expected: "2125-09-05"},
+		{input: "101y", errMsg: "too far"},
+		{input: "36000y", errMsg: "too far"},
+		{input: "1201m", errMsg: "too far"},
+		{input: "5218w", errMsg: "too far"},
+		{input: "36524d", expected: "2125-09-05"},
+		{input: "36525d", errMsg: "too far"},
+		{input: "2126-01-01", errMsg: "too far"},
+		{input: "0", errMsg: "not supported"},
+		{input: "never", errMsg: "not supported"},
+		{input: "", errMsg: "invalid expiration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			date, err := parseExpiry(tt.input, now)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, date)
+		})
+	}
+}
+
+func TestParseGPGVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected [3]int
+		ok       bool
+	}{
+		{"gpg (GnuPG) 2.4.5\nlibgcrypt 1.10.3\n", [3]int{2, 4, 5}, true},
+		{"gpg (GnuPG/MacGPG2) 2.2.41\n", [3]int{2, 2, 41}, true},
+		{"gpg (GnuPG) 2.2.27-unknown\n", [3]int{2, 2, 27}, true},
+		{"gpg (GnuPG) 2.1\n", [3]int{2, 1, 0}, true},
+		{"gpg (GnuPG) 1.4.23\n", [3]int{1, 4, 23}, true},
+		{"Home: /home/user/.gnupg\n", [3]int{}, false},
+		{"", [3]int{}, false},
+	}
+
+	for _, tt := range tests {
+		version, ok := parseGPGVersion([]byte(tt.input))
+		assert.Equal(t, tt.ok, ok, tt.input)
+		assert.Equal(t, tt.expected, version, tt.input)
+	}
+}
+
 func TestParseCapabilities(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -42,52 +113,279 @@ func TestParseCapabilities(t *testing.T) {
 	}
 }
 
-func TestParseKeyLine(t *testing.T) {
+func TestParseCardStatus_PINRetryCounter(t *testing.T) {
 	tests := []struct {
 		name          string
 		input         string
-		expectedType  string
-		expectedKeyID string
-		hasExpires    bool
+		expectedUser  int
+		expectedReset int
+		expectedAdmin int
+	}{
+		{
+			name:          "default counters",
+			input:         "PIN retry counter : 3 0 3\n",
+			expectedUser:  3,
+			expectedReset: 0,
+			expectedAdmin: 3,
+		},
+		{
+			name:          "admin PIN nearly locked",
+			input:         "PIN retry counter : 2 3 1\n",
+			expectedUser:  2,
+			expectedReset: 3,
+			expectedAdmin: 1,
+		},
+		{
+			name:          "counter line missing",
+			input:         "Serial number ....: 12345678\n",
+			expectedUser:  -1,
+			expectedReset: -1,
+			expectedAdmin: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cardInfo := parseCardStatus([]byte(tt.input))
+			assert.Equal(t, tt.expectedUser, cardInfo.PINRetries)
+			assert.Equal(t, tt.expectedReset, cardInfo.ResetCodeRetries)
+			assert.Equal(t, tt.expectedAdmin, cardInfo.AdminPINRetries)
+		})
+	}
+}
+
+func TestParseKeyDate(t *testing.T) {
+	date, err := ParseKeyDate("2025-09-05")
+	assert.NoError(t, err)
+	assert.Equal(t, 2025, date.Year())
+	assert.Equal(t, time.September, date.Month())
+	assert.Equal(t, 5, date.Day())
+
+	_, err = ParseKeyDate("not a date")
+	assert.Error(t, err)
+}
+
+func TestParseCardStatus_URL(t *testing.T) {
+	input := `Serial number ....: 12345678
+URL of public key : https://keys.openpgp.org/vks/v1/by-fingerprint/FA57C85131F11B28EE236A4F07AAA1E535650AF5
+Signature key ....: DC47D1B090A51498
+`
+
+	info := parseCardStatus([]byte(input))
+
+	assert.Equal(t, "https://keys.openpgp.org/vks/v1/by-fingerprint/FA57C85131F11B28EE236A4F07AAA1E535650AF5", info.URL)
+	assert.Len(t, info.Keys, 1, "the URL line must not be parsed as a key slot")
+
+	info = parseCardStatus([]byte("URL of public key : [not set]\n"))
+	assert.Empty(t, info.URL)
+}
+
+func TestParseCardStatus_Cardholder(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{name: "accented", line: "Name of cardholder: José Müller", expected: "José Müller"},
+		{name: "apostrophe", line: "Name of cardholder: Siobhán O'Brien", expected: "Siobhán O'Brien"},
+		{name: "non-Latin", line: "Name of cardholder: 山田 太郎", expected: "山田 太郎"},
+		{name: "colon in name", line: "Name of cardholder: Team: Release Signing", expected: "Team: Release Signing"},
+		{name: "leading tab and spaces", line: "\t  Name of cardholder:   José  Luis \t", expected: "José  Luis"},
+		{name: "contains key", line: "Name of cardholder: Mickey Mouse", expected: "Mickey Mouse"},
+		{name: "not set", line: "Name of cardholder: [not set]", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseCardStatus([]byte("Serial number ....: 12345678\n" + tt.line + "\n"))
+			assert.Equal(t, tt.expected, info.Cardholder)
+			assert.Empty(t, info.Keys, "the cardholder line must not be read as a key slot")
+		})
+	}
+}
+
+func TestParseCardStatus_LoginData(t *testing.T) {
+	input := `Serial number ....: 12345678
+Login data .......: jdoe
+Signature key ....: DC47D1B090A51498
+`
+
+	info := parseCardStatus([]byte(input))
+	assert.Equal(t, "jdoe", info.LoginData)
+
+	info = parseCardStatus([]byte("Login data .......: [not set]\n"))
+	assert.Empty(t, info.LoginData)
+}
+
+func TestParseCardStatus_KeyAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected map[string]string
 	}{
 		{
-			name:          "primary key with expiration",
-			input:         "sec   rsa4096/ABC123DEF4567890 2023-01-01 [SC] [expires: 2028-01-01]",
-			expectedType:  "sec",
-			expectedKeyID: "ABC123DEF4567890",
-			hasExpires:    true,
+			name: "RSA only",
+			line: "Key attributes ...: rsa2048 rsa2048 rsa2048",
+			expected: map[string]string{
+				"Signature": "rsa2048", "Encryption": "rsa2048", "Authentication": "rsa2048",
+			},
 		},
 		{
-			name:          "subkey without expiration",
-			input:         "ssb   ed25519/ABC123DEF456 2023-01-01 [S]",
-			expectedType:  "ssb",
-			expectedKeyID: "ABC123DEF456",
-			hasExpires:    false,
+			name: "ECC only",
+			line: "Key attributes ...: ed25519 cv25519 ed25519",
+			expected: map[string]string{
+				"Signature": "ed25519", "Encryption": "cv25519", "Authentication": "ed25519",
+			},
 		},
 		{
-			name:          "primary key on card (sec#)",
-			input:         "sec#  ed25519/07AAA1E535650AF5 2025-09-05 [SC] [expires: 2030-09-04]",
-			expectedType:  "sec",
-			expectedKeyID: "07AAA1E535650AF5",
-			hasExpires:    true,
+			name: "mixed",
+			line: "Key attributes ...: ed25519 rsa4096 nistp256",
+			expected: map[string]string{
+				"Signature": "ed25519", "Encryption": "rsa4096", "Authentication": "nistp256",
+			},
 		},
 		{
-			name:          "subkey on card (ssb>)",
-			input:         "ssb>  ed25519/DC47D1B090A51498 2025-09-05 [S] [expires: 2030-09-04]",
-			expectedType:  "ssb",
-			expectedKeyID: "DC47D1B090A51498",
-			hasExpires:    true,
+			name:     "missing",
+			line:     "",
+			expected: map[string]string{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			key := parseKeyLine(tt.input)
-			assert.Equal(t, tt.expectedType, key.Type)
-			assert.Equal(t, tt.expectedKeyID, key.KeyID)
-			if tt.hasExpires {
-				assert.NotEmpty(t, key.Expires)
-			}
+			input := "Serial number ....: 12345678\n" + tt.line + "\nSignature key ....: DC47D1B090A51498\n"
+			info := parseCardStatus([]byte(input))
+			assert.Equal(t, tt.expected, info.KeyAttributes)
+			assert.Equal(t, "DC47D1B090A51498", info.Keys["Signature"])
 		})
 	}
 }
+
+func TestCardInfo_KeyAttributeList(t *testing.T) {
+	info := parseCardStatus([]byte("Key attributes ...: ed25519 cv25519 rsa4096\n"))
+	assert.Equal(t, []string{"ed25519", "cv25519", "rsa4096"}, info.KeyAttributeList())
+
+	assert.Empty(t, (&CardInfo{}).KeyAttributeList())
+}
+
+func TestParseCardStatus_ReaderAndFirmware(t *testing.T) {
+	input := `Reader ...........: Yubico Yubikey NEO OTP+U2F+CCID 00 00
+Application ID ...: D2760001240102000006123456780000
+Version ..........: 2.0
+Card firmware ....: 3.4.9
+Serial number ....: 12345678
+Signature key ....: [none]
+`
+
+	info := parseCardStatus([]byte(input))
+
+	assert.Equal(t, "Yubico Yubikey NEO OTP+U2F+CCID 00 00", info.Reader)
+	assert.Equal(t, "Yubikey NEO", info.Model)
+	assert.Equal(t, "3.4.9", info.FirmwareVersion, "the Version line is the OpenPGP spec version, not firmware")
+	assert.Empty(t, info.Keys, "the reader line must not be parsed as a key slot")
+}
+
+func TestModelFromReader(t *testing.T) {
+	tests := []struct {
+		reader string
+		want   string
+	}{
+		{"Yubico YubiKey OTP FIDO CCID 00 00", "YubiKey"},
+		{"Yubico YubiKey FIDO+CCID 01 00", "YubiKey"},
+		{"Yubico Yubikey NEO OTP+U2F+CCID 00 00", "Yubikey NEO"},
+		{"1050:0407:X:0", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reader, func(t *testing.T) {
+			assert.Equal(t, tt.want, modelFromReader(tt.reader))
+		})
+	}
+}
+
+func TestParseCardStatus_SignatureCounter(t *testing.T) {
+	info := parseCardStatus([]byte("Signature counter : 42\nSignature key ....: DC47D1B090A51498\n"))
+	assert.Equal(t, 42, info.SignatureCounter)
+	assert.Len(t, info.Keys, 1)
+
+	info = parseCardStatus([]byte("Serial number ....: 12345678\n"))
+	assert.Equal(t, -1, info.SignatureCounter)
+}
+
+func TestNormalizeCardSerial(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "key listing with space", input: "0006 12345678", expected: "12345678"},
+		{name: "key listing without space", input: "000612345678", expected: "12345678"},
+		{name: "zero-padded serial", input: "0006000012345678", expected: "12345678"},
+		{name: "grouped serial", input: "0006 1234 5678", expected: "12345678"},
+		{name: "full application ID", input: "D2760001240103040006123456780000", expected: "12345678"},
+		{name: "lowercase application ID", input: "d2760001240103040006123456780000", expected: "12345678"},
+		{name: "card status serial", input: "12345678", expected: "12345678"},
+		{name: "card status serial with leading zeros", input: "01234567", expected: "1234567"},
+		{name: "empty", input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeCardSerial(tt.input))
+		})
+	}
+}
+
+func TestParseColonKeyList_Revoked(t *testing.T) {
+	output := `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+ssb:r:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+ssb:u:255:22:0257F6B8152D7F35:1759276800:1916956800:::::s:::+::ed25519::
+`
+	keys := parseColonKeyList([]byte(output))
+
+	require.Len(t, keys, 3)
+	assert.False(t, keys[0].Revoked)
+	assert.True(t, keys[1].Revoked)
+	assert.False(t, keys[2].Revoked)
+}
+
+func TestParseColonKeyList(t *testing.T) {
+	output := `sec:u:255:22:07AAA1E535650AF5:1757030400:1914710400::u:::scESCA:::#::ed25519:::0:
+fpr:::::::::FA57C85131F11B28EE236A4F07AAA1E535650AF5:
+grp:::::::::0123456789ABCDEF0123456789ABCDEF01234567:
+uid:u::::1757030400::0123456789ABCDEF::Test User <test@example.com>::::::::::0:
+ssb:u:255:18:116DB85718F8B287:1757030400:1914710400:::::e:::D2760001240103040006123456780000::cv25519::
+fpr:::::::::222222222222222222222222116DB85718F8B287:
+ssb:u:4096:1:0257F6B8152D7F35:1759276800::::::sa:::+:::23::0:
+`
+	keys := parseColonKeyList([]byte(output))
+
+	assert.Len(t, keys, 3)
+
+	assert.Equal(t, Key{
+		Type:         "sec",
+		KeyID:        "07AAA1E535650AF5",
+		Algorithm:    "ed25519",
+		Fingerprint:  "FA57C85131F11B28EE236A4F07AAA1E535650AF5",
+		Capabilities: []string{"S", "C"},
+		Created:      "2025-09-05",
+		Expires:      "2030-09-04",
+		Secret:       SecretStub,
+		UserID:       "Test User <test@example.com>",
+	}, keys[0])
+
+	assert.Equal(t, "ssb", keys[1].Type)
+	assert.Equal(t, "cv25519", keys[1].Algorithm)
+	assert.Equal(t, []string{"E"}, keys[1].Capabilities)
+	assert.Equal(t, "0006 12345678", keys[1].CardNo)
+	assert.Equal(t, "12345678", keys[1].CardSerial)
+	assert.Equal(t, "222222222222222222222222116DB85718F8B287", keys[1].Fingerprint)
+	assert.Equal(t, SecretOnCard, keys[1].Secret)
+
+	assert.Equal(t, "rsa4096", keys[2].Algorithm)
+	assert.Equal(t, []string{"S", "A"}, keys[2].Capabilities)
+	assert.Equal(t, "", keys[2].Expires)
+	assert.Equal(t, "", keys[2].CardNo, "a secret key on disk is not on a card")
+	assert.Equal(t, SecretLocal, keys[2].Secret)
+}
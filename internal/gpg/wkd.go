@@ -0,0 +1,35 @@
+package gpg
+
+import (
+	"crypto/sha1"
+	"strings"
+)
+
+// zbase32Alphabet is the z-base-32 alphabet used for WKD file names.
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// WKDHash returns the Web Key Directory file name for the local part of an
+// email address: the z-base-32 encoded SHA-1 of the lower-cased local part.
+func WKDHash(localPart string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(localPart)))
+	return zbase32Encode(sum[:])
+}
+
+// zbase32Encode encodes data with the z-base-32 alphabet, 5 bits per
+// character, without padding.
+func zbase32Encode(data []byte) string {
+	var b strings.Builder
+	var buffer, bits uint
+	for _, c := range data {
+		buffer = buffer<<8 | uint(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			b.WriteByte(zbase32Alphabet[(buffer>>bits)&0x1F])
+		}
+	}
+	if bits > 0 {
+		b.WriteByte(zbase32Alphabet[(buffer<<(5-bits))&0x1F])
+	}
+	return b.String()
+}
@@ -0,0 +1,20 @@
+package gpg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWKDHash(t *testing.T) {
+	// Example from the OpenPGP Web Key Directory draft
+	assert.Equal(t, "iy9q119eutrkn8s1mk4r39qejnbu3n5q", WKDHash("Joe.Doe"))
+	assert.Equal(t, WKDHash("joe.doe"), WKDHash("JOE.DOE"))
+	assert.Len(t, WKDHash("jane"), 32)
+}
+
+func TestZbase32Encode(t *testing.T) {
+	assert.Equal(t, "", zbase32Encode(nil))
+	assert.Equal(t, "yy", zbase32Encode([]byte{0x00}))
+	assert.Equal(t, "9h", zbase32Encode([]byte{0xFF}))
+}
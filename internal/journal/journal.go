@@ -0,0 +1,85 @@
+// Package journal keeps a record of the operations ykgpg performed on keys
+// and YubiKeys: one JSON object per line, appended to a file that is never
+// rewritten.
+//
+// Entries hold only identifiers and outcomes. PINs, passphrases and key
+// material have no place in an Entry and must never be put in Error.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one operation in the journal.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// Serial is the YubiKey the operation was performed on, if any.
+	Serial string `json:"serial,omitempty"`
+	// KeyIDs are the keys and subkeys the operation affected.
+	KeyIDs  []string `json:"key_ids,omitempty"`
+	Success bool     `json:"success"`
+	// Error is why the operation failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Append adds entry as a line at the end of the journal at path, creating
+// the file and its directory if needed.
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns the entries of the journal at path, oldest first. A journal
+// that doesn't exist yet has no entries. Blank lines are skipped; any other
+// line that isn't an entry is an error.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid journal entry: %w", path, lineNo, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return entries, nil
+}
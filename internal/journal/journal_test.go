@@ -0,0 +1,57 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ykgpg", "journal.jsonl")
+
+	entries, err := Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing journal is empty")
+
+	first := Entry{
+		Time:    time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+		Command: "setup-batch",
+		Serial:  "12345678",
+		KeyIDs:  []string{"ABC123DEF4567890", "DC47D1B090A51498"},
+		Success: true,
+	}
+	second := Entry{
+		Time:    time.Date(2026, 10, 16, 9, 45, 0, 0, time.UTC),
+		Command: "revoke",
+		KeyIDs:  []string{"DC47D1B090A51498"},
+		Error:   "failed to edit key: exit status 2",
+	}
+	require.NoError(t, Append(path, first))
+	require.NoError(t, Append(path, second))
+
+	entries, err = Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{first, second}, entries)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `{"time":"2026-10-16T09:30:00Z","command":"setup-batch","serial":"12345678",`)
+	assert.NotContains(t, string(data), `"serial":""`, "empty fields are left out")
+}
+
+func TestRead_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"command\":\"extend\",\"success\":true}\n\nnot json\n"), 0600))
+
+	_, err := Read(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "journal.jsonl:3: invalid journal entry")
+}
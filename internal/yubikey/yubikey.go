@@ -2,8 +2,13 @@ package yubikey
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bobbydams/yubikey-manager/internal/executor"
 	"github.com/bobbydams/yubikey-manager/internal/gpg"
@@ -25,19 +30,140 @@ type YubiKeyService interface {
 	// (false, nil) if OpenPGP is not supported (e.g., older YubiKey models),
 	// (false, error) if unable to determine.
 	SupportsOpenPGP(ctx context.Context) (bool, error)
+
+	// ChangeUserPIN changes the OpenPGP User PIN with a scripted gpg
+	// --card-edit session.
+	ChangeUserPIN(ctx context.Context, oldPIN, newPIN string) error
+
+	// ChangeAdminPIN changes the OpenPGP Admin PIN with a scripted gpg
+	// --card-edit session.
+	ChangeAdminPIN(ctx context.Context, oldPIN, newPIN string) error
+
+	// UnblockPIN sets a new User PIN with the Reset Code with a scripted
+	// gpg --card-edit session, which also resets the User PIN retry counter.
+	UnblockPIN(ctx context.Context, resetCode, newUserPIN string) error
+
+	// IsDefaultAdminPIN reports whether the Admin PIN is still
+	// DefaultAdminPIN. If it isn't, the probe uses up one Admin PIN retry.
+	IsDefaultAdminPIN(ctx context.Context) (bool, error)
+
+	// SetCardholderName sets the cardholder name stored on the card.
+	SetCardholderName(ctx context.Context, given, surname, adminPIN string) error
+
+	// SetCardURL sets the URL of the public key stored on the card.
+	SetCardURL(ctx context.Context, url, adminPIN string) error
+
+	// SetLoginData sets the login data (e.g. a user name) stored on the card.
+	SetLoginData(ctx context.Context, value, adminPIN string) error
+
+	// SetKeyAttributes sets the key type of a card slot ("Signature",
+	// "Encryption" or "Authentication"), e.g. to "ed25519" or "rsa4096".
+	SetKeyAttributes(ctx context.Context, slot, algo, adminPIN string) error
+
+	// ResetOpenPGP resets the OpenPGP applet using ykman, deleting all keys
+	// on the card and restoring the default PINs.
+	ResetOpenPGP(ctx context.Context) error
+
+	// GetTouchPolicy returns the touch policy of each key slot ("sig", "enc",
+	// "aut"), as reported by ykman.
+	GetTouchPolicy(ctx context.Context) (map[string]string, error)
+
+	// SetTouchPolicy sets the touch policy of a key slot using ykman.
+	// ykman prompts for the Admin PIN itself.
+	SetTouchPolicy(ctx context.Context, slot, policy string) error
+
+	// ListCards returns the serial number of every connected YubiKey,
+	// as reported by 'ykman list --serials'.
+	ListCards(ctx context.Context) ([]string, error)
+
+	// SelectCard makes the YubiKey with the given serial the one used by
+	// later gpg and ykman commands.
+	SelectCard(ctx context.Context, serial string) error
+}
+
+// TouchSlots are the key slots accepted by SetTouchPolicy, in display order.
+var TouchSlots = []string{"sig", "enc", "aut"}
+
+// TouchPolicies are the touch policies accepted by SetTouchPolicy.
+// "fixed" and "cached-fixed" can only be undone by resetting the OpenPGP applet.
+var TouchPolicies = []string{"off", "on", "fixed", "cached", "cached-fixed"}
+
+// touchSlotLabels maps the slot labels in 'ykman openpgp info' to slot names.
+var touchSlotLabels = map[string]string{
+	"Signature key":      "sig",
+	"Encryption key":     "enc",
+	"Authentication key": "aut",
+}
+
+// KeyserverURL returns the keys.openpgp.org URL serving the public key with the given fingerprint.
+func KeyserverURL(fingerprint string) string {
+	return "https://keys.openpgp.org/vks/v1/by-fingerprint/" + strings.ToUpper(strings.ReplaceAll(fingerprint, " ", ""))
+}
+
+// Minimum PIN lengths enforced by the OpenPGP applet.
+const (
+	MinUserPINLength  = 6
+	MinAdminPINLength = 8
+)
+
+// Factory default OpenPGP PINs, restored by ResetOpenPGP.
+const (
+	DefaultUserPIN  = "123456"
+	DefaultAdminPIN = "12345678"
+)
+
+// DefaultPINRetries is the factory retry counter of each PIN, which a
+// correct entry restores.
+const DefaultPINRetries = 3
+
+// DefaultYkmanBinary is the ykman program run when no other is configured.
+const DefaultYkmanBinary = "ykman"
+
+const (
+	// DefaultCardRetries is how many times a transient card status error is
+	// retried, e.g. while the YubiKey settles after being inserted.
+	DefaultCardRetries = 3
+	// DefaultCardRetryDelay is the backoff before the first retry; each
+	// further retry waits one delay longer.
+	DefaultCardRetryDelay = 250 * time.Millisecond
+)
+
+// transientCardErrors are scdaemon errors that usually clear up on their own
+// shortly after the card is inserted or released by another process.
+var transientCardErrors = []string{
+	"card error",
+	"No such device",
+	"Card reset",
+	"Conflicting use",
 }
 
 // Service implements YubiKeyService.
 type Service struct {
 	gpgService gpg.GPGService
 	exec       executor.Executor
+	// GPGBinary and YkmanBinary are the programs to run for card edits and
+	// ykman commands, e.g. an absolute path when they aren't on $PATH.
+	GPGBinary   string
+	YkmanBinary string
+	// CardRetries is how many times card status is retried after a transient
+	// error, waiting CardRetryDelay times the attempt number in between.
+	CardRetries    int
+	CardRetryDelay time.Duration
+	// Device is the serial of the YubiKey ykman commands are run against,
+	// set by SelectCard. Empty means ykman picks the only connected one.
+	Device string
 }
 
-// NewService creates a new YubiKey service.
+// NewService creates a new YubiKey service that runs gpg.DefaultBinary and
+// DefaultYkmanBinary.
 func NewService(gpgService gpg.GPGService, exec executor.Executor) *Service {
 	return &Service{
-		gpgService: gpgService,
-		exec:       exec,
+		gpgService:     gpgService,
+		exec:           exec,
+		GPGBinary:      gpg.DefaultBinary,
+		YkmanBinary:    DefaultYkmanBinary,
+		CardRetries:    DefaultCardRetries,
+		CardRetryDelay: DefaultCardRetryDelay,
 	}
 }
 
@@ -46,7 +172,7 @@ func NewService(gpgService gpg.GPGService, exec executor.Executor) *Service {
 // (false, nil) if no YubiKey is present,
 // (false, error) if YubiKey is present but not initialized for OpenPGP or doesn't support it.
 func (s *Service) IsPresent(ctx context.Context) (bool, error) {
-	_, err := s.gpgService.CardStatus(ctx)
+	_, err := s.cardStatus(ctx)
 	if err != nil {
 		// Check if the error indicates the card is present but not initialized
 		errStr := err.Error()
@@ -69,18 +195,132 @@ func (s *Service) IsPresent(ctx context.Context) (bool, error) {
 }
 
 // GetCardInfo returns information about the connected YubiKey.
+// When gpg doesn't report the firmware version, it is filled in from
+// 'ykman info' if ykman is installed.
 func (s *Service) GetCardInfo(ctx context.Context) (*gpg.CardInfo, error) {
-	info, err := s.gpgService.CardStatus(ctx)
+	info, err := s.cardStatus(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get card info: %w", err)
 	}
+	if info.FirmwareVersion == "" {
+		s.addDeviceInfo(ctx, info)
+	}
 	return info, nil
 }
 
+// cardStatus reads the card status, retrying up to CardRetries times with a
+// linear backoff while the error looks transient.
+func (s *Service) cardStatus(ctx context.Context) (*gpg.CardInfo, error) {
+	for attempt := 1; ; attempt++ {
+		info, err := s.gpgService.CardStatus(ctx)
+		if err == nil || attempt > s.CardRetries || !isTransientCardError(err) {
+			return info, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(time.Duration(attempt) * s.CardRetryDelay):
+		}
+	}
+}
+
+// isTransientCardError reports whether a card status error is worth retrying.
+// "Operation not supported by device" means the OpenPGP applet is missing or
+// uninitialized, which a retry won't fix.
+func isTransientCardError(err error) bool {
+	errStr := err.Error()
+	if strings.Contains(errStr, "Operation not supported by device") {
+		return false
+	}
+	for _, pattern := range transientCardErrors {
+		if strings.Contains(errStr, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// addDeviceInfo fills in the firmware version and model from 'ykman info'.
+// Errors are ignored: ykman is optional and the card info is still usable.
+func (s *Service) addDeviceInfo(ctx context.Context, info *gpg.CardInfo) {
+	output, err := s.exec.Run(ctx, s.YkmanBinary, s.ykmanArgs("info")...)
+	if err != nil {
+		return
+	}
+	device := parseYkmanInfo(string(output))
+	// Don't attribute another connected YubiKey's details to this card
+	if serial := device["Serial number"]; serial != "" && info.Serial != "" && serial != info.Serial {
+		return
+	}
+	if version := device["Firmware version"]; version != "" {
+		info.FirmwareVersion = version
+	}
+	if model := device["Device type"]; model != "" {
+		info.Model = model
+	}
+}
+
+// parseYkmanInfo parses the "Label: value" lines of 'ykman info' output.
+func parseYkmanInfo(output string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		label := strings.TrimSpace(parts[0])
+		if _, seen := fields[label]; !seen {
+			fields[label] = strings.TrimSpace(parts[1])
+		}
+	}
+	return fields
+}
+
+// MinEd25519Firmware is the first YubiKey firmware with ed25519/cv25519 and
+// touch policy support in the OpenPGP applet.
+const MinEd25519Firmware = "5.2.3"
+
+// FirmwareAtLeast reports whether a firmware version such as "5.4.3" is at
+// least minimum. Returns false if either version can't be parsed.
+func FirmwareAtLeast(version, minimum string) bool {
+	v, ok := parseFirmwareVersion(version)
+	if !ok {
+		return false
+	}
+	m, ok := parseFirmwareVersion(minimum)
+	if !ok {
+		return false
+	}
+	for i := range v {
+		if v[i] != m[i] {
+			return v[i] > m[i]
+		}
+	}
+	return true
+}
+
+// parseFirmwareVersion parses a "major.minor.patch" firmware version.
+// Missing components are treated as zero.
+func parseFirmwareVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimSpace(version), ".")
+	if len(fields) == 0 || len(fields) > 3 || fields[0] == "" {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
 // EditCard starts an interactive GPG card edit session.
 func (s *Service) EditCard(ctx context.Context) error {
 	args := []string{"--card-edit"}
-	return s.exec.RunInteractive(ctx, "gpg", args...)
+	return s.exec.RunInteractive(ctx, s.GPGBinary, args...)
 }
 
 // SupportsOpenPGP checks if the connected YubiKey supports OpenPGP functionality.
@@ -88,7 +328,7 @@ func (s *Service) EditCard(ctx context.Context) error {
 func (s *Service) SupportsOpenPGP(ctx context.Context) (bool, error) {
 	// First, try to check if ykman is available and can detect OpenPGP
 	// This is the most reliable method
-	ykmanOutput, err := s.exec.Run(ctx, "ykman", "info")
+	ykmanOutput, err := s.exec.Run(ctx, s.YkmanBinary, s.ykmanArgs("info")...)
 	if err == nil {
 		// ykman is available, check if OpenPGP is enabled/available
 		outputStr := string(ykmanOutput)
@@ -123,7 +363,7 @@ func (s *Service) SupportsOpenPGP(ctx context.Context) (bool, error) {
 
 	// Check the error to see if it's a "not supported" vs "not initialized" case
 	errStr := err.Error()
-	
+
 	// If the error specifically mentions the device doesn't support the operation,
 	// and we can't detect it via ykman either, it likely doesn't support OpenPGP
 	if strings.Contains(errStr, "Operation not supported by device") {
@@ -135,3 +375,420 @@ func (s *Service) SupportsOpenPGP(ctx context.Context) (bool, error) {
 	// Other errors might indicate the device isn't present or other issues
 	return false, fmt.Errorf("unable to check OpenPGP support: %w", err)
 }
+
+// ChangeUserPIN changes the OpenPGP User PIN by scripting the passwd menu
+// of a gpg --card-edit session. The PINs are written to gpg's stdin through
+// loopback pinentry, so they never appear in the process arguments.
+func (s *Service) ChangeUserPIN(ctx context.Context, oldPIN, newPIN string) error {
+	if len(newPIN) < MinUserPINLength {
+		return fmt.Errorf("new User PIN must be at least %d characters", MinUserPINLength)
+	}
+
+	script := strings.Join([]string{"admin", "passwd", "1", oldPIN, newPIN, "q", "quit"}, "\n") + "\n"
+	if err := s.runCardEditScript(ctx, script, "User PIN"); err != nil {
+		return fmt.Errorf("failed to change User PIN: %w", err)
+	}
+	return nil
+}
+
+// ChangeAdminPIN changes the OpenPGP Admin PIN the same way as
+// ChangeUserPIN, through option 3 of the passwd menu.
+func (s *Service) ChangeAdminPIN(ctx context.Context, oldPIN, newPIN string) error {
+	if len(newPIN) < MinAdminPINLength {
+		return fmt.Errorf("new Admin PIN must be at least %d characters", MinAdminPINLength)
+	}
+
+	script := strings.Join([]string{"admin", "passwd", "3", oldPIN, newPIN, "q", "quit"}, "\n") + "\n"
+	if err := s.runCardEditScript(ctx, script, "Admin PIN"); err != nil {
+		return fmt.Errorf("failed to change Admin PIN: %w", err)
+	}
+	return nil
+}
+
+// UnblockPIN sets a new User PIN with the Reset Code through the unblock
+// command of a gpg --card-edit session, which also resets the User PIN
+// retry counter. Like ChangeUserPIN, it passes the Reset Code and PIN on
+// gpg's stdin.
+func (s *Service) UnblockPIN(ctx context.Context, resetCode, newUserPIN string) error {
+	if len(newUserPIN) < MinUserPINLength {
+		return fmt.Errorf("new User PIN must be at least %d characters", MinUserPINLength)
+	}
+
+	script := strings.Join([]string{"unblock", resetCode, newUserPIN, "quit"}, "\n") + "\n"
+	if err := s.runCardEditScript(ctx, script, "Reset Code"); err != nil {
+		return fmt.Errorf("failed to unblock User PIN: %w", err)
+	}
+	return nil
+}
+
+// IsDefaultAdminPIN reports whether the Admin PIN is still the factory
+// default, by using ykman to change it from DefaultAdminPIN to itself. That
+// leaves the card as it was if the default is set; if it isn't, the card
+// rejects the PIN and one Admin PIN retry is used up. Only the public
+// default is ever passed to ykman.
+func (s *Service) IsDefaultAdminPIN(ctx context.Context) (bool, error) {
+	args := []string{"openpgp", "access", "change-admin-pin", "--admin-pin", DefaultAdminPIN, "--new-admin-pin", DefaultAdminPIN}
+	_, err := s.exec.Run(ctx, s.YkmanBinary, s.ykmanArgs(args...)...)
+	switch {
+	case err == nil:
+		return true, nil
+	case isWrongPINError(err):
+		return false, nil
+	default:
+		return false, ykmanError("failed to check the Admin PIN", err)
+	}
+}
+
+// wrongPINPattern matches ykman's message for a PIN the card rejected, e.g.
+// "Error: Wrong Admin PIN, 2 attempts remaining."
+var wrongPINPattern = regexp.MustCompile(`(?i)\bwrong (admin )?pin, \d+ attempts? remaining`)
+
+// isWrongPINError reports whether a ykman error means the card rejected
+// the PIN, rather than failing for another reason.
+func isWrongPINError(err error) bool {
+	return wrongPINPattern.MatchString(err.Error())
+}
+
+// SetCardholderName sets the cardholder name by scripting a gpg --card-edit
+// session, then re-reads the card status to confirm the name was stored.
+// ykman has no command for the cardholder name, so gpg is used directly.
+func (s *Service) SetCardholderName(ctx context.Context, given, surname, adminPIN string) error {
+	surname = strings.TrimSpace(surname)
+	given = strings.TrimSpace(given)
+	if surname == "" {
+		return fmt.Errorf("cardholder surname is required")
+	}
+
+	script := strings.Join([]string{"admin", "name", surname, given, adminPIN, "quit"}, "\n") + "\n"
+	if err := s.runCardEdit(ctx, script); err != nil {
+		return fmt.Errorf("failed to set cardholder name: %w", err)
+	}
+
+	// Read the card directly; the device details from GetCardInfo aren't needed
+	info, err := s.gpgService.CardStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+	// gpg displays the name as "Given Surname"
+	expected := strings.TrimSpace(given + " " + surname)
+	if info.Cardholder != expected {
+		return fmt.Errorf("cardholder name was not updated (card reports %q): admin PIN likely incorrect", info.Cardholder)
+	}
+	return nil
+}
+
+// SetCardURL sets the card's public key URL by scripting a gpg --card-edit
+// session, then re-reads the card status to confirm the URL was stored.
+func (s *Service) SetCardURL(ctx context.Context, url, adminPIN string) error {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return fmt.Errorf("URL is required")
+	}
+
+	script := strings.Join([]string{"admin", "url", url, adminPIN, "quit"}, "\n") + "\n"
+	if err := s.runCardEdit(ctx, script); err != nil {
+		return fmt.Errorf("failed to set card URL: %w", err)
+	}
+
+	// Read the card directly; the device details from GetCardInfo aren't needed
+	info, err := s.gpgService.CardStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+	if info.URL != url {
+		return fmt.Errorf("card URL was not updated (card reports %q): admin PIN likely incorrect", info.URL)
+	}
+	return nil
+}
+
+// SetLoginData sets the card's login data by scripting a gpg --card-edit
+// session, then re-reads the card status to confirm it was stored.
+func (s *Service) SetLoginData(ctx context.Context, value, adminPIN string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("login data is required")
+	}
+
+	script := strings.Join([]string{"admin", "login", value, adminPIN, "quit"}, "\n") + "\n"
+	if err := s.runCardEdit(ctx, script); err != nil {
+		return fmt.Errorf("failed to set login data: %w", err)
+	}
+
+	// Read the card directly; the device details from GetCardInfo aren't needed
+	info, err := s.gpgService.CardStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+	if info.LoginData != value {
+		return fmt.Errorf("login data was not updated (card reports %q): admin PIN likely incorrect", info.LoginData)
+	}
+	return nil
+}
+
+// SetKeyAttributes changes the key type of a card slot by scripting gpg
+// --card-edit's key-attr command, then re-reads the card status to confirm.
+// key-attr walks through every slot, so the other slots are given their
+// current attributes. Supported types are ed25519 (Signature and
+// Authentication), cv25519 (Encryption) and rsa2048/rsa3072/rsa4096.
+func (s *Service) SetKeyAttributes(ctx context.Context, slot, algo, adminPIN string) error {
+	algo = strings.ToLower(strings.TrimSpace(algo))
+
+	info, err := s.GetCardInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if (algo == "ed25519" || algo == "cv25519") && info.FirmwareVersion != "" && !FirmwareAtLeast(info.FirmwareVersion, MinEd25519Firmware) {
+		return fmt.Errorf("firmware %s does not support %s keys; %s or later is required", info.FirmwareVersion, algo, MinEd25519Firmware)
+	}
+
+	script := []string{"admin", "key-attr"}
+	found, pinSent := false, false
+	for n := 1; n <= len(gpg.CardSlots); n++ {
+		name := gpg.CardSlots[n]
+		current, ok := info.KeyAttributes[name]
+		if !ok {
+			return fmt.Errorf("the card does not report the %s slot's key attributes", name)
+		}
+		target := current
+		if name == slot {
+			target, found = algo, true
+		}
+
+		answers, err := keyAttrAnswers(name, target)
+		if err != nil {
+			return err
+		}
+		script = append(script, answers...)
+		// gpg keeps an RSA slot whose size is unchanged without touching the
+		// card; any other answer is written and asks for the Admin PIN once
+		if !pinSent && !(strings.HasPrefix(target, "rsa") && target == current) {
+			script = append(script, adminPIN)
+			pinSent = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("invalid slot %q (expected Signature, Encryption or Authentication)", slot)
+	}
+	script = append(script, "quit")
+
+	if err := s.runCardEdit(ctx, strings.Join(script, "\n")+"\n"); err != nil {
+		return fmt.Errorf("failed to set key attributes: %w", err)
+	}
+
+	// Read the card directly; the device details from GetCardInfo aren't needed
+	info, err = s.gpgService.CardStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+	if got := info.KeyAttributes[slot]; got != algo {
+		return fmt.Errorf("%s slot key attributes were not updated (card reports %q): admin PIN likely incorrect", slot, got)
+	}
+	return nil
+}
+
+// keyAttrAnswers returns the answers to key-attr's prompts that select
+// algo for a slot: the key kind (1 RSA, 2 ECC) and then the RSA key size
+// or the curve (1 is Curve 25519, ed25519 or cv25519 depending on the slot).
+func keyAttrAnswers(slot, algo string) ([]string, error) {
+	switch algo {
+	case "rsa2048", "rsa3072", "rsa4096":
+		return []string{"1", strings.TrimPrefix(algo, "rsa")}, nil
+	case "ed25519":
+		if slot != "Encryption" {
+			return []string{"2", "1"}, nil
+		}
+	case "cv25519":
+		if slot == "Encryption" {
+			return []string{"2", "1"}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported key attribute %q for the %s slot", algo, slot)
+}
+
+// ResetOpenPGP resets the OpenPGP applet using ykman, deleting all keys
+// on the card and restoring the default PINs.
+func (s *Service) ResetOpenPGP(ctx context.Context) error {
+	if _, err := s.exec.Run(ctx, s.YkmanBinary, s.ykmanArgs("openpgp", "reset", "--force")...); err != nil {
+		return ykmanError("failed to reset OpenPGP applet", err)
+	}
+	return nil
+}
+
+// GetTouchPolicy returns the touch policy of each key slot by parsing
+// 'ykman openpgp info'.
+func (s *Service) GetTouchPolicy(ctx context.Context) (map[string]string, error) {
+	output, err := s.exec.Run(ctx, s.YkmanBinary, s.ykmanArgs("openpgp", "info")...)
+	if err != nil {
+		return nil, ykmanError("failed to read touch policies", err)
+	}
+	policies := parseTouchPolicies(string(output))
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("ykman did not report any touch policies")
+	}
+	return policies, nil
+}
+
+// SetTouchPolicy sets the touch policy of a key slot using ykman.
+// It runs interactively so ykman can prompt for the Admin PIN.
+func (s *Service) SetTouchPolicy(ctx context.Context, slot, policy string) error {
+	slot = strings.ToLower(strings.TrimSpace(slot))
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if !containsString(TouchSlots, slot) {
+		return fmt.Errorf("invalid slot %q (expected one of: %s)", slot, strings.Join(TouchSlots, ", "))
+	}
+	if !containsString(TouchPolicies, policy) {
+		return fmt.Errorf("invalid touch policy %q (expected one of: %s)", policy, strings.Join(TouchPolicies, ", "))
+	}
+
+	if err := s.exec.RunInteractive(ctx, s.YkmanBinary, s.ykmanArgs("openpgp", "keys", "set-touch", slot, policy, "--force")...); err != nil {
+		return ykmanError("failed to set touch policy", err)
+	}
+	return nil
+}
+
+// ListCards returns the serial number of every connected YubiKey.
+func (s *Service) ListCards(ctx context.Context) ([]string, error) {
+	output, err := s.exec.Run(ctx, s.YkmanBinary, "list", "--serials")
+	if err != nil {
+		return nil, ykmanError("failed to list YubiKeys", err)
+	}
+	var serials []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if serial := strings.TrimSpace(line); serial != "" {
+			serials = append(serials, serial)
+		}
+	}
+	return serials, nil
+}
+
+// SelectCard switches gpg-agent to the YubiKey with the given serial and
+// runs later ykman commands against it. Switching cards needs GnuPG 2.3
+// or later.
+func (s *Service) SelectCard(ctx context.Context, serial string) error {
+	output, err := s.exec.Run(ctx, "gpg-connect-agent", "scd getinfo card_list", "/bye")
+	if err != nil {
+		return fmt.Errorf("failed to list cards: %w", err)
+	}
+
+	aid := ""
+	for _, candidate := range parseCardList(string(output)) {
+		if gpg.NormalizeCardSerial(candidate) == gpg.NormalizeCardSerial(serial) {
+			aid = candidate
+			break
+		}
+	}
+	if aid == "" {
+		return fmt.Errorf("gpg-agent does not see a card with serial %s (switching cards requires GnuPG 2.3 or later)", serial)
+	}
+
+	output, err = s.exec.Run(ctx, "gpg-connect-agent", "scd switchcard "+aid, "/bye")
+	if err == nil {
+		err = agentError(string(output))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to switch to card %s: %w", serial, err)
+	}
+
+	s.Device = serial
+	return nil
+}
+
+// parseCardList extracts the application IDs from the "S SERIALNO <AID>"
+// lines gpg-agent prints for 'scd getinfo card_list'.
+func parseCardList(output string) []string {
+	var aids []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "S" && fields[1] == "SERIALNO" {
+			aids = append(aids, fields[2])
+		}
+	}
+	return aids
+}
+
+// agentError returns the first "ERR" line of gpg-connect-agent output as
+// an error, or nil if there is none.
+func agentError(output string) error {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "ERR ") {
+			return fmt.Errorf("%s", strings.TrimPrefix(line, "ERR "))
+		}
+	}
+	return nil
+}
+
+// ykmanArgs prefixes args with --device when a YubiKey has been selected,
+// so ykman doesn't act on another connected key.
+func (s *Service) ykmanArgs(args ...string) []string {
+	if s.Device == "" {
+		return args
+	}
+	return append([]string{"--device", s.Device}, args...)
+}
+
+// parseTouchPolicies extracts the per-slot touch policies from the
+// "Touch policies" section of 'ykman openpgp info' output, e.g.:
+//
+//	Touch policies:
+//	  Signature key:      Off
+//	  Encryption key:     Cached-Fixed
+func parseTouchPolicies(output string) map[string]string {
+	policies := make(map[string]string)
+	inSection := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Touch policies") {
+			inSection = true
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		for label, slot := range touchSlotLabels {
+			if strings.HasPrefix(line, label) {
+				value := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, label), ":"))
+				policies[slot] = strings.ToLower(value)
+			}
+		}
+	}
+	return policies
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// runCardEdit runs a gpg --card-edit session driven by script via --command-fd.
+// The Admin PIN is read from the script through loopback pinentry.
+func (s *Service) runCardEdit(ctx context.Context, script string) error {
+	return s.runCardEditScript(ctx, script, "Admin PIN")
+}
+
+// runCardEditScript is runCardEdit for scripts that send other PINs; pin
+// names the PIN reported as rejected when the card refuses the operation.
+func (s *Service) runCardEditScript(ctx context.Context, script, pin string) error {
+	args := []string{"--pinentry-mode", "loopback", "--command-fd", "0", "--status-fd", "1", "--card-edit"}
+	output, err := s.exec.RunWithInput(ctx, []byte(script), s.GPGBinary, args...)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(output), "SC_OP_FAILURE") || strings.Contains(string(output), "BAD_PASSPHRASE") {
+		return fmt.Errorf("the card rejected the %s", pin)
+	}
+	return nil
+}
+
+// ykmanError wraps an error from a ykman invocation, replacing the raw
+// "executable file not found" error with installation guidance.
+func ykmanError(msg string, err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%s: ykman is not installed. Install it with 'brew install ykman' (macOS) or see https://github.com/Yubico/yubikey-manager", msg)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
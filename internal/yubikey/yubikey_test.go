@@ -3,6 +3,8 @@ package yubikey
 import (
 	"context"
 	"fmt"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/bobbydams/yubikey-manager/internal/executor"
@@ -20,6 +22,14 @@ func (m *MockGPGService) ListSecretKeys(ctx context.Context, keyID string) ([]gp
 	return nil, nil
 }
 
+func (m *MockGPGService) ListAllSecretKeys(ctx context.Context) ([]gpg.Key, error) {
+	return nil, nil
+}
+
+func (m *MockGPGService) ListPublicKeys(ctx context.Context, keyID string) ([]gpg.Key, error) {
+	return nil, nil
+}
+
 func (m *MockGPGService) CardStatus(ctx context.Context) (*gpg.CardInfo, error) {
 	if m.CardStatusFunc != nil {
 		return m.CardStatusFunc(ctx)
@@ -31,6 +41,10 @@ func (m *MockGPGService) ExportPublicKey(ctx context.Context, keyID string) ([]b
 	return nil, nil
 }
 
+func (m *MockGPGService) ExportSSHKey(ctx context.Context, keyID string) ([]byte, error) {
+	return nil, nil
+}
+
 func (m *MockGPGService) ExportSecretSubkeys(ctx context.Context, keyID string) ([]byte, error) {
 	return nil, nil
 }
@@ -43,10 +57,26 @@ func (m *MockGPGService) ImportKey(ctx context.Context, keyData []byte) error {
 	return nil
 }
 
+func (m *MockGPGService) ImportKeyFile(ctx context.Context, path, fingerprint string) (*gpg.ImportResult, error) {
+	return &gpg.ImportResult{}, nil
+}
+
+func (m *MockGPGService) InspectKeyFile(ctx context.Context, path string) (*gpg.Key, error) {
+	return &gpg.Key{}, nil
+}
+
+func (m *MockGPGService) ImportKeyIsolated(ctx context.Context, path string) ([]gpg.Key, error) {
+	return nil, nil
+}
+
 func (m *MockGPGService) ExportOwnerTrust(ctx context.Context) ([]byte, error) {
 	return nil, nil
 }
 
+func (m *MockGPGService) ImportOwnerTrust(ctx context.Context, data []byte) error {
+	return nil
+}
+
 func (m *MockGPGService) CheckTrustDB(ctx context.Context) error {
 	return nil
 }
@@ -55,6 +85,58 @@ func (m *MockGPGService) EditKey(ctx context.Context, keyID string) error {
 	return nil
 }
 
+func (m *MockGPGService) EncryptSymmetric(ctx context.Context, inputPath, outputPath, passphrase string) error {
+	return nil
+}
+
+func (m *MockGPGService) GPGVersion(ctx context.Context) (int, int, int, error) {
+	return 2, 4, 0, nil
+}
+
+func (m *MockGPGService) PingAgent(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockGPGService) LaunchAgent(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockGPGService) AddSubkey(ctx context.Context, fingerprint, algorithm, usage, expiry, passphrase string) error {
+	return nil
+}
+
+func (m *MockGPGService) MoveSubkeyToCard(ctx context.Context, keyID, subkeyID string, slot int, passphrase, adminPIN string) error {
+	return nil
+}
+
+func (m *MockGPGService) GenerateRevocationCertificate(ctx context.Context, keyID string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockGPGService) ExtendExpiration(ctx context.Context, keyID string, subkeyIDs []string, expiry string, passphrase string) error {
+	return nil
+}
+
+func (m *MockGPGService) PublishKey(ctx context.Context, keyID string, servers []string) []error {
+	return make([]error, len(servers))
+}
+
+func (m *MockGPGService) RefreshKey(ctx context.Context, keyID, server string) (bool, error) {
+	return false, nil
+}
+
+func (m *MockGPGService) ExportPublicKeyMinimal(ctx context.Context, keyID string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockGPGService) ExportPublicKeyBinary(ctx context.Context, keyID string, minimal bool) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockGPGService) ExportForWKD(ctx context.Context, email string) (string, []byte, error) {
+	return "", nil, nil
+}
+
 func TestService_IsPresent(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -119,6 +201,70 @@ func TestService_IsPresent(t *testing.T) {
 	}
 }
 
+func TestService_GetCardInfo_RetriesTransientErrors(t *testing.T) {
+	calls := 0
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			calls++
+			if calls < 3 {
+				return nil, fmt.Errorf("gpg: selecting card failed: No such device")
+			}
+			return &gpg.CardInfo{Serial: "12345678", FirmwareVersion: "5.4.3"}, nil
+		},
+	}
+	svc := NewService(mockGPG, executor.NewMockExecutor())
+	svc.CardRetryDelay = 0
+
+	info, err := svc.GetCardInfo(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "12345678", info.Serial)
+	assert.Equal(t, 3, calls)
+}
+
+func TestService_GetCardInfo_RetriesExhausted(t *testing.T) {
+	calls := 0
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			calls++
+			return nil, fmt.Errorf("gpg: selecting card failed: card error")
+		},
+	}
+	svc := NewService(mockGPG, executor.NewMockExecutor())
+	svc.CardRetries = 2
+	svc.CardRetryDelay = 0
+
+	_, err := svc.GetCardInfo(context.Background())
+
+	require.Error(t, err)
+	assert.Equal(t, 3, calls, "one attempt plus two retries")
+}
+
+func TestService_IsPresent_DoesNotRetryUnsupported(t *testing.T) {
+	calls := 0
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			calls++
+			return nil, fmt.Errorf("gpg: selecting card failed: Operation not supported by device")
+		},
+	}
+	svc := NewService(mockGPG, executor.NewMockExecutor())
+	svc.CardRetryDelay = 0
+
+	_, err := svc.IsPresent(context.Background())
+
+	require.Error(t, err)
+	// Once from IsPresent and once from the SupportsOpenPGP fallback
+	assert.Equal(t, 2, calls)
+}
+
+func TestIsTransientCardError(t *testing.T) {
+	assert.True(t, isTransientCardError(fmt.Errorf("gpg: selecting card failed: No such device")))
+	assert.True(t, isTransientCardError(fmt.Errorf("gpg: OpenPGP card not available: card error")))
+	assert.False(t, isTransientCardError(fmt.Errorf("gpg: selecting card failed: Operation not supported by device")))
+	assert.False(t, isTransientCardError(fmt.Errorf("gpg: signing failed: Bad PIN")))
+}
+
 func TestService_GetCardInfo(t *testing.T) {
 	expectedCardInfo := &gpg.CardInfo{
 		Serial:     "12345678",
@@ -142,6 +288,74 @@ func TestService_GetCardInfo(t *testing.T) {
 	assert.Equal(t, expectedCardInfo, cardInfo)
 }
 
+func TestService_GetCardInfo_DeviceInfo(t *testing.T) {
+	ykmanInfo := []byte(`Device type: YubiKey 5 NFC
+Serial number: 12345678
+Firmware version: 5.4.3
+Form factor: Keychain (USB-A)
+`)
+	newMockGPG := func() *MockGPGService {
+		return &MockGPGService{
+			CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+				return &gpg.CardInfo{Serial: "12345678", Model: "YubiKey"}, nil
+			},
+		}
+	}
+
+	t.Run("filled from ykman", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("ykman info", ykmanInfo)
+
+		cardInfo, err := NewService(newMockGPG(), mockExec).GetCardInfo(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, "5.4.3", cardInfo.FirmwareVersion)
+		assert.Equal(t, "YubiKey 5 NFC", cardInfo.Model)
+	})
+
+	t.Run("ykman unavailable", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError("ykman info", fmt.Errorf("failed to execute command: %w", exec.ErrNotFound))
+
+		cardInfo, err := NewService(newMockGPG(), mockExec).GetCardInfo(context.Background())
+
+		require.NoError(t, err)
+		assert.Empty(t, cardInfo.FirmwareVersion)
+		assert.Equal(t, "YubiKey", cardInfo.Model)
+	})
+
+	t.Run("ykman reports a different YubiKey", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("ykman info", []byte(strings.Replace(string(ykmanInfo), "12345678", "87654321", 1)))
+
+		cardInfo, err := NewService(newMockGPG(), mockExec).GetCardInfo(context.Background())
+
+		require.NoError(t, err)
+		assert.Empty(t, cardInfo.FirmwareVersion)
+	})
+}
+
+func TestFirmwareAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"5.4.3", true},
+		{"5.2.3", true},
+		{"5.2.2", false},
+		{"5.10", true},
+		{"4.3.7", false},
+		{"", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.want, FirmwareAtLeast(tt.version, MinEd25519Firmware))
+		})
+	}
+}
+
 func TestService_SupportsOpenPGP(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -218,3 +432,455 @@ func TestService_SupportsOpenPGP(t *testing.T) {
 		})
 	}
 }
+
+// cardEdit is the command run by scripted gpg --card-edit sessions.
+const cardEdit = "gpg --pinentry-mode loopback --command-fd 0 --status-fd 1 --card-edit"
+
+func TestService_ChangeUserPIN(t *testing.T) {
+	tests := []struct {
+		name          string
+		newPIN        string
+		output        string
+		gpgError      error
+		expectCall    bool
+		errorContains string
+	}{
+		{
+			name:       "successful change",
+			newPIN:     "654321",
+			output:     "[GNUPG:] SC_OP_SUCCESS\n",
+			expectCall: true,
+		},
+		{
+			name:          "new PIN too short",
+			newPIN:        "12345",
+			expectCall:    false,
+			errorContains: "at least 6 characters",
+		},
+		{
+			name:          "wrong current PIN",
+			newPIN:        "654321",
+			output:        "[GNUPG:] SC_OP_FAILURE 2\n",
+			expectCall:    true,
+			errorContains: "the card rejected the User PIN",
+		},
+		{
+			name:          "gpg fails",
+			newPIN:        "654321",
+			gpgError:      fmt.Errorf("exit status 2"),
+			expectCall:    true,
+			errorContains: "failed to change User PIN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExec := executor.NewMockExecutor()
+			mockExec.SetOutput(cardEdit, []byte(tt.output))
+			if tt.gpgError != nil {
+				mockExec.SetError(cardEdit, tt.gpgError)
+			}
+			svc := NewService(&MockGPGService{}, mockExec)
+
+			err := svc.ChangeUserPIN(context.Background(), "123456", tt.newPIN)
+
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+			} else {
+				require.NoError(t, err)
+			}
+			if !tt.expectCall {
+				assert.Len(t, mockExec.Calls, 0)
+				return
+			}
+			require.Len(t, mockExec.Calls, 1)
+			call := mockExec.Calls[0]
+			assert.Equal(t, "admin\npasswd\n1\n123456\n"+tt.newPIN+"\nq\nquit\n", string(call.Input))
+			assert.NotContains(t, strings.Join(call.Args, " "), "123456", "PINs must not be passed as arguments")
+		})
+	}
+}
+
+func TestService_UnblockPIN(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	err := svc.UnblockPIN(context.Background(), "12345678", "12345")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least 6 characters")
+	assert.Len(t, mockExec.Calls, 0)
+
+	err = svc.UnblockPIN(context.Background(), "12345678", "654321")
+	require.NoError(t, err)
+	require.Len(t, mockExec.Calls, 1)
+	assert.True(t, mockExec.VerifyCall("gpg", strings.Fields(cardEdit)[1:]...))
+	assert.Equal(t, "unblock\n12345678\n654321\nquit\n", string(mockExec.Calls[0].Input))
+
+	mockExec.SetOutput(cardEdit, []byte("[GNUPG:] SC_OP_FAILURE 2\n"))
+	err = svc.UnblockPIN(context.Background(), "00000000", "654321")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to unblock User PIN: the card rejected the Reset Code")
+}
+
+func TestService_IsDefaultAdminPIN(t *testing.T) {
+	ctx := context.Background()
+	adminCmd := "ykman openpgp access change-admin-pin --admin-pin 12345678 --new-admin-pin 12345678"
+
+	t.Run("default accepted", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		svc := NewService(&MockGPGService{}, mockExec)
+
+		isDefault, err := svc.IsDefaultAdminPIN(ctx)
+		require.NoError(t, err)
+		assert.True(t, isDefault)
+		assert.True(t, mockExec.VerifyCall("ykman", strings.Fields(adminCmd)[1:]...))
+		assert.Len(t, mockExec.Calls, 1, "only the Admin PIN is probed")
+	})
+
+	t.Run("default rejected", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError(adminCmd, fmt.Errorf("command failed with exit code 2: Error: Wrong Admin PIN, 2 attempts remaining."))
+		svc := NewService(&MockGPGService{}, mockExec)
+
+		isDefault, err := svc.IsDefaultAdminPIN(ctx)
+		require.NoError(t, err)
+		assert.False(t, isDefault)
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError(adminCmd, fmt.Errorf("Error: No YubiKey detected"))
+		svc := NewService(&MockGPGService{}, mockExec)
+
+		_, err := svc.IsDefaultAdminPIN(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to check the Admin PIN")
+	})
+}
+
+func TestIsWrongPINError(t *testing.T) {
+	assert.True(t, isWrongPINError(fmt.Errorf("Error: Wrong PIN, 2 attempts remaining.")))
+	assert.True(t, isWrongPINError(fmt.Errorf("command failed with exit code 2: Error: Wrong Admin PIN, 1 attempt remaining.")))
+	assert.False(t, isWrongPINError(fmt.Errorf("Error: Wrong device selected")))
+	assert.False(t, isWrongPINError(fmt.Errorf("Error: PIN is blocked")))
+	assert.False(t, isWrongPINError(fmt.Errorf("ERROR: Something went wrong")))
+}
+
+func TestService_ChangeAdminPIN(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	err := svc.ChangeAdminPIN(context.Background(), "12345678", "1234567")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least 8 characters")
+	assert.Len(t, mockExec.Calls, 0)
+
+	err = svc.ChangeAdminPIN(context.Background(), "12345678", "87654321")
+	require.NoError(t, err)
+	require.Len(t, mockExec.Calls, 1)
+	assert.True(t, mockExec.VerifyCall("gpg", strings.Fields(cardEdit)[1:]...))
+	assert.Equal(t, "admin\npasswd\n3\n12345678\n87654321\nq\nquit\n", string(mockExec.Calls[0].Input))
+
+	mockExec.SetOutput(cardEdit, []byte("[GNUPG:] SC_OP_FAILURE 2\n"))
+	err = svc.ChangeAdminPIN(context.Background(), "00000000", "87654321")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the card rejected the Admin PIN")
+}
+
+func TestService_ResetOpenPGP(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	require.NoError(t, svc.ResetOpenPGP(context.Background()))
+	assert.True(t, mockExec.VerifyCall("ykman", "openpgp", "reset", "--force"))
+
+	mockExec.SetError("ykman openpgp reset --force", fmt.Errorf("failed to execute command: %w", exec.ErrNotFound))
+	err := svc.ResetOpenPGP(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ykman is not installed")
+}
+
+func TestService_CustomBinaries(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(&MockGPGService{}, mockExec)
+	svc.GPGBinary = "gpg2"
+	svc.YkmanBinary = "/opt/ykman/bin/ykman"
+
+	require.NoError(t, svc.ResetOpenPGP(context.Background()))
+	require.NoError(t, svc.EditCard(context.Background()))
+
+	assert.True(t, mockExec.VerifyCall("/opt/ykman/bin/ykman", "openpgp", "reset", "--force"))
+	require.Len(t, mockExec.InteractiveCalls, 1)
+	assert.Equal(t, "gpg2", mockExec.InteractiveCalls[0].Name)
+}
+
+func TestService_GetTouchPolicy(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("ykman openpgp info", []byte(`OpenPGP version:            3.4
+Application version:        5.4.3
+PIN tries remaining:        3
+Reset code tries remaining: 0
+Admin PIN tries remaining:  3
+Require PIN for signature:  Once
+
+Touch policies:
+  Signature key:      On
+  Encryption key:     Off
+  Authentication key: Cached-Fixed
+  Attestation key:    Off
+`))
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	policies, err := svc.GetTouchPolicy(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"sig": "on", "enc": "off", "aut": "cached-fixed"}, policies)
+}
+
+func TestService_GetTouchPolicy_NoPolicies(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("ykman openpgp info", []byte("OpenPGP version: 2.1\n"))
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	_, err := svc.GetTouchPolicy(context.Background())
+	assert.Error(t, err)
+}
+
+func TestService_SetTouchPolicy(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	require.NoError(t, svc.SetTouchPolicy(context.Background(), "SIG", "On"))
+	require.Len(t, mockExec.InteractiveCalls, 1)
+	assert.Equal(t, []string{"openpgp", "keys", "set-touch", "sig", "on", "--force"}, mockExec.InteractiveCalls[0].Args)
+
+	err := svc.SetTouchPolicy(context.Background(), "att", "on")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid slot")
+
+	err = svc.SetTouchPolicy(context.Background(), "sig", "always")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid touch policy")
+	assert.Len(t, mockExec.InteractiveCalls, 1)
+}
+
+func TestService_SetCardholderName(t *testing.T) {
+	tests := []struct {
+		name          string
+		cardholder    string
+		editOutput    string
+		errorContains string
+	}{
+		{
+			name:       "name applied",
+			cardholder: "Jane Doe",
+		},
+		{
+			name:          "admin PIN rejected",
+			editOutput:    "[GNUPG:] SC_OP_FAILURE 2\n",
+			errorContains: "rejected the Admin PIN",
+		},
+		{
+			name:          "name unchanged",
+			cardholder:    "Old Name",
+			errorContains: "was not updated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExec := executor.NewMockExecutor()
+			mockExec.SetOutput(cardEdit, []byte(tt.editOutput))
+			mockGPG := &MockGPGService{
+				CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+					return &gpg.CardInfo{Cardholder: tt.cardholder}, nil
+				},
+			}
+			svc := NewService(mockGPG, mockExec)
+
+			err := svc.SetCardholderName(context.Background(), "Jane", "Doe", "12345678")
+
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, mockExec.Calls, 1)
+			assert.Equal(t, "admin\nname\nDoe\nJane\n12345678\nquit\n", string(mockExec.Calls[0].Input))
+			assert.NotContains(t, mockExec.Calls[0].Args, "12345678")
+		})
+	}
+}
+
+func TestService_SetCardholderName_RequiresSurname(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	err := svc.SetCardholderName(context.Background(), "Jane", " ", "12345678")
+	assert.Error(t, err)
+	assert.Len(t, mockExec.Calls, 0)
+}
+
+func TestService_SetCardURL(t *testing.T) {
+	url := "https://keys.openpgp.org/vks/v1/by-fingerprint/FA57C85131F11B28EE236A4F07AAA1E535650AF5"
+	cardURL := url
+	mockExec := executor.NewMockExecutor()
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			return &gpg.CardInfo{URL: cardURL}, nil
+		},
+	}
+	svc := NewService(mockGPG, mockExec)
+
+	err := svc.SetCardURL(context.Background(), url, "12345678")
+	require.NoError(t, err)
+	require.Len(t, mockExec.Calls, 1)
+	assert.Equal(t, "admin\nurl\n"+url+"\n12345678\nquit\n", string(mockExec.Calls[0].Input))
+
+	cardURL = ""
+	err = svc.SetCardURL(context.Background(), url, "00000000")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin PIN likely incorrect")
+}
+
+func TestService_SetLoginData(t *testing.T) {
+	cardLogin := "jdoe"
+	mockExec := executor.NewMockExecutor()
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			return &gpg.CardInfo{LoginData: cardLogin}, nil
+		},
+	}
+	svc := NewService(mockGPG, mockExec)
+
+	err := svc.SetLoginData(context.Background(), " jdoe ", "12345678")
+	require.NoError(t, err)
+	require.Len(t, mockExec.Calls, 1)
+	assert.Equal(t, "admin\nlogin\njdoe\n12345678\nquit\n", string(mockExec.Calls[0].Input))
+
+	cardLogin = ""
+	err = svc.SetLoginData(context.Background(), "jdoe", "00000000")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin PIN likely incorrect")
+
+	assert.Error(t, svc.SetLoginData(context.Background(), "", "12345678"))
+}
+
+func TestService_SetKeyAttributes(t *testing.T) {
+	rsa := map[string]string{"Signature": "rsa2048", "Encryption": "rsa2048", "Authentication": "rsa2048"}
+	ecc := map[string]string{"Signature": "ed25519", "Encryption": "cv25519", "Authentication": "ed25519"}
+
+	// The card reports before on the first read and after once key-attr ran
+	var before, after map[string]string
+	reads := 0
+	mockExec := executor.NewMockExecutor()
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			reads++
+			if reads == 1 {
+				return &gpg.CardInfo{FirmwareVersion: "5.4.3", KeyAttributes: before}, nil
+			}
+			return &gpg.CardInfo{FirmwareVersion: "5.4.3", KeyAttributes: after}, nil
+		},
+	}
+	svc := NewService(mockGPG, mockExec)
+	run := func(from, to map[string]string, slot, algo string) error {
+		before, after, reads = from, to, 0
+		mockExec.Reset()
+		return svc.SetKeyAttributes(context.Background(), slot, algo, "12345678")
+	}
+
+	// Unchanged RSA slots are re-entered; the PIN follows the first change
+	require.NoError(t, run(rsa, map[string]string{"Signature": "rsa2048", "Encryption": "cv25519", "Authentication": "rsa2048"}, "Encryption", "cv25519"))
+	require.Len(t, mockExec.Calls, 1)
+	assert.Equal(t, "admin\nkey-attr\n1\n2048\n2\n1\n12345678\n1\n2048\nquit\n", string(mockExec.Calls[0].Input))
+
+	// Curve 25519 slots are always rewritten, so the PIN follows the first one
+	require.NoError(t, run(ecc, ecc, "Signature", "ed25519"))
+	assert.Equal(t, "admin\nkey-attr\n2\n1\n12345678\n2\n1\n2\n1\nquit\n", string(mockExec.Calls[0].Input))
+
+	// A slot that doesn't change afterwards points at a wrong PIN
+	err := run(rsa, rsa, "Signature", "ed25519")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin PIN likely incorrect")
+
+	err = run(rsa, rsa, "Encryption", "ed25519")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported key attribute")
+
+	assert.Error(t, run(rsa, rsa, "Decryption", "ed25519"))
+}
+
+func TestService_SetKeyAttributes_OldFirmware(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockGPG := &MockGPGService{
+		CardStatusFunc: func(ctx context.Context) (*gpg.CardInfo, error) {
+			return &gpg.CardInfo{FirmwareVersion: "4.3.7", KeyAttributes: map[string]string{
+				"Signature": "rsa2048", "Encryption": "rsa2048", "Authentication": "rsa2048",
+			}}, nil
+		},
+	}
+	svc := NewService(mockGPG, mockExec)
+
+	err := svc.SetKeyAttributes(context.Background(), "Signature", "ed25519", "12345678")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support ed25519")
+	assert.Empty(t, mockExec.Calls)
+}
+
+func TestKeyserverURL(t *testing.T) {
+	assert.Equal(t, "https://keys.openpgp.org/vks/v1/by-fingerprint/FA57C85131F11B28EE236A4F07AAA1E535650AF5",
+		KeyserverURL("fa57 c851 31f1 1b28 ee23  6a4f 07aa a1e5 3565 0af5"))
+}
+
+func TestService_ListCards(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("ykman list --serials", []byte("12345678\n23456789\n"))
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	serials, err := svc.ListCards(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"12345678", "23456789"}, serials)
+
+	mockExec.SetError("ykman list --serials", fmt.Errorf("failed to execute command: %w", exec.ErrNotFound))
+	_, err = svc.ListCards(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ykman is not installed")
+}
+
+func TestService_SelectCard(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg-connect-agent scd getinfo card_list /bye", []byte(
+		"S SERIALNO D2760001240103040006123456780000\nS SERIALNO D2760001240103040006234567890000\nOK\n"))
+	mockExec.SetOutput("gpg-connect-agent scd switchcard D2760001240103040006234567890000 /bye", []byte("OK\n"))
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	require.NoError(t, svc.SelectCard(context.Background(), "23456789"))
+	assert.True(t, mockExec.VerifyCall("gpg-connect-agent", "scd switchcard D2760001240103040006234567890000", "/bye"))
+	assert.Equal(t, "23456789", svc.Device)
+
+	// ykman commands now target the selected YubiKey
+	require.NoError(t, svc.ResetOpenPGP(context.Background()))
+	assert.True(t, mockExec.VerifyCall("ykman", "--device", "23456789", "openpgp", "reset", "--force"))
+}
+
+func TestService_SelectCard_Errors(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg-connect-agent scd getinfo card_list /bye", []byte(
+		"S SERIALNO D2760001240103040006123456780000\nOK\n"))
+	svc := NewService(&MockGPGService{}, mockExec)
+
+	err := svc.SelectCard(context.Background(), "99999999")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GnuPG 2.3")
+	assert.Empty(t, svc.Device)
+
+	mockExec.SetOutput("gpg-connect-agent scd switchcard D2760001240103040006123456780000 /bye",
+		[]byte("ERR 100696144 No such device <SCD>\n"))
+	err = svc.SelectCard(context.Background(), "12345678")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No such device")
+	assert.Empty(t, svc.Device)
+}
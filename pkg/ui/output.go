@@ -8,10 +8,25 @@ import (
 	"github.com/fatih/color"
 )
 
+// LogLevel controls which Log* messages are printed.
+type LogLevel int
+
+const (
+	// LogLevelQuiet prints only warnings and errors.
+	LogLevelQuiet LogLevel = iota
+	// LogLevelNormal also prints info and success messages.
+	LogLevelNormal
+	// LogLevelVerbose also prints debug messages.
+	LogLevelVerbose
+)
+
 var (
 	// colorEnabled controls whether colors are used
 	colorEnabled = true
 
+	// logLevel controls which Log* messages are printed
+	logLevel = LogLevelNormal
+
 	// InfoColor is used for informational messages
 	InfoColor = color.New(color.FgBlue)
 	// SuccessColor is used for success messages
@@ -28,6 +43,8 @@ var (
 	ValueColor = color.New(color.FgHiWhite)
 	// KeyColor is used for key IDs and fingerprints
 	KeyColor = color.New(color.FgMagenta)
+	// DebugColor is used for debug messages
+	DebugColor = color.New(color.FgHiBlack)
 )
 
 // SetColorEnabled enables or disables color output globally
@@ -47,13 +64,39 @@ func IsColorEnabled() bool {
 	return colorEnabled
 }
 
+// SetLogLevel sets which Log* messages are printed.
+func SetLogLevel(level LogLevel) {
+	logLevel = level
+}
+
+// GetLogLevel returns the current log level.
+func GetLogLevel() LogLevel {
+	return logLevel
+}
+
+// LogDebug prints a debug message with [DEBUG] prefix in verbose mode.
+func LogDebug(format string, args ...interface{}) {
+	if logLevel < LogLevelVerbose {
+		return
+	}
+	DebugColor.Fprintf(os.Stderr, "[DEBUG] %s\n", fmt.Sprintf(format, args...))
+}
+
 // LogInfo prints an informational message with [INFO] prefix.
+// It is suppressed in quiet mode.
 func LogInfo(format string, args ...interface{}) {
+	if logLevel < LogLevelNormal {
+		return
+	}
 	InfoColor.Fprintf(os.Stdout, "[INFO] %s\n", fmt.Sprintf(format, args...))
 }
 
 // LogSuccess prints a success message with [SUCCESS] prefix.
+// It is suppressed in quiet mode.
 func LogSuccess(format string, args ...interface{}) {
+	if logLevel < LogLevelNormal {
+		return
+	}
 	SuccessColor.Fprintf(os.Stdout, "[SUCCESS] %s\n", fmt.Sprintf(format, args...))
 }
 
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"io"
+	"os"
 	"testing"
 
 	"github.com/fatih/color"
@@ -279,3 +281,69 @@ func TestColorOutputWithoutANSI(t *testing.T) {
 		PrintSection("section")
 	})
 }
+
+// captureOutput returns what fn writes to os.Stdout and os.Stderr.
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = stdoutW, stderrW
+	defer func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+	}()
+
+	fn()
+
+	stdoutW.Close()
+	stderrW.Close()
+	stdout, _ := io.ReadAll(stdoutR)
+	stderr, _ := io.ReadAll(stderrR)
+	return string(stdout), string(stderr)
+}
+
+func TestLogLevel(t *testing.T) {
+	originalLevel := logLevel
+	originalNoColor := color.NoColor
+	defer func() {
+		logLevel = originalLevel
+		color.NoColor = originalNoColor
+	}()
+	color.NoColor = true
+
+	logAll := func() {
+		LogDebug("debug")
+		LogInfo("info")
+		LogSuccess("success")
+		LogWarning("warning")
+		LogError("error")
+	}
+
+	t.Run("quiet", func(t *testing.T) {
+		SetLogLevel(LogLevelQuiet)
+		assert.Equal(t, LogLevelQuiet, GetLogLevel())
+		stdout, stderr := captureOutput(t, logAll)
+		assert.Empty(t, stdout)
+		assert.Equal(t, "[WARNING] warning\n[ERROR] error\n", stderr)
+	})
+
+	t.Run("normal", func(t *testing.T) {
+		SetLogLevel(LogLevelNormal)
+		stdout, stderr := captureOutput(t, logAll)
+		assert.Equal(t, "[INFO] info\n[SUCCESS] success\n", stdout)
+		assert.NotContains(t, stderr, "[DEBUG]")
+	})
+
+	t.Run("verbose", func(t *testing.T) {
+		SetLogLevel(LogLevelVerbose)
+		stdout, stderr := captureOutput(t, logAll)
+		assert.Contains(t, stdout, "[INFO] info")
+		assert.Contains(t, stderr, "[DEBUG] debug")
+	})
+}
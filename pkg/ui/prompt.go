@@ -2,6 +2,7 @@ package ui
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -9,11 +10,39 @@ import (
 	"golang.org/x/term"
 )
 
+// ErrNonInteractive is returned by the prompt functions in non-interactive mode.
+var ErrNonInteractive = errors.New("input required but running with --non-interactive")
+
+// nonInteractive is set by SetNonInteractive.
+var nonInteractive bool
+
+// SetNonInteractive turns non-interactive mode on or off. In this mode
+// Confirm answers yes without reading input and the Prompt functions
+// return ErrNonInteractive instead of blocking.
+func SetNonInteractive(enabled bool) {
+	nonInteractive = enabled
+}
+
+// IsNonInteractive reports whether non-interactive mode is on.
+func IsNonInteractive() bool {
+	return nonInteractive
+}
+
+// nonInteractiveError returns ErrNonInteractive naming the prompt.
+func nonInteractiveError(prompt string) error {
+	return fmt.Errorf("%w: %s", ErrNonInteractive, strings.TrimRight(strings.TrimSpace(prompt), ":"))
+}
+
 // Confirm prompts the user for a yes/no confirmation.
 // Returns true if the user responds with 'y' or 'yes' (case-insensitive).
 // Returns false for any other response or empty input.
+// In non-interactive mode it returns true without reading input.
 func Confirm(prompt string) bool {
 	fmt.Printf("%s [y/N] ", prompt)
+	if nonInteractive {
+		fmt.Println("y (non-interactive)")
+		return true
+	}
 	os.Stdout.Sync()
 	
 	fd := int(os.Stdin.Fd())
@@ -91,9 +120,38 @@ func Confirm(prompt string) bool {
 	return responseStr == "y" || responseStr == "yes"
 }
 
+// ConfirmMatch asks the user to type expected, such as a card serial or key
+// ID, to confirm a destructive operation on the right card or key. What was
+// typed is echoed back so the user can check it. Returns true only for an
+// exact match, ignoring surrounding whitespace.
+// In non-interactive mode it returns false without reading input: a
+// destructive operation must then be confirmed with the command's own flag,
+// such as reset --yes.
+func ConfirmMatch(prompt, expected string) bool {
+	fmt.Println(prompt)
+	if nonInteractive {
+		fmt.Printf("Type %s to confirm: not confirmed (non-interactive)\n", expected)
+		return false
+	}
+
+	typed, err := Prompt(fmt.Sprintf("Type %s to confirm: ", expected))
+	if err != nil {
+		return false
+	}
+	if typed != expected {
+		fmt.Printf("You typed %q, which does not match %s\n", typed, expected)
+		return false
+	}
+	fmt.Printf("Confirmed: %s\n", typed)
+	return true
+}
+
 // Prompt reads a line of input from the user.
 // Returns the trimmed input string.
 func Prompt(prompt string) (string, error) {
+	if nonInteractive {
+		return "", nonInteractiveError(prompt)
+	}
 	fmt.Print(prompt)
 	os.Stdout.Sync()
 	
@@ -192,3 +250,90 @@ func PromptRequired(prompt string) (string, error) {
 		LogWarning("This field is required. Please enter a value.")
 	}
 }
+
+// PromptSecret reads a line of input from the user without echoing it.
+// Use this for PINs and passphrases. Only the line ending is stripped;
+// surrounding spaces are preserved since they may be part of a passphrase.
+func PromptSecret(prompt string) (string, error) {
+	if nonInteractive {
+		return "", nonInteractiveError(prompt)
+	}
+	fmt.Print(prompt)
+	os.Stdout.Sync()
+
+	fd := int(os.Stdin.Fd())
+
+	// If not a terminal, use simple bufio reading
+	if !term.IsTerminal(fd) {
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil && response == "" {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return strings.TrimRight(response, "\r\n"), nil
+	}
+
+	// For terminals, use raw mode so nothing typed is echoed
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to disable terminal echo: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// Read characters one by one until newline, without echoing them
+	var response []byte
+	for {
+		var b [1]byte
+		n, err := os.Stdin.Read(b[:])
+		if err != nil || n == 0 {
+			break
+		}
+
+		// Handle Enter key
+		if b[0] == '\n' || b[0] == '\r' {
+			break
+		}
+
+		// Handle Ctrl+C
+		if b[0] == 3 {
+			term.Restore(fd, oldState)
+			fmt.Print("\r\n")
+			os.Exit(130)
+		}
+
+		// Handle backspace/delete
+		if b[0] == 127 || b[0] == 8 {
+			if len(response) > 0 {
+				response = response[:len(response)-1]
+			}
+			continue
+		}
+
+		// Keep printable characters (including multibyte UTF-8 bytes)
+		if b[0] >= 32 {
+			response = append(response, b[0])
+		}
+	}
+
+	// Raw mode doesn't translate \n, so return the cursor explicitly
+	fmt.Print("\r\n")
+
+	return string(response), nil
+}
+
+// PromptSecretRequired reads a secret without echoing it and ensures it's not empty.
+// Continues prompting until a non-empty response is provided.
+func PromptSecretRequired(prompt string) (string, error) {
+	for {
+		response, err := PromptSecret(prompt)
+		if err != nil {
+			return "", err
+		}
+
+		if response != "" {
+			return response, nil
+		}
+
+		LogWarning("This field is required. Please enter a value.")
+	}
+}
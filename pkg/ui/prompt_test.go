@@ -135,6 +135,40 @@ func TestConfirm_Yes(t *testing.T) {
 	}
 }
 
+func TestConfirmMatch(t *testing.T) {
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"exact match", "12345678\n", true},
+		{"surrounding whitespace", "  12345678 \r\n", true},
+		{"different serial", "87654321\n", false},
+		{"partial match", "1234\n", false},
+		{"yes is not enough", "y\n", false},
+		{"empty input", "\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			require.NoError(t, err)
+			defer r.Close()
+			os.Stdin = r
+
+			go func() {
+				defer w.Close()
+				_, _ = w.WriteString(tt.input)
+			}()
+
+			assert.Equal(t, tt.want, ConfirmMatch("Reset YubiKey 12345678?", "12345678"))
+		})
+	}
+}
+
 func TestConfirm_WithCarriageReturn(t *testing.T) {
 	oldStdin := os.Stdin
 	defer func() { os.Stdin = oldStdin }()
@@ -207,3 +241,58 @@ func TestPromptRequired_RetriesOnEmpty(t *testing.T) {
 	// until non-empty) and is verified by the function structure.
 }
 
+
+func TestPromptSecret_NonTerminal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"simple", "123456\n", "123456"},
+		{"carriage return", "s3cret\r\n", "s3cret"},
+		{"preserves spaces", "  pass phrase  \n", "  pass phrase  "},
+		{"no trailing newline", "abc", "abc"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldStdin := os.Stdin
+			defer func() { os.Stdin = oldStdin }()
+
+			r, w, err := os.Pipe()
+			require.NoError(t, err)
+			defer r.Close()
+			defer w.Close()
+
+			os.Stdin = r
+
+			go func() {
+				defer w.Close()
+				_, _ = w.WriteString(tc.input)
+			}()
+
+			result, err := PromptSecret("PIN: ")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestNonInteractive(t *testing.T) {
+	SetNonInteractive(true)
+	defer SetNonInteractive(false)
+	assert.True(t, IsNonInteractive())
+
+	assert.True(t, Confirm("Continue?"))
+	assert.False(t, ConfirmMatch("Reset YubiKey 12345678?", "12345678"), "destructive operations need an explicit flag")
+
+	_, err := Prompt("Press Enter to continue: ")
+	require.ErrorIs(t, err, ErrNonInteractive)
+	assert.Contains(t, err.Error(), "Press Enter to continue")
+
+	_, err = PromptRequired("Name: ")
+	assert.ErrorIs(t, err, ErrNonInteractive)
+
+	_, err = PromptSecretRequired("Admin PIN: ")
+	assert.ErrorIs(t, err, ErrNonInteractive)
+}
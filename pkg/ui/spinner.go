@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// spinnerFrames are drawn in turn while a Spinner runs.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// spinnerInterval is how long each frame is shown.
+const spinnerInterval = 100 * time.Millisecond
+
+// Spinner shows that a long operation, such as a keyserver upload, is still
+// running. On a terminal with colors enabled it animates on one line; on
+// any other output it prints its message once. It prints nothing in quiet mode.
+type Spinner struct {
+	message string
+	out     io.Writer
+	animate bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewSpinner creates a Spinner that writes message to stdout.
+func NewSpinner(message string) *Spinner {
+	animate := colorEnabled && term.IsTerminal(int(os.Stdout.Fd()))
+	return newSpinner(os.Stdout, message, animate)
+}
+
+// StartSpinner creates a Spinner for message and starts it.
+func StartSpinner(message string) *Spinner {
+	s := NewSpinner(message)
+	s.Start()
+	return s
+}
+
+func newSpinner(out io.Writer, message string, animate bool) *Spinner {
+	return &Spinner{message: message, out: out, animate: animate}
+}
+
+// Start shows the spinner. Call Stop when the operation finishes.
+func (s *Spinner) Start() {
+	if logLevel < LogLevelNormal {
+		return
+	}
+	if !s.animate {
+		fmt.Fprintln(s.out, s.message)
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// run draws frames until Stop is called, then clears the line.
+func (s *Spinner) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		fmt.Fprintf(s.out, "\r%s %s", InfoColor.Sprint(spinnerFrames[i%len(spinnerFrames)]), s.message)
+		select {
+		case <-s.stop:
+			fmt.Fprint(s.out, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop removes the spinner. It is safe to call more than once, and on a
+// spinner that was never started.
+func (s *Spinner) Stop() {
+	s.once.Do(func() {
+		if s.stop == nil {
+			return
+		}
+		close(s.stop)
+		<-s.done
+	})
+}
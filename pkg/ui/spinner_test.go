@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpinner_Static(t *testing.T) {
+	var out bytes.Buffer
+	s := newSpinner(&out, "Uploading...", false)
+
+	s.Start()
+	s.Stop()
+	s.Stop()
+
+	assert.Equal(t, "Uploading...\n", out.String())
+}
+
+func TestSpinner_Animated(t *testing.T) {
+	var out bytes.Buffer
+	s := newSpinner(&out, "Uploading...", true)
+
+	s.Start()
+	time.Sleep(spinnerInterval + 50*time.Millisecond)
+	s.Stop()
+
+	output := out.String()
+	assert.Contains(t, output, "Uploading...")
+	assert.True(t, strings.HasSuffix(output, "\r\033[K"), "the spinner line should be cleared on stop")
+}
+
+func TestSpinner_Quiet(t *testing.T) {
+	original := logLevel
+	defer SetLogLevel(original)
+	SetLogLevel(LogLevelQuiet)
+
+	var out bytes.Buffer
+	s := newSpinner(&out, "Uploading...", true)
+	s.Start()
+	s.Stop()
+
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+var (
+	// savedTerminal is the state of the terminal on stdin recorded by
+	// SaveTerminalState, or nil if stdin isn't a terminal.
+	savedTerminal   *term.State
+	savedTerminalMu sync.Mutex
+)
+
+// SaveTerminalState records the state of the terminal on stdin, if it is
+// one, so that RestoreTerminal can put it back.
+func SaveTerminalState() {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return
+	}
+	state, err := term.GetState(fd)
+	if err != nil {
+		return
+	}
+	savedTerminalMu.Lock()
+	savedTerminal = state
+	savedTerminalMu.Unlock()
+}
+
+// RestoreTerminal puts the terminal back into the state recorded by
+// SaveTerminalState, undoing the raw mode of an interrupted prompt or the
+// settings of an interactive program such as pinentry. It does nothing if
+// no state was saved.
+func RestoreTerminal() {
+	savedTerminalMu.Lock()
+	defer savedTerminalMu.Unlock()
+	if savedTerminal != nil {
+		_ = term.Restore(int(os.Stdin.Fd()), savedTerminal)
+	}
+}
@@ -0,0 +1,48 @@
+package ykgpg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+)
+
+// defaultPINChecks reports whether the Admin PIN on the card has been
+// changed from the factory default. A wrong guess uses up an Admin PIN
+// retry, and a blocked Admin PIN means resetting the card, so the PIN is
+// only probed when cardInfo shows its retry counter at the maximum of
+// yubikey.DefaultPINRetries, and never with dryRun. The User PIN isn't
+// probed.
+func (m *Manager) defaultPINChecks(ctx context.Context, cardInfo *gpg.CardInfo, dryRun bool) []CheckResult {
+	check := CheckResult{Name: "default Admin PIN changed"}
+	retries := -1
+	if cardInfo != nil {
+		retries = cardInfo.AdminPINRetries
+	}
+
+	switch {
+	case dryRun:
+		// The probe changes the PIN, so --dry-run would only print it
+		check.Status, check.Message = CheckWarn, "not checked with --dry-run"
+		return []CheckResult{check}
+	case retries < 0:
+		check.Status, check.Message = CheckWarn, "not checked: the retry counter couldn't be read"
+		return []CheckResult{check}
+	case retries != yubikey.DefaultPINRetries:
+		check.Status, check.Message = CheckWarn, fmt.Sprintf("not checked: %d of %d retries left", retries, yubikey.DefaultPINRetries)
+		return []CheckResult{check}
+	}
+
+	found, err := m.yubikey.IsDefaultAdminPIN(ctx)
+	switch {
+	case err != nil:
+		check.Status, check.Message = CheckWarn, fmt.Sprintf("not checked: %v", err)
+	case found:
+		check.Status = CheckFail
+		check.Message = fmt.Sprintf("the Admin PIN is still the default %s; change it now with 'ykgpg pin change --admin'", yubikey.DefaultAdminPIN)
+	default:
+		check.Status = CheckPass
+	}
+	return []CheckResult{check}
+}
@@ -0,0 +1,89 @@
+package ykgpg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPINChecks(t *testing.T) {
+	ctx := context.Background()
+	adminCmd := "ykman openpgp access change-admin-pin --admin-pin 12345678 --new-admin-pin 12345678"
+	healthy := &gpg.CardInfo{PINRetries: 3, ResetCodeRetries: 0, AdminPINRetries: 3}
+
+	t.Run("default still set", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, healthy, false)
+
+		require.Len(t, checks, 1)
+		assert.Equal(t, "default Admin PIN changed", checks[0].Name)
+		assert.Equal(t, CheckFail, checks[0].Status)
+		assert.Contains(t, checks[0].Message, "still the default 12345678")
+		assert.Contains(t, checks[0].Message, "ykgpg pin change --admin")
+		assert.Len(t, mockExec.Calls, 1, "the User PIN is not probed")
+	})
+
+	t.Run("PIN changed", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError(adminCmd, fmt.Errorf("Error: Wrong Admin PIN, 2 attempts remaining."))
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, healthy, false)
+
+		assert.Equal(t, CheckPass, checks[0].Status)
+	})
+
+	t.Run("retry counter below the maximum is not probed", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, &gpg.CardInfo{PINRetries: 3, AdminPINRetries: 2}, false)
+
+		assert.Equal(t, CheckWarn, checks[0].Status)
+		assert.Contains(t, checks[0].Message, "2 of 3 retries left")
+		assert.Empty(t, mockExec.Calls)
+	})
+
+	t.Run("unreadable retry counter is not probed", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, &gpg.CardInfo{PINRetries: 3, AdminPINRetries: -1}, false)
+
+		assert.Equal(t, CheckWarn, checks[0].Status)
+		assert.Contains(t, checks[0].Message, "couldn't be read")
+		assert.Empty(t, mockExec.Calls)
+	})
+
+	t.Run("no card info", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, nil, false)
+
+		assert.Equal(t, CheckWarn, checks[0].Status)
+		assert.Empty(t, mockExec.Calls)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, healthy, true)
+
+		assert.Equal(t, CheckWarn, checks[0].Status)
+		assert.Contains(t, checks[0].Message, "--dry-run")
+		assert.Empty(t, mockExec.Calls)
+	})
+
+	t.Run("probe error", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError(adminCmd, fmt.Errorf("Error: Failed connecting to the YubiKey"))
+
+		checks := newTestManager(t, mockExec).defaultPINChecks(ctx, healthy, false)
+
+		assert.Equal(t, CheckWarn, checks[0].Status)
+		assert.Contains(t, checks[0].Message, "not checked: failed to check the Admin PIN")
+	})
+}
@@ -0,0 +1,116 @@
+package ykgpg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+)
+
+// encryptionTestPayload is the data encrypted and decrypted by the
+// encryption test.
+const encryptionTestPayload = "ykgpg encryption test\n"
+
+// ErrDecryptNeedsPIN is returned by TryEncryptRoundTrip when decrypting
+// without a prompt failed, likely because the PIN isn't cached.
+var ErrDecryptNeedsPIN = errors.New("decryption requires PIN entry")
+
+// checkEncryption adds the check that the card holds an encryption key,
+// and with testEncrypt the encryption test. Without testEncrypt the check
+// is only made if the primary key has an encryption subkey, and a card
+// without one is a warning.
+func (m *Manager) checkEncryption(ctx context.Context, keys []gpg.Key, result *VerifyResult, opts VerifyOptions) {
+	if result.Card == nil || (!opts.TestEncrypt && !hasEncryptionSubkey(keys)) {
+		return
+	}
+
+	fingerprint := strings.ToUpper(strings.ReplaceAll(result.Card.Keys[gpg.CardSlots[2]], " ", ""))
+	if fingerprint == "" {
+		status := CheckWarn
+		if opts.TestEncrypt {
+			status = CheckFail
+			result.EncryptionFailed = true
+		}
+		result.add("encryption key on YubiKey", status, fmt.Sprintf("the Encryption slot is empty; decryption will use a local subkey or fail. "+
+			"Move the encryption subkey with 'gpg --edit-key %s' → keytocard → (2), or create one with 'ykgpg setup-encryption'", m.cfg.PrimaryKeyID))
+		return
+	}
+
+	subkey := gpg.FindSubkey(keys, fingerprint)
+	if subkey == nil {
+		result.add("encryption key on YubiKey", CheckWarn, fmt.Sprintf("the Encryption slot holds %s, which is not a subkey of %s", fingerprint, m.cfg.PrimaryKeyID))
+		return
+	}
+	result.add("encryption key on YubiKey", CheckPass, "encryption subkey: "+subkey.KeyID)
+
+	if !opts.TestEncrypt {
+		return
+	}
+	switch err := m.TryEncryptRoundTrip(ctx, fingerprint, opts.PinentryMode, opts.SigningTimeout); {
+	case err == nil:
+		result.add("encryption test", CheckPass, "encrypted to the card's subkey and decrypted with the YubiKey")
+	case errors.Is(err, ErrDecryptNeedsPIN):
+		result.add("encryption test", CheckWarn, "decryption requires PIN entry; test manually: "+ManualEncryptionCommand(fingerprint))
+	default:
+		result.add("encryption test", CheckFail, err.Error())
+		result.EncryptionFailed = true
+	}
+}
+
+// hasEncryptionSubkey reports whether keys include an unrevoked encryption
+// subkey.
+func hasEncryptionSubkey(keys []gpg.Key) bool {
+	for _, key := range keys {
+		if key.Type == "ssb" && !key.Revoked && slices.Contains(key.Capabilities, "E") {
+			return true
+		}
+	}
+	return false
+}
+
+// ManualEncryptionCommand returns a shell command that encrypts a test
+// payload to the subkey with the given fingerprint and decrypts it, for
+// users to run when the encryption test needs a PIN.
+func ManualEncryptionCommand(fingerprint string) string {
+	return fmt.Sprintf("echo 'test' | gpg --trust-model always --encrypt --armor --recipient %s! | gpg --decrypt", fingerprint)
+}
+
+// TryEncryptRoundTrip encrypts a test payload to the subkey with the given
+// fingerprint and decrypts it without prompting, which needs the YubiKey
+// holding that subkey. Decryption is tried for each attempt of the pinentry
+// mode in turn, each limited to timeout. It returns ErrDecryptNeedsPIN if
+// no attempt could decrypt, and an error if encrypting failed or the
+// decrypted text doesn't match.
+func (m *Manager) TryEncryptRoundTrip(ctx context.Context, fingerprint, pinentryMode string, timeout time.Duration) error {
+	attempts, err := pinentryAttemptArgs(pinentryMode, "--decrypt")
+	if err != nil {
+		return err
+	}
+
+	encryptCtx, cancel := context.WithTimeout(ctx, timeout)
+	ciphertext, err := m.exec.RunWithInput(encryptCtx, []byte(encryptionTestPayload), m.gpgBinary(),
+		"--batch", "--trust-model", "always", "--armor", "--recipient", fingerprint+"!", "--encrypt")
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to encrypt to %s: %w", fingerprint, err)
+	}
+
+	for _, args := range attempts {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		plaintext, err := m.exec.RunWithInput(attemptCtx, ciphertext, m.gpgBinary(), args...)
+		cancel()
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(plaintext, []byte(encryptionTestPayload)) {
+			return fmt.Errorf("decrypted text does not match what was encrypted")
+		}
+		return nil
+	}
+	return ErrDecryptNeedsPIN
+}
@@ -0,0 +1,127 @@
+package ykgpg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testEncSubkeyID  = "9A8B7C6D5E4F3A2B"
+	testEncSubkeyFpr = "1122334455667788990011229A8B7C6D5E4F3A2B"
+	testEncrypt      = "gpg --batch --trust-model always --armor --recipient " + testEncSubkeyFpr + "! --encrypt"
+	testDecrypt      = "gpg --batch --decrypt"
+	testDecryptLoop  = "gpg --batch --pinentry-mode=loopback --decrypt"
+)
+
+// testEncKeyListing is testKeyListing with an encryption subkey on the
+// same card.
+const testEncKeyListing = testKeyListing + `ssb:u:255:18:9A8B7C6D5E4F3A2B:1757030400:1914710400:::::e:::D2760001240103040006123456780000::cv25519::
+fpr:::::::::1122334455667788990011229A8B7C6D5E4F3A2B:
+`
+
+// testEncCardStatus is testCardStatus with the encryption subkey on the card.
+const testEncCardStatus = testCardStatus + "Encryption key....: 1122 3344 5566 7788 9900  1122 9A8B 7C6D 5E4F 3A2B\n"
+
+func TestManager_Verify_Encryption(t *testing.T) {
+	ctx := context.Background()
+
+	newMock := func(listing, cardStatus string) *executor.MockExecutor {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(listing))
+		mockExec.SetOutput("gpg --card-status", []byte(cardStatus))
+		mockExec.SetOutput(testEncrypt, []byte("-----BEGIN PGP MESSAGE-----\n"))
+		mockExec.SetOutput(testDecrypt, []byte(encryptionTestPayload))
+		return mockExec
+	}
+
+	t.Run("signing-only key is not checked", func(t *testing.T) {
+		result, err := newTestManager(t, newMock(testKeyListing, testCardStatus)).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		for _, check := range result.Checks {
+			assert.NotContains(t, check.Name, "encryption")
+		}
+	})
+
+	t.Run("encryption subkey not on the card", func(t *testing.T) {
+		result, err := newTestManager(t, newMock(testEncKeyListing, testCardStatus)).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		check := checkNamed(t, result, "encryption key on YubiKey")
+		assert.Equal(t, CheckWarn, check.Status)
+		assert.Contains(t, check.Message, "the Encryption slot is empty")
+		assert.False(t, result.EncryptionFailed)
+
+		result, err = newTestManager(t, newMock(testKeyListing, testCardStatus)).Verify(ctx, VerifyOptions{TestEncrypt: true})
+		require.NoError(t, err)
+		assert.Equal(t, CheckFail, checkNamed(t, result, "encryption key on YubiKey").Status)
+		assert.True(t, result.EncryptionFailed)
+	})
+
+	t.Run("encryption subkey on the card", func(t *testing.T) {
+		mockExec := newMock(testEncKeyListing, testEncCardStatus)
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		check := checkNamed(t, result, "encryption key on YubiKey")
+		assert.Equal(t, CheckPass, check.Status)
+		assert.Equal(t, "encryption subkey: "+testEncSubkeyID, check.Message)
+		assert.False(t, mockExec.VerifyCall("gpg", "--batch", "--decrypt"), "no round trip without TestEncrypt")
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		mockExec := newMock(testEncKeyListing, testEncCardStatus)
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{TestEncrypt: true})
+		require.NoError(t, err)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "encryption test").Status)
+		assert.False(t, result.EncryptionFailed)
+		assert.True(t, mockExec.VerifyCall("gpg", "--batch", "--decrypt"))
+	})
+
+	t.Run("decryption needs a PIN", func(t *testing.T) {
+		mockExec := newMock(testEncKeyListing, testEncCardStatus)
+		mockExec.SetError(testDecrypt, fmt.Errorf("exit status 2"))
+		mockExec.SetError(testDecryptLoop, fmt.Errorf("exit status 2"))
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{TestEncrypt: true})
+		require.NoError(t, err)
+		check := checkNamed(t, result, "encryption test")
+		assert.Equal(t, CheckWarn, check.Status)
+		assert.Contains(t, check.Message, "--recipient "+testEncSubkeyFpr+"!")
+		assert.False(t, result.EncryptionFailed)
+	})
+
+	t.Run("wrong plaintext", func(t *testing.T) {
+		mockExec := newMock(testEncKeyListing, testEncCardStatus)
+		mockExec.SetOutput(testDecrypt, []byte("something else\n"))
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{TestEncrypt: true})
+		require.NoError(t, err)
+		check := checkNamed(t, result, "encryption test")
+		assert.Equal(t, CheckFail, check.Status)
+		assert.Contains(t, check.Message, "does not match")
+		assert.True(t, result.EncryptionFailed)
+	})
+}
+
+func TestManager_TryEncryptRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput(testEncrypt, []byte("ciphertext"))
+	mockExec.SetError(testDecrypt, fmt.Errorf("exit status 2"))
+	mockExec.SetOutput(testDecryptLoop, []byte(encryptionTestPayload))
+	manager := newTestManager(t, mockExec)
+
+	require.NoError(t, manager.TryEncryptRoundTrip(ctx, testEncSubkeyFpr, PinentryModeAuto, DefaultSigningTimeout))
+	last := mockExec.Calls[len(mockExec.Calls)-1]
+	assert.Equal(t, []byte("ciphertext"), last.Input, "the ciphertext is decrypted")
+
+	assert.ErrorIs(t, manager.TryEncryptRoundTrip(ctx, testEncSubkeyFpr, PinentryModeAgent, DefaultSigningTimeout), ErrDecryptNeedsPIN)
+
+	mockExec.SetError(testEncrypt, fmt.Errorf("unusable public key"))
+	err := manager.TryEncryptRoundTrip(ctx, testEncSubkeyFpr, PinentryModeAuto, DefaultSigningTimeout)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDecryptNeedsPIN)
+	assert.Contains(t, err.Error(), "failed to encrypt")
+}
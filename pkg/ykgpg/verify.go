@@ -0,0 +1,523 @@
+package ykgpg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/git"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+)
+
+// Check statuses reported by Verify.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// CheckResult is the outcome of one verify check.
+type CheckResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Pinentry modes for the signing test. "agent" relies on a PIN already
+// cached by gpg-agent (or its own pinentry), "loopback" asks gpg to handle
+// the PIN itself, and "auto" tries both.
+const (
+	PinentryModeAuto     = "auto"
+	PinentryModeLoopback = "loopback"
+	PinentryModeAgent    = "agent"
+)
+
+// DefaultSigningTimeout bounds each non-interactive signing attempt in
+// Verify unless VerifyOptions says otherwise.
+const DefaultSigningTimeout = 3 * time.Second
+
+// cardDetectTimeout bounds detecting the YubiKey in Verify, since gpg can
+// hang waiting for PIN entry, card selection or a touch.
+const cardDetectTimeout = 3 * time.Second
+
+// SigningTestPayload is the data signed by the signing tests.
+const SigningTestPayload = "test\n"
+
+// VerifyOptions controls Verify.
+type VerifyOptions struct {
+	// PinentryMode is how the signing test invokes gpg: PinentryModeAuto
+	// (the default), PinentryModeLoopback or PinentryModeAgent.
+	PinentryMode string
+
+	// SigningTimeout bounds each signing attempt; DefaultSigningTimeout if zero.
+	SigningTimeout time.Duration
+
+	// CheckDefaultPINs probes whether the factory default Admin PIN is
+	// still set. If it isn't, the probe costs one Admin PIN attempt.
+	CheckDefaultPINs bool
+
+	// DryRun reports the default PIN checks as skipped, since the probe
+	// changes the PIN and a dry-run executor would only print it.
+	DryRun bool
+
+	// LocalGitConfig also reads the git config of the repository in the
+	// working directory. A value set there overrides the global one, and
+	// the effective value decides the git checks.
+	LocalGitConfig bool
+
+	// Strict makes the tag.gpgsign and push.gpgsign checks fail, rather
+	// than warn, when signing isn't enabled.
+	Strict bool
+
+	// TestEncrypt requires an encryption key on the card and encrypts and
+	// decrypts a test payload with it, using PinentryMode and
+	// SigningTimeout like the signing test.
+	TestEncrypt bool
+}
+
+// VerifyResult is the outcome of Verify: the checks in the order they ran,
+// and which kinds of check failed.
+type VerifyResult struct {
+	Checks []CheckResult
+
+	// AgentNotRunning is set if gpg-agent didn't answer. A caller may
+	// offer to start it with Manager.LaunchAgent and verify again.
+	AgentNotRunning bool
+	// NoCard is set if no YubiKey was detected.
+	NoCard bool
+	// SigningFailed is set if the signing test failed.
+	SigningFailed bool
+	// GitMisconfigured is set if git isn't set up to sign with the key.
+	GitMisconfigured bool
+	// EncryptionFailed is set if the encryption test failed, or with
+	// TestEncrypt if the card has no encryption key.
+	EncryptionFailed bool
+
+	// Card is the connected YubiKey, or nil if it couldn't be read.
+	Card *CardInfo
+
+	// SigningKeySpec selects the signing subkey on Card for gpg. It is
+	// empty if the subkey wasn't identified and the signing test skipped.
+	SigningKeySpec string
+
+	// SigningNeedsPIN is set if signing without a prompt failed, likely
+	// because the PIN isn't cached. The "signing test" check is then a
+	// warning, which a caller that can prompt may replace by signing
+	// interactively with SigningKeySpec.
+	SigningNeedsPIN bool
+}
+
+// VerifySummary counts the verify checks by status. Result is CheckFail if
+// any check failed and CheckPass otherwise, as warnings don't fail verify.
+type VerifySummary struct {
+	Result   string `json:"result"`
+	Passed   int    `json:"passed"`
+	Warnings int    `json:"warnings"`
+	Failed   int    `json:"failed"`
+}
+
+// Summary counts the checks by status.
+func (r *VerifyResult) Summary() VerifySummary {
+	var summary VerifySummary
+	for _, check := range r.Checks {
+		switch check.Status {
+		case CheckPass:
+			summary.Passed++
+		case CheckWarn:
+			summary.Warnings++
+		default:
+			summary.Failed++
+		}
+	}
+	summary.Result = CheckPass
+	if summary.Failed > 0 {
+		summary.Result = CheckFail
+	}
+	return summary
+}
+
+// Failures returns the number of failed checks.
+func (r *VerifyResult) Failures() int {
+	return r.Summary().Failed
+}
+
+// SetCheck replaces the check with the same name as check, such as the
+// "signing test" after the caller signed interactively, or appends it if
+// there is none.
+func (r *VerifyResult) SetCheck(check CheckResult) {
+	for i := range r.Checks {
+		if r.Checks[i].Name == check.Name {
+			r.Checks[i] = check
+			return
+		}
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// add appends a check.
+func (r *VerifyResult) add(name, status, message string) {
+	r.Checks = append(r.Checks, CheckResult{Name: name, Status: status, Message: message})
+}
+
+// ValidatePinentryMode returns an error if mode isn't a known pinentry mode.
+func ValidatePinentryMode(mode string) error {
+	_, err := signingAttemptArgs(mode, "")
+	return err
+}
+
+// Verify checks that the primary key is set up for signing with the
+// connected YubiKey: the master key is offline, the signing subkey is on
+// the card and can sign, and git signs commits with the key. If the key
+// has an encryption subkey, the card must hold one too. Failing
+// checks are reported in the result; the error is only for invalid options.
+func (m *Manager) Verify(ctx context.Context, opts VerifyOptions) (*VerifyResult, error) {
+	if opts.PinentryMode == "" {
+		opts.PinentryMode = PinentryModeAuto
+	}
+	if err := ValidatePinentryMode(opts.PinentryMode); err != nil {
+		return nil, err
+	}
+	if opts.SigningTimeout <= 0 {
+		opts.SigningTimeout = DefaultSigningTimeout
+	}
+
+	result := &VerifyResult{}
+
+	// Card operations fail with opaque errors without gpg-agent
+	if err := m.gpg.PingAgent(ctx); err != nil {
+		result.add("gpg-agent reachable", CheckFail, "gpg-agent is not running; start it with 'gpgconf --launch gpg-agent'")
+		result.AgentNotRunning = true
+	} else {
+		result.add("gpg-agent reachable", CheckPass, "")
+	}
+
+	// Check GPG key exists
+	keys, err := m.gpg.ListSecretKeys(ctx, m.cfg.PrimaryKeyID)
+	if err == nil && len(keys) > 0 {
+		result.add("primary key exists", CheckPass, "")
+	} else {
+		result.add("primary key exists", CheckFail, fmt.Sprintf("%s not found in the secret keyring", m.cfg.PrimaryKeyID))
+	}
+
+	// Check master key is NOT on machine
+	primary := m.cfg.PrimaryKeyFingerprint
+	if primary == "" {
+		primary = m.cfg.PrimaryKeyID
+	}
+	switch found, onMachine := gpg.MasterKeyOnMachine(keys, primary); {
+	case !found:
+		result.add("master key is offline", CheckWarn, "primary key not in the secret key listing")
+	case onMachine:
+		result.add("master key is offline", CheckWarn, "master key is on this machine")
+	default:
+		result.add("master key is offline", CheckPass, "sec# = offline")
+	}
+
+	// Check YubiKey and find the signing subkey on it
+	signingSubkeyID := m.checkCard(ctx, keys, result)
+
+	// Probe the factory default Admin PIN, only when asked since a wrong
+	// guess uses up a retry
+	if opts.CheckDefaultPINs && !result.NoCard {
+		result.Checks = append(result.Checks, m.defaultPINChecks(ctx, result.Card, opts.DryRun)...)
+	}
+
+	m.checkEncryption(ctx, keys, result, opts)
+
+	m.checkGit(ctx, result, opts.LocalGitConfig, opts.Strict)
+
+	// Check the signing subkey is actually on the card, so the signing test
+	// below can't pass by using a local copy
+	if signingSubkeyID != "" {
+		subkey := gpg.FindSubkey(keys, signingSubkeyID)
+		switch {
+		case subkey == nil || subkey.Secret == "":
+			result.add("signing subkey on YubiKey", CheckWarn, "subkey not in the secret key listing")
+		case subkey.Secret == gpg.SecretOnCard:
+			result.add("signing subkey on YubiKey", CheckPass, "ssb> = on card")
+		case subkey.Secret == gpg.SecretLocal:
+			result.add("signing subkey on YubiKey", CheckWarn, fmt.Sprintf("subkey %s was not actually moved to the YubiKey; "+
+				"signing will use the local copy. Run 'ykgpg move-subkey' to move it, or delete the local copy", subkey.KeyID))
+		default:
+			result.add("signing subkey on YubiKey", CheckWarn, fmt.Sprintf("subkey %s is a stub that doesn't point at a card", subkey.KeyID))
+		}
+	}
+
+	// Test signing with the specific subkey ID from the current YubiKey
+	if signingSubkeyID == "" {
+		// Don't fall back to the primary key ID, as gpg would prompt for card selection
+		result.add("signing test", CheckWarn, "skipped: unable to identify the signing subkey on the YubiKey. "+
+			"This may happen if the subkey was recently moved to it; try 'gpg --card-status'")
+		return result, nil
+	}
+
+	// Target the subkey on the current card by its full fingerprint
+	result.SigningKeySpec = gpg.SubkeySpec(keys, signingSubkeyID)
+	if err := m.TrySign(ctx, result.SigningKeySpec, opts.PinentryMode, opts.SigningTimeout); err != nil {
+		result.SigningNeedsPIN = true
+		result.add("signing test", CheckWarn, "requires PIN entry; test manually: "+ManualSigningCommand(result.SigningKeySpec))
+		return result, nil
+	}
+	result.Checks = append(result.Checks, m.SignatureCounterCheck(ctx, result.Card))
+
+	return result, nil
+}
+
+// checkCard adds the YubiKey check and records the card in result. It
+// returns the ID of the signing subkey on the card, or "" if it wasn't
+// identified.
+func (m *Manager) checkCard(ctx context.Context, keys []gpg.Key, result *VerifyResult) string {
+	cardCtx, cancel := context.WithTimeout(ctx, cardDetectTimeout)
+	defer cancel()
+
+	present, err := m.yubikey.IsPresent(cardCtx)
+	switch {
+	case err == nil && present:
+	case cardCtx.Err() == context.DeadlineExceeded:
+		result.add("YubiKey present", CheckFail, "YubiKey detection timed out; gpg may be waiting for user interaction")
+		result.NoCard = true
+		return ""
+	default:
+		result.add("YubiKey present", CheckFail, "no YubiKey detected")
+		result.NoCard = true
+		return ""
+	}
+
+	card, err := m.yubikey.GetCardInfo(cardCtx)
+	switch {
+	case err == nil:
+	case cardCtx.Err() == context.DeadlineExceeded:
+		result.add("YubiKey present", CheckWarn, "gpg --card-status timed out; gpg may be waiting for PIN entry, "+
+			"card selection (unplug all but one YubiKey) or a touch. Try running 'gpg --card-status' manually")
+		return ""
+	default:
+		result.add("YubiKey present", CheckPass, "unable to get card info")
+		return ""
+	}
+	result.Card = card
+
+	var signingSubkeyID, otherSerial string
+	message := "serial: " + m.serialWithLabel(card.Serial)
+	if subkey, err := gpg.FindSigningSubkeyOnCard(keys, card); err == nil {
+		// A guess that gpg places on another YubiKey would make the signing
+		// test prompt for that card, or pass misleadingly
+		if otherSerial = gpg.OtherCardSerial(subkey, card); otherSerial == "" {
+			signingSubkeyID = subkey.KeyID
+			message += "; signing subkey: " + signingSubkeyID
+			if gpg.GuessedSigningSubkey(subkey, card) {
+				message += " (most recent signing subkey on a card; if this is wrong, specify the key ID manually)"
+			}
+		}
+	}
+	result.add("YubiKey present", CheckPass, message)
+	if otherSerial != "" {
+		result.add("signing subkey on this YubiKey", CheckWarn, "a signing subkey exists on a different YubiKey: serial "+m.serialWithLabel(otherSerial))
+	}
+	return signingSubkeyID
+}
+
+// checkGit adds the checks that git signs commits, tags and pushes with the
+// primary key, using the global config and, with local, the repository
+// config on top. Unsigned tags and pushes only warn unless strict is set.
+// An unreadable setting counts as unset.
+func (m *Manager) checkGit(ctx context.Context, result *VerifyResult, local, strict bool) {
+	signingKey := m.readGitValue(ctx, "user.signingkey", local)
+	if key := signingKey.effective(); key != "" && (strings.Contains(key, m.cfg.PrimaryKeyID) || strings.Contains(key, m.cfg.PrimaryKeyFingerprint)) {
+		result.add("git signing key", CheckPass, signingKey.scopes())
+	} else {
+		result.add("git signing key", CheckFail, fmt.Sprintf("configured: %s; run %s to fix", signingKey, signingKey.fixCommand()))
+		result.GitMisconfigured = true
+	}
+
+	gpgSign := m.readGitValue(ctx, "commit.gpgsign", local)
+	if gpgSign.effective() == "true" {
+		result.add("git commit signing", CheckPass, gpgSign.scopes())
+	} else {
+		result.add("git commit signing", CheckFail, fmt.Sprintf("%s; run %s to fix", gpgSign.notEnabled("commit.gpgsign"), gpgSign.fixCommand()))
+		result.GitMisconfigured = true
+	}
+
+	failStatus := CheckWarn
+	if strict {
+		failStatus = CheckFail
+	}
+
+	tagSign := m.readGitValue(ctx, "tag.gpgsign", local)
+	if tagSign.effective() == "true" {
+		result.add("git tag signing", CheckPass, tagSign.scopes())
+	} else {
+		result.add("git tag signing", failStatus, fmt.Sprintf("%s; run %s to fix", tagSign.notEnabled("tag.gpgsign"), tagSign.fixCommand()))
+		result.GitMisconfigured = result.GitMisconfigured || strict
+	}
+
+	// if-asked signs pushes for servers that accept signed pushes, without
+	// failing against the many that don't
+	pushSign := m.readGitValue(ctx, "push.gpgsign", local)
+	if value := pushSign.effective(); value == "true" || value == "if-asked" {
+		result.add("git push signing", CheckPass, pushSign.scopes())
+	} else {
+		result.add("git push signing", failStatus, fmt.Sprintf("%s; run 'git config %s push.gpgsign if-asked' to fix", pushSign.notEnabled("push.gpgsign"), pushSign.configScope()))
+		result.GitMisconfigured = result.GitMisconfigured || strict
+	}
+}
+
+// gitValue is a git config value in the global config and, if it was
+// read, the repository config.
+type gitValue struct {
+	global string
+	local  string
+
+	// withLocal is set if the repository config was read, and localErr
+	// if that failed, e.g. outside a git repository.
+	withLocal bool
+	localErr  error
+}
+
+// readGitValue reads key from the global config and, with local, the
+// repository config.
+func (m *Manager) readGitValue(ctx context.Context, key string, local bool) gitValue {
+	value := gitValue{withLocal: local}
+	value.global, _ = m.git.GetConfig(ctx, git.ScopeGlobal, key)
+	if local {
+		value.local, value.localErr = m.git.GetConfig(ctx, git.ScopeLocal, key)
+	}
+	return value
+}
+
+// effective returns the value git uses: the repository value if set,
+// otherwise the global one.
+func (v gitValue) effective() string {
+	if v.local != "" {
+		return v.local
+	}
+	return v.global
+}
+
+// String describes the value, in each scope if the repository config was
+// read.
+func (v gitValue) String() string {
+	if !v.withLocal {
+		return fmt.Sprintf("%q", v.global)
+	}
+	local := fmt.Sprintf("%q", v.local)
+	if v.localErr != nil {
+		local = "unreadable (not in a git repository?)"
+	}
+	return fmt.Sprintf("global %q, local %s", v.global, local)
+}
+
+// scopes is the message for a passing check: the values in each scope if
+// the repository config was read, otherwise nothing.
+func (v gitValue) scopes() string {
+	if !v.withLocal {
+		return ""
+	}
+	return v.String()
+}
+
+// notEnabled says that key, whose value v is, isn't enabled, with the value
+// in each scope if the repository config was read.
+func (v gitValue) notEnabled(key string) string {
+	if !v.withLocal {
+		return key + " is not enabled"
+	}
+	return fmt.Sprintf("%s is not enabled (%s)", key, v)
+}
+
+// configScope returns the git config option for the scope that takes
+// effect, for fix commands.
+func (v gitValue) configScope() string {
+	if v.local != "" {
+		return "--local"
+	}
+	return "--global"
+}
+
+// fixCommand returns the git-config command that fixes the value where it
+// takes effect.
+func (v gitValue) fixCommand() string {
+	if v.local != "" {
+		return "'ykgpg git-config --local'"
+	}
+	return "'ykgpg git-config'"
+}
+
+// LaunchAgent starts gpg-agent if it isn't running.
+func (m *Manager) LaunchAgent(ctx context.Context) error {
+	return m.gpg.LaunchAgent(ctx)
+}
+
+// ManualSigningCommand returns a shell command that signs a test payload
+// with keySpec, for users to run when the signing test needs a PIN.
+func ManualSigningCommand(keySpec string) string {
+	return fmt.Sprintf("echo 'test' | gpg --default-key %s --sign --armor", keySpec)
+}
+
+// TrySign signs a test payload with keySpec without prompting, trying each
+// attempt for the pinentry mode in turn, each limited to timeout. It
+// returns nil on the first success, or the error of the last attempt.
+func (m *Manager) TrySign(ctx context.Context, keySpec, pinentryMode string, timeout time.Duration) error {
+	attempts, err := signingAttemptArgs(pinentryMode, keySpec)
+	if err != nil {
+		return err
+	}
+
+	for _, args := range attempts {
+		// Each attempt gets its own timeout so a pinentry or card-selection
+		// prompt can't hang the self-test
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		_, err = m.exec.RunWithInput(attemptCtx, []byte(SigningTestPayload), m.gpgBinary(), args...)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// SignatureCounterCheck returns the signing test result after a successful
+// signature: a pass, noting whether the card's signature counter went up
+// from before, or a warning if it didn't. The counter isn't reported if the
+// card doesn't expose it.
+func (m *Manager) SignatureCounterCheck(ctx context.Context, before *CardInfo) CheckResult {
+	check := CheckResult{Name: "signing test", Status: CheckPass}
+	if before == nil || before.SignatureCounter < 0 {
+		return check
+	}
+	after, err := m.gpg.CardStatus(ctx)
+	if err != nil || after.SignatureCounter < 0 {
+		return check
+	}
+	if after.SignatureCounter > before.SignatureCounter {
+		check.Message = fmt.Sprintf("Signature counter: %d → %d (signed by the YubiKey)", before.SignatureCounter, after.SignatureCounter)
+		return check
+	}
+	check.Status = CheckWarn
+	check.Message = fmt.Sprintf("Signature counter unchanged at %d; the signature may not have come from this YubiKey", after.SignatureCounter)
+	return check
+}
+
+// signingAttemptArgs returns the gpg argument sets to try, in order, for a
+// non-interactive signing test with the given pinentry mode.
+func signingAttemptArgs(mode, keyID string) ([][]string, error) {
+	return pinentryAttemptArgs(mode, "--default-key", keyID, "--sign", "--armor")
+}
+
+// pinentryAttemptArgs returns the gpg argument sets to try, in order, to
+// run gpg with args without prompting in the given pinentry mode.
+func pinentryAttemptArgs(mode string, args ...string) ([][]string, error) {
+	agentArgs := append([]string{"--batch"}, args...)
+	loopbackArgs := append([]string{"--batch", "--pinentry-mode=loopback"}, args...)
+
+	switch mode {
+	case PinentryModeAuto:
+		return [][]string{agentArgs, loopbackArgs}, nil
+	case PinentryModeAgent:
+		return [][]string{agentArgs}, nil
+	case PinentryModeLoopback:
+		return [][]string{loopbackArgs}, nil
+	default:
+		return nil, fmt.Errorf("invalid pinentry mode %q (expected %s, %s, or %s)", mode, PinentryModeAuto, PinentryModeLoopback, PinentryModeAgent)
+	}
+}
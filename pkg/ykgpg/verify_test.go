@@ -0,0 +1,291 @@
+package ykgpg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkNamed returns the check with the given name, or fails the test.
+func checkNamed(t *testing.T, result *VerifyResult, name string) CheckResult {
+	t.Helper()
+	for _, check := range result.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	require.Failf(t, "missing check", "no %q check in %v", name, result.Checks)
+	return CheckResult{}
+}
+
+func TestManager_Verify(t *testing.T) {
+	ctx := context.Background()
+	signAgent := "gpg --batch --default-key " + testSubkeyFpr + "! --sign --armor"
+	signLoopback := "gpg --batch --pinentry-mode=loopback --default-key " + testSubkeyFpr + "! --sign --armor"
+
+	newMock := func() *executor.MockExecutor {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(testKeyListing))
+		mockExec.SetOutput("gpg --card-status", []byte(testCardStatus))
+		mockExec.SetOutput("git config --global --get user.signingkey", []byte(testKeyID+"\n"))
+		mockExec.SetOutput("git config --global --get commit.gpgsign", []byte("true\n"))
+		return mockExec
+	}
+
+	t.Run("all checks pass", func(t *testing.T) {
+		result, err := newTestManager(t, newMock()).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+
+		assert.Zero(t, result.Failures())
+		assert.False(t, result.AgentNotRunning)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "gpg-agent reachable").Status)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "master key is offline").Status)
+		assert.Contains(t, checkNamed(t, result, "YubiKey present").Message, "signing subkey: "+testSubkeyID)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "signing subkey on YubiKey").Status)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "git signing key").Status)
+		assert.Equal(t, CheckWarn, checkNamed(t, result, "signing test").Status, "the mocked counter doesn't go up")
+		assert.Equal(t, testSubkeyFpr+"!", result.SigningKeySpec)
+		assert.False(t, result.SigningNeedsPIN)
+	})
+
+	t.Run("gpg-agent not running", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetError("gpg-connect-agent --no-autostart /bye", fmt.Errorf("exit status 1"))
+		manager := newTestManager(t, mockExec)
+
+		result, err := manager.Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.AgentNotRunning)
+		assert.Equal(t, CheckFail, checkNamed(t, result, "gpg-agent reachable").Status)
+		assert.Contains(t, checkNamed(t, result, "gpg-agent reachable").Message, "gpgconf --launch gpg-agent")
+
+		assert.Error(t, manager.LaunchAgent(ctx), "the agent still doesn't answer")
+		assert.True(t, mockExec.VerifyCall("gpgconf", "--launch", "gpg-agent"))
+	})
+
+	t.Run("signing needs a PIN", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetError(signAgent, fmt.Errorf("exit status 2"))
+		mockExec.SetError(signLoopback, fmt.Errorf("exit status 2"))
+
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.SigningNeedsPIN)
+		assert.Contains(t, checkNamed(t, result, "signing test").Message, "requires PIN entry")
+	})
+
+	t.Run("git not configured", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetOutput("git config --global --get commit.gpgsign", []byte("false\n"))
+
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.GitMisconfigured)
+		assert.Equal(t, 1, result.Failures())
+	})
+
+	t.Run("repository git config overrides global", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetOutput("git config --global --get commit.gpgsign", []byte("false\n"))
+		mockExec.SetOutput("git config --local --get commit.gpgsign", []byte("true\n"))
+		mockExec.SetOutput("git config --local --get user.signingkey", []byte("0000111122223333\n"))
+
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{LocalGitConfig: true})
+		require.NoError(t, err)
+		assert.True(t, result.GitMisconfigured)
+
+		signingKey := checkNamed(t, result, "git signing key")
+		assert.Equal(t, CheckFail, signingKey.Status, "the wrong local key overrides the global one")
+		assert.Contains(t, signingKey.Message, `global "`+testKeyID+`", local "0000111122223333"`)
+		assert.Contains(t, signingKey.Message, "git-config --local")
+
+		commitSigning := checkNamed(t, result, "git commit signing")
+		assert.Equal(t, CheckPass, commitSigning.Status, "enabled for this repository")
+		assert.Equal(t, `global "false", local "true"`, commitSigning.Message)
+	})
+
+	t.Run("global git config without --local", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetOutput("git config --local --get user.signingkey", []byte("0000111122223333\n"))
+
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.GitMisconfigured)
+		assert.Empty(t, checkNamed(t, result, "git signing key").Message)
+		assert.False(t, mockExec.VerifyCall("git", "config", "--local", "--get", "user.signingkey"))
+	})
+
+	t.Run("not in a git repository", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetError("git config --local --get user.signingkey", fmt.Errorf("exit status 128"))
+		mockExec.SetError("git config --local --get commit.gpgsign", fmt.Errorf("exit status 128"))
+
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{LocalGitConfig: true})
+		require.NoError(t, err)
+		assert.False(t, result.GitMisconfigured, "the global config still applies")
+		assert.Contains(t, checkNamed(t, result, "git signing key").Message, "not in a git repository")
+	})
+
+	t.Run("tag and push signing", func(t *testing.T) {
+		result, err := newTestManager(t, newMock()).Verify(ctx, VerifyOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.GitMisconfigured, "unsigned tags and pushes only warn")
+		assert.Equal(t, CheckWarn, checkNamed(t, result, "git tag signing").Status)
+		assert.Contains(t, checkNamed(t, result, "git tag signing").Message, "tag.gpgsign is not enabled")
+		assert.Equal(t, CheckWarn, checkNamed(t, result, "git push signing").Status)
+		assert.Contains(t, checkNamed(t, result, "git push signing").Message, "git config --global push.gpgsign if-asked")
+
+		result, err = newTestManager(t, newMock()).Verify(ctx, VerifyOptions{Strict: true})
+		require.NoError(t, err)
+		assert.True(t, result.GitMisconfigured)
+		assert.Equal(t, CheckFail, checkNamed(t, result, "git tag signing").Status)
+		assert.Equal(t, CheckFail, checkNamed(t, result, "git push signing").Status)
+
+		mockExec := newMock()
+		mockExec.SetOutput("git config --global --get tag.gpgsign", []byte("true\n"))
+		mockExec.SetOutput("git config --global --get push.gpgsign", []byte("if-asked\n"))
+		result, err = newTestManager(t, mockExec).Verify(ctx, VerifyOptions{Strict: true})
+		require.NoError(t, err)
+		assert.False(t, result.GitMisconfigured)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "git tag signing").Status)
+		assert.Equal(t, CheckPass, checkNamed(t, result, "git push signing").Status)
+	})
+
+	t.Run("no card", func(t *testing.T) {
+		mockExec := newMock()
+		mockExec.SetError("gpg --card-status", fmt.Errorf("no card"))
+
+		result, err := newTestManager(t, mockExec).Verify(ctx, VerifyOptions{CheckDefaultPINs: true})
+		require.NoError(t, err)
+		assert.True(t, result.NoCard)
+		assert.Nil(t, result.Card)
+		assert.Empty(t, result.SigningKeySpec)
+		assert.Equal(t, CheckWarn, checkNamed(t, result, "signing test").Status)
+		for _, check := range result.Checks {
+			assert.NotContains(t, check.Name, "default", "PINs aren't probed without a card")
+		}
+	})
+
+	t.Run("invalid pinentry mode", func(t *testing.T) {
+		_, err := newTestManager(t, newMock()).Verify(ctx, VerifyOptions{PinentryMode: "gui"})
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyResult_Summary(t *testing.T) {
+	result := &VerifyResult{}
+	assert.Equal(t, VerifySummary{Result: CheckPass}, result.Summary())
+
+	result.Checks = []CheckResult{
+		{Name: "primary key exists", Status: CheckPass},
+		{Name: "master key is offline", Status: CheckWarn},
+		{Name: "signing test", Status: CheckWarn},
+	}
+	assert.Equal(t, VerifySummary{Result: CheckPass, Passed: 1, Warnings: 2}, result.Summary(), "warnings don't fail verify")
+
+	result.SetCheck(CheckResult{Name: "signing test", Status: CheckFail, Message: "bad PIN"})
+	result.SetCheck(CheckResult{Name: "git commit signing", Status: CheckFail})
+	assert.Equal(t, VerifySummary{Result: CheckFail, Passed: 1, Warnings: 1, Failed: 2}, result.Summary())
+	assert.Len(t, result.Checks, 4)
+	assert.Equal(t, "bad PIN", result.Checks[2].Message)
+	assert.Equal(t, 2, result.Failures())
+}
+
+func TestSigningAttemptArgs(t *testing.T) {
+	keyID := "ABC123DEF4567890"
+
+	tests := []struct {
+		name         string
+		mode         string
+		wantAttempts int
+		wantLoopback []bool
+		wantErr      bool
+	}{
+		{
+			name:         "auto tries agent then loopback",
+			mode:         "auto",
+			wantAttempts: 2,
+			wantLoopback: []bool{false, true},
+		},
+		{
+			name:         "agent only",
+			mode:         "agent",
+			wantAttempts: 1,
+			wantLoopback: []bool{false},
+		},
+		{
+			name:         "loopback only",
+			mode:         "loopback",
+			wantAttempts: 1,
+			wantLoopback: []bool{true},
+		},
+		{
+			name:    "invalid mode",
+			mode:    "gui",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, err := signingAttemptArgs(tt.mode, keyID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, attempts, tt.wantAttempts)
+			for i, args := range attempts {
+				assert.Contains(t, args, "--batch")
+				assert.Contains(t, args, keyID)
+				assert.Equal(t, tt.wantLoopback[i], contains(args, "--pinentry-mode=loopback"))
+			}
+		})
+	}
+}
+
+// contains reports whether args includes arg.
+func contains(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func TestManager_TrySign(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetError("gpg --batch --default-key KEY! --sign --armor", fmt.Errorf("exit status 2"))
+	manager := newTestManager(t, mockExec)
+
+	require.NoError(t, manager.TrySign(context.Background(), "KEY!", PinentryModeAuto, DefaultSigningTimeout))
+	assert.True(t, mockExec.VerifyCall("gpg", "--batch", "--pinentry-mode=loopback", "--default-key", "KEY!", "--sign", "--armor"))
+	assert.Equal(t, []byte(SigningTestPayload), mockExec.Calls[len(mockExec.Calls)-1].Input)
+
+	assert.Error(t, manager.TrySign(context.Background(), "KEY!", PinentryModeAgent, DefaultSigningTimeout))
+}
+
+func TestManager_SignatureCounterCheck(t *testing.T) {
+	mockExec := executor.NewMockExecutor()
+	mockExec.SetOutput("gpg --card-status", []byte("Signature counter : 43\n"))
+	manager := newTestManager(t, mockExec)
+
+	check := manager.SignatureCounterCheck(context.Background(), &gpg.CardInfo{SignatureCounter: 42})
+	assert.Equal(t, CheckPass, check.Status)
+	assert.Contains(t, check.Message, "42 → 43")
+
+	check = manager.SignatureCounterCheck(context.Background(), &gpg.CardInfo{SignatureCounter: 43})
+	assert.Equal(t, CheckWarn, check.Status)
+	assert.Contains(t, check.Message, "unchanged at 43")
+
+	check = manager.SignatureCounterCheck(context.Background(), nil)
+	assert.Equal(t, CheckPass, check.Status)
+	assert.Empty(t, check.Message)
+}
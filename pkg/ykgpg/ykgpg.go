@@ -0,0 +1,246 @@
+// Package ykgpg exposes the operations of the ykgpg command as a library,
+// for programs that provision YubiKeys without shelling out to the binary.
+//
+// A Manager is built from a Config and an Executor that runs gpg, ykman and
+// git. Its methods return structured results and never print or prompt; the
+// ykgpg command is a thin wrapper that renders them.
+//
+//	cfg, err := ykgpg.LoadConfig()
+//	if err != nil {
+//		return err
+//	}
+//	manager, err := ykgpg.New(cfg, ykgpg.NewExecutor(cfg))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := manager.Verify(ctx, ykgpg.VerifyOptions{})
+package ykgpg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bobbydams/yubikey-manager/internal/config"
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/bobbydams/yubikey-manager/internal/git"
+	"github.com/bobbydams/yubikey-manager/internal/gpg"
+	"github.com/bobbydams/yubikey-manager/internal/yubikey"
+)
+
+// Config is the ykgpg configuration: the primary key, the user and the
+// external programs to run. See LoadConfig.
+type Config = config.Config
+
+// Executor runs the external commands. NewExecutor returns the one used by
+// the ykgpg command; tests can substitute their own.
+type Executor = executor.Executor
+
+// Key is a primary key or subkey from a gpg key listing.
+type Key = gpg.Key
+
+// CardInfo is the OpenPGP card status of a YubiKey.
+type CardInfo = gpg.CardInfo
+
+// ErrNoCard is returned when an operation needs a YubiKey and none is
+// connected.
+var ErrNoCard = errors.New("no YubiKey detected")
+
+// ErrOnlyPublicKey is returned when the keyring has the public primary key
+// but none of its secret parts: neither the master key nor subkey stubs.
+var ErrOnlyPublicKey = errors.New("only public key present; secret key and subkeys not imported")
+
+// ErrKeyNotFound is returned when the keyring has no key with the
+// configured ID at all.
+var ErrKeyNotFound = errors.New("key ID not found in keyring")
+
+// LoadConfig reads the configuration the way the ykgpg command does: from
+// the config file, YKGPG_* environment variables and defaults.
+func LoadConfig() (*Config, error) {
+	return config.Load()
+}
+
+// NewExecutor returns an executor that runs commands bounded by the
+// configured gpg_timeout, with GNUPGHOME set from gnupg_home if given.
+func NewExecutor(cfg *Config) Executor {
+	exec := executor.NewRealExecutorWithTimeout(cfg.GPGTimeout)
+	if home := cfg.GnuPGHome; home != "" {
+		// gpg resolves a relative home against its own working directory
+		if abs, err := filepath.Abs(home); err == nil {
+			home = abs
+		}
+		exec.Env = []string{"GNUPGHOME=" + home}
+	}
+	return exec
+}
+
+// Manager performs ykgpg operations for the primary key in its Config.
+type Manager struct {
+	cfg     *Config
+	exec    Executor
+	gpg     gpg.GPGService
+	yubikey yubikey.YubiKeyService
+	git     git.GitService
+}
+
+// New validates cfg and returns a Manager that runs commands with exec.
+func New(cfg *Config, exec Executor) (*Manager, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("no configuration given")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	gpgSvc := gpg.NewService(exec)
+	if cfg.GPGBinary != "" {
+		gpgSvc.Binary = cfg.GPGBinary
+	}
+	gpgSvc.KeyserverProxy = cfg.KeyserverProxy
+	yubikeySvc := yubikey.NewService(gpgSvc, exec)
+	yubikeySvc.GPGBinary = gpgSvc.Binary
+	if cfg.YkmanBinary != "" {
+		yubikeySvc.YkmanBinary = cfg.YkmanBinary
+	}
+	yubikeySvc.CardRetries = cfg.CardRetries
+
+	return NewWithServices(cfg, exec, gpgSvc, yubikeySvc), nil
+}
+
+// NewWithServices returns a Manager that uses the given services, so that
+// the ykgpg command shares them (and the YubiKey it selected) with its own
+// code. cfg must already be validated. Library callers should use New.
+func NewWithServices(cfg *Config, exec Executor, gpgSvc gpg.GPGService, yubikeySvc yubikey.YubiKeyService) *Manager {
+	return &Manager{
+		cfg:     cfg,
+		exec:    exec,
+		gpg:     gpgSvc,
+		yubikey: yubikeySvc,
+		git:     git.NewService(exec),
+	}
+}
+
+// gpgBinary returns the configured gpg program.
+func (m *Manager) gpgBinary() string {
+	if m.cfg.GPGBinary != "" {
+		return m.cfg.GPGBinary
+	}
+	return gpg.DefaultBinary
+}
+
+// serialWithLabel returns the serial followed by its recorded label, if any.
+func (m *Manager) serialWithLabel(serial string) string {
+	if entry, ok := m.cfg.YubiKeyFor(serial); ok {
+		return fmt.Sprintf("%s — %s", serial, entry.String())
+	}
+	return serial
+}
+
+// Status is the state of the primary key and the connected YubiKey.
+type Status struct {
+	// Keys is the primary key and its subkeys from the secret keyring.
+	Keys []Key
+
+	// CardPresent is true if a YubiKey is connected.
+	CardPresent bool
+
+	// Card is the connected YubiKey, or nil if there is none or its status
+	// couldn't be read. CardError says why in the latter case, or why
+	// detecting the YubiKey failed.
+	Card      *CardInfo
+	CardError error
+
+	// TouchPolicies maps each slot ("sig", "enc", "aut") to its touch
+	// policy. It is nil when ykman isn't available.
+	TouchPolicies map[string]string
+
+	// SigningSubkey is the signing subkey on Card, or nil if none is found.
+	SigningSubkey *Key
+
+	// OtherCardSerial is the serial of a different YubiKey that holds the
+	// only signing subkey gpg knows of on a card, if that is the case.
+	OtherCardSerial string
+}
+
+// Status lists the primary key and inspects the connected YubiKey. It is
+// an error wrapping ErrOnlyPublicKey or ErrKeyNotFound if the primary key
+// isn't in the secret keyring; problems with the YubiKey are reported in
+// the Status instead.
+func (m *Manager) Status(ctx context.Context) (*Status, error) {
+	keys, err := m.gpg.ListSecretKeys(ctx, m.cfg.PrimaryKeyID)
+	if err != nil || len(keys) == 0 {
+		return nil, m.missingKeyError(ctx)
+	}
+
+	status := &Status{Keys: keys}
+	present, err := m.yubikey.IsPresent(ctx)
+	if err != nil {
+		status.CardError = err
+		return status, nil
+	}
+	if !present {
+		return status, nil
+	}
+	status.CardPresent = true
+
+	card, err := m.yubikey.GetCardInfo(ctx)
+	if err != nil {
+		status.CardError = err
+		return status, nil
+	}
+	status.Card = card
+	if policies, err := m.yubikey.GetTouchPolicy(ctx); err == nil {
+		status.TouchPolicies = policies
+	}
+	if subkey, err := gpg.FindSigningSubkeyOnCard(keys, card); err == nil {
+		if status.OtherCardSerial = gpg.OtherCardSerial(subkey, card); status.OtherCardSerial == "" {
+			status.SigningSubkey = subkey
+		}
+	}
+
+	return status, nil
+}
+
+// missingKeyError explains why the primary key isn't in the secret keyring:
+// either only its public key was imported, or there is no such key.
+func (m *Manager) missingKeyError(ctx context.Context) error {
+	if keys, err := m.gpg.ListPublicKeys(ctx, m.cfg.PrimaryKeyID); err == nil && len(keys) > 0 {
+		return fmt.Errorf("primary key %s: %w", m.cfg.PrimaryKeyID, ErrOnlyPublicKey)
+	}
+	return fmt.Errorf("primary key %s: %w", m.cfg.PrimaryKeyID, ErrKeyNotFound)
+}
+
+// MoveSubkey moves a subkey of the primary key to the given slot of the
+// connected YubiKey (1=Signature, 2=Encryption, 3=Authentication). The key
+// passphrase, empty if there is none, and the Admin PIN are passed to gpg on
+// stdin.
+func (m *Manager) MoveSubkey(ctx context.Context, subkeyID string, slot int, passphrase, adminPIN string) error {
+	present, err := m.yubikey.IsPresent(ctx)
+	if err != nil {
+		return err
+	}
+	if !present {
+		return ErrNoCard
+	}
+	card, err := m.yubikey.GetCardInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get card info: %w", err)
+	}
+	if card.AdminPINRetries == 0 {
+		return fmt.Errorf("admin PIN is blocked")
+	}
+
+	keys, err := m.gpg.ListSecretKeys(ctx, m.cfg.PrimaryKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+	if subkey := gpg.FindSubkey(keys, subkeyID); subkey != nil && subkey.Secret == gpg.SecretOnCard {
+		return fmt.Errorf("subkey %s is already on a card", subkey.KeyID)
+	}
+
+	if err := m.gpg.MoveSubkeyToCard(ctx, m.cfg.PrimaryKeyID, subkeyID, slot, passphrase, adminPIN); err != nil {
+		return fmt.Errorf("failed to move subkey: %w", err)
+	}
+	return nil
+}
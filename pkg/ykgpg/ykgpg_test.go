@@ -0,0 +1,186 @@
+package ykgpg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bobbydams/yubikey-manager/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKeyID       = "ABC123DEF4567890"
+	testFingerprint = "ABCDEF1234567890ABCDEF12ABC123DEF4567890"
+	testListKeys    = "gpg --list-secret-keys --with-colons --fingerprint --with-subkey-fingerprint " + testKeyID
+	testSubkeyID    = "DC47D1B090A51498"
+	testSubkeyFpr   = "0C1B2E3F4A5B6C7D8E9F0A1BDC47D1B090A51498"
+)
+
+// testKeyListing lists an offline primary key with a signing subkey on the
+// card with serial 12345678.
+const testKeyListing = `sec:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+fpr:::::::::ABCDEF1234567890ABCDEF12ABC123DEF4567890:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::D2760001240103040006123456780000::ed25519::
+fpr:::::::::0C1B2E3F4A5B6C7D8E9F0A1BDC47D1B090A51498:
+`
+
+// testCardStatus is the card status of the YubiKey holding the subkey.
+const testCardStatus = `Serial number ....: 12345678
+Signature key ....: 0C1B 2E3F 4A5B 6C7D 8E9F  0A1B DC47 D1B0 90A5 1498
+PIN retry counter : 3 0 3
+Signature counter : 7
+`
+
+// newTestManager returns a Manager for the test key that runs commands with
+// mockExec.
+func newTestManager(t *testing.T, mockExec *executor.MockExecutor) *Manager {
+	t.Helper()
+	manager, err := New(&Config{
+		PrimaryKeyID:          testKeyID,
+		PrimaryKeyFingerprint: testFingerprint,
+		UserName:              "Test User",
+		UserEmail:             "test@example.com",
+	}, mockExec)
+	require.NoError(t, err)
+	return manager
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, executor.NewMockExecutor())
+	assert.Error(t, err)
+
+	_, err = New(&Config{PrimaryKeyID: testKeyID}, executor.NewMockExecutor())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid configuration")
+
+	cfg := &Config{
+		PrimaryKeyID:          "abc123def4567890",
+		PrimaryKeyFingerprint: testFingerprint,
+		UserName:              "Test User",
+		UserEmail:             "test@example.com",
+	}
+	_, err = New(cfg, executor.NewMockExecutor())
+	require.NoError(t, err)
+	assert.Equal(t, testKeyID, cfg.PrimaryKeyID, "the key ID is normalized")
+}
+
+func TestNewExecutor(t *testing.T) {
+	exec := NewExecutor(&Config{GPGTimeout: time.Minute, GnuPGHome: "/tmp/gnupg"})
+	realExec, ok := exec.(*executor.RealExecutor)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, realExec.Timeout)
+	assert.Equal(t, []string{"GNUPGHOME=/tmp/gnupg"}, realExec.Env)
+
+	realExec = NewExecutor(&Config{}).(*executor.RealExecutor)
+	assert.Empty(t, realExec.Env)
+}
+
+func TestManager_Status(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("card with signing subkey", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(testKeyListing))
+		mockExec.SetOutput("gpg --card-status", []byte(testCardStatus))
+		mockExec.SetOutput("ykman openpgp info", []byte("Touch policies:\n  Signature key: On\n"))
+
+		status, err := newTestManager(t, mockExec).Status(ctx)
+		require.NoError(t, err)
+
+		assert.Len(t, status.Keys, 2)
+		assert.True(t, status.CardPresent)
+		require.NotNil(t, status.Card)
+		assert.Equal(t, "12345678", status.Card.Serial)
+		assert.Equal(t, "on", status.TouchPolicies["sig"])
+		require.NotNil(t, status.SigningSubkey)
+		assert.Equal(t, testSubkeyID, status.SigningSubkey.KeyID)
+		assert.Empty(t, status.OtherCardSerial)
+	})
+
+	t.Run("signing subkey on another card", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(testKeyListing))
+		mockExec.SetOutput("gpg --card-status", []byte("Serial number ....: 87654321\nSignature key ....: [none]\n"))
+
+		status, err := newTestManager(t, mockExec).Status(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, status.SigningSubkey)
+		assert.Equal(t, "12345678", status.OtherCardSerial)
+	})
+
+	t.Run("no card", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(testKeyListing))
+		mockExec.SetError("gpg --card-status", fmt.Errorf("no card"))
+
+		status, err := newTestManager(t, mockExec).Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, status.CardPresent)
+		assert.Nil(t, status.Card)
+	})
+
+	t.Run("primary key missing", func(t *testing.T) {
+		_, err := newTestManager(t, executor.NewMockExecutor()).Status(ctx)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.NotErrorIs(t, err, ErrOnlyPublicKey)
+	})
+
+	t.Run("only the public key", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError(testListKeys, fmt.Errorf("exit status 2"))
+		mockExec.SetOutput("gpg --list-keys --with-colons --fingerprint --with-subkey-fingerprint "+testKeyID,
+			[]byte("pub:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::::ed25519:::0:\n"))
+
+		_, err := newTestManager(t, mockExec).Status(ctx)
+		assert.ErrorIs(t, err, ErrOnlyPublicKey)
+		assert.Contains(t, err.Error(), "secret key and subkeys not imported")
+	})
+}
+
+func TestManager_MoveSubkey(t *testing.T) {
+	ctx := context.Background()
+	localListing := `sec:u:255:22:ABC123DEF4567890:1757030400:1914710400::u:::scESC:::#::ed25519:::0:
+ssb:u:255:22:DC47D1B090A51498:1757030400:1914710400:::::s:::+::ed25519::
+fpr:::::::::0C1B2E3F4A5B6C7D8E9F0A1BDC47D1B090A51498:
+`
+
+	t.Run("moves the subkey", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(localListing))
+		mockExec.SetOutput("gpg --card-status", []byte(testCardStatus))
+
+		err := newTestManager(t, mockExec).MoveSubkey(ctx, testSubkeyID, 1, "", "12345678")
+		require.NoError(t, err)
+		assert.True(t, mockExec.VerifyCall("gpg", "--pinentry-mode", "loopback", "--command-fd", "0", "--status-fd", "1", "--edit-key", testKeyID))
+	})
+
+	t.Run("already on a card", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput(testListKeys, []byte(testKeyListing))
+		mockExec.SetOutput("gpg --card-status", []byte(testCardStatus))
+
+		err := newTestManager(t, mockExec).MoveSubkey(ctx, testSubkeyID, 1, "", "12345678")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already on a card")
+	})
+
+	t.Run("admin PIN blocked", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetOutput("gpg --card-status", []byte("Serial number ....: 12345678\nPIN retry counter : 3 0 0\n"))
+
+		err := newTestManager(t, mockExec).MoveSubkey(ctx, testSubkeyID, 1, "", "12345678")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "admin PIN is blocked")
+	})
+
+	t.Run("no card", func(t *testing.T) {
+		mockExec := executor.NewMockExecutor()
+		mockExec.SetError("gpg --card-status", fmt.Errorf("no card"))
+
+		err := newTestManager(t, mockExec).MoveSubkey(ctx, testSubkeyID, 1, "", "12345678")
+		assert.ErrorIs(t, err, ErrNoCard)
+	})
+}